@@ -20,13 +20,19 @@ VERSION:
    %s
 
 COMMANDS:
+   upgrade  Update an existing bitrise config to what the scanners generate today
+   doctor   Audit an existing bitrise config against the project
+   import   Generate a bitrise config from the config of another CI service
    help, h  Shows a list of commands or help for one command
 
 GLOBAL OPTIONS:
-   --minimal      create empty bitrise config and secrets
-   --private      is a private repository
-   --help, -h     show help
-   --version, -v  print the version`, version.VERSION)
+   --minimal           create empty bitrise config and secrets
+   --private           is a private repository
+   --fastlane-lanes    generate a workflow for every fastlane lane
+   --store-deploy      generate store deploy workflows: Google Play for Android and App Store Connect for iOS apps
+   --play-track value  Google Play track of the store deploy workflow: internal, alpha, beta, production (default: "internal")
+   --help, -h          show help
+   --version, -v       print the version`, version.VERSION)
 
 func Test_HelpTest(t *testing.T) {
 	t.Log("help command")
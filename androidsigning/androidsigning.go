@@ -0,0 +1,264 @@
+package androidsigning
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/gradlescript"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+)
+
+const (
+	// ReleaseWorkflowID is the ID of the workflow building the signed App Bundle of the Android config.
+	ReleaseWorkflowID      = "build_release_aab"
+	releaseWorkflowSummary = "Build a signed Android App Bundle (AAB) of your app to upload it to Google Play."
+	// releaseWorkflowDescription is completed with the signing method of the project.
+	releaseWorkflowDescription = "The workflow will first clone your Git repository, install Android tools, set the project's version code based on the build number, run Android lint and unit tests, build the project's AAB file%s and save it."
+
+	// buildWorkflowID is the ID of the Android scanner's APK build workflow.
+	buildWorkflowID = "build_apk"
+
+	buildTypeInputKey = "build_type"
+	buildTypeAAB      = "aab"
+)
+
+var (
+	signingConfigPattern = regexp.MustCompile(`\bsigningConfig\s*=?\s*signingConfigs(?:\.getByName\(\s*["']([\w-]+)["']\s*\)|\[\s*["']([\w-]+)["']\s*\]|\.([\w-]+))`)
+	// propertiesFilePattern matches the properties files the build script loads, like rootProject.file("keystore.properties").
+	propertiesFilePattern    = regexp.MustCompile(`["']([\w./-]*\.properties)["']`)
+	signingPropertiesPattern = regexp.MustCompile(`(?i)^\s*[\w.]*(storeFile|storePassword|keyAlias|keyPassword)\s*[=:]`)
+)
+
+// skippedDirs are not searched for build scripts and keystores, they hold build outputs and dependencies.
+var skippedDirs = map[string]bool{"build": true, "node_modules": true, "Pods": true}
+
+// Signing is the release signing setup of the selected Android app module.
+type Signing struct {
+	// SigningConfig is the signing config the release build type of the module uses, like app/build.gradle (release).
+	// It is empty if the project expects the app to be signed on Bitrise.
+	SigningConfig string
+	// MissingPropertiesFiles are the properties files the release signing config reads, but which are not in the repository.
+	MissingPropertiesFiles []string
+	// PropertiesFiles are the committed properties files with signing credentials.
+	PropertiesFiles []string
+	// KeystoreFiles are the committed keystore files, apart from the debug keystore.
+	KeystoreFiles []string
+}
+
+// SignedByGradle reports whether the Gradle build of the release variant signs the app.
+func (s Signing) SignedByGradle() bool {
+	return s.SigningConfig != ""
+}
+
+// Detect inspects the signingConfigs of the module's build script and the keystore and properties files committed
+// to the Android project to find out whether the release build is signed by Gradle. A signing config reading
+// a properties file which is not in the repository, like a git ignored keystore.properties, can not sign on Bitrise.
+// The projectLocation and module are the PROJECT_LOCATION and MODULE of the Android config, the returned paths are
+// relative to the search dir.
+func Detect(searchDir, projectLocation, module string) (Signing, error) {
+	var signing Signing
+	projectDir := filepath.Join(searchDir, projectLocation)
+	moduleDir := filepath.Join(projectDir, filepath.FromSlash(strings.ReplaceAll(strings.TrimPrefix(module, ":"), ":", "/")))
+	var buildScript string
+	properties := map[string]bool{}
+
+	if err := filepath.WalkDir(projectDir, func(pth string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			if pth != projectDir && (strings.HasPrefix(name, ".") || skippedDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(searchDir, pth)
+		if err != nil {
+			return err
+		}
+		switch {
+		case name == "build.gradle" || name == "build.gradle.kts":
+			if filepath.Dir(pth) == moduleDir && buildScript == "" {
+				buildScript = relPath
+			}
+		case strings.HasSuffix(name, ".properties"):
+			properties[name] = true
+			isSigningProperties, err := hasSigningProperties(pth)
+			if err != nil {
+				return err
+			}
+			if isSigningProperties {
+				signing.PropertiesFiles = append(signing.PropertiesFiles, relPath)
+			}
+		case (strings.HasSuffix(name, ".jks") || strings.HasSuffix(name, ".keystore")) && name != "debug.keystore":
+			signing.KeystoreFiles = append(signing.KeystoreFiles, relPath)
+		}
+		return nil
+	}); err != nil {
+		return Signing{}, err
+	}
+	if buildScript == "" {
+		return signing, nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(searchDir, buildScript))
+	if err != nil {
+		return Signing{}, fmt.Errorf("read %s: %s", buildScript, err)
+	}
+	script := gradlescript.StripComments(string(content))
+	if !gradlescript.IsApplication(script) {
+		return signing, nil
+	}
+
+	signingConfig := releaseSigningConfig(script)
+	if signingConfig == "" {
+		return signing, nil
+	}
+
+	for _, match := range propertiesFilePattern.FindAllStringSubmatch(script, -1) {
+		if name := filepath.Base(match[1]); !properties[name] {
+			signing.MissingPropertiesFiles = append(signing.MissingPropertiesFiles, match[1])
+		}
+	}
+	if len(signing.MissingPropertiesFiles) == 0 {
+		signing.SigningConfig = fmt.Sprintf("%s (%s)", buildScript, signingConfig)
+	}
+
+	return signing, nil
+}
+
+// AddReleaseWorkflow adds the release AAB workflow to the Android config, based on its APK build workflow.
+// The workflow signs the bundle with the Android Sign step unless the Gradle build signs it, in which case
+// the Android Sign step is also removed from the APK build workflow.
+func AddReleaseWorkflow(config *bitriseModels.BitriseDataModel, signing Signing) bool {
+	if config.ProjectType != android.ScannerName {
+		return false
+	}
+	buildWorkflow, ok := config.Workflows[buildWorkflowID]
+	if !ok {
+		return false
+	}
+
+	var buildStepList, releaseStepList []bitriseModels.StepListItemModel
+	for _, item := range buildWorkflow.Steps {
+		switch steps.ID(item) {
+		case initSteps.SignAPKID:
+			if signing.SignedByGradle() {
+				continue
+			}
+			releaseStepList = append(releaseStepList, withoutRunIf(item))
+		case initSteps.AndroidBuildID:
+			releaseStepList = append(releaseStepList, steps.WithInput(item, buildTypeInputKey, buildTypeAAB))
+		default:
+			releaseStepList = append(releaseStepList, item)
+		}
+		buildStepList = append(buildStepList, item)
+	}
+	buildWorkflow.Steps = buildStepList
+	config.Workflows[buildWorkflowID] = buildWorkflow
+
+	signingDescription := ", sign it with your uploaded keystore"
+	if signing.SignedByGradle() {
+		signingDescription = " signed by its Gradle signing config"
+	}
+	config.Workflows[ReleaseWorkflowID] = bitriseModels.WorkflowModel{
+		Summary:     releaseWorkflowSummary,
+		Description: fmt.Sprintf(releaseWorkflowDescription, signingDescription),
+		Steps:       releaseStepList,
+	}
+	return true
+}
+
+// SecretEnvs returns the keystore secrets the Android Sign steps of the config expect, as empty secret envs.
+// It returns nil if the config does not sign Android apps with the Android Sign step.
+func SecretEnvs(config bitriseModels.BitriseDataModel) []envmanModels.EnvironmentItemModel {
+	signs := false
+	for _, workflow := range config.Workflows {
+		if steps.Contains(workflow.Steps, initSteps.SignAPKID) {
+			signs = true
+			break
+		}
+	}
+	if !signs {
+		return nil
+	}
+
+	var envs []envmanModels.EnvironmentItemModel
+	for _, key := range steps.AndroidKeystoreSecrets {
+		envs = append(envs, steps.SecretEnv(key, "Required by the Android Sign step, upload the keystore as a Bitrise file to set it"))
+	}
+	return envs
+}
+
+// releaseSigningConfig returns the name of the non-debug signing config the release build type uses,
+// or the default config uses if the release build type does not set one.
+func releaseSigningConfig(script string) string {
+	androidBlock, ok := gradlescript.Block(script, "android")
+	if !ok {
+		return ""
+	}
+
+	var bodies []string
+	if buildTypes, ok := gradlescript.Block(androidBlock, "buildTypes"); ok {
+		if blocks, ok := gradlescript.NamedBlocks(buildTypes); ok {
+			for _, block := range blocks {
+				if block.Name == "release" {
+					bodies = append(bodies, block.Body)
+				}
+			}
+		}
+	}
+	if defaultConfig, ok := gradlescript.Block(androidBlock, "defaultConfig"); ok {
+		bodies = append(bodies, defaultConfig)
+	}
+
+	for _, body := range bodies {
+		match := signingConfigPattern.FindStringSubmatch(body)
+		if match == nil {
+			continue
+		}
+		name := match[1] + match[2] + match[3]
+		if name == "debug" {
+			return ""
+		}
+		return name
+	}
+	return ""
+}
+
+func hasSigningProperties(pth string) (bool, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if signingPropertiesPattern.MatchString(line) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// withoutRunIf drops the run_if of the step, the Android Sign step only runs if a keystore is uploaded by default.
+func withoutRunIf(item bitriseModels.StepListItemModel) bitriseModels.StepListItemModel {
+	key, _, err := item.GetKeyAndType()
+	if err != nil {
+		return item
+	}
+	step, err := item.GetStep()
+	if err != nil {
+		return item
+	}
+	step.RunIf = nil
+	return bitriseModels.StepListItemModel{key: *step}
+}
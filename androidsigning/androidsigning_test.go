@@ -0,0 +1,180 @@
+package androidsigning
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const groovySigningBuildScript = `apply plugin: 'com.android.application'
+
+def keystoreProperties = new Properties()
+keystoreProperties.load(new FileInputStream(rootProject.file("keystore.properties")))
+
+android {
+    signingConfigs {
+        release {
+            storeFile file(keystoreProperties['storeFile'])
+            storePassword keystoreProperties['storePassword']
+        }
+    }
+    buildTypes {
+        release {
+            signingConfig signingConfigs.release
+        }
+    }
+}
+`
+
+const kotlinSigningBuildScript = `plugins {
+    id("com.android.application")
+}
+
+android {
+    signingConfigs {
+        create("upload") {
+            storeFile = file(System.getenv("UPLOAD_KEYSTORE_PATH"))
+        }
+    }
+    buildTypes {
+        getByName("release") {
+            signingConfig = signingConfigs.getByName("upload")
+        }
+    }
+}
+`
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "app", "build.gradle.kts"), kotlinSigningBuildScript)
+	testutil.WriteFile(t, filepath.Join(dir, "app", "upload.jks"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "app", "debug.keystore"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "app", "build", "outputs", "release.jks"), "")
+
+	signing, err := Detect(dir, ".", "app")
+	require.NoError(t, err)
+	require.Equal(t, Signing{
+		SigningConfig: "app/build.gradle.kts (upload)",
+		KeystoreFiles: []string{"app/upload.jks"},
+	}, signing)
+	require.True(t, signing.SignedByGradle())
+}
+
+func TestDetect_missingPropertiesFile(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "app", "build.gradle"), groovySigningBuildScript)
+
+	signing, err := Detect(dir, ".", "app")
+	require.NoError(t, err)
+	require.False(t, signing.SignedByGradle())
+	require.Equal(t, []string{"keystore.properties"}, signing.MissingPropertiesFiles)
+
+	testutil.WriteFile(t, filepath.Join(dir, "keystore.properties"), "storePassword=secret\nstoreFile=release.jks\n")
+
+	signing, err = Detect(dir, ".", "app")
+	require.NoError(t, err)
+	require.Equal(t, Signing{
+		SigningConfig:   "app/build.gradle (release)",
+		PropertiesFiles: []string{"keystore.properties"},
+	}, signing)
+}
+
+func TestDetect_selectedProjectAndModule(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "android", "app", "build.gradle.kts"), kotlinSigningBuildScript)
+	testutil.WriteFile(t, filepath.Join(dir, "android", "app", "upload.jks"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "android", "wear", "build.gradle"), groovySigningBuildScript)
+	testutil.WriteFile(t, filepath.Join(dir, "android", "keystore.properties"), "storePassword=secret\nstoreFile=release.jks\n")
+	testutil.WriteFile(t, filepath.Join(dir, "legacy", "app", "build.gradle"), groovySigningBuildScript)
+	testutil.WriteFile(t, filepath.Join(dir, "legacy", "app", "legacy.jks"), "")
+
+	t.Log("uses the signing config of the selected module")
+	{
+		signing, err := Detect(dir, "android", "wear")
+		require.NoError(t, err)
+		require.Equal(t, Signing{
+			SigningConfig:   "android/wear/build.gradle (release)",
+			PropertiesFiles: []string{"android/keystore.properties"},
+			KeystoreFiles:   []string{"android/app/upload.jks"},
+		}, signing)
+	}
+
+	t.Log("ignores the other Android projects of the repository")
+	{
+		signing, err := Detect(dir, "android", "app")
+		require.NoError(t, err)
+		require.Equal(t, "android/app/build.gradle.kts (upload)", signing.SigningConfig)
+		require.Equal(t, []string{"android/app/upload.jks"}, signing.KeystoreFiles)
+
+		signing, err = Detect(dir, "legacy", "app")
+		require.NoError(t, err)
+		require.False(t, signing.SignedByGradle())
+		require.Equal(t, []string{"keystore.properties"}, signing.MissingPropertiesFiles)
+		require.Equal(t, []string{"legacy/app/legacy.jks"}, signing.KeystoreFiles)
+	}
+}
+
+func TestReleaseSigningConfig(t *testing.T) {
+	require.Equal(t, "", releaseSigningConfig(`android { buildTypes { release { signingConfig signingConfigs.debug } } }`))
+	require.Equal(t, "", releaseSigningConfig(`android { buildTypes { release { minifyEnabled true } } }`))
+	require.Equal(t, "play", releaseSigningConfig(`android { defaultConfig { signingConfig = signingConfigs["play"] } }`))
+}
+
+func buildConfig() bitriseModels.BitriseDataModel {
+	return bitriseModels.BitriseDataModel{
+		ProjectType: android.ScannerName,
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			buildWorkflowID: {Steps: []bitriseModels.StepListItemModel{
+				initSteps.GitCloneStepListItem(),
+				initSteps.AndroidBuildStepListItem(envmanModels.EnvironmentItemModel{android.VariantInputKey: "$VARIANT"}),
+				initSteps.SignAPKStepListItem(),
+				initSteps.DeployToBitriseIoStepListItem(),
+			}},
+		},
+	}
+}
+
+func TestAddReleaseWorkflow(t *testing.T) {
+	config := buildConfig()
+	require.True(t, AddReleaseWorkflow(&config, Signing{}))
+
+	releaseStepList := config.Workflows[ReleaseWorkflowID].Steps
+	require.Len(t, releaseStepList, 4)
+
+	buildStep, err := releaseStepList[steps.Index(releaseStepList, initSteps.AndroidBuildID)].GetStep()
+	require.NoError(t, err)
+	require.Contains(t, buildStep.Inputs, envmanModels.EnvironmentItemModel{buildTypeInputKey: buildTypeAAB})
+	require.Contains(t, buildStep.Inputs, envmanModels.EnvironmentItemModel{android.VariantInputKey: "$VARIANT"})
+
+	signStep, err := releaseStepList[steps.Index(releaseStepList, initSteps.SignAPKID)].GetStep()
+	require.NoError(t, err)
+	require.Nil(t, signStep.RunIf)
+
+	var keys []string
+	for _, env := range SecretEnvs(config) {
+		key, value, err := env.GetKeyValuePair()
+		require.NoError(t, err)
+		require.Empty(t, value)
+		keys = append(keys, key)
+	}
+	require.Equal(t, steps.AndroidKeystoreSecrets, keys)
+}
+
+func TestAddReleaseWorkflow_signedByGradle(t *testing.T) {
+	config := buildConfig()
+	require.True(t, AddReleaseWorkflow(&config, Signing{SigningConfig: "app/build.gradle (release)"}))
+
+	require.False(t, steps.Contains(config.Workflows[ReleaseWorkflowID].Steps, initSteps.SignAPKID))
+	require.False(t, steps.Contains(config.Workflows[buildWorkflowID].Steps, initSteps.SignAPKID))
+	require.Empty(t, SecretEnvs(config))
+
+	config.ProjectType = "ios"
+	require.False(t, AddReleaseWorkflow(&config, Signing{}))
+}
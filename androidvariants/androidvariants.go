@@ -0,0 +1,267 @@
+package androidvariants
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	"github.com/bitrise-io/bitrise-init/detectors/gradle"
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-plugins-init/gradlescript"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultBuildTypes are created by the Android Gradle plugin for every app module.
+var defaultBuildTypes = []string{"debug", "release"}
+
+var (
+	// conventionPluginPattern matches the convention plugins of the build logic, like nowinandroid.android.application,
+	// they may configure the build types and flavors out of the module's build script.
+	conventionPluginPattern = regexp.MustCompile(`[\w.-]+[.-]android[.-]application`)
+	dimensionPattern        = regexp.MustCompile(`\b(?:dimension\s*=?\s*|setDimension\(\s*)["']([\w-]+)["']`)
+	flavorDimensionsPattern = regexp.MustCompile(`\bflavorDimensions\b[^\n]*`)
+	quotedPattern           = regexp.MustCompile(`["']([\w-]+)["']`)
+	// dynamicPattern matches the constructs which create or filter variants in code, the variants of these scripts are not computed.
+	dynamicPattern = regexp.MustCompile(`\bforEach\b|\beach\s*\{|\bfor\s*\(|\ball\s*\{|\bconfigureEach\b|\bvariantFilter\b|\bbeforeVariants\b`)
+)
+
+// Module is an Android app module of a Gradle project.
+type Module struct {
+	// Path is the module dir relative to the Gradle project root, the same as the Android scanner's module option values.
+	Path string
+	// Variants are the build variants of the module, nil if they could not be computed from the build script.
+	Variants []string
+}
+
+// Detect returns the app modules of the Gradle project, the modules applying the com.android.application plugin.
+func Detect(projectDir string) ([]Module, error) {
+	rootEntry, err := direntry.WalkDir(projectDir, 4)
+	if err != nil {
+		return nil, err
+	}
+	project, err := gradle.ScanProject(*rootEntry)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, nil
+	}
+
+	var buildScripts []direntry.DirEntry
+	for _, includedProject := range project.IncludedProjects {
+		buildScripts = append(buildScripts, includedProject.BuildScriptFileEntry)
+	}
+	if len(buildScripts) == 0 {
+		buildScripts = project.AllBuildScriptFileEntries
+	}
+
+	var modules []Module
+	for _, buildScript := range buildScripts {
+		modulePath, err := filepath.Rel(project.RootDirEntry.AbsPath, filepath.Dir(buildScript.AbsPath))
+		if err != nil || modulePath == "." {
+			continue
+		}
+
+		content, err := os.ReadFile(buildScript.AbsPath)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %s", buildScript.RelPath, err)
+		}
+		script := gradlescript.StripComments(string(content))
+
+		isApp := gradlescript.IsApplication(script)
+		if !isApp && !conventionPluginPattern.MatchString(script) {
+			continue
+		}
+
+		module := Module{Path: filepath.ToSlash(modulePath)}
+		if isApp {
+			module.Variants = variants(script)
+		}
+		modules = append(modules, module)
+	}
+
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Path < modules[j].Path })
+	return modules, nil
+}
+
+// RefineOptions replaces the free text module and variant options of the Android scanner with selectors
+// of the detected app modules and their variants. A variant option stays free text if the variants of the module
+// could not be computed, and the options of a Gradle project without detected app modules are kept as they are.
+func RefineOptions(scanResult *models.ScanResultModel, searchDir string) {
+	root, ok := scanResult.ScannerToOptionRoot[android.ScannerName]
+	if !ok {
+		return
+	}
+
+	for location, moduleOption := range root.ChildOptionMap {
+		modules, err := Detect(filepath.Join(searchDir, location))
+		if err != nil {
+			log.Warnf("Failed to detect the Android modules of %s: %s", location, err)
+			continue
+		}
+
+		var detected []Module
+		for _, module := range modules {
+			if variantOption, ok := moduleOption.ChildOptionMap[module.Path]; ok && configOption(variantOption) != nil {
+				detected = append(detected, module)
+			}
+		}
+		if len(detected) == 0 {
+			continue
+		}
+
+		refinedModuleOption := models.NewOption(moduleOption.Title, moduleOption.Summary, moduleOption.EnvKey, models.TypeSelector)
+		root.AddOption(location, refinedModuleOption)
+
+		for _, module := range detected {
+			variantOption := moduleOption.ChildOptionMap[module.Path]
+			config := configOption(variantOption)
+
+			refinedVariantOption := models.NewOption(variantOption.Title, variantOption.Summary, variantOption.EnvKey, models.TypeSelector)
+			refinedModuleOption.AddOption(module.Path, refinedVariantOption)
+			if module.Variants == nil {
+				log.Infof("Android module detected: %s/%s (variants not computed)", location, module.Path)
+				refinedVariantOption.Type = models.TypeOptionalUserInput
+				refinedVariantOption.AddConfig("", models.NewConfigOption(config.Config, config.Icons))
+				continue
+			}
+
+			log.Infof("Android module detected: %s/%s (%s)", location, module.Path, strings.Join(module.Variants, ", "))
+			for _, variant := range module.Variants {
+				refinedVariantOption.AddConfig(variant, models.NewConfigOption(config.Config, config.Icons))
+			}
+		}
+	}
+
+	scanResult.ScannerToOptionRoot[android.ScannerName] = root
+}
+
+// configOption returns the config leaf of the Android scanner's variant option.
+func configOption(variantOption *models.OptionNode) *models.OptionNode {
+	if variantOption == nil {
+		return nil
+	}
+	config, ok := variantOption.ChildOptionMap[""]
+	if !ok || config == nil || !config.IsConfigOption() {
+		return nil
+	}
+	return config
+}
+
+// variants computes the build variants of a module: every combination of one flavor per flavor dimension,
+// in the order of the dimensions, with every build type. It returns nil if the build script creates or filters
+// the build types or flavors in code.
+func variants(script string) []string {
+	android, ok := gradlescript.Block(script, "android")
+	if !ok {
+		return defaultBuildTypes
+	}
+	if dynamicPattern.MatchString(android) {
+		return nil
+	}
+
+	buildTypes := append([]string{}, defaultBuildTypes...)
+	if body, ok := gradlescript.Block(android, "buildTypes"); ok {
+		entries, ok := gradlescript.NamedBlocks(body)
+		if !ok {
+			return nil
+		}
+		for _, entry := range entries {
+			if !slices.Contains(buildTypes, entry.Name) {
+				buildTypes = append(buildTypes, entry.Name)
+			}
+		}
+	}
+
+	var flavorGroups [][]string
+	if body, ok := gradlescript.Block(android, "productFlavors"); ok {
+		entries, ok := gradlescript.NamedBlocks(body)
+		if !ok {
+			return nil
+		}
+		flavorGroups, ok = groupFlavors(entries, flavorDimensions(android))
+		if !ok {
+			return nil
+		}
+	}
+
+	combinations := []string{""}
+	for _, group := range flavorGroups {
+		var next []string
+		for _, prefix := range combinations {
+			for _, flavor := range group {
+				next = append(next, joinVariantName(prefix, flavor))
+			}
+		}
+		combinations = next
+	}
+
+	var result []string
+	for _, combination := range combinations {
+		for _, buildType := range buildTypes {
+			result = append(result, joinVariantName(combination, buildType))
+		}
+	}
+	return result
+}
+
+// groupFlavors groups the flavors by their dimensions, in the order of the flavor dimensions.
+// The flavors of a single dimension project may omit the dimension.
+func groupFlavors(flavors []gradlescript.NamedBlock, dimensions []string) ([][]string, bool) {
+	if len(flavors) == 0 {
+		return nil, true
+	}
+	if len(dimensions) <= 1 {
+		var group []string
+		for _, flavor := range flavors {
+			group = append(group, flavor.Name)
+		}
+		return [][]string{group}, true
+	}
+
+	groups := make([][]string, len(dimensions))
+	for _, flavor := range flavors {
+		match := dimensionPattern.FindStringSubmatch(flavor.Body)
+		if match == nil {
+			return nil, false
+		}
+		index := slices.Index(dimensions, match[1])
+		if index == -1 {
+			return nil, false
+		}
+		groups[index] = append(groups[index], flavor.Name)
+	}
+	for _, group := range groups {
+		if len(group) == 0 {
+			return nil, false
+		}
+	}
+	return groups, true
+}
+
+// flavorDimensions returns the dimensions of the flavorDimensions statements, in the Groovy
+// (flavorDimensions "tier", "env") and in the Kotlin (flavorDimensions += listOf("tier", "env")) forms.
+func flavorDimensions(android string) []string {
+	var dimensions []string
+	for _, statement := range flavorDimensionsPattern.FindAllString(android, -1) {
+		for _, match := range quotedPattern.FindAllStringSubmatch(statement, -1) {
+			if !slices.Contains(dimensions, match[1]) {
+				dimensions = append(dimensions, match[1])
+			}
+		}
+	}
+	return dimensions
+}
+
+func joinVariantName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + strings.ToUpper(name[:1]) + name[1:]
+}
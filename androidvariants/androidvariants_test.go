@@ -0,0 +1,150 @@
+package androidvariants
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const groovyBuildScript = `plugins {
+    id 'com.android.application'
+}
+
+android {
+    flavorDimensions "tier", "env"
+
+    buildTypes {
+        release {
+            minifyEnabled true
+        }
+        // qa { }
+        staging {
+            initWith debug
+        }
+    }
+
+    productFlavors {
+        free {
+            dimension "tier"
+        }
+        paid {
+            dimension "tier"
+        }
+        dev {
+            dimension "env"
+        }
+    }
+}
+`
+
+const kotlinBuildScript = `plugins {
+    alias(libs.plugins.android.application)
+}
+
+android {
+    buildTypes {
+        getByName("release") {
+            isMinifyEnabled = true
+        }
+        create("benchmark")
+    }
+    productFlavors {
+        create("demo") {
+            dimension = "contentType"
+        }
+        create("prod") {
+            dimension = "contentType"
+        }
+    }
+}
+`
+
+const libraryBuildScript = `plugins {
+    id("com.android.library")
+}
+`
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "gradlew"), "#!/bin/sh\n")
+	testutil.WriteFile(t, filepath.Join(dir, "settings.gradle.kts"), `include(":app", ":wear", ":core", ":benchmark")`)
+	testutil.WriteFile(t, filepath.Join(dir, "build.gradle.kts"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "app", "build.gradle"), groovyBuildScript)
+	testutil.WriteFile(t, filepath.Join(dir, "wear", "build.gradle.kts"), kotlinBuildScript)
+	testutil.WriteFile(t, filepath.Join(dir, "core", "build.gradle.kts"), libraryBuildScript)
+	testutil.WriteFile(t, filepath.Join(dir, "benchmark", "build.gradle.kts"), `plugins { id("nowinandroid.android.application") }`)
+
+	modules, err := Detect(dir)
+	require.NoError(t, err)
+	require.Equal(t, []Module{
+		{
+			Path: "app",
+			Variants: []string{
+				"freeDevDebug", "freeDevRelease", "freeDevStaging",
+				"paidDevDebug", "paidDevRelease", "paidDevStaging",
+			},
+		},
+		{Path: "benchmark"},
+		{
+			Path:     "wear",
+			Variants: []string{"demoDebug", "demoRelease", "demoBenchmark", "prodDebug", "prodRelease", "prodBenchmark"},
+		},
+	}, modules)
+}
+
+func TestVariants(t *testing.T) {
+	require.Equal(t, []string{"debug", "release"}, variants(`apply plugin: "com.android.application"`))
+	require.Equal(t, []string{"freeDebug", "freeRelease", "paidDebug", "paidRelease"}, variants(`android {
+    productFlavors {
+        free {}
+        paid {}
+    }
+}`))
+	require.Nil(t, variants(`android {
+    productFlavors {
+        ["free", "paid"].each { name -> create(name) }
+    }
+}`))
+	require.Nil(t, variants(`android {
+    flavorDimensions += listOf("tier", "env")
+    productFlavors {
+        create("free") { dimension = "tier" }
+        create("paid")
+    }
+}`))
+}
+
+func TestRefineOptions(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "android", "gradlew"), "#!/bin/sh\n")
+	testutil.WriteFile(t, filepath.Join(dir, "android", "settings.gradle"), "include ':app'\n")
+	testutil.WriteFile(t, filepath.Join(dir, "android", "build.gradle"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "android", "app", "build.gradle"), groovyBuildScript)
+
+	variantOption := models.NewOption(android.VariantInputTitle, android.VariantInputSummary, android.VariantInputEnvKey, models.TypeOptionalUserInput)
+	moduleOption := models.NewOption(android.ModuleInputTitle, android.ModuleInputSummary, android.ModuleInputEnvKey, models.TypeUserInput)
+	root := models.NewOption(android.ProjectLocationInputTitle, android.ProjectLocationInputSummary, android.ProjectLocationInputEnvKey, models.TypeSelector)
+	root.AddOption("android", moduleOption)
+	moduleOption.AddOption("app", variantOption)
+	variantOption.AddConfig("", models.NewConfigOption(android.ConfigName, nil))
+
+	scanResult := models.ScanResultModel{ScannerToOptionRoot: map[string]models.OptionNode{android.ScannerName: *root}}
+	RefineOptions(&scanResult, dir)
+
+	refinedRoot := scanResult.ScannerToOptionRoot[android.ScannerName]
+	refinedModuleOption, ok := refinedRoot.Child("android")
+	require.True(t, ok)
+	require.Equal(t, models.TypeSelector, refinedModuleOption.Type)
+	require.Equal(t, []string{"app"}, refinedModuleOption.GetValues())
+
+	refinedVariantOption, ok := refinedModuleOption.Child("app")
+	require.True(t, ok)
+	require.Equal(t, models.TypeSelector, refinedVariantOption.Type)
+	require.Equal(t, android.VariantInputEnvKey, refinedVariantOption.EnvKey)
+	require.Len(t, refinedVariantOption.ChildOptionMap, 6)
+	require.Equal(t, android.ConfigName, refinedVariantOption.ChildOptionMap["paidDevStaging"].Config)
+}
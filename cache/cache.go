@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+)
+
+const searchDepth = 6
+
+// Manager describes a dependency manager and how its dependencies are cached.
+type Manager struct {
+	Name string
+	// LockFiles are the file names marking the presence of the dependency manager.
+	LockFiles []string
+
+	// RestoreStepID and SaveStepID are the dedicated cache steps of the dependency manager.
+	RestoreStepID string
+	SaveStepID    string
+	restoreStep   func() bitriseModels.StepListItemModel
+	saveStep      func() bitriseModels.StepListItemModel
+
+	// cachePaths and envs are used with the generic key-based cache steps,
+	// if the dependency manager has no dedicated cache steps.
+	cachePaths []string
+	envs       []envmanModels.EnvironmentItemModel
+}
+
+// Managers are the dependency managers the cache pass knows about.
+var Managers = []Manager{
+	{
+		Name:          "Gradle",
+		LockFiles:     []string{"build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts"},
+		RestoreStepID: initSteps.CacheRestoreGradleID,
+		SaveStepID:    initSteps.CacheSaveGradleID,
+		restoreStep:   initSteps.RestoreGradleCache,
+		saveStep:      initSteps.SaveGradleCache,
+	},
+	{
+		Name:          "CocoaPods",
+		LockFiles:     []string{"Podfile.lock"},
+		RestoreStepID: initSteps.CacheRestoreCocoapodsID,
+		SaveStepID:    initSteps.CacheSaveCocoapodsID,
+		restoreStep:   initSteps.RestoreCocoapodsCache,
+		saveStep:      initSteps.SaveCocoapodsCache,
+	},
+	{
+		Name:          "Carthage",
+		LockFiles:     []string{"Cartfile.resolved"},
+		RestoreStepID: initSteps.CacheRestoreCarthageID,
+		SaveStepID:    initSteps.CacheSaveCarthageID,
+		restoreStep:   initSteps.RestoreCarthageCache,
+		saveStep:      initSteps.SaveCarthageCache,
+	},
+	{
+		Name:          "Swift Package Manager",
+		LockFiles:     []string{"Package.resolved"},
+		RestoreStepID: initSteps.CacheRestoreSPMID,
+		SaveStepID:    initSteps.CacheSaveSPMID,
+		restoreStep:   initSteps.RestoreSPMCache,
+		saveStep:      initSteps.SaveSPMCache,
+	},
+	{
+		Name:          "npm",
+		LockFiles:     []string{"package-lock.json", "yarn.lock"},
+		RestoreStepID: initSteps.CacheRestoreNPMID,
+		SaveStepID:    initSteps.CacheSaveNPMID,
+		restoreStep:   initSteps.RestoreNPMCache,
+		saveStep:      initSteps.SaveNPMCache,
+	},
+	{
+		Name:          "Dart",
+		LockFiles:     []string{"pubspec.lock"},
+		RestoreStepID: initSteps.CacheRestoreDartID,
+		SaveStepID:    initSteps.CacheSaveDartID,
+		restoreStep:   initSteps.RestoreDartCache,
+		saveStep:      initSteps.SaveDartCache,
+	},
+	{
+		Name:      "pnpm",
+		LockFiles: []string{"pnpm-lock.yaml"},
+		// default pnpm store locations on Linux and macOS
+		cachePaths: []string{"~/.local/share/pnpm/store", "~/Library/pnpm/store"},
+	},
+	{
+		Name:       "Bundler",
+		LockFiles:  []string{"Gemfile.lock"},
+		cachePaths: []string{"~/.bundle/vendor"},
+		// install gems into a fixed dir, so that they can be cached wherever the Gemfile is, like in ios/
+		envs: []envmanModels.EnvironmentItemModel{{"BUNDLE_PATH": "$HOME/.bundle/vendor"}},
+	},
+}
+
+// DetectedManager is a dependency manager found in the project, with the relative paths of its lock files.
+type DetectedManager struct {
+	Manager
+	LockFilePaths []string
+}
+
+// IsCached reports whether the step list both restores and saves the cache of the dependency manager.
+func (m DetectedManager) IsCached(stepList []bitriseModels.StepListItemModel) bool {
+	restoreStep, saveStep := m.CacheSteps()
+	return hasCacheStep(stepList, restoreStep) && hasCacheStep(stepList, saveStep)
+}
+
+// HasDedicatedSteps reports whether the dependency manager has its own restore and save cache steps.
+func (m Manager) HasDedicatedSteps() bool {
+	return m.restoreStep != nil && m.saveStep != nil
+}
+
+// Detect returns the dependency managers whose lock files are present in the search dir.
+func Detect(searchDir string) ([]DetectedManager, error) {
+	rootEntry, err := direntry.WalkDir(searchDir, searchDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	var detected []DetectedManager
+	for _, manager := range Managers {
+		var lockFilePaths []string
+		for _, lockFile := range manager.LockFiles {
+			for _, entry := range rootEntry.FindAllEntriesByName(lockFile, false) {
+				lockFilePaths = append(lockFilePaths, strings.TrimPrefix(entry.RelPath, "./"))
+			}
+		}
+		if len(lockFilePaths) == 0 {
+			continue
+		}
+
+		sort.Strings(lockFilePaths)
+		detected = append(detected, DetectedManager{Manager: manager, LockFilePaths: lockFilePaths})
+	}
+
+	return detected, nil
+}
+
+// AddCacheSteps makes sure that every workflow cloning the repository restores the caches of the detected
+// dependency managers right after the git clone step and saves them at the end of the workflow
+// (before the deploy step). Cache steps already present in a workflow or its step bundles are kept as-is.
+func AddCacheSteps(config *bitriseModels.BitriseDataModel, managers []DetectedManager) {
+	for workflowID, workflow := range config.Workflows {
+		if !steps.Contains(workflow.Steps, initSteps.GitCloneID) {
+			continue
+		}
+
+		stepList := workflow.Steps
+		// the cache steps of the step bundles the workflow runs count as its own
+		allSteps := steps.WithBundleSteps(stepList, config.StepBundles)
+		var restoreSteps, saveSteps []bitriseModels.StepListItemModel
+		for _, manager := range managers {
+			restoreStep, saveStep := manager.CacheSteps()
+
+			if !hasCacheStep(allSteps, restoreStep) {
+				restoreSteps = append(restoreSteps, restoreStep)
+			}
+			if !hasCacheStep(allSteps, saveStep) {
+				saveSteps = append(saveSteps, saveStep)
+			}
+
+			if !manager.HasDedicatedSteps() {
+				workflow.Environments = appendMissingEnvs(workflow.Environments, manager.envs)
+			}
+		}
+
+		stepList = steps.Insert(stepList, steps.Index(stepList, initSteps.GitCloneID)+1, restoreSteps...)
+		stepList = steps.Insert(stepList, saveIndex(stepList), saveSteps...)
+
+		workflow.Steps = stepList
+		config.Workflows[workflowID] = workflow
+	}
+}
+
+// CacheSteps returns the restore and save cache steps of the dependency manager.
+func (m DetectedManager) CacheSteps() (bitriseModels.StepListItemModel, bitriseModels.StepListItemModel) {
+	if m.HasDedicatedSteps() {
+		return m.restoreStep(), m.saveStep()
+	}
+
+	key := m.CacheKey()
+	return steps.RestoreCacheStepListItem(fmt.Sprintf("Restore %s cache", m.Name), key),
+		steps.SaveCacheStepListItem(fmt.Sprintf("Save %s cache", m.Name), key, strings.Join(m.cachePaths, "\n"))
+}
+
+// CacheKey returns the key-based cache key of the dependency manager, which changes when any of the lock files change.
+func (m DetectedManager) CacheKey() string {
+	var quotedPaths []string
+	for _, pth := range m.LockFilePaths {
+		quotedPaths = append(quotedPaths, fmt.Sprintf("%q", pth))
+	}
+
+	name := strings.ToLower(strings.ReplaceAll(m.Name, " ", "-"))
+	return fmt.Sprintf(`{{ .OS }}-{{ .Arch }}-%s-{{ checksum %s }}`, name, strings.Join(quotedPaths, " "))
+}
+
+// hasCacheStep reports whether the step list already has the given cache step. Dedicated cache steps are matched
+// by step ID, the generic key-based cache steps by their title as they are used for multiple dependency managers.
+func hasCacheStep(stepList []bitriseModels.StepListItemModel, cacheStep bitriseModels.StepListItemModel) bool {
+	id := steps.ID(cacheStep)
+	if id != steps.RestoreCacheID && id != steps.SaveCacheID {
+		return steps.Contains(stepList, id)
+	}
+
+	cacheStepModel, err := cacheStep.GetStep()
+	if err != nil || cacheStepModel.Title == nil {
+		return false
+	}
+
+	for _, item := range stepList {
+		if steps.ID(item) != id {
+			continue
+		}
+		step, err := item.GetStep()
+		if err == nil && step.Title != nil && *step.Title == *cacheStepModel.Title {
+			return true
+		}
+	}
+	return false
+}
+
+// saveIndex returns the position of the save cache steps: before the deploy step, or at the end of the workflow.
+func saveIndex(stepList []bitriseModels.StepListItemModel) int {
+	if idx := steps.Index(stepList, initSteps.DeployToBitriseIoID); idx != -1 && idx == len(stepList)-1 {
+		return idx
+	}
+	return len(stepList)
+}
+
+func appendMissingEnvs(envs []envmanModels.EnvironmentItemModel, newEnvs []envmanModels.EnvironmentItemModel) []envmanModels.EnvironmentItemModel {
+	for _, newEnv := range newEnvs {
+		newKey, _, err := newEnv.GetKeyValuePair()
+		if err != nil {
+			continue
+		}
+
+		found := false
+		for _, env := range envs {
+			if key, _, err := env.GetKeyValuePair(); err == nil && key == newKey {
+				found = true
+				break
+			}
+		}
+		if !found {
+			envs = append(envs, newEnv)
+		}
+	}
+	return envs
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+func managerByName(t *testing.T, name string) Manager {
+	for _, manager := range Managers {
+		if manager.Name == name {
+			return manager
+		}
+	}
+	require.FailNow(t, "unknown dependency manager: "+name)
+	return Manager{}
+}
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	for _, pth := range []string{"Gemfile.lock", "ios/Podfile.lock", "pnpm-lock.yaml", "node_modules/dep/package-lock.json"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(pth)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, pth), nil, 0644))
+	}
+
+	managers, err := Detect(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, manager := range managers {
+		names = append(names, manager.Name)
+	}
+	require.Equal(t, []string{"CocoaPods", "pnpm", "Bundler"}, names)
+	require.Equal(t, []string{"ios/Podfile.lock"}, managers[0].LockFilePaths)
+	require.Equal(t, `{{ .OS }}-{{ .Arch }}-bundler-{{ checksum "Gemfile.lock" }}`, managers[2].CacheKey())
+}
+
+func TestAddCacheSteps(t *testing.T) {
+	t.Log("adds the missing cache steps around the build steps")
+	{
+		config := bitriseModels.BitriseDataModel{
+			Workflows: map[string]bitriseModels.WorkflowModel{
+				"primary": {
+					Steps: []bitriseModels.StepListItemModel{
+						initSteps.GitCloneStepListItem(),
+						initSteps.RestoreNPMCache(),
+						initSteps.NpmStepListItem("install", ""),
+						initSteps.SaveNPMCache(),
+						initSteps.DeployToBitriseIoStepListItem(),
+					},
+				},
+				"utility": {
+					Steps: []bitriseModels.StepListItemModel{
+						initSteps.ScriptStepListItem("Hello", "echo hello"),
+					},
+				},
+			},
+		}
+
+		managers := []DetectedManager{
+			{Manager: managerByName(t, "npm"), LockFilePaths: []string{"package-lock.json"}},
+			{Manager: managerByName(t, "Bundler"), LockFilePaths: []string{"Gemfile.lock"}},
+		}
+		AddCacheSteps(&config, managers)
+
+		var ids []string
+		for _, item := range config.Workflows["primary"].Steps {
+			ids = append(ids, steps.ID(item))
+		}
+		require.Equal(t, []string{
+			initSteps.GitCloneID,
+			steps.RestoreCacheID,
+			initSteps.CacheRestoreNPMID,
+			initSteps.NpmID,
+			initSteps.CacheSaveNPMID,
+			steps.SaveCacheID,
+			initSteps.DeployToBitriseIoID,
+		}, ids)
+		require.Equal(t, []envmanModels.EnvironmentItemModel{{"BUNDLE_PATH": "$HOME/.bundle/vendor"}}, config.Workflows["primary"].Environments)
+
+		require.Equal(t, 1, len(config.Workflows["utility"].Steps))
+	}
+
+	t.Log("running the pass twice does not duplicate cache steps")
+	{
+		config := bitriseModels.BitriseDataModel{
+			Workflows: map[string]bitriseModels.WorkflowModel{
+				"primary": {
+					Steps: []bitriseModels.StepListItemModel{
+						initSteps.GitCloneStepListItem(),
+					},
+				},
+			},
+		}
+
+		managers := []DetectedManager{{Manager: managerByName(t, "pnpm"), LockFilePaths: []string{"pnpm-lock.yaml"}}}
+		AddCacheSteps(&config, managers)
+		AddCacheSteps(&config, managers)
+
+		require.Equal(t, 3, len(config.Workflows["primary"].Steps))
+	}
+
+	t.Log("the cache steps of the step bundles count as the workflow's own")
+	{
+		config := bitriseModels.BitriseDataModel{
+			StepBundles: map[string]bitriseModels.StepBundleModel{
+				"install": {Steps: []bitriseModels.StepListItemStepOrBundleModel{
+					bitriseModels.StepListItemStepOrBundleModel(initSteps.RestoreNPMCache()),
+					bitriseModels.StepListItemStepOrBundleModel(initSteps.NpmStepListItem("install", "")),
+					bitriseModels.StepListItemStepOrBundleModel(initSteps.SaveNPMCache()),
+				}},
+			},
+			Workflows: map[string]bitriseModels.WorkflowModel{
+				"primary": {
+					Steps: []bitriseModels.StepListItemModel{
+						initSteps.GitCloneStepListItem(),
+						{bitriseModels.StepListItemStepBundleKeyPrefix + "install": bitriseModels.StepBundleListItemModel{}},
+					},
+				},
+			},
+		}
+
+		managers := []DetectedManager{{Manager: managerByName(t, "npm"), LockFilePaths: []string{"package-lock.json"}}}
+		AddCacheSteps(&config, managers)
+
+		require.Equal(t, 2, len(config.Workflows["primary"].Steps))
+	}
+}
+
+func TestCacheSteps(t *testing.T) {
+	// the gems of a Gemfile outside of the repository root are cached too
+	manager := DetectedManager{Manager: managerByName(t, "Bundler"), LockFilePaths: []string{"ios/Gemfile.lock"}}
+	restoreStep, saveStep := manager.CacheSteps()
+
+	restore, err := restoreStep.GetStep()
+	require.NoError(t, err)
+	require.Equal(t, envmanModels.EnvironmentItemModel{"key": `{{ .OS }}-{{ .Arch }}-bundler-{{ checksum "ios/Gemfile.lock" }}`}, restore.Inputs[0])
+
+	save, err := saveStep.GetStep()
+	require.NoError(t, err)
+	require.Equal(t, envmanModels.EnvironmentItemModel{"paths": "~/.bundle/vendor"}, save.Inputs[1])
+}
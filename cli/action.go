@@ -10,10 +10,13 @@ import (
 
 	"github.com/bitrise-io/bitrise-init/scanner"
 	"github.com/bitrise-io/bitrise-init/scanners"
+	"github.com/bitrise-io/bitrise-plugins-init/scanresult"
+	"github.com/bitrise-io/bitrise-plugins-init/storedeploy"
 	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
-	envmanModels "github.com/bitrise-io/envman/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
 	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/sliceutil"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
@@ -21,6 +24,11 @@ import (
 func action(c *cli.Context) error {
 	minimal := c.Bool("minimal")
 
+	playTrack := c.String("play-track")
+	if c.Bool("store-deploy") && !sliceutil.IsStringInSlice(playTrack, storedeploy.Tracks) {
+		return fmt.Errorf("invalid Google Play track (%s), available tracks: %s", playTrack, strings.Join(storedeploy.Tracks, ", "))
+	}
+
 	configPth := "./bitrise.yml"
 	if exist, err := pathutil.IsPathExists(configPth); err != nil {
 		return err
@@ -37,6 +45,7 @@ func action(c *cli.Context) error {
 
 	// generate config
 	var bitriseConfig bitriseModels.BitriseDataModel
+	secrets := envmanModels.EnvsSerializeModel{}
 	if minimal {
 		scanResult, err := scanner.ManualConfig()
 		if err != nil {
@@ -67,17 +76,34 @@ func action(c *cli.Context) error {
 		}
 
 		isPrivateRepo := c.Bool("private")
-		scanResult := scanner.Config(currentDir, isPrivateRepo)
+		scanResult := scanProject(currentDir, isPrivateRepo)
 
 		if len(scanResult.ScannerToOptionRoot) == 0 {
 			return fmt.Errorf("no known platform type detected")
 		}
 
-		config, err := scanner.AskForConfig(scanResult)
+		inference, recommendations := inferExportMethod(currentDir)
+		config, err := scanresult.AskForConfig(scanResult, recommendations)
 		if err != nil {
 			return err
 		}
 
+		if c.Bool("fastlane-lanes") {
+			secrets.Envs = addFastlaneLaneWorkflows(&config, currentDir, isPrivateRepo)
+		}
+		addAndroidReleaseWorkflow(&config, currentDir)
+		addFlutterWorkflows(&config, currentDir)
+		addE2ETestWorkflows(&config, currentDir)
+		addMaestroWorkflow(&config, currentDir)
+		addNodeWorkspaceWorkflows(&config, currentDir)
+		if c.Bool("store-deploy") {
+			addStoreDeployWorkflows(&config, currentDir, playTrack)
+		}
+		secrets.Envs = append(secrets.Envs, scannerSecrets(config)...)
+		warnManualSigning(config, inference, secrets.Envs)
+
+		augmentConfig(&config, currentDir)
+
 		bitriseConfig = config
 	}
 
@@ -93,7 +119,6 @@ func action(c *cli.Context) error {
 
 	log.Infof("bitrise config generated at: %s", configPth)
 
-	secrets := envmanModels.EnvsSerializeModel{}
 	secretsBytes, err := yaml.Marshal(secrets)
 	if err != nil {
 		return fmt.Errorf("failed to marshal bitrise secrets, error: %s", err)
@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	"github.com/bitrise-io/bitrise-plugins-init/androidsigning"
+	"github.com/bitrise-io/bitrise-plugins-init/cache"
+	"github.com/bitrise-io/bitrise-plugins-init/e2etests"
+	"github.com/bitrise-io/bitrise-plugins-init/exportmethod"
+	"github.com/bitrise-io/bitrise-plugins-init/fastlane"
+	"github.com/bitrise-io/bitrise-plugins-init/flutterbuild"
+	"github.com/bitrise-io/bitrise-plugins-init/maestro"
+	"github.com/bitrise-io/bitrise-plugins-init/nodeworkspaces"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/dotnet"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/unity"
+	"github.com/bitrise-io/bitrise-plugins-init/scanresult"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/storedeploy"
+	"github.com/bitrise-io/bitrise-plugins-init/toolversions"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// augmentConfig runs the post-generation passes on the scanner generated config.
+func augmentConfig(config *bitriseModels.BitriseDataModel, searchDir string) {
+	tools, err := toolversions.Detect(searchDir)
+	if err != nil {
+		log.Warnf("Failed to detect tool versions: %s", err)
+	} else if len(tools) > 0 {
+		for _, tool := range tools {
+			log.Infof("Tool version detected: %s %s (%s)", tool.Name, tool.Version, tool.Source)
+		}
+		toolversions.AddSetupStep(config, tools)
+	}
+
+	managers, err := cache.Detect(searchDir)
+	if err != nil {
+		log.Warnf("Failed to detect dependency managers: %s", err)
+	} else if len(managers) > 0 {
+		for _, manager := range managers {
+			log.Infof("Dependency manager detected: %s (%s)", manager.Name, manager.LockFilePaths[0])
+		}
+		cache.AddCacheSteps(config, managers)
+	}
+}
+
+// addFastlaneLaneWorkflows adds a workflow for every lane of the project's Fastfiles
+// and returns the secrets the fastlane configuration expects, as empty secret envs.
+func addFastlaneLaneWorkflows(config *bitriseModels.BitriseDataModel, searchDir string, isPrivateRepo bool) []envmanModels.EnvironmentItemModel {
+	fastfiles, err := fastlane.Detect(searchDir)
+	if err != nil {
+		log.Warnf("Failed to detect fastlane lanes: %s", err)
+		return nil
+	}
+	if len(fastfiles) == 0 {
+		log.Warnf("No fastlane lanes found")
+		return nil
+	}
+
+	var sshKeyActivation models.SSHKeyActivation
+	if isPrivateRepo {
+		sshKeyActivation = models.SSHKeyActivationMandatory
+	} else {
+		sshKeyActivation = models.SSHKeyActivationNone
+	}
+	for _, workflowID := range fastlane.AddLaneWorkflows(config, fastfiles, sshKeyActivation) {
+		log.Infof("Workflow generated for fastlane lane: %s", workflowID)
+	}
+
+	secrets, err := fastlane.Secrets(searchDir, fastfiles)
+	if err != nil {
+		log.Warnf("Failed to detect fastlane secrets: %s", err)
+		return nil
+	}
+	for _, secret := range secrets {
+		log.Infof("Secret placeholder added: %s (%s)", secret.Key, secret.Source)
+	}
+	return fastlane.SecretEnvs(secrets)
+}
+
+// addAndroidReleaseWorkflow adds the release App Bundle workflow to the Android config, signed on Bitrise
+// or by the project's Gradle signing config, and warns about the keystores committed to the repository.
+func addAndroidReleaseWorkflow(config *bitriseModels.BitriseDataModel, searchDir string) {
+	if config.ProjectType != android.ScannerName {
+		return
+	}
+
+	projectLocation := steps.AppEnv(*config, android.ProjectLocationInputEnvKey)
+	module := steps.AppEnv(*config, android.ModuleInputEnvKey)
+	signing, err := androidsigning.Detect(searchDir, projectLocation, module)
+	if err != nil {
+		log.Warnf("Failed to detect the Android signing setup: %s", err)
+		return
+	}
+
+	for _, keystore := range signing.KeystoreFiles {
+		log.Warnf("Keystore committed to the repository: %s", keystore)
+		log.Warnf("Please be advised, that for security considerations, it is recommended to upload the keystore to Bitrise instead")
+	}
+	for _, propertiesFile := range signing.MissingPropertiesFiles {
+		log.Warnf("The release signing config reads %s, which is not in the repository, the app is signed on Bitrise", propertiesFile)
+	}
+
+	if !androidsigning.AddReleaseWorkflow(config, signing) {
+		return
+	}
+	if signing.SignedByGradle() {
+		log.Infof("Workflow generated for the release App Bundle: %s (signed by %s)", androidsigning.ReleaseWorkflowID, signing.SigningConfig)
+	} else {
+		log.Infof("Workflow generated for the release App Bundle: %s (signed on Bitrise)", androidsigning.ReleaseWorkflowID)
+	}
+}
+
+// addFlutterWorkflows passes the selected flavor to the Flutter builds and adds the integration test workflow
+// to the Flutter config.
+func addFlutterWorkflows(config *bitriseModels.BitriseDataModel, searchDir string) {
+	if flavor, ok, err := flutterbuild.SetFlavor(config, searchDir); err != nil {
+		log.Warnf("Failed to detect the Flutter flavors: %s", err)
+	} else if ok {
+		log.Infof("Flutter builds use the flavor: %s (%s)", flavor.Name, flavor.BuildParams())
+	}
+
+	added, err := flutterbuild.AddIntegrationTestWorkflow(config, searchDir)
+	if err != nil {
+		log.Warnf("Failed to add the Flutter integration test workflow: %s", err)
+	} else if added {
+		log.Infof("Workflow generated for the integration tests: %s (sharded by the %s pipeline)", flutterbuild.IntegrationTestWorkflowID, flutterbuild.IntegrationTestPipelineID)
+	}
+}
+
+// addE2ETestWorkflows adds the Detox, Maestro and Appium test workflows to the React Native config,
+// run by a pipeline after the primary workflow.
+func addE2ETestWorkflows(config *bitriseModels.BitriseDataModel, searchDir string) {
+	workflowIDs, err := e2etests.AddWorkflows(config, searchDir)
+	if err != nil {
+		log.Warnf("Failed to detect the end-to-end tests: %s", err)
+		return
+	}
+	for _, workflowID := range workflowIDs {
+		log.Infof("Workflow generated for the end-to-end tests: %s (run by the %s pipeline)", workflowID, e2etests.PipelineID)
+	}
+}
+
+// addMaestroWorkflow adds the Maestro UI test workflow to the native Android and iOS configs, running the flows
+// whose appId is the application ID of the config's app.
+func addMaestroWorkflow(config *bitriseModels.BitriseDataModel, searchDir string) {
+	var appID string
+	var err error
+	switch config.ProjectType {
+	case android.ScannerName:
+		appID, err = storedeploy.PackageName(searchDir, *config)
+	case string(ios.XcodeProjectTypeIOS):
+		appID, err = storedeploy.BundleID(searchDir, *config)
+	default:
+		return
+	}
+	if err != nil {
+		log.Warnf("Failed to read the application ID: %s", err)
+		return
+	}
+
+	flows, err := maestro.FindFlows(searchDir)
+	if err != nil {
+		log.Warnf("Failed to detect the Maestro flows: %s", err)
+		return
+	}
+	if len(flows) == 0 {
+		return
+	}
+	appFlows := maestro.FlowsOf(flows, appID)
+	if len(appFlows) == 0 {
+		log.Warnf("None of the %d Maestro flows tests the app (%s), no UI test workflow generated", len(flows), appID)
+		return
+	}
+
+	if !maestro.AddUITestWorkflow(config, appFlows) {
+		return
+	}
+	if shards := maestro.Shards(appFlows); shards > 1 {
+		log.Infof("Workflow generated for the Maestro flows: %s (%d flows, sharded by the %s pipeline into %d)", maestro.UITestWorkflowID, len(appFlows), maestro.UITestPipelineID, shards)
+	} else {
+		log.Infof("Workflow generated for the Maestro flows: %s (%d flows)", maestro.UITestWorkflowID, len(appFlows))
+	}
+}
+
+// addNodeWorkspaceWorkflows replaces the test workflow of the Node.js workspace config with the workflows
+// of the selected workspace mode.
+func addNodeWorkspaceWorkflows(config *bitriseModels.BitriseDataModel, searchDir string) {
+	workflowIDs, err := nodeworkspaces.Apply(config, searchDir)
+	if err != nil {
+		log.Warnf("Failed to generate the Node.js workspace workflows: %s", err)
+		return
+	}
+	for _, workflowID := range workflowIDs {
+		log.Infof("Workflow generated for the Node.js workspace: %s (dependencies installed by the %s step bundle)", workflowID, nodeworkspaces.InstallBundleID)
+	}
+}
+
+// addStoreDeployWorkflows adds the Google Play deploy workflow to the configs building an Android app and the
+// App Store deploy workflow to the configs archiving an iOS app, and stores the app's package name and bundle ID as app envs.
+func addStoreDeployWorkflows(config *bitriseModels.BitriseDataModel, searchDir, playTrack string) {
+	packageName, err := storedeploy.PackageName(searchDir, *config)
+	if err != nil {
+		log.Warnf("Failed to read the Android package name: %s", err)
+	}
+	if storedeploy.AddGooglePlayWorkflow(config, packageName, playTrack) {
+		log.Infof("Workflow generated for the Google Play deploy: %s (%s track)", storedeploy.GooglePlayWorkflowID, playTrack)
+		if packageName == "" {
+			log.Warnf("Android package name not found, please set the %s app env", storedeploy.PackageNameEnvKey)
+		}
+	}
+
+	bundleID, err := storedeploy.BundleID(searchDir, *config)
+	if err != nil {
+		log.Warnf("Failed to read the iOS bundle ID: %s", err)
+	}
+	if storedeploy.AddAppStoreWorkflow(config, bundleID) {
+		log.Infof("Workflow generated for the App Store deploy: %s", storedeploy.AppStoreWorkflowID)
+		if bundleID == "" {
+			log.Warnf("iOS bundle ID not found, please set the %s app env", storedeploy.BundleIDEnvKey)
+		}
+	}
+}
+
+// inferExportMethod infers the iOS export method from the signing setup of the repository and returns it as the
+// recommended distribution method of the iOS option trees.
+func inferExportMethod(searchDir string) (exportmethod.Inference, map[string]scanresult.Recommendation) {
+	inference, err := exportmethod.Infer(searchDir)
+	if err != nil {
+		log.Warnf("Failed to infer the iOS export method: %s", err)
+		return exportmethod.Inference{}, nil
+	}
+	if inference.Method == "" {
+		return inference, nil
+	}
+
+	log.Infof("iOS export method inferred: %s (%s)", inference.Method, inference.Reason)
+	return inference, map[string]scanresult.Recommendation{
+		ios.DistributionMethodEnvKey: {Value: inference.Method, Reason: inference.Explanation()},
+	}
+}
+
+// warnManualSigning warns if the iOS app of the config is signed manually, but the certificate and provisioning
+// profile secrets are neither set in the environment nor in the generated secrets.
+func warnManualSigning(config bitriseModels.BitriseDataModel, inference exportmethod.Inference, secrets []envmanModels.EnvironmentItemModel) {
+	if !inference.ManualSigning || steps.AppEnv(config, ios.DistributionMethodEnvKey) == "" {
+		return
+	}
+
+	configured := map[string]bool{}
+	for _, env := range secrets {
+		if key, _, err := env.GetKeyValuePair(); err == nil {
+			configured[key] = true
+		}
+	}
+	var missing []string
+	for _, key := range exportmethod.ProfileSecrets {
+		if !configured[key] && os.Getenv(key) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	if len(inference.ProfileSpecifiers) > 0 {
+		log.Warnf("The iOS app is signed manually with the provisioning profiles: %s", strings.Join(inference.ProfileSpecifiers, ", "))
+	} else {
+		log.Warnf("The iOS app is signed manually (CODE_SIGN_STYLE = Manual)")
+	}
+	log.Warnf("No code signing secrets are configured (%s), please upload the certificate and the provisioning profiles to Bitrise", strings.Join(missing, ", "))
+}
+
+// scannerSecrets returns the code signing and license secrets the scanner generated config expects, as empty secret envs.
+func scannerSecrets(config bitriseModels.BitriseDataModel) []envmanModels.EnvironmentItemModel {
+	envs := androidsigning.SecretEnvs(config)
+	envs = append(envs, storedeploy.SecretEnvs(config)...)
+	envs = append(envs, dotnet.SigningSecretEnvs(config)...)
+	envs = append(envs, unity.LicenseSecretEnvs(config)...)
+	for _, env := range envs {
+		key, _, err := env.GetKeyValuePair()
+		if err != nil {
+			continue
+		}
+		log.Infof("Secret placeholder added: %s", key)
+	}
+	return envs
+}
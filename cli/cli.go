@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 
+	"github.com/bitrise-io/bitrise-plugins-init/storedeploy"
 	"github.com/bitrise-io/bitrise-plugins-init/version"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -45,6 +47,102 @@ func Run() {
 			Name:  "private",
 			Usage: "is a private repository",
 		},
+		cli.BoolFlag{
+			Name:  "fastlane-lanes",
+			Usage: "generate a workflow for every fastlane lane",
+		},
+		cli.BoolFlag{
+			Name:  "store-deploy",
+			Usage: "generate store deploy workflows: Google Play for Android and App Store Connect for iOS apps",
+		},
+		cli.StringFlag{
+			Name:  "play-track",
+			Value: storedeploy.DefaultTrack,
+			Usage: "Google Play track of the store deploy workflow: " + strings.Join(storedeploy.Tracks, ", "),
+		},
+	}
+
+	app.Commands = []cli.Command{
+		{
+			Name:  "upgrade",
+			Usage: "Update an existing bitrise config to what the scanners generate today",
+			Action: func(c *cli.Context) error {
+				if err := upgradeAction(c); err != nil {
+					log.Fatal(err)
+				}
+
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "config",
+					Value: "./bitrise.yml",
+					Usage: "path of the bitrise config to upgrade",
+				},
+				cli.BoolFlag{
+					Name:  "yes",
+					Usage: "apply all proposed changes without asking",
+				},
+				cli.BoolFlag{
+					Name:  "private",
+					Usage: "is a private repository",
+				},
+			},
+		},
+		{
+			Name:  "doctor",
+			Usage: "Audit an existing bitrise config against the project",
+			Action: func(c *cli.Context) error {
+				if err := doctorAction(c); err != nil {
+					log.Fatal(err)
+				}
+
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "config",
+					Value: "./bitrise.yml",
+					Usage: "path of the bitrise config to audit",
+				},
+				cli.StringFlag{
+					Name:  "secrets",
+					Value: "./.bitrise.secrets.yml",
+					Usage: "path of the bitrise secrets",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Value: "text",
+					Usage: "output format: text or json",
+				},
+				cli.BoolFlag{
+					Name:  "private",
+					Usage: "is a private repository",
+				},
+			},
+		},
+		{
+			Name:  "import",
+			Usage: "Generate a bitrise config from the config of another CI service",
+			Action: func(c *cli.Context) error {
+				if err := importAction(c); err != nil {
+					log.Fatal(err)
+				}
+
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "source",
+					Usage: "CI service to import: " + strings.Join(importSourceNames(), ", ") + " (detected if not set)",
+				},
+				cli.StringFlag{
+					Name:  "config",
+					Value: "./bitrise.yml",
+					Usage: "path of the bitrise config to generate",
+				},
+			},
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-plugins-init/cache"
+	"github.com/bitrise-io/bitrise-plugins-init/doctor"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	initLog "github.com/bitrise-io/go-utils/log"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	textFormat = "text"
+	jsonFormat = "json"
+)
+
+func doctorAction(c *cli.Context) error {
+	configPth := c.String("config")
+	secretsPth := c.String("secrets")
+	format := c.String("format")
+
+	if format != textFormat && format != jsonFormat {
+		return fmt.Errorf("invalid format (%s), available formats: %s, %s", format, textFormat, jsonFormat)
+	}
+	if format == jsonFormat {
+		// keep the scanner logs out of the JSON report
+		initLog.SetOutWriter(os.Stderr)
+	}
+
+	content, err := os.ReadFile(configPth)
+	if err != nil {
+		return fmt.Errorf("failed to read bitrise config, error: %s", err)
+	}
+
+	var config bitriseModels.BitriseDataModel
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return fmt.Errorf("failed to parse bitrise config, error: %s", err)
+	}
+
+	secretKeys, err := readSecretKeys(secretsPth)
+	if err != nil {
+		return err
+	}
+
+	// scan the project
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory, error: %s", err)
+	}
+
+	managers, err := cache.Detect(currentDir)
+	if err != nil {
+		log.Warnf("Failed to detect dependency managers: %s", err)
+	}
+
+	findings := doctor.Audit(config, doctor.Project{
+		SearchDir:          currentDir,
+		ScanResult:         scanProject(currentDir, c.Bool("private")),
+		DependencyManagers: managers,
+		SecretKeys:         secretKeys,
+	})
+
+	if format == jsonFormat {
+		if findings == nil {
+			findings = []doctor.Finding{}
+		}
+		out, err := json.MarshalIndent(struct {
+			Findings []doctor.Finding `json:"findings"`
+		}{findings}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal findings, error: %s", err)
+		}
+		fmt.Println(string(out))
+	} else {
+		printFindings(findings)
+	}
+
+	if doctor.HasErrors(findings) {
+		return fmt.Errorf("bitrise config (%s) has errors", configPth)
+	}
+
+	return nil
+}
+
+func printFindings(findings []doctor.Finding) {
+	fmt.Println()
+	if len(findings) == 0 {
+		fmt.Println("No problems found")
+		return
+	}
+
+	errorCount := 0
+	for _, finding := range findings {
+		if finding.Severity == doctor.Error {
+			errorCount++
+		}
+
+		location := "app"
+		if finding.WorkflowID != "" {
+			location = "workflows." + finding.WorkflowID
+		}
+		fmt.Printf("%-7s %-15s %s: %s\n", finding.Severity, finding.Check, location, finding.Message)
+	}
+	fmt.Println()
+	fmt.Printf("%d error(s), %d warning(s)\n", errorCount, len(findings)-errorCount)
+}
+
+func readSecretKeys(pth string) ([]string, error) {
+	content, err := os.ReadFile(pth)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets, error: %s", err)
+	}
+
+	var secrets envmanModels.EnvsSerializeModel
+	if err := yaml.Unmarshal(content, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets, error: %s", err)
+	}
+
+	var keys []string
+	for _, env := range secrets.Envs {
+		if key, _, err := env.GetKeyValuePair(); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-init/importer"
+	"github.com/bitrise-io/bitrise-plugins-init/importer/circleci"
+	"github.com/bitrise-io/bitrise-plugins-init/importer/githubactions"
+	"github.com/bitrise-io/bitrise-plugins-init/importer/gitlabci"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// importSources are the CI services whose configs can be imported, in detection order.
+var importSources = []importer.Source{
+	githubactions.Source{},
+	gitlabci.Source{},
+	circleci.Source{},
+}
+
+func importSourceNames() []string {
+	var names []string
+	for _, source := range importSources {
+		names = append(names, source.Name())
+	}
+	return names
+}
+
+func importAction(c *cli.Context) error {
+	sourceName := c.String("source")
+	configPth := c.String("config")
+
+	if exist, err := pathutil.IsPathExists(configPth); err != nil {
+		return err
+	} else if exist {
+		return fmt.Errorf("config path (%s) already exist", configPth)
+	}
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory, error: %s", err)
+	}
+
+	source, err := selectImportSource(sourceName, currentDir)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Importing %s config", source.Name())
+
+	result, err := source.Import(currentDir)
+	if err != nil {
+		return fmt.Errorf("failed to import %s config, error: %s", source.Name(), err)
+	}
+
+	configBytes, err := importer.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bitrise config, error: %s", err)
+	}
+
+	if err := fileutil.WriteBytesToFile(configPth, configBytes); err != nil {
+		return fmt.Errorf("failed to write bitrise config, error: %s", err)
+	}
+
+	log.Infof("bitrise config generated at: %s", configPth)
+	log.Infof("%d workflow(s) and %d pipeline(s) imported", len(result.Config.Workflows), len(result.Config.Pipelines))
+
+	if len(result.TODOs) > 0 {
+		fmt.Println()
+		log.Warnf("%d part(s) of the %s config could not be translated, they are marked with TODO comments:", len(result.TODOs), source.Name())
+		for _, todo := range result.TODOs {
+			fmt.Println("- " + todo.String())
+		}
+	}
+
+	return nil
+}
+
+func selectImportSource(sourceName, searchDir string) (importer.Source, error) {
+	if sourceName != "" {
+		for _, source := range importSources {
+			if source.Name() == sourceName {
+				return source, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown source (%s), available sources: %s", sourceName, strings.Join(importSourceNames(), ", "))
+	}
+
+	for _, source := range importSources {
+		detected, err := source.Detect(searchDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect %s config, error: %s", source.Name(), err)
+		}
+		if detected {
+			return source, nil
+		}
+	}
+	return nil, fmt.Errorf("no CI config found to import, available sources: %s", strings.Join(importSourceNames(), ", "))
+}
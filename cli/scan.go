@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanner"
+	"github.com/bitrise-io/bitrise-plugins-init/androidvariants"
+	"github.com/bitrise-io/bitrise-plugins-init/flutterbuild"
+	"github.com/bitrise-io/bitrise-plugins-init/nodeworkspaces"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// scanProject runs the project and automation tool scanners of bitrise-init and the plugin's own scanners
+// on the search dir. The outputs of the bitrise-init scanners excluded by a detected plugin scanner are dropped,
+// the free text Android module and variant options are replaced with the detected modules and variants,
+// the Flutter projects with flavors get a flavor option and the Node.js workspace roots a workspace mode option.
+func scanProject(searchDir string, isPrivateRepo bool) models.ScanResultModel {
+	scanResult := scanner.Config(searchDir, isPrivateRepo)
+
+	if absSearchDir, err := pathutil.AbsPath(searchDir); err == nil {
+		searchDir = absSearchDir
+	}
+	scanners.Merge(&scanResult, scanners.Run(scanners.ProjectScanners(), searchDir, isPrivateRepo))
+	androidvariants.RefineOptions(&scanResult, searchDir)
+	flutterbuild.RefineOptions(&scanResult, searchDir)
+	nodeworkspaces.RefineOptions(&scanResult, searchDir)
+
+	return scanResult
+}
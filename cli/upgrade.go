@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	yamlv2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bitrise-io/bitrise-plugins-init/scanresult"
+	"github.com/bitrise-io/bitrise-plugins-init/upgrade"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/goinp/goinp"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+func upgradeAction(c *cli.Context) error {
+	configPth := c.String("config")
+	acceptAll := c.Bool("yes")
+
+	content, err := os.ReadFile(configPth)
+	if err != nil {
+		return fmt.Errorf("failed to read bitrise config, error: %s", err)
+	}
+
+	var existing bitriseModels.BitriseDataModel
+	if err := yamlv2.Unmarshal(content, &existing); err != nil {
+		return fmt.Errorf("failed to parse bitrise config, error: %s", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("failed to parse bitrise config, error: %s", err)
+	}
+
+	// rescan the project
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory, error: %s", err)
+	}
+
+	scanResult := scanProject(currentDir, c.Bool("private"))
+	recommended, found, err := scanresult.SelectConfig(scanResult, existing)
+	if err != nil {
+		return err
+	}
+	if found {
+		augmentConfig(&recommended, currentDir)
+	} else {
+		log.Warnf("No scanner generates configs for the project type (%s), only step updates are proposed", existing.ProjectType)
+	}
+
+	changes := upgrade.Plan(existing, recommended)
+	if len(changes) == 0 {
+		log.Infof("bitrise config is up to date")
+		return nil
+	}
+
+	// review changes
+	var accepted []upgrade.Change
+	for _, change := range changes {
+		fmt.Println()
+		fmt.Println(change.Summary)
+		fmt.Println(change.Hunk)
+
+		if !acceptAll {
+			apply, err := goinp.AskForBoolWithDefault("Apply this change?", true)
+			if err != nil {
+				return err
+			}
+			if !apply {
+				continue
+			}
+		}
+		accepted = append(accepted, change)
+	}
+	fmt.Println()
+
+	if len(accepted) == 0 {
+		log.Infof("No changes applied")
+		return nil
+	}
+
+	if err := upgrade.Apply(&doc, accepted); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to marshal bitrise config, error: %s", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to marshal bitrise config, error: %s", err)
+	}
+
+	if err := fileutil.WriteBytesToFile(configPth, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write bitrise config, error: %s", err)
+	}
+
+	log.Infof("%d of %d changes applied to: %s", len(accepted), len(changes), configPth)
+
+	return nil
+}
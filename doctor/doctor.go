@@ -0,0 +1,319 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/cache"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/upgrade"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+)
+
+// Severity is the severity of a finding.
+type Severity string
+
+const (
+	// Error findings break the builds.
+	Error Severity = "error"
+	// Warning findings are recommendations.
+	Warning Severity = "warning"
+)
+
+// Checks reported by Audit.
+const (
+	StalePathCheck      = "stale-path"
+	MissingCacheCheck   = "missing-cache"
+	OutdatedStepCheck   = "outdated-step"
+	DeprecatedStepCheck = "deprecated-step"
+	MissingDeployCheck  = "missing-deploy"
+	MissingSecretCheck  = "missing-secret"
+)
+
+const secretsFileName = ".bitrise.secrets.yml"
+
+// pathEnvKeys are the scanner env keys holding a project relative path.
+var pathEnvKeys = []string{
+	ios.ProjectPathInputEnvKey,
+	android.ProjectLocationInputEnvKey,
+	"BITRISE_FLUTTER_PROJECT_LOCATION",
+	"NODEJS_PROJECT_DIR",
+	"PROJECT_ROOT_DIR",
+	"WORKDIR",
+	"FASTLANE_WORK_DIR",
+	"CORDOVA_WORK_DIR",
+	"IONIC_WORK_DIR",
+}
+
+// builtInEnvPrefixes are the prefixes of the env vars exposed by the Bitrise CLI and the steps.
+var builtInEnvPrefixes = []string{"BITRISE_", "BITRISEIO_", "GIT_CLONE_", "GIT_REPOSITORY_"}
+
+var envReferencePattern = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)\}?`)
+
+// Finding is a single problem of the audited config.
+type Finding struct {
+	Severity   Severity `json:"severity"`
+	Check      string   `json:"check"`
+	WorkflowID string   `json:"workflow,omitempty"`
+	Message    string   `json:"message"`
+}
+
+// Project is the current state of the repository the config is audited against.
+type Project struct {
+	SearchDir          string
+	ScanResult         models.ScanResultModel
+	DependencyManagers []cache.DetectedManager
+	// SecretKeys are the keys defined in the .bitrise.secrets.yml file.
+	SecretKeys []string
+}
+
+// Audit checks the config against the project and returns the findings, app level findings first,
+// followed by the workflow level ones in workflow ID order.
+func Audit(config bitriseModels.BitriseDataModel, project Project) []Finding {
+	optionValues := collectOptionValues(project.ScanResult)
+
+	findings := checkEnvs("", config.App.Environments, project, optionValues)
+
+	definedEnvs := map[string]bool{}
+	for _, key := range project.SecretKeys {
+		definedEnvs[key] = true
+	}
+	addEnvKeys(definedEnvs, config.App.Environments)
+
+	plannedChanges := map[string][]upgrade.Change{}
+	for _, change := range upgrade.Plan(config, bitriseModels.BitriseDataModel{}) {
+		plannedChanges[change.WorkflowID] = append(plannedChanges[change.WorkflowID], change)
+	}
+
+	for _, workflowID := range maputil.SortedKeys(config.Workflows) {
+		workflow := config.Workflows[workflowID]
+		stepList := steps.WithBundleSteps(workflow.Steps, config.StepBundles)
+
+		findings = append(findings, checkEnvs(workflowID, workflow.Environments, project, optionValues)...)
+		findings = append(findings, checkSteps(workflowID, stepList, project.DependencyManagers)...)
+
+		for _, change := range plannedChanges[workflowID] {
+			check := DeprecatedStepCheck
+			if change.Kind == upgrade.VersionBump {
+				check = OutdatedStepCheck
+			}
+			findings = append(findings, Finding{Severity: Warning, Check: check, WorkflowID: workflowID, Message: change.Summary})
+		}
+
+		workflowEnvs := map[string]bool{}
+		for key := range definedEnvs {
+			workflowEnvs[key] = true
+		}
+		addEnvKeys(workflowEnvs, workflow.Environments)
+		findings = append(findings, checkSecrets(workflowID, stepList, workflowEnvs)...)
+	}
+
+	return findings
+}
+
+// HasErrors reports whether any of the findings is an error.
+func HasErrors(findings []Finding) bool {
+	for _, finding := range findings {
+		if finding.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// checkEnvs reports the envs referencing a path, scheme or module, which the scanners no longer find.
+func checkEnvs(workflowID string, envs []envmanModels.EnvironmentItemModel, project Project, optionValues map[string]*selectorValues) []Finding {
+	values := map[string]string{}
+	for _, env := range envs {
+		if key, value, err := env.GetKeyValuePair(); err == nil {
+			values[key] = value
+		}
+	}
+
+	var findings []Finding
+	for _, env := range envs {
+		key, value, err := env.GetKeyValuePair()
+		if err != nil || value == "" || strings.Contains(value, "$") {
+			continue
+		}
+
+		if knownValues, ok := optionValues[key]; ok {
+			if !knownValues.values[filepath.Clean(value)] {
+				// the optional selectors accept custom values too, like a build variant the scanners can not list
+				severity := Error
+				if knownValues.optional {
+					severity = Warning
+				}
+				findings = append(findings, Finding{
+					Severity:   severity,
+					Check:      StalePathCheck,
+					WorkflowID: workflowID,
+					Message:    fmt.Sprintf("%s (%s) is not found by the scanners, found: %s", key, value, strings.Join(maputil.SortedKeys(knownValues.values), ", ")),
+				})
+			}
+			continue
+		}
+
+		pth := ""
+		switch {
+		case slices.Contains(pathEnvKeys, key):
+			pth = value
+		case key == android.ModuleInputEnvKey && values[android.ProjectLocationInputEnvKey] != "":
+			pth = filepath.Join(values[android.ProjectLocationInputEnvKey], value)
+		}
+		if pth == "" {
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(project.SearchDir, pth)); os.IsNotExist(err) {
+			findings = append(findings, Finding{
+				Severity:   Error,
+				Check:      StalePathCheck,
+				WorkflowID: workflowID,
+				Message:    fmt.Sprintf("%s (%s) does not exist", key, value),
+			})
+		}
+	}
+
+	return findings
+}
+
+func checkSteps(workflowID string, stepList []bitriseModels.StepListItemModel, managers []cache.DetectedManager) []Finding {
+	// only the workflows building the repository are expected to cache dependencies and deploy artifacts
+	if !steps.Contains(stepList, initSteps.GitCloneID) {
+		return nil
+	}
+
+	var findings []Finding
+	for _, manager := range managers {
+		if !manager.IsCached(stepList) {
+			findings = append(findings, Finding{
+				Severity:   Warning,
+				Check:      MissingCacheCheck,
+				WorkflowID: workflowID,
+				Message:    fmt.Sprintf("%s dependencies (%s) are not cached", manager.Name, strings.Join(manager.LockFilePaths, ", ")),
+			})
+		}
+	}
+
+	if !steps.Contains(stepList, initSteps.DeployToBitriseIoID) {
+		findings = append(findings, Finding{
+			Severity:   Warning,
+			Check:      MissingDeployCheck,
+			WorkflowID: workflowID,
+			Message:    fmt.Sprintf("%s is missing, build artifacts and test results are not exported", initSteps.DeployToBitriseIoID),
+		})
+	}
+
+	return findings
+}
+
+// checkSecrets reports the env vars referenced by step inputs, which are neither defined in the config
+// nor in the secrets file, nor exposed by Bitrise.
+func checkSecrets(workflowID string, stepList []bitriseModels.StepListItemModel, definedEnvs map[string]bool) []Finding {
+	var findings []Finding
+	reported := map[string]bool{}
+
+	for _, item := range stepList {
+		step, err := item.GetStep()
+		if err != nil {
+			continue
+		}
+
+		for _, input := range step.Inputs {
+			key, value, err := input.GetKeyValuePair()
+			// scripts reference their own shell variables
+			if err != nil || (steps.ID(item) == initSteps.ScriptID && key == "content") {
+				continue
+			}
+
+			for _, match := range envReferencePattern.FindAllStringSubmatch(value, -1) {
+				envKey := match[1]
+				if definedEnvs[envKey] || reported[envKey] || isBuiltInEnv(envKey) {
+					continue
+				}
+				reported[envKey] = true
+
+				findings = append(findings, Finding{
+					Severity:   Warning,
+					Check:      MissingSecretCheck,
+					WorkflowID: workflowID,
+					Message:    fmt.Sprintf("%s is referenced by %s, but not defined in the config or in %s", envKey, steps.ID(item), secretsFileName),
+				})
+			}
+		}
+	}
+
+	return findings
+}
+
+// selectorValues are the values found by the scanners for a selector option.
+type selectorValues struct {
+	values map[string]bool
+	// optional reports whether the option is an optional selector, which accepts custom values too.
+	optional bool
+}
+
+// collectOptionValues returns the values found by the scanners for the selector options, by env key.
+func collectOptionValues(scanResult models.ScanResultModel) map[string]*selectorValues {
+	optionValues := map[string]*selectorValues{}
+
+	var walk func(option *models.OptionNode)
+	walk = func(option *models.OptionNode) {
+		if option == nil {
+			return
+		}
+		if option.EnvKey != "" && (option.Type == models.TypeSelector || option.Type == models.TypeOptionalSelector) {
+			if optionValues[option.EnvKey] == nil {
+				optionValues[option.EnvKey] = &selectorValues{values: map[string]bool{}}
+			}
+			if option.Type == models.TypeOptionalSelector {
+				optionValues[option.EnvKey].optional = true
+			}
+			for value := range option.ChildOptionMap {
+				optionValues[option.EnvKey].values[filepath.Clean(value)] = true
+			}
+		}
+		for _, child := range option.ChildOptionMap {
+			walk(child)
+		}
+	}
+
+	for _, option := range scanResult.ScannerToOptionRoot {
+		option := option
+		walk(&option)
+	}
+
+	return optionValues
+}
+
+func addEnvKeys(keys map[string]bool, envs []envmanModels.EnvironmentItemModel) {
+	for _, env := range envs {
+		if key, _, err := env.GetKeyValuePair(); err == nil {
+			keys[key] = true
+		}
+	}
+}
+
+func isBuiltInEnv(key string) bool {
+	switch key {
+	case "CI", "PR", "HOME", "PATH", "PWD", "TMPDIR", "USER", "JAVA_HOME", "ANDROID_HOME", "ANDROID_SDK_ROOT":
+		return true
+	}
+	for _, prefix := range builtInEnvPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
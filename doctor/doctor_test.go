@@ -0,0 +1,128 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/cache"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "android", "app"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "yarn.lock"), nil, 0644))
+
+	managers, err := cache.Detect(dir)
+	require.NoError(t, err)
+
+	schemeOption := models.NewOption("Scheme", "", "BITRISE_SCHEME", models.TypeSelector)
+	schemeOption.AddConfig("App", models.NewConfigOption("ios-config", nil))
+	projectOption := models.NewOption("Project", "", "BITRISE_PROJECT_PATH", models.TypeSelector)
+	projectOption.AddOption("ios/App.xcworkspace", schemeOption)
+
+	project := Project{
+		SearchDir:          dir,
+		ScanResult:         models.ScanResultModel{ScannerToOptionRoot: map[string]models.OptionNode{"ios": *projectOption}},
+		DependencyManagers: managers,
+		SecretKeys:         []string{"SIGNING_KEY"},
+	}
+
+	config := bitriseModels.BitriseDataModel{
+		App: bitriseModels.AppModel{
+			Environments: []envmanModels.EnvironmentItemModel{
+				{"BITRISE_PROJECT_PATH": "./ios/App.xcworkspace"},
+				{"BITRISE_SCHEME": "Removed"},
+				{"PROJECT_LOCATION": "android"},
+				{"MODULE": "lib"},
+			},
+		},
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			"primary": {
+				Steps: []bitriseModels.StepListItemModel{
+					initSteps.GitCloneStepListItem(),
+					initSteps.RestoreNPMCache(),
+					initSteps.ScriptStepListItem("Sign", "echo $LOCAL_VAR",
+						envmanModels.EnvironmentItemModel{"key": "$SIGNING_KEY"},
+						envmanModels.EnvironmentItemModel{"password": "${SIGNING_PASSWORD}"},
+						envmanModels.EnvironmentItemModel{"output": "$BITRISE_DEPLOY_DIR"},
+					),
+					initSteps.DeployToBitriseIoStepListItem(),
+				},
+			},
+			"utility": {
+				Steps: []bitriseModels.StepListItemModel{
+					initSteps.ScriptStepListItem("Hello", "echo hello"),
+				},
+			},
+		},
+	}
+
+	findings := Audit(config, project)
+	require.Equal(t, []Finding{
+		{Severity: Error, Check: StalePathCheck, Message: "BITRISE_SCHEME (Removed) is not found by the scanners, found: App"},
+		{Severity: Error, Check: StalePathCheck, Message: "MODULE (lib) does not exist"},
+		{Severity: Warning, Check: MissingCacheCheck, WorkflowID: "primary", Message: "npm dependencies (yarn.lock) are not cached"},
+		{Severity: Warning, Check: MissingSecretCheck, WorkflowID: "primary", Message: "SIGNING_PASSWORD is referenced by script, but not defined in the config or in .bitrise.secrets.yml"},
+	}, findings)
+	require.True(t, HasErrors(findings))
+}
+
+func TestAudit_bundledCacheSteps(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package-lock.json"), nil, 0644))
+
+	managers, err := cache.Detect(dir)
+	require.NoError(t, err)
+
+	config := bitriseModels.BitriseDataModel{
+		StepBundles: map[string]bitriseModels.StepBundleModel{
+			"install_dependencies": {
+				Steps: []bitriseModels.StepListItemStepOrBundleModel{
+					bitriseModels.StepListItemStepOrBundleModel(initSteps.RestoreNPMCache()),
+					bitriseModels.StepListItemStepOrBundleModel(initSteps.NpmStepListItem("ci", "")),
+					bitriseModels.StepListItemStepOrBundleModel(initSteps.SaveNPMCache()),
+				},
+			},
+		},
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			"primary": {
+				Steps: []bitriseModels.StepListItemModel{
+					initSteps.GitCloneStepListItem(),
+					{bitriseModels.StepListItemStepBundleKeyPrefix + "install_dependencies": bitriseModels.StepBundleListItemModel{}},
+					initSteps.DeployToBitriseIoStepListItem(),
+				},
+			},
+		},
+	}
+
+	findings := Audit(config, Project{SearchDir: dir, DependencyManagers: managers})
+	require.Empty(t, findings)
+}
+
+func TestAudit_optionalSelector(t *testing.T) {
+	variantOption := models.NewOption("Variant", "", "VARIANT", models.TypeOptionalSelector)
+	variantOption.AddConfig("debug", models.NewConfigOption("android-config", nil))
+	variantOption.AddConfig("release", models.NewConfigOption("android-config", nil))
+
+	project := Project{
+		SearchDir:  t.TempDir(),
+		ScanResult: models.ScanResultModel{ScannerToOptionRoot: map[string]models.OptionNode{"android": *variantOption}},
+	}
+	config := bitriseModels.BitriseDataModel{
+		App: bitriseModels.AppModel{
+			Environments: []envmanModels.EnvironmentItemModel{{"VARIANT": "stagingRelease"}},
+		},
+	}
+
+	findings := Audit(config, project)
+	require.Equal(t, []Finding{
+		{Severity: Warning, Check: StalePathCheck, Message: "VARIANT (stagingRelease) is not found by the scanners, found: debug, release"},
+	}, findings)
+	require.False(t, HasErrors(findings))
+}
@@ -0,0 +1,353 @@
+package e2etests
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+)
+
+const (
+	// ScannerName is the name of the bitrise-init React Native scanner and the project type of its configs.
+	ScannerName = "react-native"
+	// ProjectDirEnvKey is the app env of the React Native scanner's project directory option.
+	ProjectDirEnvKey = "WORKDIR"
+
+	Detox   = "detox"
+	Maestro = "maestro"
+	Appium  = "appium"
+
+	platformAndroid = "android"
+	platformIOS     = "ios"
+
+	// PipelineID is the ID of the pipeline running the end-to-end test workflows after the primary workflow.
+	PipelineID = "e2e_tests"
+
+	debugVariant       = "Debug"
+	debugConfiguration = "Debug"
+	buildTypeInputKey  = "build_type"
+	buildTypeAPK       = "apk"
+	workingDirInputKey = "working_dir"
+)
+
+var (
+	detoxConfigFileNames = []string{".detoxrc.js", ".detoxrc.cjs", ".detoxrc.json", ".detoxrc", "detox.config.js", "detox.config.cjs"}
+	wdioConfigPattern    = regexp.MustCompile(`^wdio(?:\.[\w-]+)?\.conf\.(?:js|cjs|mjs|ts)$`)
+	maestroDirs          = []string{".maestro", "maestro"}
+	// detoxConfigurationPattern matches the configuration names of a JavaScript Detox config, like 'ios.sim.debug': {.
+	detoxConfigurationPattern = regexp.MustCompile(`["']?((?:ios|android)(?:\.[\w-]+)+)["']?\s*:\s*\{`)
+	frameworkTitles           = map[string]string{Detox: "Detox", Maestro: "Maestro", Appium: "Appium (WebdriverIO)"}
+	platformTitles            = map[string]string{platformAndroid: "Android emulator", platformIOS: "iOS simulator"}
+)
+
+// Suite is an end-to-end test suite of a React Native project.
+type Suite struct {
+	Framework string
+	// Config is the config file of Detox and WebdriverIO, or the flows directory of Maestro, relative to the project dir.
+	Config string
+	// Configurations are the Detox configurations to run by platform, the debug simulator and emulator ones if any.
+	Configurations map[string]string
+}
+
+type packageJSON struct {
+	Detox json.RawMessage `json:"detox"`
+}
+
+type detoxConfig struct {
+	Configurations map[string]json.RawMessage `json:"configurations"`
+}
+
+// Detect returns the Detox, Maestro and WebdriverIO suites of the React Native project.
+func Detect(projectDir string) ([]Suite, error) {
+	var pkg packageJSON
+	if content, err := fsutil.ReadOptionalFile(filepath.Join(projectDir, "package.json")); err != nil {
+		return nil, err
+	} else if content != "" {
+		if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+			return nil, fmt.Errorf("parse package.json: %s", err)
+		}
+	}
+
+	var suites []Suite
+
+	for _, name := range detoxConfigFileNames {
+		content, err := fsutil.ReadOptionalFile(filepath.Join(projectDir, name))
+		if err != nil {
+			return nil, err
+		}
+		if content != "" {
+			suites = append(suites, Suite{Framework: Detox, Config: name, Configurations: detoxConfigurations(content)})
+			break
+		}
+	}
+	if len(suites) == 0 && len(pkg.Detox) > 0 {
+		suites = append(suites, Suite{Framework: Detox, Config: "package.json", Configurations: detoxConfigurations(string(pkg.Detox))})
+	}
+
+	for _, dir := range maestroDirs {
+		flows, err := filepath.Glob(filepath.Join(projectDir, dir, "*.y*ml"))
+		if err != nil {
+			return nil, err
+		}
+		if len(flows) > 0 {
+			suites = append(suites, Suite{Framework: Maestro, Config: dir})
+			break
+		}
+	}
+
+	entries, err := os.ReadDir(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && wdioConfigPattern.MatchString(entry.Name()) {
+			suites = append(suites, Suite{Framework: Appium, Config: entry.Name()})
+			break
+		}
+	}
+
+	return suites, nil
+}
+
+// AddWorkflows adds an end-to-end test workflow for every suite of the React Native config's project and
+// every native platform of the config, and the pipeline running them after the primary workflow.
+// It returns the IDs of the added workflows.
+func AddWorkflows(config *bitriseModels.BitriseDataModel, searchDir string) ([]string, error) {
+	if config.ProjectType != ScannerName {
+		return nil, nil
+	}
+	primary, ok := config.Workflows[string(models.PrimaryWorkflowID)]
+	if !ok {
+		return nil, nil
+	}
+
+	var platforms []string
+	if steps.AppEnv(*config, android.ProjectLocationInputEnvKey) != "" {
+		platforms = append(platforms, platformAndroid)
+	}
+	if steps.AppEnv(*config, ios.ProjectPathInputEnvKey) != "" {
+		platforms = append(platforms, platformIOS)
+	}
+	if len(platforms) == 0 {
+		return nil, nil
+	}
+
+	suites, err := Detect(filepath.Join(searchDir, steps.AppEnv(*config, ProjectDirEnvKey)))
+	if err != nil {
+		return nil, err
+	}
+
+	var workflowIDs []string
+	for _, suite := range suites {
+		for _, platform := range platforms {
+			workflowID := fmt.Sprintf("e2e_%s_%s", suite.Framework, platform)
+			config.Workflows[workflowID] = bitriseModels.WorkflowModel{
+				Summary:     fmt.Sprintf("Run the %s end-to-end tests on an %s.", frameworkTitles[suite.Framework], platformTitles[platform]),
+				Description: fmt.Sprintf("The workflow will first clone your Git repository, install your project's dependencies, build a debug app, start an %s and run the %s tests. The JUnit results are exported as test reports.", platformTitles[platform], frameworkTitles[suite.Framework]),
+				Steps:       workflowSteps(primary.Steps, *config, suite, platform),
+			}
+			workflowIDs = append(workflowIDs, workflowID)
+		}
+	}
+	if len(workflowIDs) == 0 {
+		return nil, nil
+	}
+
+	pipelineWorkflows := bitriseModels.GraphPipelineWorkflowListItemModel{string(models.PrimaryWorkflowID): {}}
+	for _, workflowID := range workflowIDs {
+		pipelineWorkflows[workflowID] = bitriseModels.GraphPipelineWorkflowModel{DependsOn: []string{string(models.PrimaryWorkflowID)}}
+	}
+	if config.Pipelines == nil {
+		config.Pipelines = map[string]bitriseModels.PipelineModel{}
+	}
+	config.Pipelines[PipelineID] = bitriseModels.PipelineModel{Workflows: pipelineWorkflows}
+
+	return workflowIDs, nil
+}
+
+// workflowSteps returns the steps of an end-to-end test workflow: the steps of the primary workflow installing
+// the dependencies, the steps building the app and starting the device, and the test run.
+func workflowSteps(primarySteps []bitriseModels.StepListItemModel, config bitriseModels.BitriseDataModel, suite Suite, platform string) []bitriseModels.StepListItemModel {
+	var stepList []bitriseModels.StepListItemModel
+	for _, item := range primarySteps {
+		switch steps.ID(item) {
+		case initSteps.DeployToBitriseIoID, initSteps.CacheSaveNPMID:
+			continue
+		case initSteps.NpmID, initSteps.YarnID:
+			if steps.Input(item, "command") == "test" {
+				continue
+			}
+		}
+		stepList = append(stepList, item)
+	}
+
+	workingDir := envmanModels.EnvironmentItemModel{workingDirInputKey: "$" + ProjectDirEnvKey}
+	if platform == platformIOS && steps.WorkflowWithStep(config, initSteps.CocoapodsInstallID) != "" {
+		stepList = append(stepList, initSteps.CocoapodsInstallStepListItem())
+	}
+
+	if suite.Framework == Detox {
+		configuration := suite.Configurations[platform]
+		if platform == platformAndroid {
+			stepList = append(stepList, initSteps.AvdManagerStepListItem(), initSteps.WaitForAndroidEmulatorStepListItem())
+		}
+		return append(stepList,
+			initSteps.ScriptStepListItem("Run Detox tests", fmt.Sprintf(detoxScript, platformSetup(platform), configuration, configuration), workingDir),
+			initSteps.DeployToBitriseIoStepListItem(),
+		)
+	}
+
+	// Maestro and WebdriverIO test the debug app, which loads the JavaScript bundle from Metro
+	if platform == platformAndroid {
+		stepList = append(stepList,
+			initSteps.AvdManagerStepListItem(),
+			initSteps.AndroidBuildStepListItem(
+				envmanModels.EnvironmentItemModel{android.GradlewPathInputKey: "$" + android.ProjectLocationInputEnvKey + "/gradlew"},
+				envmanModels.EnvironmentItemModel{android.ProjectLocationInputKey: "$" + android.ProjectLocationInputEnvKey},
+				envmanModels.EnvironmentItemModel{android.ModuleInputKey: "$" + android.ModuleInputEnvKey},
+				envmanModels.EnvironmentItemModel{android.VariantInputKey: debugVariant},
+				envmanModels.EnvironmentItemModel{buildTypeInputKey: buildTypeAPK},
+			),
+			initSteps.WaitForAndroidEmulatorStepListItem(),
+			steps.InstallAndroidAppStepListItem(`adb -s "$BITRISE_EMULATOR_SERIAL" reverse tcp:8081 tcp:8081`),
+		)
+	} else {
+		stepList = append(stepList,
+			steps.XcodeBuildForSimulatorStepListItem(
+				envmanModels.EnvironmentItemModel{ios.ProjectPathInputKey: "$" + ios.ProjectPathInputEnvKey},
+				envmanModels.EnvironmentItemModel{ios.SchemeInputKey: "$" + ios.SchemeInputEnvKey},
+				envmanModels.EnvironmentItemModel{ios.ConfigurationInputKey: debugConfiguration},
+			),
+			steps.BootSimulatorAndInstallAppStepListItem(`envman add --key IOS_SIMULATOR_UDID --value "$device_id"`),
+		)
+	}
+	stepList = append(stepList, initSteps.ScriptStepListItem("Start Metro", metroScript, workingDir))
+
+	if suite.Framework == Maestro {
+		stepList = append(stepList, initSteps.ScriptStepListItem("Run Maestro flows", maestroScriptHeader+steps.MaestroTestCommands("maestro", "Maestro", suite.Config), workingDir))
+	} else {
+		stepList = append(stepList, initSteps.ScriptStepListItem("Run WebdriverIO tests", fmt.Sprintf(wdioScript, appPathEnvs[platform], suite.Config), workingDir))
+	}
+	return append(stepList, initSteps.DeployToBitriseIoStepListItem())
+}
+
+// detoxConfigurations returns the debug simulator and emulator configurations of a Detox config by platform,
+// falling back to the first configuration of the platform and the names of the Detox project template.
+func detoxConfigurations(content string) map[string]string {
+	var names []string
+	var config detoxConfig
+	if err := json.Unmarshal([]byte(content), &config); err == nil {
+		for name := range config.Configurations {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	} else if idx := strings.Index(content, "configurations"); idx != -1 {
+		for _, match := range detoxConfigurationPattern.FindAllStringSubmatch(content[idx:], -1) {
+			names = append(names, match[1])
+		}
+	}
+
+	configurations := map[string]string{platformAndroid: "android.emu.debug", platformIOS: "ios.sim.debug"}
+	for platform, device := range map[string]string{platformAndroid: "emu", platformIOS: "sim"} {
+		var candidates []string
+		for _, name := range names {
+			if strings.HasPrefix(name, platform+".") {
+				candidates = append(candidates, name)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		configurations[platform] = candidates[0]
+		for _, name := range candidates {
+			if strings.Contains(name, device) && strings.Contains(name, "debug") {
+				configurations[platform] = name
+				break
+			}
+		}
+	}
+	return configurations
+}
+
+// platformSetup installs the tools Detox needs on the platform.
+func platformSetup(platform string) string {
+	if platform == platformIOS {
+		return "brew tap wix/brew && brew install applesimutils\n"
+	}
+	return ""
+}
+
+// appPathEnvs are the outputs of the build steps with the path of the debug app, passed to WebdriverIO as APP_PATH.
+var appPathEnvs = map[string]string{platformAndroid: "$BITRISE_APK_PATH", platformIOS: "$BITRISE_APP_DIR_PATH"}
+
+// collectReportsScript copies the JUnit reports the test run wrote to the project, apart from the dependencies
+// and the native projects, to the test result dir.
+const collectReportsScript = `find . \( -path ./node_modules -o -path ./android -o -path ./ios \) -prune -o -name '*.xml' -newer "$marker" -print | while read -r report; do
+  if grep -q "<testsuite" "$report"; then
+    cp "$report" "$report_dir/$(echo "${report#./}" | tr '/' '_')"
+  fi
+done
+exit "$exit_code"
+`
+
+// detoxScript is completed with the platform setup and the configuration.
+const detoxScript = `#!/usr/bin/env bash
+set -euxo pipefail
+
+report_dir="$BITRISE_TEST_RESULT_DIR/detox"
+mkdir -p "$report_dir"
+echo '{"test-name": "Detox"}' > "$report_dir/test-info.json"
+marker="$(mktemp)"
+
+%snpx detox build --configuration %s
+
+exit_code=0
+npx detox test --configuration %s --headless --record-logs failing --artifacts-location "$BITRISE_DEPLOY_DIR/detox" || exit_code=$?
+
+` + collectReportsScript
+
+const metroScript = `#!/usr/bin/env bash
+set -euxo pipefail
+
+nohup npx react-native start > "$BITRISE_DEPLOY_DIR/metro.log" 2>&1 &
+for _ in $(seq 1 60); do
+  if curl -s http://localhost:8081/status | grep -q "packager-status:running"; then
+    exit 0
+  fi
+  sleep 2
+done
+echo "Metro did not start"
+exit 1
+`
+
+// maestroScriptHeader is followed by the Maestro test commands.
+const maestroScriptHeader = `#!/usr/bin/env bash
+set -euxo pipefail
+
+`
+
+// wdioScript is completed with the app path and the WebdriverIO config.
+const wdioScript = `#!/usr/bin/env bash
+set -euxo pipefail
+
+report_dir="$BITRISE_TEST_RESULT_DIR/webdriverio"
+mkdir -p "$report_dir"
+echo '{"test-name": "WebdriverIO"}' > "$report_dir/test-info.json"
+marker="$(mktemp)"
+
+exit_code=0
+APP_PATH="%s" npx wdio run %s || exit_code=$?
+
+` + collectReportsScript
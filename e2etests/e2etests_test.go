@@ -0,0 +1,118 @@
+package e2etests
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const detoxrc = `/** @type {Detox.DetoxConfig} */
+module.exports = {
+  apps: {
+    'ios.debug': { type: 'ios.app', build: 'xcodebuild ...' },
+  },
+  configurations: {
+    'ios.sim.release': { device: 'simulator', app: 'ios.release' },
+    'ios.sim.debug': { device: 'simulator', app: 'ios.debug' },
+    'android.att.debug': { device: 'attached', app: 'android.debug' },
+    "android.emu.debug": { device: 'emulator', app: 'android.debug' },
+  },
+};
+`
+
+func reactNativeConfig(envs ...envmanModels.EnvironmentItemModel) bitriseModels.BitriseDataModel {
+	config := bitriseModels.BitriseDataModel{
+		ProjectType: ScannerName,
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			string(models.PrimaryWorkflowID): {Steps: []bitriseModels.StepListItemModel{
+				initSteps.GitCloneStepListItem(),
+				initSteps.RestoreNPMCache(),
+				initSteps.YarnStepListItem("install", "$WORKDIR"),
+				initSteps.YarnStepListItem("test", "$WORKDIR"),
+				initSteps.SaveNPMCache(),
+				initSteps.DeployToBitriseIoStepListItem(),
+			}},
+			string(models.DeployWorkflowID): {Steps: []bitriseModels.StepListItemModel{
+				initSteps.CocoapodsInstallStepListItem(),
+			}},
+		},
+	}
+	config.App.Environments = envs
+	return config
+}
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, ".detoxrc.js"), detoxrc)
+	testutil.WriteFile(t, filepath.Join(dir, ".maestro", "login.yaml"), "appId: io.bitrise.notes\n---\n- launchApp\n")
+	testutil.WriteFile(t, filepath.Join(dir, "wdio.android.conf.ts"), "export const config = {}\n")
+
+	suites, err := Detect(dir)
+	require.NoError(t, err)
+	require.Equal(t, []Suite{
+		{Framework: Detox, Config: ".detoxrc.js", Configurations: map[string]string{platformAndroid: "android.emu.debug", platformIOS: "ios.sim.debug"}},
+		{Framework: Maestro, Config: ".maestro"},
+		{Framework: Appium, Config: "wdio.android.conf.ts"},
+	}, suites)
+}
+
+func TestDetectPackageJSON(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "package.json"), `{
+  "name": "notes",
+  "detox": {"configurations": {"ios.release": {}, "android.emulator": {}}}
+}`)
+
+	suites, err := Detect(dir)
+	require.NoError(t, err)
+	require.Equal(t, []Suite{
+		{Framework: Detox, Config: "package.json", Configurations: map[string]string{platformAndroid: "android.emulator", platformIOS: "ios.release"}},
+	}, suites)
+}
+
+func TestAddWorkflows(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "app", ".detoxrc.js"), detoxrc)
+	testutil.WriteFile(t, filepath.Join(dir, "app", ".maestro", "login.yaml"), "appId: io.bitrise.notes\n")
+
+	config := reactNativeConfig()
+	workflowIDs, err := AddWorkflows(&config, dir)
+	require.NoError(t, err)
+	require.Empty(t, workflowIDs)
+
+	config = reactNativeConfig(
+		envmanModels.EnvironmentItemModel{ProjectDirEnvKey: "app"},
+		envmanModels.EnvironmentItemModel{android.ProjectLocationInputEnvKey: "app/android"},
+		envmanModels.EnvironmentItemModel{ios.ProjectPathInputEnvKey: "app/ios/Notes.xcworkspace"},
+	)
+	workflowIDs, err = AddWorkflows(&config, dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"e2e_detox_android", "e2e_detox_ios", "e2e_maestro_android", "e2e_maestro_ios"}, workflowIDs)
+
+	detoxSteps := config.Workflows["e2e_detox_ios"].Steps
+	require.Equal(t, 3, steps.Index(detoxSteps, initSteps.CocoapodsInstallID))
+	require.False(t, steps.Contains(detoxSteps, initSteps.CacheSaveNPMID))
+	require.Equal(t, len(detoxSteps)-1, steps.Index(detoxSteps, initSteps.DeployToBitriseIoID))
+	script, err := detoxSteps[4].GetStep()
+	require.NoError(t, err)
+	require.Contains(t, script.Inputs[0]["content"], "npx detox test --configuration ios.sim.debug")
+
+	maestroSteps := config.Workflows["e2e_maestro_android"].Steps
+	require.Less(t, steps.Index(maestroSteps, initSteps.AvdManagerID), steps.Index(maestroSteps, initSteps.AndroidBuildID))
+	require.Less(t, steps.Index(maestroSteps, initSteps.AndroidBuildID), steps.Index(maestroSteps, initSteps.WaitForAndroidEmulatorID))
+	require.False(t, steps.Contains(maestroSteps, initSteps.CocoapodsInstallID))
+	require.True(t, steps.Contains(config.Workflows["e2e_maestro_ios"].Steps, steps.XcodeBuildForSimulatorID))
+
+	pipeline := config.Pipelines[PipelineID]
+	require.Len(t, pipeline.Workflows, 5)
+	require.Equal(t, []string{string(models.PrimaryWorkflowID)}, pipeline.Workflows["e2e_maestro_ios"].DependsOn)
+}
@@ -0,0 +1,282 @@
+package exportmethod
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-plist"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+)
+
+const (
+	appStore    = "app-store"
+	adHoc       = "ad-hoc"
+	enterprise  = "enterprise"
+	development = "development"
+)
+
+// methodAliases maps the export methods of the Xcode export options and the fastlane match types
+// to the export methods of the iOS scanner.
+var methodAliases = map[string]string{
+	"app-store":         appStore,
+	"app-store-connect": appStore,
+	"appstore":          appStore,
+	"ad-hoc":            adHoc,
+	"adhoc":             adHoc,
+	"release-testing":   adHoc,
+	"enterprise":        enterprise,
+	"development":       development,
+	"debugging":         development,
+}
+
+// ProfileSecrets are the secrets the certificate and the provisioning profiles of the manually signed builds are installed from.
+var ProfileSecrets = []string{"BITRISE_CERTIFICATE_URL", "BITRISE_PROVISION_PROFILE_URL"}
+
+// capabilities are the entitlements which need an explicit App ID with the capability enabled.
+var capabilities = map[string]string{
+	"aps-environment":                                  "Push Notifications",
+	"com.apple.security.application-groups":            "App Groups",
+	"com.apple.developer.associated-domains":           "Associated Domains",
+	"com.apple.developer.icloud-container-identifiers": "iCloud",
+}
+
+var (
+	// exportMethodPattern matches the export method of gym, like export_method: "app-store" or export_method("ad-hoc").
+	exportMethodPattern = regexp.MustCompile(`\bexport_method\s*(?::|\(|=>)\s*["']([\w-]+)["']`)
+	// matchActionPattern matches the match actions of a Fastfile, like match(type: "appstore").
+	matchActionPattern = regexp.MustCompile(`\b(?:match|sync_code_signing)\s*\(([^)]*)\)`)
+	matchTypePattern   = regexp.MustCompile(`\btype\s*(?::|=>)\s*["'](\w+)["']`)
+	// matchfileTypePattern matches the type of a Matchfile, like type("appstore") or type "adhoc".
+	matchfileTypePattern = regexp.MustCompile(`(?m)^\s*type\s*\(?\s*["'](\w+)["']`)
+	profileWordPattern   = regexp.MustCompile(`[\s_-]+`)
+)
+
+// skippedDirs are not searched for signing artifacts, they hold build outputs and dependencies.
+var skippedDirs = map[string]bool{"build": true, "node_modules": true, "Pods": true, "Carthage": true, "DerivedData": true}
+
+// Inference is the export method the signing setup of the repository suggests.
+type Inference struct {
+	// Method is one of the iOS scanner's export methods, it is empty if the repository has no hint of the method.
+	Method string
+	// Reason explains where the method comes from, like ExportOptions.plist (method: app-store).
+	Reason string
+	// ManualSigning reports whether an app target signs its release configuration manually.
+	ManualSigning bool
+	// ProfileSpecifiers are the provisioning profiles the manually signed app targets use.
+	ProfileSpecifiers []string
+	// Capabilities are the capabilities the entitlements of the app enable, like Push Notifications.
+	Capabilities []string
+}
+
+// Explanation describes why the method is recommended, including the capabilities the distribution profile has to support.
+func (i Inference) Explanation() string {
+	if len(i.Capabilities) == 0 {
+		return i.Reason
+	}
+	return fmt.Sprintf("%s; the profile needs the %s capabilities", i.Reason, strings.Join(i.Capabilities, ", "))
+}
+
+// signal is an export method hint and the file it was found in.
+type signal struct {
+	method string
+	reason string
+}
+
+// Infer collects the export method hints of the repository and returns the most specific one.
+// The export options plists are the most specific as they are passed to xcodebuild as is, followed by the fastlane
+// gym and match setup, the names of the provisioning profiles in the Xcode projects and the push environment
+// of the entitlements.
+func Infer(searchDir string) (Inference, error) {
+	var inference Inference
+	var exportOptions, fastlane, profiles, entitlements []signal
+	foundCapabilities := map[string]bool{}
+
+	if err := filepath.WalkDir(searchDir, func(pth string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		relPath, err := filepath.Rel(searchDir, pth)
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if pth != searchDir && (strings.HasPrefix(name, ".") || skippedDirs[name]) {
+				return filepath.SkipDir
+			}
+			if filepath.Ext(name) != ".xcodeproj" {
+				return nil
+			}
+			projectProfiles, manual, err := projectSigning(pth)
+			if err != nil {
+				return fmt.Errorf("open %s: %s", relPath, err)
+			}
+			if manual {
+				inference.ManualSigning = true
+			}
+			for _, profile := range projectProfiles {
+				if !slices.Contains(inference.ProfileSpecifiers, profile) {
+					inference.ProfileSpecifiers = append(inference.ProfileSpecifiers, profile)
+				}
+				profiles = append(profiles, signal{method: profileMethod(profile), reason: fmt.Sprintf("%s (PROVISIONING_PROFILE_SPECIFIER: %s)", relPath, profile)})
+			}
+			return filepath.SkipDir
+		}
+
+		switch {
+		case strings.HasPrefix(strings.ToLower(name), "exportoptions") && filepath.Ext(name) == ".plist":
+			values, err := readPlist(pth)
+			if err != nil {
+				return err
+			}
+			if method, ok := values["method"].(string); ok {
+				exportOptions = append(exportOptions, signal{method: method, reason: fmt.Sprintf("%s (method: %s)", relPath, method)})
+			}
+		case name == "Gymfile" || name == "Fastfile":
+			content, err := os.ReadFile(pth)
+			if err != nil {
+				return err
+			}
+			fastlane = append(fastlane, fastlaneSignals(relPath, string(content))...)
+		case name == "Matchfile":
+			content, err := os.ReadFile(pth)
+			if err != nil {
+				return err
+			}
+			if match := matchfileTypePattern.FindStringSubmatch(string(content)); match != nil {
+				fastlane = append(fastlane, signal{method: match[1], reason: fmt.Sprintf("%s (type: %s)", relPath, match[1])})
+			}
+		case filepath.Ext(name) == ".entitlements":
+			values, err := readPlist(pth)
+			if err != nil {
+				return err
+			}
+			for key, capability := range capabilities {
+				if _, ok := values[key]; ok {
+					foundCapabilities[capability] = true
+				}
+			}
+			if environment, _ := values["aps-environment"].(string); environment == "production" {
+				entitlements = append(entitlements, signal{method: appStore, reason: fmt.Sprintf("%s (aps-environment: production)", relPath)})
+			}
+		}
+		return nil
+	}); err != nil {
+		return Inference{}, err
+	}
+
+	for capability := range foundCapabilities {
+		inference.Capabilities = append(inference.Capabilities, capability)
+	}
+	sort.Strings(inference.Capabilities)
+
+	for _, signals := range [][]signal{exportOptions, fastlane, profiles, entitlements} {
+		for _, s := range signals {
+			if method, ok := methodAliases[s.method]; ok {
+				inference.Method = method
+				inference.Reason = s.reason
+				return inference, nil
+			}
+		}
+	}
+	return inference, nil
+}
+
+// fastlaneSignals returns the gym export methods of a Fastfile or Gymfile, followed by the types of its match actions.
+func fastlaneSignals(relPath, content string) []signal {
+	var signals []signal
+	for _, match := range exportMethodPattern.FindAllStringSubmatch(content, -1) {
+		signals = append(signals, signal{method: match[1], reason: fmt.Sprintf("%s (export_method: %s)", relPath, match[1])})
+	}
+	for _, action := range matchActionPattern.FindAllStringSubmatch(content, -1) {
+		if match := matchTypePattern.FindStringSubmatch(action[1]); match != nil {
+			signals = append(signals, signal{method: match[1], reason: fmt.Sprintf("%s (match type: %s)", relPath, match[1])})
+		}
+	}
+	return signals
+}
+
+// projectSigning returns the provisioning profiles of the app targets' release configurations,
+// and whether any of them is signed manually.
+func projectSigning(pth string) ([]string, bool, error) {
+	project, err := xcodeproj.Open(pth)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var profiles []string
+	manual := false
+	for _, target := range project.Proj.Targets {
+		if !target.IsAppProduct() {
+			continue
+		}
+		for _, buildConfiguration := range target.BuildConfigurationList.BuildConfigurations {
+			if !isReleaseConfiguration(buildConfiguration.Name, target.BuildConfigurationList.DefaultConfigurationName) {
+				continue
+			}
+			if style, err := buildConfiguration.BuildSettings.String("CODE_SIGN_STYLE"); err == nil && style == "Manual" {
+				manual = true
+			}
+
+			var keys []string
+			for key := range buildConfiguration.BuildSettings {
+				if strings.HasPrefix(key, "PROVISIONING_PROFILE_SPECIFIER") {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				profile, err := buildConfiguration.BuildSettings.String(key)
+				if err != nil || profile == "" {
+					continue
+				}
+				manual = true
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+	return profiles, manual, nil
+}
+
+// isReleaseConfiguration reports whether the build configuration is the one archives use by default.
+func isReleaseConfiguration(name, defaultName string) bool {
+	if defaultName != "" {
+		return name == defaultName
+	}
+	return name == "Release"
+}
+
+// profileMethod returns the export method the name of a provisioning profile suggests, like match AppStore io.bitrise.Notes.
+func profileMethod(profile string) string {
+	words := strings.ToLower(profileWordPattern.ReplaceAllString(profile, ""))
+	switch {
+	case strings.Contains(words, "appstore") || strings.Contains(words, "distribution"):
+		return appStore
+	case strings.Contains(words, "adhoc"):
+		return adHoc
+	case strings.Contains(words, "enterprise") || strings.Contains(words, "inhouse"):
+		return enterprise
+	case strings.Contains(words, "development"):
+		return development
+	}
+	return ""
+}
+
+func readPlist(pth string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if _, err := plist.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("parse %s: %s", pth, err)
+	}
+	return values, nil
+}
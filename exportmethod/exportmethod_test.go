@@ -0,0 +1,161 @@
+package exportmethod
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const pbxproj = `// !$*UTF8*$!
+{
+	archiveVersion = 1;
+	classes = {
+	};
+	objectVersion = 56;
+	objects = {
+		A10000000000000000000001 /* Project object */ = {
+			isa = PBXProject;
+			attributes = {
+				LastUpgradeCheck = 1500;
+			};
+			buildConfigurationList = A10000000000000000000002;
+			mainGroup = A10000000000000000000009;
+			targets = (
+				A10000000000000000000003 /* Notes */,
+			);
+		};
+		A10000000000000000000002 = {
+			isa = XCConfigurationList;
+			buildConfigurations = (
+			);
+			defaultConfigurationName = Release;
+		};
+		A10000000000000000000003 /* Notes */ = {
+			isa = PBXNativeTarget;
+			buildConfigurationList = A10000000000000000000004;
+			buildPhases = (
+			);
+			dependencies = (
+			);
+			name = Notes;
+			productReference = A10000000000000000000007 /* Notes.app */;
+			productType = "com.apple.product-type.application";
+		};
+		A10000000000000000000007 /* Notes.app */ = {
+			isa = PBXFileReference;
+			explicitFileType = wrapper.application;
+			includeInIndex = 0;
+			path = Notes.app;
+			sourceTree = BUILT_PRODUCTS_DIR;
+		};
+		A10000000000000000000004 = {
+			isa = XCConfigurationList;
+			buildConfigurations = (
+				A10000000000000000000005 /* Debug */,
+				A10000000000000000000006 /* Release */,
+			);
+			defaultConfigurationName = Release;
+		};
+		A10000000000000000000005 /* Debug */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				CODE_SIGN_STYLE = Manual;
+				"PROVISIONING_PROFILE_SPECIFIER[sdk=iphoneos*]" = "match Development io.bitrise.Notes";
+			};
+			name = Debug;
+		};
+		A10000000000000000000006 /* Release */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				CODE_SIGN_STYLE = Manual;
+				"PROVISIONING_PROFILE_SPECIFIER[sdk=iphoneos*]" = "match AdHoc io.bitrise.Notes";
+			};
+			name = Release;
+		};
+	};
+	rootObject = A10000000000000000000001 /* Project object */;
+}
+`
+
+const entitlements = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>aps-environment</key>
+	<string>production</string>
+	<key>com.apple.security.application-groups</key>
+	<array>
+		<string>group.io.bitrise.Notes</string>
+	</array>
+</dict>
+</plist>
+`
+
+const exportOptions = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>method</key>
+	<string>release-testing</string>
+</dict>
+</plist>
+`
+
+func TestInfer(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "ios", "Notes", "Notes.entitlements"), entitlements)
+
+	inference, err := Infer(dir)
+	require.NoError(t, err)
+	require.Equal(t, Inference{
+		Method:       appStore,
+		Reason:       "ios/Notes/Notes.entitlements (aps-environment: production)",
+		Capabilities: []string{"App Groups", "Push Notifications"},
+	}, inference)
+
+	testutil.WriteFile(t, filepath.Join(dir, "ios", "Notes.xcodeproj", "project.pbxproj"), pbxproj)
+
+	inference, err = Infer(dir)
+	require.NoError(t, err)
+	require.Equal(t, adHoc, inference.Method)
+	require.Equal(t, "ios/Notes.xcodeproj (PROVISIONING_PROFILE_SPECIFIER: match AdHoc io.bitrise.Notes)", inference.Reason)
+	require.True(t, inference.ManualSigning)
+	require.Equal(t, []string{"match AdHoc io.bitrise.Notes"}, inference.ProfileSpecifiers)
+
+	testutil.WriteFile(t, filepath.Join(dir, "fastlane", "Matchfile"), "git_url(\"git@github.com:bitrise-io/certificates.git\")\ntype(\"enterprise\")\n")
+
+	inference, err = Infer(dir)
+	require.NoError(t, err)
+	require.Equal(t, enterprise, inference.Method)
+	require.Equal(t, "fastlane/Matchfile (type: enterprise)", inference.Reason)
+
+	testutil.WriteFile(t, filepath.Join(dir, "ios", "ExportOptions.plist"), exportOptions)
+
+	inference, err = Infer(dir)
+	require.NoError(t, err)
+	require.Equal(t, adHoc, inference.Method)
+	require.Equal(t, "ios/ExportOptions.plist (method: release-testing)", inference.Reason)
+	require.Equal(t, "ios/ExportOptions.plist (method: release-testing); the profile needs the App Groups, Push Notifications capabilities", inference.Explanation())
+}
+
+func TestFastlaneSignals(t *testing.T) {
+	signals := fastlaneSignals("fastlane/Fastfile", `lane :beta do
+  match(type: "adhoc", readonly: true)
+  build_app(scheme: "Notes", export_method: "ad-hoc")
+end`)
+	require.Equal(t, []signal{
+		{method: "ad-hoc", reason: "fastlane/Fastfile (export_method: ad-hoc)"},
+		{method: "adhoc", reason: "fastlane/Fastfile (match type: adhoc)"},
+	}, signals)
+}
+
+func TestProfileMethod(t *testing.T) {
+	require.Equal(t, appStore, profileMethod("match AppStore io.bitrise.Notes"))
+	require.Equal(t, appStore, profileMethod("Notes Distribution"))
+	require.Equal(t, adHoc, profileMethod("Notes Ad-Hoc"))
+	require.Equal(t, enterprise, profileMethod("match InHouse io.bitrise.Notes"))
+	require.Equal(t, development, profileMethod("iOS Team Development Profile"))
+	require.Equal(t, "", profileMethod("Notes"))
+}
@@ -0,0 +1,288 @@
+package fastlane
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	initFastlane "github.com/bitrise-io/bitrise-init/scanners/fastlane"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-init/utility"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+const (
+	// LaneEnvKey and WorkDirEnvKey are the workflow envs the fastlane step inputs refer to,
+	// the same envs the fastlane scanner's lane and work dir options set.
+	LaneEnvKey    = "FASTLANE_LANE"
+	WorkDirEnvKey = "FASTLANE_WORK_DIR"
+
+	iosPlatform = "ios"
+
+	appfileName   = "Appfile"
+	matchfileName = "Matchfile"
+)
+
+var (
+	// ENV["MATCH_PASSWORD"] or ENV.fetch("MATCH_PASSWORD")
+	envReferencePattern = regexp.MustCompile(`ENV(?:\[|\.fetch\()\s*["']([A-Za-z_][A-Za-z0-9_]*)["']`)
+	// git_url("https://github.com/org/certificates")
+	matchGitURLPattern      = regexp.MustCompile(`^\s*git_url\s*\(?\s*["']([^"']*)["']`)
+	matchStorageModePattern = regexp.MustCompile(`^\s*storage_mode\s*\(?\s*["'](\w+)["']`)
+	// apple_id, team_id and itc_team_id identify the App Store Connect account, json_key_file the Google Play one.
+	appStoreConnectPattern = regexp.MustCompile(`^\s*(apple_id|team_id|itc_team_id)\b`)
+	googlePlayPattern      = regexp.MustCompile(`^\s*(json_key_file|json_key_data)\b`)
+
+	workflowIDPattern = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+)
+
+// Lane is a public lane of a Fastfile, Platform is empty for lanes outside of platform blocks.
+type Lane struct {
+	Platform string
+	Name     string
+}
+
+// String returns the lane the way the fastlane step's lane input expects it.
+func (l Lane) String() string {
+	if l.Platform == "" {
+		return l.Name
+	}
+	return l.Platform + " " + l.Name
+}
+
+// Fastfile is a Fastfile found in the project with its lanes in the order they are declared.
+type Fastfile struct {
+	Path    string
+	WorkDir string
+	Lanes   []Lane
+	// UsesMatch is set if there is a Matchfile next to the Fastfile.
+	UsesMatch bool
+}
+
+// Secret is a secret the fastlane configuration expects, Source is the file it is needed by.
+type Secret struct {
+	Key    string
+	Source string
+}
+
+// Detect returns the Fastfiles of the search dir which declare at least one lane.
+// The paths are relative to the search dir.
+func Detect(searchDir string) ([]Fastfile, error) {
+	fileList, err := pathutil.ListPathInDirSortedByComponents(searchDir, true)
+	if err != nil {
+		return nil, fmt.Errorf("search for files in %s: %s", searchDir, err)
+	}
+
+	fastfilePaths, err := initFastlane.FilterFastfiles(fileList)
+	if err != nil {
+		return nil, fmt.Errorf("search for Fastfiles in %s: %s", searchDir, err)
+	}
+
+	var fastfiles []Fastfile
+	for _, pth := range fastfilePaths {
+		lanes, err := inspectLanes(filepath.Join(searchDir, pth))
+		if err != nil {
+			return nil, fmt.Errorf("inspect %s: %s", pth, err)
+		}
+		if len(lanes) == 0 {
+			continue
+		}
+
+		fastfiles = append(fastfiles, Fastfile{
+			Path:      pth,
+			WorkDir:   initFastlane.WorkDir(pth),
+			Lanes:     lanes,
+			UsesMatch: utility.FileExists(filepath.Join(searchDir, filepath.Dir(pth), matchfileName)),
+		})
+	}
+
+	return fastfiles, nil
+}
+
+// inspectLanes returns the lanes of the Fastfile the way the fastlane scanner finds them, grouped by platform:
+// the lanes outside of platform blocks first, then the lanes of each platform in alphabetical order.
+func inspectLanes(pth string) ([]Lane, error) {
+	laneNames, err := initFastlane.InspectFastfile(pth)
+	if err != nil {
+		return nil, err
+	}
+
+	var lanes []Lane
+	for _, laneName := range laneNames {
+		if platform, name, ok := strings.Cut(laneName, " "); ok {
+			lanes = append(lanes, Lane{Platform: platform, Name: name})
+		} else {
+			lanes = append(lanes, Lane{Name: laneName})
+		}
+	}
+	// the scanner returns the platforms in map order
+	sort.SliceStable(lanes, func(i, j int) bool {
+		return lanes[i].Platform < lanes[j].Platform
+	})
+	return lanes, nil
+}
+
+// AddLaneWorkflows adds a workflow running a single lane for every lane of the Fastfiles.
+// The workflow ID is the platform and the lane name, prefixed with the work dir
+// if the same lane is declared in more than one Fastfile. Existing workflows are kept as-is.
+func AddLaneWorkflows(config *bitriseModels.BitriseDataModel, fastfiles []Fastfile, sshKeyActivation models.SSHKeyActivation) []string {
+	if config.Workflows == nil {
+		config.Workflows = map[string]bitriseModels.WorkflowModel{}
+	}
+
+	laneCount := map[string]int{}
+	for _, fastfile := range fastfiles {
+		for _, lane := range fastfile.Lanes {
+			laneCount[lane.String()]++
+		}
+	}
+
+	var workflowIDs []string
+	for _, fastfile := range fastfiles {
+		for _, lane := range fastfile.Lanes {
+			workflowID := workflowIDPattern.ReplaceAllString(strings.ReplaceAll(lane.String(), " ", "_"), "_")
+			if laneCount[lane.String()] > 1 && fastfile.WorkDir != "." {
+				workflowID = workflowIDPattern.ReplaceAllString(fastfile.WorkDir, "_") + "_" + workflowID
+			}
+			if _, ok := config.Workflows[workflowID]; ok {
+				continue
+			}
+
+			stepList := initSteps.DefaultPrepareStepList(initSteps.PrepareListParams{SSHKeyActivation: sshKeyActivation})
+			// match installs the code signing files itself
+			if lane.Platform == iosPlatform && !fastfile.UsesMatch {
+				stepList = append(stepList, initSteps.CertificateAndProfileInstallerStepListItem())
+			}
+			stepList = append(stepList, initSteps.FastlaneStepListItem(
+				envmanModels.EnvironmentItemModel{"lane": "$" + LaneEnvKey},
+				envmanModels.EnvironmentItemModel{"work_dir": "$" + WorkDirEnvKey},
+				envmanModels.EnvironmentItemModel{"enable_cache": "no"},
+			))
+			stepList = append(stepList, initSteps.DefaultDeployStepList()...)
+
+			config.Workflows[workflowID] = bitriseModels.WorkflowModel{
+				Summary: fmt.Sprintf("Runs the `%s` fastlane lane", lane),
+				Environments: []envmanModels.EnvironmentItemModel{
+					{LaneEnvKey: lane.String()},
+					{WorkDirEnvKey: fastfile.WorkDir},
+				},
+				Steps: stepList,
+			}
+			workflowIDs = append(workflowIDs, workflowID)
+		}
+	}
+
+	return workflowIDs
+}
+
+// Secrets returns the secrets the Appfiles and Matchfiles next to the Fastfiles expect:
+// the App Store Connect API key if the Appfile sets up an App Store Connect account,
+// the Google Play service account key if it sets up a Google Play one,
+// the match repository and its passphrase if there is a Matchfile,
+// and the envs both files refer to.
+func Secrets(searchDir string, fastfiles []Fastfile) ([]Secret, error) {
+	var secrets []Secret
+	seen := map[string]bool{}
+	add := func(source string, keys ...string) {
+		for _, key := range keys {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			secrets = append(secrets, Secret{Key: key, Source: source})
+		}
+	}
+
+	for _, fastfile := range fastfiles {
+		dir := filepath.Dir(fastfile.Path)
+
+		appfilePth := filepath.Join(dir, appfileName)
+		content, err := fsutil.ReadOptionalFile(filepath.Join(searchDir, appfilePth))
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range codeLines(content) {
+			if appStoreConnectPattern.MatchString(line) {
+				add(appfilePth, "APP_STORE_CONNECT_API_KEY_KEY_ID", "APP_STORE_CONNECT_API_KEY_ISSUER_ID", "APP_STORE_CONNECT_API_KEY_KEY")
+			}
+			if googlePlayPattern.MatchString(line) {
+				add(appfilePth, "SUPPLY_JSON_KEY_DATA")
+			}
+			add(appfilePth, envReferences(line)...)
+		}
+
+		matchfilePth := filepath.Join(dir, matchfileName)
+		content, err = fsutil.ReadOptionalFile(filepath.Join(searchDir, matchfilePth))
+		if err != nil {
+			return nil, err
+		}
+		if content == "" {
+			continue
+		}
+
+		storageMode, gitURL := "git", ""
+		var references []string
+		for _, line := range codeLines(content) {
+			if match := matchStorageModePattern.FindStringSubmatch(line); match != nil {
+				storageMode = match[1]
+			}
+			if match := matchGitURLPattern.FindStringSubmatch(line); match != nil {
+				gitURL = match[1]
+			}
+			references = append(references, envReferences(line)...)
+		}
+
+		if storageMode == "git" {
+			if gitURL == "" {
+				add(matchfilePth, "MATCH_GIT_URL")
+			} else if strings.HasPrefix(gitURL, "https://") {
+				// the SSH key of the app can not clone a repository over HTTPS
+				add(matchfilePth, "MATCH_GIT_BASIC_AUTHORIZATION")
+			}
+		}
+		if storageMode != "google_cloud" {
+			add(matchfilePth, "MATCH_PASSWORD")
+		}
+		add(matchfilePth, references...)
+	}
+
+	sort.SliceStable(secrets, func(i, j int) bool {
+		return secrets[i].Source < secrets[j].Source
+	})
+	return secrets, nil
+}
+
+// SecretEnvs returns the secrets as empty env items, to be filled in by the user.
+func SecretEnvs(secrets []Secret) []envmanModels.EnvironmentItemModel {
+	var envs []envmanModels.EnvironmentItemModel
+	for _, secret := range secrets {
+		envs = append(envs, steps.SecretEnv(secret.Key, "Required by "+secret.Source))
+	}
+	return envs
+}
+
+func envReferences(line string) []string {
+	var keys []string
+	for _, match := range envReferencePattern.FindAllStringSubmatch(line, -1) {
+		keys = append(keys, match[1])
+	}
+	return keys
+}
+
+// codeLines returns the lines of the Ruby file without the comment lines.
+func codeLines(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,127 @@
+package fastlane
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const fastfile = `default_platform(:ios)
+
+lane :lint do
+  swiftlint
+end
+
+private_lane :setup do
+end
+
+platform :ios do
+  lane :test do
+    scan
+  end
+
+  lane :beta do
+    match(type: "appstore")
+    pilot
+  end
+end
+
+platform :android do
+  lane :deploy do
+    gradle(task: "bundleRelease")
+    supply
+  end
+end
+`
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "fastlane", "Fastfile"), fastfile)
+	testutil.WriteFile(t, filepath.Join(dir, "fastlane", "Matchfile"), "git_url(\"git@github.com:org/certificates.git\")\n")
+	testutil.WriteFile(t, filepath.Join(dir, "tools", "fastlane", "Fastfile"), "# no lanes\n")
+
+	fastfiles, err := Detect(dir)
+	require.NoError(t, err)
+	require.Equal(t, []Fastfile{{
+		Path:    "fastlane/Fastfile",
+		WorkDir: ".",
+		Lanes: []Lane{
+			{Name: "lint"},
+			{Platform: "android", Name: "deploy"},
+			{Platform: "ios", Name: "test"},
+			{Platform: "ios", Name: "beta"},
+		},
+		UsesMatch: true,
+	}}, fastfiles)
+}
+
+func TestAddLaneWorkflows(t *testing.T) {
+	config := bitriseModels.BitriseDataModel{
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			"ios_test": {Summary: "existing"},
+		},
+	}
+	fastfiles := []Fastfile{
+		{Path: "fastlane/Fastfile", WorkDir: ".", Lanes: []Lane{{Platform: "ios", Name: "test"}, {Platform: "ios", Name: "beta"}, {Name: "lint"}}},
+		{Path: "mobile/fastlane/Fastfile", WorkDir: "mobile", Lanes: []Lane{{Name: "lint"}}},
+	}
+
+	workflowIDs := AddLaneWorkflows(&config, fastfiles, models.SSHKeyActivationMandatory)
+	require.Equal(t, []string{"ios_beta", "lint", "mobile_lint"}, workflowIDs)
+	require.Equal(t, "existing", config.Workflows["ios_test"].Summary)
+
+	beta := config.Workflows["ios_beta"]
+	require.Equal(t, "ios beta", beta.Environments[0][LaneEnvKey])
+	require.Equal(t, ".", beta.Environments[1][WorkDirEnvKey])
+	var ids []string
+	for _, item := range beta.Steps {
+		ids = append(ids, steps.ID(item))
+	}
+	require.Equal(t, []string{
+		initSteps.ActivateSSHKeyID,
+		initSteps.GitCloneID,
+		initSteps.CertificateAndProfileInstallerID,
+		initSteps.FastlaneID,
+		initSteps.DeployToBitriseIoID,
+	}, ids)
+
+	require.False(t, steps.Contains(config.Workflows["lint"].Steps, initSteps.CertificateAndProfileInstallerID))
+	require.Equal(t, "mobile", config.Workflows["mobile_lint"].Environments[1][WorkDirEnvKey])
+}
+
+func TestSecrets(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "fastlane", "Appfile"), `app_identifier("io.bitrise.app")
+# apple_id("commented@bitrise.io")
+team_id(ENV["TEAM_ID"])
+json_key_file("play-store.json")
+`)
+	testutil.WriteFile(t, filepath.Join(dir, "fastlane", "Matchfile"), `storage_mode("git")
+type("appstore")
+username(ENV.fetch("MATCH_USERNAME"))
+`)
+	testutil.WriteFile(t, filepath.Join(dir, "ios", "fastlane", "Matchfile"), "git_url(\"https://github.com/org/certificates\")\n")
+
+	secrets, err := Secrets(dir, []Fastfile{
+		{Path: "fastlane/Fastfile", WorkDir: "."},
+		{Path: "ios/fastlane/Fastfile", WorkDir: "ios"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Secret{
+		{Key: "APP_STORE_CONNECT_API_KEY_KEY_ID", Source: "fastlane/Appfile"},
+		{Key: "APP_STORE_CONNECT_API_KEY_ISSUER_ID", Source: "fastlane/Appfile"},
+		{Key: "APP_STORE_CONNECT_API_KEY_KEY", Source: "fastlane/Appfile"},
+		{Key: "TEAM_ID", Source: "fastlane/Appfile"},
+		{Key: "SUPPLY_JSON_KEY_DATA", Source: "fastlane/Appfile"},
+		{Key: "MATCH_GIT_URL", Source: "fastlane/Matchfile"},
+		{Key: "MATCH_PASSWORD", Source: "fastlane/Matchfile"},
+		{Key: "MATCH_USERNAME", Source: "fastlane/Matchfile"},
+		{Key: "MATCH_GIT_BASIC_AUTHORIZATION", Source: "ios/fastlane/Matchfile"},
+	}, secrets)
+}
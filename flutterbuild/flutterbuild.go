@@ -0,0 +1,340 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-init/utility"
+	"github.com/bitrise-io/bitrise-plugins-init/gradlescript"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ScannerName is the name of the bitrise-init Flutter scanner and the project type of its configs.
+	ScannerName = "flutter"
+	// ProjectLocationEnvKey is the app env of the Flutter scanner's project location option.
+	ProjectLocationEnvKey = "BITRISE_FLUTTER_PROJECT_LOCATION"
+
+	// FlavorEnvKey is the app env of the flavor option.
+	FlavorEnvKey  = "FLUTTER_FLAVOR"
+	flavorTitle   = "Flavor"
+	flavorSummary = "The flavor of the Flutter app your builds use, it is passed to the Flutter commands with the --flavor flag."
+
+	// IntegrationTestWorkflowID is the ID of the workflow running the integration tests on an Android emulator.
+	IntegrationTestWorkflowID = "run_integration_tests"
+	// IntegrationTestPipelineID is the ID of the pipeline running the integration test shards in parallel.
+	IntegrationTestPipelineID  = "integration_tests"
+	integrationTestSummary     = "Run your Flutter integration tests on an Android emulator."
+	integrationTestDescription = "The workflow will first clone your Git repository, install Flutter and your project's dependencies, start an Android emulator and run the integration tests of its shard. Run it through the integration_tests pipeline to split the tests between parallel shards; set the parallel count of the pipeline to change the number of shards."
+	integrationTestDir         = "integration_test"
+	// integrationTestShards is the number of parallel integration test workflows of the generated pipeline.
+	integrationTestShards = "2"
+
+	buildStepAndroidParamsKey = "android_additional_params"
+	buildStepIOSParamsKey     = "ios_additional_params"
+	releaseParam              = "--release"
+)
+
+var (
+	xcconfigFlavorPattern = regexp.MustCompile(`^(?:Debug|Release|Profile)-([\w-]+)\.xcconfig$`)
+	// dartDefineDirs are the directories searched for the dart define files of the flavors, relative to the project.
+	dartDefineDirs = []string{"", "config", "configs", "env", "environments", "dart_defines", "dart-defines"}
+)
+
+// Flavor is a flavor of a Flutter app with the flavor specific inputs of the Flutter commands.
+type Flavor struct {
+	Name string
+	// EntryPoint is the lib/main_<flavor>.dart entry point relative to the project, empty if the app uses lib/main.dart.
+	EntryPoint string
+	// DartDefineFile is the file with the flavor's --dart-define values relative to the project, like config/dev.json.
+	DartDefineFile string
+}
+
+// BuildParams returns the Flutter command parameters building the flavor.
+func (f Flavor) BuildParams() string {
+	params := []string{"--flavor", f.Name}
+	if f.EntryPoint != "" {
+		params = append(params, "-t", f.EntryPoint)
+	}
+	if f.DartDefineFile != "" {
+		params = append(params, "--dart-define-from-file="+f.DartDefineFile)
+	}
+	return strings.Join(params, " ")
+}
+
+// TestParams returns the flutter test parameters running the tests of the flavor. The entry point is not passed:
+// the integration tests are their own entry points, and -t selects the test tags of flutter test.
+func (f Flavor) TestParams() string {
+	params := []string{"--flavor", f.Name}
+	if f.DartDefineFile != "" {
+		params = append(params, "--dart-define-from-file="+f.DartDefineFile)
+	}
+	return strings.Join(params, " ")
+}
+
+// DetectFlavors returns the flavors of the Flutter project: the product flavors of its Android app module and
+// the custom schemes and the flavor specific xcconfig files of its iOS project.
+func DetectFlavors(projectDir string) ([]Flavor, error) {
+	names := map[string]bool{}
+
+	for _, buildScript := range []string{"build.gradle", "build.gradle.kts"} {
+		content, err := fsutil.ReadOptionalFile(filepath.Join(projectDir, "android", "app", buildScript))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range productFlavors(gradlescript.StripComments(content)) {
+			names[name] = true
+		}
+	}
+
+	schemes, err := filepath.Glob(filepath.Join(projectDir, "ios", "Runner.xcodeproj", "xcshareddata", "xcschemes", "*.xcscheme"))
+	if err != nil {
+		return nil, err
+	}
+	for _, scheme := range schemes {
+		if name := strings.TrimSuffix(filepath.Base(scheme), ".xcscheme"); name != "Runner" {
+			names[name] = true
+		}
+	}
+
+	xcconfigs, err := filepath.Glob(filepath.Join(projectDir, "ios", "Flutter", "*.xcconfig"))
+	if err != nil {
+		return nil, err
+	}
+	for _, xcconfig := range xcconfigs {
+		if match := xcconfigFlavorPattern.FindStringSubmatch(filepath.Base(xcconfig)); match != nil {
+			names[match[1]] = true
+		}
+	}
+
+	var flavors []Flavor
+	for name := range names {
+		flavor := Flavor{Name: name}
+		entryPoint := filepath.Join("lib", "main_"+name+".dart")
+		if utility.FileExists(filepath.Join(projectDir, entryPoint)) {
+			flavor.EntryPoint = entryPoint
+		}
+		flavor.DartDefineFile = dartDefineFile(projectDir, name)
+		flavors = append(flavors, flavor)
+	}
+	sort.Slice(flavors, func(i, j int) bool { return flavors[i].Name < flavors[j].Name })
+	return flavors, nil
+}
+
+// RefineOptions adds the flavor option to the Flutter projects with flavors, the flavors lead to the project's config.
+func RefineOptions(scanResult *models.ScanResultModel, searchDir string) {
+	root, ok := scanResult.ScannerToOptionRoot[ScannerName]
+	if !ok {
+		return
+	}
+
+	for location, configOption := range root.ChildOptionMap {
+		if configOption == nil || !configOption.IsConfigOption() {
+			continue
+		}
+		flavors, err := DetectFlavors(filepath.Join(searchDir, location))
+		if err != nil {
+			log.Warnf("Failed to detect the flavors of the Flutter project %s: %s", location, err)
+			continue
+		}
+		if len(flavors) == 0 {
+			continue
+		}
+
+		var flavorNames []string
+		flavorOption := models.NewOption(flavorTitle, flavorSummary, FlavorEnvKey, models.TypeSelector)
+		for _, flavor := range flavors {
+			flavorOption.AddConfig(flavor.Name, models.NewConfigOption(configOption.Config, configOption.Icons))
+			flavorNames = append(flavorNames, flavor.Name)
+		}
+		root.AddOption(location, flavorOption)
+		log.Infof("Flutter flavors detected: %s (%s)", location, strings.Join(flavorNames, ", "))
+	}
+
+	scanResult.ScannerToOptionRoot[ScannerName] = root
+}
+
+// SetFlavor passes the selected flavor to the Flutter Build steps of the config. It returns the flavor,
+// or false if the config has no selected flavor.
+func SetFlavor(config *bitriseModels.BitriseDataModel, searchDir string) (Flavor, bool, error) {
+	flavorName := steps.AppEnv(*config, FlavorEnvKey)
+	if config.ProjectType != ScannerName || flavorName == "" {
+		return Flavor{}, false, nil
+	}
+
+	flavor, err := selectedFlavor(*config, searchDir)
+	if err != nil {
+		return Flavor{}, false, err
+	}
+
+	params := releaseParam + " " + flavor.BuildParams()
+	for workflowID, workflow := range config.Workflows {
+		for i, item := range workflow.Steps {
+			if steps.ID(item) != initSteps.FlutterBuildID {
+				continue
+			}
+			item = steps.WithInput(item, buildStepAndroidParamsKey, params)
+			workflow.Steps[i] = steps.WithInput(item, buildStepIOSParamsKey, params)
+		}
+		config.Workflows[workflowID] = workflow
+	}
+	return flavor, true, nil
+}
+
+// AddIntegrationTestWorkflow adds the integration test workflow and its sharding pipeline to the Flutter config,
+// if the project has an integration_test directory and an Android app to run the tests on.
+func AddIntegrationTestWorkflow(config *bitriseModels.BitriseDataModel, searchDir string) (bool, error) {
+	if config.ProjectType != ScannerName {
+		return false, nil
+	}
+	projectDir := filepath.Join(searchDir, steps.AppEnv(*config, ProjectLocationEnvKey))
+	if !hasIntegrationTests(filepath.Join(projectDir, integrationTestDir)) || !fsutil.IsDir(filepath.Join(projectDir, "android")) {
+		return false, nil
+	}
+	primary, ok := config.Workflows[string(models.PrimaryWorkflowID)]
+	if !ok {
+		return false, nil
+	}
+
+	flavorParams := ""
+	if steps.AppEnv(*config, FlavorEnvKey) != "" {
+		flavor, err := selectedFlavor(*config, searchDir)
+		if err != nil {
+			return false, err
+		}
+		flavorParams = " " + flavor.TestParams()
+	}
+
+	// the steps of the primary workflow preparing the project are kept, the test and deploy steps are replaced
+	var stepList []bitriseModels.StepListItemModel
+	for _, item := range primary.Steps {
+		id := steps.ID(item)
+		if id == initSteps.FlutterTestID || id == initSteps.FlutterAnalyzeID {
+			break
+		}
+		stepList = append(stepList, item)
+	}
+	stepList = append(stepList,
+		initSteps.AvdManagerStepListItem(),
+		initSteps.WaitForAndroidEmulatorStepListItem(),
+		initSteps.ScriptStepListItem("Run integration tests", fmt.Sprintf(integrationTestScript, flavorParams)),
+	)
+
+	config.Workflows[IntegrationTestWorkflowID] = bitriseModels.WorkflowModel{
+		Summary:     integrationTestSummary,
+		Description: integrationTestDescription,
+		Steps:       stepList,
+	}
+	if config.Pipelines == nil {
+		config.Pipelines = map[string]bitriseModels.PipelineModel{}
+	}
+	config.Pipelines[IntegrationTestPipelineID] = bitriseModels.PipelineModel{
+		Workflows: bitriseModels.GraphPipelineWorkflowListItemModel{
+			IntegrationTestWorkflowID: {Parallel: integrationTestShards},
+		},
+	}
+	return true, nil
+}
+
+// integrationTestScript runs every integration test whose index matches the shard of the parallel workflow,
+// it is completed with the flavor parameters.
+const integrationTestScript = `#!/usr/bin/env bash
+set -eo pipefail
+
+cd "$BITRISE_FLUTTER_PROJECT_LOCATION"
+
+shard_index="${BITRISE_IO_PARALLEL_INDEX:-0}"
+shard_total="${BITRISE_IO_PARALLEL_TOTAL:-1}"
+
+tests=()
+index=0
+for test in $(find integration_test -name '*_test.dart' | sort); do
+  if [ $((index %% shard_total)) -eq "$shard_index" ]; then
+    tests+=("$test")
+  fi
+  index=$((index + 1))
+done
+
+if [ ${#tests[@]} -eq 0 ]; then
+  echo "No integration tests in shard $shard_index"
+  exit 0
+fi
+
+set -x
+flutter test "${tests[@]}" -d "$BITRISE_EMULATOR_SERIAL"%s
+`
+
+// selectedFlavor returns the flavor of the config's project matching the flavor app env.
+func selectedFlavor(config bitriseModels.BitriseDataModel, searchDir string) (Flavor, error) {
+	flavorName := steps.AppEnv(config, FlavorEnvKey)
+	flavors, err := DetectFlavors(filepath.Join(searchDir, steps.AppEnv(config, ProjectLocationEnvKey)))
+	if err != nil {
+		return Flavor{}, err
+	}
+	for _, flavor := range flavors {
+		if flavor.Name == flavorName {
+			return flavor, nil
+		}
+	}
+	return Flavor{Name: flavorName}, nil
+}
+
+// productFlavors returns the product flavors of the android block of a build script.
+func productFlavors(script string) []string {
+	android, ok := gradlescript.Block(script, "android")
+	if !ok {
+		return nil
+	}
+	productFlavors, ok := gradlescript.Block(android, "productFlavors")
+	if !ok {
+		return nil
+	}
+	blocks, ok := gradlescript.NamedBlocks(productFlavors)
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for _, block := range blocks {
+		names = append(names, block.Name)
+	}
+	return names
+}
+
+// dartDefineFile returns the first of the <flavor>.json, <flavor>.env and .env.<flavor> files in the dart define dirs.
+func dartDefineFile(projectDir, flavor string) string {
+	for _, dir := range dartDefineDirs {
+		for _, name := range []string{flavor + ".json", flavor + ".env", ".env." + flavor} {
+			pth := filepath.Join(dir, name)
+			if utility.FileExists(filepath.Join(projectDir, pth)) {
+				return pth
+			}
+		}
+	}
+	return ""
+}
+
+// hasIntegrationTests reports whether the dir has a test file, the integration test script runs the tests of the subdirs too.
+func hasIntegrationTests(dir string) bool {
+	found := false
+	_ = filepath.WalkDir(dir, func(pth string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), "_test.dart") {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
@@ -0,0 +1,171 @@
+package flutterbuild
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const flavoredBuildScript = `plugins {
+    id("com.android.application")
+}
+
+android {
+    flavorDimensions += "environment"
+    productFlavors {
+        create("dev") {
+            dimension = "environment"
+            applicationIdSuffix = ".dev"
+        }
+        create("prod") {
+            dimension = "environment"
+        }
+    }
+}
+`
+
+func flavoredProject(t *testing.T) string {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "app", "android", "app", "build.gradle.kts"), flavoredBuildScript)
+	testutil.WriteFile(t, filepath.Join(dir, "app", "ios", "Runner.xcodeproj", "xcshareddata", "xcschemes", "Runner.xcscheme"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "app", "ios", "Runner.xcodeproj", "xcshareddata", "xcschemes", "dev.xcscheme"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "app", "ios", "Flutter", "Release-staging.xcconfig"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "app", "ios", "Flutter", "Generated.xcconfig"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "app", "lib", "main_dev.dart"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "app", "config", "dev.json"), "{}")
+	testutil.WriteFile(t, filepath.Join(dir, "app", ".env.prod"), "")
+	return dir
+}
+
+func flutterConfig(envs ...envmanModels.EnvironmentItemModel) bitriseModels.BitriseDataModel {
+	config := bitriseModels.BitriseDataModel{
+		ProjectType: ScannerName,
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			"primary": {Steps: []bitriseModels.StepListItemModel{
+				initSteps.GitCloneStepListItem(),
+				initSteps.FlutterInstallStepListItem("", false),
+				initSteps.FlutterTestStepListItem(),
+				initSteps.DeployToBitriseIoStepListItem(),
+			}},
+			"deploy": {Steps: []bitriseModels.StepListItemModel{
+				initSteps.GitCloneStepListItem(),
+				initSteps.FlutterInstallStepListItem("", false),
+				initSteps.FlutterBuildStepListItem(envmanModels.EnvironmentItemModel{"platform": "both"}),
+				initSteps.DeployToBitriseIoStepListItem(),
+			}},
+		},
+	}
+	config.App.Environments = append([]envmanModels.EnvironmentItemModel{{ProjectLocationEnvKey: "app"}}, envs...)
+	return config
+}
+
+func TestDetectFlavors(t *testing.T) {
+	dir := flavoredProject(t)
+
+	flavors, err := DetectFlavors(filepath.Join(dir, "app"))
+	require.NoError(t, err)
+	require.Equal(t, []Flavor{
+		{Name: "dev", EntryPoint: "lib/main_dev.dart", DartDefineFile: "config/dev.json"},
+		{Name: "prod", DartDefineFile: ".env.prod"},
+		{Name: "staging"},
+	}, flavors)
+	require.Equal(t, "--flavor dev -t lib/main_dev.dart --dart-define-from-file=config/dev.json", flavors[0].BuildParams())
+
+	flavors, err = DetectFlavors(t.TempDir())
+	require.NoError(t, err)
+	require.Empty(t, flavors)
+}
+
+func TestRefineOptions(t *testing.T) {
+	dir := flavoredProject(t)
+	testutil.WriteFile(t, filepath.Join(dir, "plain", "pubspec.yaml"), "name: plain\n")
+
+	root := models.NewOption("Project location", "", ProjectLocationEnvKey, models.TypeSelector)
+	root.AddConfig("app", models.NewConfigOption("flutter-config-test-both-0", nil))
+	root.AddConfig("plain", models.NewConfigOption("flutter-config-notest-1", nil))
+	scanResult := models.ScanResultModel{ScannerToOptionRoot: map[string]models.OptionNode{ScannerName: *root}}
+
+	RefineOptions(&scanResult, dir)
+
+	refined := scanResult.ScannerToOptionRoot[ScannerName]
+	require.True(t, refined.ChildOptionMap["plain"].IsConfigOption())
+	flavorOption := refined.ChildOptionMap["app"]
+	require.Equal(t, FlavorEnvKey, flavorOption.EnvKey)
+	require.ElementsMatch(t, []string{"dev", "prod", "staging"}, flavorOption.GetValues())
+	require.Equal(t, "flutter-config-test-both-0", flavorOption.ChildOptionMap["prod"].Config)
+}
+
+func TestSetFlavor(t *testing.T) {
+	dir := flavoredProject(t)
+
+	config := flutterConfig()
+	_, ok, err := SetFlavor(&config, dir)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	config = flutterConfig(envmanModels.EnvironmentItemModel{FlavorEnvKey: "dev"})
+	flavor, ok, err := SetFlavor(&config, dir)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "lib/main_dev.dart", flavor.EntryPoint)
+
+	stepList := config.Workflows["deploy"].Steps
+	buildStep, err := stepList[steps.Index(stepList, initSteps.FlutterBuildID)].GetStep()
+	require.NoError(t, err)
+	params := "--release --flavor dev -t lib/main_dev.dart --dart-define-from-file=config/dev.json"
+	require.Contains(t, buildStep.Inputs, envmanModels.EnvironmentItemModel{buildStepAndroidParamsKey: params})
+	require.Contains(t, buildStep.Inputs, envmanModels.EnvironmentItemModel{buildStepIOSParamsKey: params})
+}
+
+func TestAddIntegrationTestWorkflow(t *testing.T) {
+	dir := flavoredProject(t)
+
+	config := flutterConfig(envmanModels.EnvironmentItemModel{FlavorEnvKey: "prod"})
+	added, err := AddIntegrationTestWorkflow(&config, dir)
+	require.NoError(t, err)
+	require.False(t, added)
+
+	testutil.WriteFile(t, filepath.Join(dir, "app", "integration_test", "flows", "login_test.dart"), "")
+
+	added, err = AddIntegrationTestWorkflow(&config, dir)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	stepList := config.Workflows[IntegrationTestWorkflowID].Steps
+	var ids []string
+	for _, item := range stepList {
+		ids = append(ids, steps.ID(item))
+	}
+	require.Equal(t, []string{initSteps.GitCloneID, initSteps.FlutterInstallID, initSteps.AvdManagerID, initSteps.WaitForAndroidEmulatorID, initSteps.ScriptID}, ids)
+
+	scriptStep, err := stepList[4].GetStep()
+	require.NoError(t, err)
+	_, content, err := scriptStep.Inputs[0].GetKeyValuePair()
+	require.NoError(t, err)
+	require.Contains(t, content, `flutter test "${tests[@]}" -d "$BITRISE_EMULATOR_SERIAL" --flavor prod --dart-define-from-file=.env.prod`)
+	require.Contains(t, content, "$((index % shard_total))")
+
+	require.Equal(t, "2", config.Pipelines[IntegrationTestPipelineID].Workflows[IntegrationTestWorkflowID].Parallel)
+
+	t.Log("the entry point of the flavor is not passed to flutter test")
+	{
+		config := flutterConfig(envmanModels.EnvironmentItemModel{FlavorEnvKey: "dev"})
+		added, err := AddIntegrationTestWorkflow(&config, dir)
+		require.NoError(t, err)
+		require.True(t, added)
+
+		scriptStep, err := config.Workflows[IntegrationTestWorkflowID].Steps[4].GetStep()
+		require.NoError(t, err)
+		_, content, err := scriptStep.Inputs[0].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Contains(t, content, `flutter test "${tests[@]}" -d "$BITRISE_EMULATOR_SERIAL" --flavor dev --dart-define-from-file=config/dev.json`+"\n")
+		require.NotContains(t, content, "main_dev.dart")
+	}
+}
@@ -6,24 +6,25 @@ require (
 	github.com/bitrise-io/bitrise-init v0.0.0-20250520133318-e1981b5c0db4
 	github.com/bitrise-io/bitrise/v2 v2.30.5
 	github.com/bitrise-io/envman v0.0.0-20210630102032-df85af51bd1a
+	github.com/bitrise-io/envman/v2 v2.5.3
+	github.com/bitrise-io/go-plist v0.0.0-20210301100253-4b1a112ccd10
 	github.com/bitrise-io/go-utils v1.0.13
+	github.com/bitrise-io/go-xcode v1.0.18
+	github.com/bitrise-io/goinp v0.0.0-20240103152431-054ed78518ef
+	github.com/bitrise-io/stepman v0.17.3
 	github.com/sirupsen/logrus v1.9.3
 	github.com/stretchr/testify v1.10.0
 	github.com/urfave/cli v1.22.15
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/Masterminds/semver/v3 v3.2.1 // indirect
 	github.com/beevik/etree v1.2.0 // indirect
-	github.com/bitrise-io/envman/v2 v2.5.3 // indirect
 	github.com/bitrise-io/go-flutter v0.1.1 // indirect
-	github.com/bitrise-io/go-plist v0.0.0-20210301100253-4b1a112ccd10 // indirect
 	github.com/bitrise-io/go-steputils v1.0.6 // indirect
 	github.com/bitrise-io/go-utils/v2 v2.0.0-alpha.22 // indirect
-	github.com/bitrise-io/go-xcode v1.0.18 // indirect
-	github.com/bitrise-io/goinp v0.0.0-20240103152431-054ed78518ef // indirect
-	github.com/bitrise-io/stepman v0.17.3 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.4 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/emirpasic/gods v1.18.1 // indirect
@@ -38,6 +39,5 @@ require (
 	golang.org/x/sys v0.29.0 // indirect
 	golang.org/x/term v0.28.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	howett.net/plist v1.0.0 // indirect
 )
@@ -0,0 +1,110 @@
+package gradlescript
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	applicationPluginPattern = regexp.MustCompile(`com\.android\.application|libs\.plugins\.android\.application`)
+	lineCommentPattern       = regexp.MustCompile(`(?m)(^|\s)//.*$`)
+	blockCommentPattern      = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	namedCallPattern         = regexp.MustCompile(`^(?:create|register|getByName|named|maybeCreate)\(\s*["']([\w-]+)["']\s*\)$`)
+	createCallPattern        = regexp.MustCompile(`\b(?:create|register|maybeCreate)\(\s*["']([\w-]+)["']\s*\)`)
+	identifierPattern        = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+)
+
+// NamedBlock is an element of a named domain object container block, like a build type or a signing config.
+type NamedBlock struct {
+	Name string
+	Body string
+}
+
+// IsApplication reports whether the Groovy or Kotlin build script applies the Android application plugin.
+func IsApplication(script string) bool {
+	return applicationPluginPattern.MatchString(script)
+}
+
+// StripComments removes the line and block comments of a Groovy or Kotlin build script.
+func StripComments(script string) string {
+	script = blockCommentPattern.ReplaceAllString(script, "")
+	return lineCommentPattern.ReplaceAllString(script, "$1")
+}
+
+// Block returns the body of the first block with the given name.
+func Block(script, name string) (string, bool) {
+	pattern := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\s*\{`)
+	location := pattern.FindStringIndex(script)
+	if location == nil {
+		return "", false
+	}
+	open := location[1] - 1
+	close := matchingBrace(script, open)
+	if close == -1 {
+		return "", false
+	}
+	return script[open+1 : close], true
+}
+
+// NamedBlocks returns the elements of a named domain object container block, like buildTypes or signingConfigs.
+// The Groovy scripts name the elements by the block name, the Kotlin scripts by the create, register and getByName calls.
+// It returns false if the block has other blocks than the elements, the elements of these blocks are not known statically.
+func NamedBlocks(body string) ([]NamedBlock, bool) {
+	var blocks []NamedBlock
+	seen := map[string]bool{}
+	add := func(name, blockBody string) {
+		if !seen[name] {
+			seen[name] = true
+			blocks = append(blocks, NamedBlock{Name: name, Body: blockBody})
+		}
+	}
+
+	rest := body
+	for {
+		open := strings.Index(rest, "{")
+		if open == -1 {
+			break
+		}
+		header := rest[:open]
+		if statementEnd := strings.LastIndexAny(header, ";\n}"); statementEnd != -1 {
+			header = header[statementEnd+1:]
+		}
+		header = strings.TrimSpace(header)
+
+		close := matchingBrace(rest, open)
+		if close == -1 {
+			return nil, false
+		}
+
+		switch match := namedCallPattern.FindStringSubmatch(header); {
+		case match != nil:
+			add(match[1], rest[open+1:close])
+		case identifierPattern.MatchString(header):
+			add(header, rest[open+1:close])
+		default:
+			return nil, false
+		}
+		rest = rest[:open] + rest[close+1:]
+	}
+
+	for _, match := range createCallPattern.FindAllStringSubmatch(rest, -1) {
+		add(match[1], "")
+	}
+	return blocks, true
+}
+
+func matchingBrace(content string, open int) int {
+	depth := 0
+	for i := open; i < len(content); i++ {
+		switch content[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,40 @@
+package gradlescript
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlock(t *testing.T) {
+	body, ok := Block("android {\n    defaultConfig { minSdk 24 }\n}\n", "defaultConfig")
+	require.True(t, ok)
+	require.Equal(t, " minSdk 24 ", body)
+
+	_, ok = Block("android {\n    defaultConfig {\n", "defaultConfig")
+	require.False(t, ok)
+}
+
+func TestNamedBlocks(t *testing.T) {
+	blocks, ok := NamedBlocks(`
+        release { minifyEnabled true }
+        getByName("debug") { isDebuggable = true }
+        create("benchmark")
+    `)
+	require.True(t, ok)
+	require.Equal(t, []NamedBlock{
+		{Name: "release", Body: " minifyEnabled true "},
+		{Name: "debug", Body: " isDebuggable = true "},
+		{Name: "benchmark"},
+	}, blocks)
+
+	_, ok = NamedBlocks(`listOf("free", "paid").forEach { create(it) }`)
+	require.False(t, ok)
+}
+
+func TestStripComments(t *testing.T) {
+	script := StripComments("apply plugin: 'com.android.application' // app\n/* apply plugin: 'kotlin-android' */\nurl 'https://jitpack.io'\n")
+	require.True(t, IsApplication(script))
+	require.NotContains(t, script, "kotlin-android")
+	require.Contains(t, script, "https://jitpack.io")
+}
@@ -0,0 +1,331 @@
+package circleci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bitrise-io/bitrise-plugins-init/importer"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// SourceName is the name of the CircleCI import source.
+const SourceName = "circleci"
+
+const configFilePath = ".circleci/config.yml"
+
+var (
+	parameterPattern  = regexp.MustCompile(`<<\s*(parameters|pipeline)\.([A-Za-z0-9_.-]+)\s*>>`)
+	invalidEnvKeyChar = regexp.MustCompile(`[^A-Za-z0-9_]`)
+)
+
+// pipelineValues are the CircleCI pipeline values with an equivalent Bitrise env var.
+var pipelineValues = map[string]string{
+	"git.branch":   "BITRISE_GIT_BRANCH",
+	"git.revision": "GIT_CLONE_COMMIT_HASH",
+	"git.tag":      "BITRISE_GIT_TAG",
+	"number":       "BITRISE_BUILD_NUMBER",
+}
+
+// Source imports the CircleCI config of a repository.
+type Source struct{}
+
+// Name ...
+func (Source) Name() string {
+	return SourceName
+}
+
+// Detect ...
+func (Source) Detect(searchDir string) (bool, error) {
+	return pathutil.IsPathExists(filepath.Join(searchDir, configFilePath))
+}
+
+// Import translates the CircleCI config: each job becomes a workflow and each CircleCI workflow becomes a graph
+// pipeline, with the required jobs as dependencies. The reusable commands and executors are inlined, the docker
+// images of a job become a container and services, the job's commands run in with groups using them.
+func (Source) Import(searchDir string) (importer.Result, error) {
+	config, err := importer.NewConfig()
+	if err != nil {
+		return importer.Result{}, err
+	}
+	config.Containers = map[string]bitriseModels.Container{}
+	config.Services = map[string]bitriseModels.Container{}
+
+	content, err := os.ReadFile(filepath.Join(searchDir, configFilePath))
+	if err != nil {
+		return importer.Result{}, err
+	}
+
+	t := translator{config: &config}
+
+	var doc yaml.Node
+	unresolved, err := importer.UnmarshalYAML(content, &doc)
+	if err != nil {
+		return importer.Result{}, fmt.Errorf("failed to parse %s: %s", configFilePath, err)
+	}
+	for _, anchor := range unresolved {
+		t.addTODO("", configFilePath, "the %s anchor is not defined, the values referring to it are left empty", anchor)
+	}
+	if err := doc.Decode(&t.file); err != nil {
+		return importer.Result{}, fmt.Errorf("failed to parse %s: %s", configFilePath, err)
+	}
+	if len(t.file.Jobs) == 0 {
+		return importer.Result{}, fmt.Errorf("no jobs found in %s", configFilePath)
+	}
+
+	for _, orb := range maputil.SortedKeys(t.file.Orbs) {
+		ref := t.file.Orbs[orb]
+		t.addTODO("", configFilePath+" orbs."+orb, "the %s orb (%s) is not translated, the jobs, commands and executors of the orb are marked with TODOs", orb, ref.Value)
+	}
+
+	for _, name := range maputil.SortedKeys(t.file.Jobs) {
+		config.Workflows[importer.WorkflowID(name)] = t.translateJob(name)
+	}
+
+	var workflowNames []string
+	for _, name := range maputil.SortedKeys(t.file.Workflows) {
+		if node := t.file.Workflows[name]; node.Kind == yaml.MappingNode {
+			workflowNames = append(workflowNames, name)
+		}
+	}
+
+	if len(workflowNames) == 0 {
+		// without workflows, the build job runs for every push
+		if workflow, ok := config.Workflows["build"]; ok {
+			workflow.Triggers = bitriseModels.Triggers{PushTriggers: []bitriseModels.PushGitEventTriggerItem{{Branch: "*"}}}
+			config.Workflows["build"] = workflow
+		}
+	}
+
+	for _, name := range workflowNames {
+		node := t.file.Workflows[name]
+		if err := t.translateWorkflow(name, &node); err != nil {
+			return importer.Result{}, err
+		}
+	}
+
+	if len(config.Containers) == 0 {
+		config.Containers = nil
+	}
+	if len(config.Services) == 0 {
+		config.Services = nil
+	}
+
+	return importer.Result{Config: config, TODOs: t.todos}, nil
+}
+
+type translator struct {
+	file   configFile
+	config *bitriseModels.BitriseDataModel
+	todos  []importer.TODO
+}
+
+func (t *translator) addTODO(workflowID, location, format string, args ...interface{}) importer.TODO {
+	todo := importer.TODO{WorkflowID: workflowID, Location: location, Message: fmt.Sprintf(format, args...)}
+	t.todos = append(t.todos, todo)
+	return todo
+}
+
+// translateWorkflow converts a CircleCI workflow to a graph pipeline. The jobs with a name or with parameter
+// arguments become workflow variants. A workflow with a single job is triggered directly, without a pipeline.
+func (t *translator) translateWorkflow(name string, node *yaml.Node) error {
+	location := configFilePath + " workflows." + name
+	pipelineID := importer.WorkflowID(name)
+
+	var w workflow
+	if err := node.Decode(&w); err != nil {
+		return fmt.Errorf("failed to parse the %s workflow of %s: %s", name, configFilePath, err)
+	}
+
+	triggers := bitriseModels.Triggers{PushTriggers: []bitriseModels.PushGitEventTriggerItem{{Branch: "*"}}}
+	if !w.Triggers.IsZero() {
+		t.addTODO("", location+".triggers", "scheduled builds are configured on bitrise.io")
+		triggers = bitriseModels.Triggers{}
+	}
+	if !w.When.IsZero() || !w.Unless.IsZero() {
+		t.addTODO("", location, "the workflow conditions are not translated, add the triggers of the %s pipeline", pipelineID)
+		triggers = bitriseModels.Triggers{}
+	}
+
+	var jobs []workflowJob
+	keys := map[string]string{}
+	for i := range w.Jobs {
+		var job workflowJob
+		if err := w.Jobs[i].Decode(&job); err != nil {
+			return fmt.Errorf("failed to parse the %s workflow of %s: %s", name, configFilePath, err)
+		}
+		jobs = append(jobs, job)
+		keys[job.reference()] = t.pipelineWorkflowKey(job)
+	}
+
+	if len(jobs) == 1 && len(jobs[0].Requires) == 0 && len(jobs[0].Options) == 0 && len(jobs[0].Args) == 0 {
+		workflowID := importer.WorkflowID(jobs[0].Job)
+		if workflow, ok := t.config.Workflows[workflowID]; ok {
+			workflow.Triggers = triggers
+			t.config.Workflows[workflowID] = workflow
+			return nil
+		}
+	}
+
+	pipeline := bitriseModels.PipelineModel{
+		Triggers:  triggers,
+		Workflows: bitriseModels.GraphPipelineWorkflowListItemModel{},
+	}
+	for i, job := range jobs {
+		jobLocation := fmt.Sprintf("%s.jobs[%d]", location, i)
+		workflowID := importer.WorkflowID(job.Job)
+		key := keys[job.reference()]
+
+		for _, option := range []string{"filters", "context", "matrix", "pre-steps", "post-steps", "serial-group"} {
+			if _, ok := job.Options[option]; !ok {
+				continue
+			}
+			switch option {
+			case "filters":
+				t.addTODO(key, jobLocation+".filters", "the branch and tag filters are not translated, add a run_if condition to the pipeline workflow")
+			case "context":
+				t.addTODO(key, jobLocation+".context", "add the env vars of the %s context as secrets", snippet(job.Options[option]))
+			default:
+				t.addTODO(key, jobLocation+"."+option, "the %s option is not translated", option)
+			}
+		}
+
+		var pipelineWorkflow bitriseModels.GraphPipelineWorkflowModel
+		_, isJob := t.file.Jobs[job.Job]
+		switch {
+		case job.Type == "approval":
+			t.addPlaceholderWorkflow(key, jobLocation, "approval jobs are not supported, the pipeline continues without approval", "")
+		case strings.Contains(job.Job, "/"):
+			t.addPlaceholderWorkflow(key, jobLocation, fmt.Sprintf("translate the %s orb job", job.Job), snippet(&w.Jobs[i]))
+		case !isJob:
+			t.addPlaceholderWorkflow(key, jobLocation, fmt.Sprintf("the %s job is not found", job.Job), "")
+		case key != workflowID:
+			pipelineWorkflow.Uses = workflowID
+			for _, arg := range maputil.SortedKeys(job.Args) {
+				pipelineWorkflow.Inputs = append(pipelineWorkflow.Inputs, bitriseModels.GraphPipelineWorkflowModelInput{parameterEnvKey(arg): job.Args[arg].Value})
+			}
+		}
+
+		if parallelism, ok := t.jobParallelism(job.Job); ok {
+			pipelineWorkflow.Parallel = parallelism
+		}
+
+		for _, required := range job.Requires {
+			dependency, ok := keys[required]
+			if !ok {
+				t.addTODO(key, jobLocation+".requires", "the required %s job is not found", required)
+				continue
+			}
+			pipelineWorkflow.DependsOn = append(pipelineWorkflow.DependsOn, dependency)
+		}
+		pipeline.Workflows[key] = pipelineWorkflow
+	}
+
+	t.config.Pipelines[pipelineID] = pipeline
+	return nil
+}
+
+// pipelineWorkflowKey returns the key of the job in the pipeline. A job with a name or with parameter arguments
+// becomes a workflow variant, with its name or with the argument values appended to the workflow ID.
+func (t *translator) pipelineWorkflowKey(job workflowJob) string {
+	if job.Name != "" {
+		return importer.WorkflowID(job.Name)
+	}
+
+	key := importer.WorkflowID(job.Job)
+	if _, isJob := t.file.Jobs[job.Job]; !isJob || job.Type == "approval" {
+		return key
+	}
+	for _, arg := range maputil.SortedKeys(job.Args) {
+		key += "_" + importer.WorkflowID(job.Args[arg].Value)
+	}
+	return key
+}
+
+// addPlaceholderWorkflow adds a workflow with a TODO step, standing for a job the importer could not translate.
+func (t *translator) addPlaceholderWorkflow(workflowID, location, message, source string) {
+	if _, ok := t.config.Workflows[workflowID]; ok {
+		return
+	}
+
+	todo := t.addTODO(workflowID, location, "%s", message)
+	t.config.Workflows[workflowID] = bitriseModels.WorkflowModel{
+		Summary: "Imported from " + configFilePath,
+		Steps:   []bitriseModels.StepListItemModel{importer.TODOStepListItem(workflowID, todo, source)},
+	}
+}
+
+func (t *translator) jobParallelism(name string) (string, bool) {
+	node, ok := t.file.Jobs[name]
+	if !ok {
+		return "", false
+	}
+
+	var j struct {
+		Parallelism string `yaml:"parallelism"`
+	}
+	if err := node.Decode(&j); err != nil {
+		return "", false
+	}
+	if parallelism, err := strconv.Atoi(j.Parallelism); err != nil || parallelism < 2 {
+		return "", false
+	}
+	return j.Parallelism, true
+}
+
+// substituteParameters returns a copy of the node with the << parameters.x >> and << pipeline.x >> references replaced.
+// The value function returns the node to replace the reference with, a scalar referring only to a non-scalar value
+// (like a steps parameter) is replaced by the value itself.
+func substituteParameters(node *yaml.Node, value func(scope, name string) (*yaml.Node, bool)) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	substituted := *node
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if match := parameterPattern.FindStringSubmatch(node.Value); match != nil && match[0] == strings.TrimSpace(node.Value) {
+			if replacement, ok := value(match[1], match[2]); ok && replacement.Kind != yaml.ScalarNode {
+				return substituteParameters(replacement, value)
+			}
+		}
+		substituted.Value = parameterPattern.ReplaceAllStringFunc(node.Value, func(reference string) string {
+			match := parameterPattern.FindStringSubmatch(reference)
+			if replacement, ok := value(match[1], match[2]); ok && replacement.Kind == yaml.ScalarNode {
+				return replacement.Value
+			}
+			return reference
+		})
+	default:
+		substituted.Content = nil
+		for _, item := range node.Content {
+			substituted.Content = append(substituted.Content, substituteParameters(item, value))
+		}
+	}
+	return &substituted
+}
+
+// parameterEnvKey returns the env var standing for a job parameter in the workflow.
+func parameterEnvKey(name string) string {
+	return strings.ToUpper(invalidEnvKeyChar.ReplaceAllString(name, "_"))
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func envItems(env environment) []envmanModels.EnvironmentItemModel {
+	var items []envmanModels.EnvironmentItemModel
+	for _, key := range maputil.SortedKeys(env) {
+		items = append(items, envmanModels.EnvironmentItemModel{key: env[key]})
+	}
+	return items
+}
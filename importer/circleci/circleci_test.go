@@ -0,0 +1,182 @@
+package circleci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	yamlv2 "gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-plugins-init/importer"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const circleConfig = `version: 2.1
+orbs:
+  node: circleci/node@5.1.0
+executors:
+  node-executor:
+    parameters:
+      tag:
+        type: string
+        default: "20.5"
+    docker:
+      - image: cimg/node:<< parameters.tag >>
+      - image: cimg/postgres:15.1
+        name: db
+commands:
+  install:
+    parameters:
+      dir:
+        type: string
+        default: "."
+    steps:
+      - restore_cache:
+          keys:
+            - npm-{{ .Branch }}-{{ checksum "package-lock.json" }}
+            - npm-
+      - run:
+          name: Install
+          command: npm ci
+          working_directory: << parameters.dir >>
+      - save_cache:
+          key: npm-{{ .Branch }}-{{ checksum "package-lock.json" }}
+          paths: [node_modules]
+defaults: &defaults
+  executor: node-executor
+jobs:
+  build:
+    <<: *defaults
+    steps:
+      - checkout
+      - install
+      - run: npm run build -- --sha=$CIRCLE_SHA1
+      - store_artifacts:
+          path: dist
+  test:
+    executor:
+      name: node-executor
+      tag: "18.0"
+    parameters:
+      shard:
+        type: string
+        default: all
+    parallelism: 4
+    steps:
+      - checkout
+      - install:
+          dir: app
+      - run:
+          command: npm test -- --shard=<< parameters.shard >>
+          when: always
+      - node/install-packages
+      - *undefined
+workflows:
+  version: 2
+  main:
+    jobs:
+      - build
+      - test:
+          name: test-unit
+          shard: unit
+          requires: [build]
+      - hold:
+          type: approval
+          requires: [test-unit]
+      - node/deploy:
+          requires: [hold]
+`
+
+func stepKeys(t *testing.T, stepList []bitriseModels.StepListItemModel) []string {
+	var keys []string
+	for _, item := range stepList {
+		key, _, err := item.GetKeyAndType()
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".circleci"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, configFilePath), []byte(circleConfig), 0644))
+
+	detected, err := Source{}.Detect(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	result, err := Source{}.Import(dir)
+	require.NoError(t, err)
+	config := result.Config
+
+	t.Log("requires become dependencies, named jobs and jobs with arguments become workflow variants")
+	{
+		pipeline := config.Pipelines["main"]
+		require.Equal(t, bitriseModels.GraphPipelineWorkflowModel{}, pipeline.Workflows["build"])
+		require.Equal(t, bitriseModels.GraphPipelineWorkflowModel{
+			DependsOn: []string{"build"},
+			Uses:      "test",
+			Inputs:    []bitriseModels.GraphPipelineWorkflowModelInput{{"SHARD": "unit"}},
+			Parallel:  "4",
+		}, pipeline.Workflows["test-unit"])
+		require.Equal(t, []string{"test-unit"}, pipeline.Workflows["hold"].DependsOn)
+		require.Equal(t, []string{"hold"}, pipeline.Workflows["node_deploy"].DependsOn)
+	}
+
+	t.Log("commands and executors are inlined")
+	{
+		build := config.Workflows["build"]
+		require.Equal(t, []string{"git-clone@8", "restore-cache@2", "with", "save-cache@1", "with", "deploy-to-bitrise-io@2"}, stepKeys(t, build.Steps))
+
+		restoreCache, err := build.Steps[1].GetStep()
+		require.NoError(t, err)
+		require.Equal(t, "npm-{{ .Branch }}-{{ checksum \"package-lock.json\" }}\nnpm-", restoreCache.Inputs[0]["key"])
+
+		with := build.Steps[4][bitriseModels.StepListItemWithKey].(bitriseModels.WithModel)
+		require.Equal(t, "cimg_node_20.5", with.ContainerID)
+		require.Equal(t, []string{"db"}, with.ServiceIDs)
+		_, content, err := with.Steps[0]["script@1"].Inputs[0].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Contains(t, content, "npm run build -- --sha=$GIT_CLONE_COMMIT_HASH")
+
+		test := config.Workflows["test"]
+		require.Equal(t, "all", test.Environments[0]["SHARD"])
+		testWith := test.Steps[2][bitriseModels.StepListItemWithKey].(bitriseModels.WithModel)
+		require.Equal(t, "cimg_node_18.0", testWith.ContainerID)
+		_, content, err = testWith.Steps[0]["script@1"].Inputs[0].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Contains(t, content, "cd \"app\"\n\nnpm ci")
+
+		testWith = test.Steps[4][bitriseModels.StepListItemWithKey].(bitriseModels.WithModel)
+		require.True(t, *testWith.Steps[0]["script@1"].IsAlwaysRun)
+		_, content, err = testWith.Steps[0]["script@1"].Inputs[0].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Contains(t, content, "npm test -- --shard=${SHARD}")
+	}
+
+	var locations []string
+	for _, todo := range result.TODOs {
+		locations = append(locations, todo.Location)
+	}
+	require.Equal(t, []string{
+		".circleci/config.yml",
+		".circleci/config.yml orbs.node",
+		".circleci/config.yml jobs.test.steps[3]",
+		".circleci/config.yml workflows.main.jobs[2]",
+		".circleci/config.yml workflows.main.jobs[3]",
+	}, locations)
+
+	t.Log("the generated config is valid")
+	{
+		out, err := importer.Marshal(result)
+		require.NoError(t, err)
+		require.Contains(t, string(out), "# TODO: translate the node/install-packages orb command")
+
+		var generated bitriseModels.BitriseDataModel
+		require.NoError(t, yamlv2.Unmarshal(out, &generated))
+		_, err = generated.Validate()
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,521 @@
+package circleci
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/importer"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// maxCommandDepth limits the nesting of reusable commands, to stop on recursive commands.
+const maxCommandDepth = 10
+
+// builtInEnvs are the CircleCI built-in env vars with an equivalent Bitrise env var.
+var builtInEnvs = map[string]string{
+	"CIRCLE_SHA1":              "GIT_CLONE_COMMIT_HASH",
+	"CIRCLE_BRANCH":            "BITRISE_GIT_BRANCH",
+	"CIRCLE_TAG":               "BITRISE_GIT_TAG",
+	"CIRCLE_BUILD_NUM":         "BITRISE_BUILD_NUMBER",
+	"CIRCLE_WORKING_DIRECTORY": "BITRISE_SOURCE_DIR",
+	"CIRCLE_PR_NUMBER":         "BITRISE_PULL_REQUEST",
+	"CIRCLE_NODE_INDEX":        "BITRISE_IO_PARALLEL_INDEX",
+	"CIRCLE_NODE_TOTAL":        "BITRISE_IO_PARALLEL_TOTAL",
+}
+
+var cacheTemplatePattern = regexp.MustCompile(`\{\{\s*(.*?)\s*\}\}`)
+var environmentTemplatePattern = regexp.MustCompile(`^\.Environment\.([A-Za-z_][A-Za-z0-9_]*)$`)
+
+// translateJob converts a job to a workflow. The job parameters become workflow envs, which the workflow
+// variants of the pipelines override.
+func (t *translator) translateJob(name string) bitriseModels.WorkflowModel {
+	location := configFilePath + " jobs." + name
+	workflowID := importer.WorkflowID(name)
+	workflow := bitriseModels.WorkflowModel{Summary: "Imported from " + configFilePath}
+	if workflowID != name {
+		workflow.Title = name
+	}
+
+	node := t.file.Jobs[name]
+	var params struct {
+		Parameters map[string]parameter `yaml:"parameters"`
+	}
+	if err := node.Decode(&params); err != nil {
+		todo := t.addTODO(workflowID, location, "failed to parse the job: %s", err)
+		workflow.Steps = append(workflow.Steps, importer.TODOStepListItem(name, todo, snippet(&node)))
+		return workflow
+	}
+
+	for _, paramName := range maputil.SortedKeys(params.Parameters) {
+		param := params.Parameters[paramName]
+		if param.Type == "steps" || param.Type == "executor" {
+			continue
+		}
+		workflow.Environments = append(workflow.Environments, envmanModels.EnvironmentItemModel{parameterEnvKey(paramName): param.Default.Value})
+	}
+
+	// the parameters are envs in the steps, everywhere else (like in the docker images) they are replaced with their default
+	var substituted yaml.Node
+	substituted.Kind = yaml.MappingNode
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		inSteps := key.Value == "steps"
+		value = substituteParameters(value, func(scope, paramName string) (*yaml.Node, bool) {
+			if scope == "pipeline" {
+				return t.pipelineValue(workflowID, location, paramName)
+			}
+			param, ok := params.Parameters[paramName]
+			switch {
+			case !ok:
+				t.addTODO(workflowID, location, "the %s parameter is not defined", paramName)
+				return nil, false
+			case param.Type == "steps" || param.Type == "executor":
+				return &param.Default, !param.Default.IsZero()
+			case inSteps:
+				return scalarNode("${" + parameterEnvKey(paramName) + "}"), true
+			case param.Default.IsZero():
+				t.addTODO(workflowID, location+"."+key.Value, "the %s parameter has no default value", paramName)
+				return nil, false
+			}
+			return &param.Default, true
+		})
+		substituted.Content = append(substituted.Content, key, value)
+	}
+
+	var j job
+	if err := substituted.Decode(&j); err != nil {
+		todo := t.addTODO(workflowID, location, "failed to parse the job: %s", err)
+		workflow.Steps = append(workflow.Steps, importer.TODOStepListItem(name, todo, snippet(&node)))
+		return workflow
+	}
+
+	env := t.resolveExecutor(location, workflowID, &j)
+	for _, key := range maputil.SortedKeys(env) {
+		workflow.Environments = append(workflow.Environments, envmanModels.EnvironmentItemModel{key: env[key]})
+	}
+
+	if !j.Macos.IsZero() {
+		var macos struct {
+			Xcode string `yaml:"xcode"`
+		}
+		_ = j.Macos.Decode(&macos)
+		t.addTODO(workflowID, location+".macos", "the job runs on macOS with Xcode %s, select a matching stack for the workflow", macos.Xcode)
+	}
+	if j.Machine.Kind == yaml.MappingNode {
+		var machine struct {
+			Image string `yaml:"image"`
+		}
+		if err := j.Machine.Decode(&machine); err == nil && machine.Image != "" {
+			t.addTODO(workflowID, location+".machine", "the job runs on the %s machine image, select a matching stack for the workflow", machine.Image)
+		}
+	}
+	if j.ResourceClass != "" {
+		t.addTODO(workflowID, location+".resource_class", "the job runs on the %s resource class, select a matching machine type for the workflow", j.ResourceClass)
+	}
+
+	stepList := t.translateSteps(location+".steps", workflowID, j.Steps, j.Shell, 0)
+	if containerID, serviceIDs := t.translateDocker(location+".docker", workflowID, j.Docker); containerID != "" {
+		stepList = groupScriptSteps(containerID, serviceIDs, stepList)
+		t.checkHomeCachePaths(location, workflowID, stepList)
+	}
+	workflow.Steps = stepList
+
+	return workflow
+}
+
+// resolveExecutor sets the executor the job refers to, unless the job sets its own environment, and returns the
+// envs of the job merged with the envs of the executor.
+func (t *translator) resolveExecutor(location, workflowID string, j *job) environment {
+	env := environment{}
+	if j.Executor.Name == "" {
+		for key, value := range j.Environment {
+			env[key] = value
+		}
+		return env
+	}
+
+	executorNode, ok := t.file.Executors[j.Executor.Name]
+	if !ok {
+		if strings.Contains(j.Executor.Name, "/") {
+			t.addTODO(workflowID, location+".executor", "translate the %s orb executor, select a matching stack or container", j.Executor.Name)
+		} else {
+			t.addTODO(workflowID, location+".executor", "the %s executor is not found", j.Executor.Name)
+		}
+		return j.Environment
+	}
+
+	var params struct {
+		Parameters map[string]parameter `yaml:"parameters"`
+	}
+	_ = executorNode.Decode(&params)
+	resolved := substituteParameters(&executorNode, func(scope, paramName string) (*yaml.Node, bool) {
+		if scope == "pipeline" {
+			return t.pipelineValue(workflowID, location, paramName)
+		}
+		if arg, ok := j.Executor.Args[paramName]; ok {
+			return arg, true
+		}
+		param := params.Parameters[paramName]
+		return &param.Default, !param.Default.IsZero()
+	})
+
+	var e executor
+	if err := resolved.Decode(&e); err != nil {
+		t.addTODO(workflowID, location+".executor", "failed to parse the %s executor: %s", j.Executor.Name, err)
+		return j.Environment
+	}
+
+	if j.executor.isZero() {
+		j.Docker, j.Macos, j.Machine = e.Docker, e.Macos, e.Machine
+	}
+	if j.ResourceClass == "" {
+		j.ResourceClass = e.ResourceClass
+	}
+	if j.Shell == "" {
+		j.Shell = e.Shell
+	}
+	for key, value := range e.Environment {
+		env[key] = value
+	}
+	for key, value := range j.Environment {
+		env[key] = value
+	}
+	return env
+}
+
+// translateDocker adds the primary docker image as a container and the secondary images as services.
+func (t *translator) translateDocker(location, workflowID string, images []dockerImage) (string, []string) {
+	var containerID string
+	var serviceIDs []string
+	for i, image := range images {
+		imageLocation := fmt.Sprintf("%s[%d]", location, i)
+		if !image.Command.IsZero() || !image.Entrypoint.IsZero() || image.User != "" {
+			t.addTODO(workflowID, imageLocation, "the command, entrypoint and user of the image are not translated")
+		}
+		if !image.AWSAuth.IsZero() {
+			t.addTODO(workflowID, imageLocation+".aws_auth", "the AWS ECR authentication is not translated")
+		}
+
+		container := bitriseModels.Container{Image: image.Image, Envs: envItems(image.Environment)}
+		if image.Auth != nil {
+			container.Credentials = bitriseModels.DockerCredentials{Username: image.Auth.Username, Password: image.Auth.Password}
+		}
+
+		if i == 0 {
+			containerID = importer.AddContainer(t.config.Containers, importer.ContainerID(image.Image), container)
+			continue
+		}
+
+		hostName := image.Name
+		if hostName == "" {
+			hostName = importer.ContainerID(importer.ImageName(image.Image))
+		}
+		serviceID := importer.AddContainer(t.config.Services, importer.WorkflowID(hostName), container)
+		if serviceID != image.Name {
+			t.addTODO(workflowID, imageLocation, "the %s service is not reachable on localhost, it is reachable on the %s host name", image.Image, serviceID)
+		}
+		serviceIDs = append(serviceIDs, serviceID)
+	}
+	return containerID, serviceIDs
+}
+
+// groupScriptSteps moves the consecutive script steps into with groups running them in the container.
+// The other steps run on the host, like every step of CircleCI runs in the primary container.
+func groupScriptSteps(containerID string, serviceIDs []string, stepList []bitriseModels.StepListItemModel) []bitriseModels.StepListItemModel {
+	var grouped, scripts []bitriseModels.StepListItemModel
+	flush := func() {
+		if len(scripts) > 0 {
+			grouped = append(grouped, importer.WithGroupStepListItem(containerID, serviceIDs, scripts))
+			scripts = nil
+		}
+	}
+	for _, item := range stepList {
+		if steps.ID(item) == initSteps.ScriptID {
+			scripts = append(scripts, item)
+			continue
+		}
+		flush()
+		grouped = append(grouped, item)
+	}
+	flush()
+	return grouped
+}
+
+// checkHomeCachePaths reports the cached paths in the home directory, the cache steps run on the host
+// and they can not reach the home directory of the container.
+func (t *translator) checkHomeCachePaths(location, workflowID string, stepList []bitriseModels.StepListItemModel) {
+	for _, item := range stepList {
+		if steps.ID(item) != steps.SaveCacheID {
+			continue
+		}
+		step, err := item.GetStep()
+		if err != nil {
+			continue
+		}
+		for _, input := range step.Inputs {
+			key, value, err := input.GetKeyValuePair()
+			if err == nil && key == "paths" && strings.Contains(fmt.Sprint(value), "~") {
+				t.addTODO(workflowID, location, "the cache steps run on the host, move the cached paths from the home directory of the container into the source directory")
+				return
+			}
+		}
+	}
+}
+
+func (t *translator) translateSteps(location, workflowID string, nodes []yaml.Node, shell string, depth int) []bitriseModels.StepListItemModel {
+	var stepList []bitriseModels.StepListItemModel
+	for i := range nodes {
+		stepLocation := fmt.Sprintf("%s[%d]", location, i)
+		node := &nodes[i]
+		for node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+
+		// the undefined anchors are reported already
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			continue
+		}
+
+		// a steps parameter
+		if node.Kind == yaml.SequenceNode {
+			var paramSteps []yaml.Node
+			for _, item := range node.Content {
+				paramSteps = append(paramSteps, *item)
+			}
+			stepList = append(stepList, t.translateSteps(stepLocation, workflowID, paramSteps, shell, depth)...)
+			continue
+		}
+
+		name, args := node.Value, &yaml.Node{}
+		if node.Kind == yaml.MappingNode && len(node.Content) == 2 {
+			name, args = node.Content[0].Value, node.Content[1]
+		}
+
+		switch name {
+		case "checkout":
+			stepList = append(stepList, initSteps.GitCloneStepListItem())
+		case "run":
+			stepList = append(stepList, t.translateRunStep(stepLocation, workflowID, args, shell))
+		case "restore_cache", "save_cache":
+			stepList = append(stepList, t.translateCacheStep(stepLocation, workflowID, name, args)...)
+		case "store_artifacts":
+			stepList = append(stepList, t.translateArtifactsStep(stepLocation, workflowID, args)...)
+		case "setup_remote_docker":
+			t.addTODO(workflowID, stepLocation, "the remote Docker environment is not needed, Docker is available on the Linux stacks")
+		case "store_test_results":
+			todo := t.addTODO(workflowID, stepLocation, "move the test results to $BITRISE_TEST_RESULT_DIR to see them on the Tests tab")
+			stepList = append(stepList, importer.TODOStepListItem(name, todo, snippet(node)))
+		case "persist_to_workspace", "attach_workspace":
+			todo := t.addTODO(workflowID, stepLocation, "share the files between the workflows as pipeline intermediate files")
+			stepList = append(stepList, importer.TODOStepListItem(name, todo, snippet(node)))
+		default:
+			stepList = append(stepList, t.translateCommand(stepLocation, workflowID, name, args, node, shell, depth)...)
+		}
+	}
+	return stepList
+}
+
+// translateCommand inlines the steps of a reusable command, with its parameters replaced by the arguments.
+func (t *translator) translateCommand(location, workflowID, name string, args, node *yaml.Node, shell string, depth int) []bitriseModels.StepListItemModel {
+	commandNode, ok := t.file.Commands[name]
+	var todo importer.TODO
+	switch {
+	case ok && depth >= maxCommandDepth:
+		todo = t.addTODO(workflowID, location, "the %s command is nested too deep", name)
+	case ok:
+		var c command
+		if err := commandNode.Decode(&c); err != nil {
+			todo = t.addTODO(workflowID, location, "failed to parse the %s command: %s", name, err)
+			break
+		}
+
+		argNodes := mappingNodes(args)
+		var commandSteps []yaml.Node
+		for i := range c.Steps {
+			commandSteps = append(commandSteps, *substituteParameters(&c.Steps[i], func(scope, paramName string) (*yaml.Node, bool) {
+				if scope == "pipeline" {
+					return t.pipelineValue(workflowID, location, paramName)
+				}
+				if arg, ok := argNodes[paramName]; ok {
+					return arg, true
+				}
+				param := c.Parameters[paramName]
+				return &param.Default, !param.Default.IsZero()
+			}))
+		}
+		return t.translateSteps(location+" "+name, workflowID, commandSteps, shell, depth+1)
+	case strings.Contains(name, "/"):
+		todo = t.addTODO(workflowID, location, "translate the %s orb command", name)
+	case name == "when" || name == "unless":
+		todo = t.addTODO(workflowID, location, "the conditional steps are not translated")
+	default:
+		todo = t.addTODO(workflowID, location, "translate the %s step", name)
+	}
+	return []bitriseModels.StepListItemModel{importer.TODOStepListItem(name, todo, snippet(node))}
+}
+
+func (t *translator) translateRunStep(location, workflowID string, args *yaml.Node, jobShell string) bitriseModels.StepListItemModel {
+	var run runStep
+	if err := args.Decode(&run); err != nil {
+		todo := t.addTODO(workflowID, location, "failed to parse the run step: %s", err)
+		return importer.TODOStepListItem("run", todo, snippet(args))
+	}
+
+	shell := run.Shell
+	if shell == "" {
+		shell = jobShell
+	}
+	shebang := "#!/usr/bin/env bash\nset -eo pipefail\n"
+	switch shell {
+	case "", "bash", "/bin/bash", "/bin/bash -eo pipefail", "/usr/bin/env bash":
+	case "sh", "/bin/sh", "/bin/sh -eo pipefail", "/bin/sh -e":
+		shebang = "#!/usr/bin/env sh\nset -e\n"
+	default:
+		todo := t.addTODO(workflowID, location+".shell", "translate the %s script", shell)
+		return importer.TODOStepListItem(run.title(), todo, run.Command)
+	}
+	if run.Background {
+		t.addTODO(workflowID, location+".background", "the command runs in the foreground, start it in the background")
+	}
+
+	content := ""
+	for _, key := range maputil.SortedKeys(run.Environment) {
+		content += fmt.Sprintf("export %s=%q\n", key, run.Environment[key])
+	}
+	if run.WorkingDirectory != "" && run.WorkingDirectory != "." {
+		content += fmt.Sprintf("cd %q\n", run.WorkingDirectory)
+	}
+	if content != "" {
+		content += "\n"
+	}
+	content += run.Command
+
+	content, untranslated := importer.TranslateEnvReferences(content, "CIRCLE_", builtInEnvs)
+	var todoComments string
+	for _, key := range untranslated {
+		todo := t.addTODO(workflowID, location, "translate the $%s built-in env var", key)
+		todoComments += "# " + todo.String() + "\n"
+	}
+	if todoComments != "" {
+		todoComments += "\n"
+	}
+
+	item := initSteps.ScriptStepListItem(run.title(), shebang+todoComments+content)
+	t.applyWhen(location+".when", workflowID, item, run.When)
+	return item
+}
+
+func (t *translator) translateCacheStep(location, workflowID, name string, args *yaml.Node) []bitriseModels.StepListItemModel {
+	var c cacheStep
+	if err := args.Decode(&c); err != nil {
+		todo := t.addTODO(workflowID, location, "failed to parse the %s step: %s", name, err)
+		return []bitriseModels.StepListItemModel{importer.TODOStepListItem(name, todo, snippet(args))}
+	}
+
+	keys := c.Keys
+	if c.Key != "" {
+		keys = append([]string{c.Key}, keys...)
+	}
+	var translatedKeys []string
+	for _, key := range keys {
+		translatedKeys = append(translatedKeys, t.translateCacheKey(location, workflowID, key))
+	}
+
+	if name == "restore_cache" {
+		title := c.Name
+		if title == "" {
+			title = "Restore cache"
+		}
+		return []bitriseModels.StepListItemModel{steps.RestoreCacheStepListItem(title, strings.Join(translatedKeys, "\n"))}
+	}
+
+	title := c.Name
+	if title == "" {
+		title = "Save cache"
+	}
+	key := ""
+	if len(translatedKeys) > 0 {
+		key = translatedKeys[0]
+	}
+	item := steps.SaveCacheStepListItem(title, key, strings.Join(c.Paths, "\n"))
+	t.applyWhen(location+".when", workflowID, item, c.When)
+	return []bitriseModels.StepListItemModel{item}
+}
+
+// translateCacheKey converts a CircleCI cache key template to a key-based cache key template.
+func (t *translator) translateCacheKey(location, workflowID, key string) string {
+	// the job parameters are envs in the steps
+	key = importer.ReplaceEnvReferences(key, func(reference, name string) string {
+		return `{{ getenv "` + name + `" }}`
+	})
+
+	return cacheTemplatePattern.ReplaceAllStringFunc(key, func(template string) string {
+		expression := cacheTemplatePattern.FindStringSubmatch(template)[1]
+		switch {
+		case strings.HasPrefix(expression, "checksum "):
+			return "{{ " + expression + " }}"
+		case expression == ".Branch":
+			return "{{ .Branch }}"
+		case expression == ".Revision":
+			return "{{ .CommitHash }}"
+		case expression == "arch":
+			return "{{ .Arch }}"
+		case strings.HasPrefix(expression, "getenv "):
+			return template
+		case environmentTemplatePattern.MatchString(expression):
+			return `{{ getenv "` + environmentTemplatePattern.FindStringSubmatch(expression)[1] + `" }}`
+		}
+		t.addTODO(workflowID, location, "translate the %s template in the cache key", template)
+		return template
+	})
+}
+
+func (t *translator) translateArtifactsStep(location, workflowID string, args *yaml.Node) []bitriseModels.StepListItemModel {
+	var a artifactsStep
+	if err := args.Decode(&a); err != nil {
+		todo := t.addTODO(workflowID, location, "failed to parse the store_artifacts step: %s", err)
+		return []bitriseModels.StepListItemModel{importer.TODOStepListItem("store_artifacts", todo, snippet(args))}
+	}
+
+	var inputs []envmanModels.EnvironmentItemModel
+	if a.Path != "" {
+		inputs = append(inputs, envmanModels.EnvironmentItemModel{"deploy_path": a.Path})
+	}
+	return []bitriseModels.StepListItemModel{initSteps.DeployToBitriseIoStepListItem(inputs...)}
+}
+
+// applyWhen sets the step to run in the given build status: on_success (default), on_fail or always.
+func (t *translator) applyWhen(location, workflowID string, item bitriseModels.StepListItemModel, when string) {
+	switch when {
+	case "", "on_success":
+	case "always":
+		importer.UpdateStep(item, func(step *stepmanModels.StepModel) {
+			step.IsAlwaysRun = pointers.NewBoolPtr(true)
+		})
+	case "on_fail":
+		importer.UpdateStep(item, func(step *stepmanModels.StepModel) {
+			step.IsAlwaysRun = pointers.NewBoolPtr(true)
+			step.RunIf = pointers.NewStringPtr("{{.IsBuildFailed}}")
+		})
+	default:
+		t.addTODO(workflowID, location, "the %s condition is not translated", when)
+	}
+}
+
+// pipelineValue returns the env var reference standing for a pipeline value.
+func (t *translator) pipelineValue(workflowID, location, name string) (*yaml.Node, bool) {
+	if key, ok := pipelineValues[name]; ok {
+		return scalarNode("$" + key), true
+	}
+	t.addTODO(workflowID, location, "translate the << pipeline.%s >> value", name)
+	return nil, false
+}
@@ -0,0 +1,261 @@
+package circleci
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type configFile struct {
+	Orbs      map[string]yaml.Node `yaml:"orbs"`
+	Executors map[string]yaml.Node `yaml:"executors"`
+	Commands  map[string]yaml.Node `yaml:"commands"`
+	Jobs      map[string]yaml.Node `yaml:"jobs"`
+	Workflows map[string]yaml.Node `yaml:"workflows"`
+}
+
+// executor is the environment a job runs in, it is set either in the job or in a reusable executor.
+type executor struct {
+	Docker        []dockerImage `yaml:"docker"`
+	Macos         yaml.Node     `yaml:"macos"`
+	Machine       yaml.Node     `yaml:"machine"`
+	ResourceClass string        `yaml:"resource_class"`
+	Environment   environment   `yaml:"environment"`
+	Shell         string        `yaml:"shell"`
+}
+
+func (e executor) isZero() bool {
+	return len(e.Docker) == 0 && e.Macos.IsZero() && e.Machine.IsZero()
+}
+
+type job struct {
+	executor    `yaml:",inline"`
+	Executor    reference            `yaml:"executor"`
+	Parameters  map[string]parameter `yaml:"parameters"`
+	Parallelism string               `yaml:"parallelism"`
+	Steps       []yaml.Node          `yaml:"steps"`
+}
+
+type dockerImage struct {
+	Image       string      `yaml:"image"`
+	Name        string      `yaml:"name"`
+	Auth        *dockerAuth `yaml:"auth"`
+	AWSAuth     yaml.Node   `yaml:"aws_auth"`
+	Environment environment `yaml:"environment"`
+	Command     yaml.Node   `yaml:"command"`
+	Entrypoint  yaml.Node   `yaml:"entrypoint"`
+	User        string      `yaml:"user"`
+}
+
+type dockerAuth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type parameter struct {
+	Type    string    `yaml:"type"`
+	Default yaml.Node `yaml:"default"`
+}
+
+type command struct {
+	Parameters map[string]parameter `yaml:"parameters"`
+	Steps      []yaml.Node          `yaml:"steps"`
+}
+
+// reference refers to a reusable executor, command or job by its name, with the arguments of its parameters.
+type reference struct {
+	Name string
+	Args map[string]*yaml.Node
+}
+
+func (r *reference) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		r.Name = value.Value
+		return nil
+	}
+
+	args := mappingNodes(value)
+	if name, ok := args["name"]; ok {
+		r.Name = name.Value
+		delete(args, "name")
+	}
+	r.Args = args
+	return nil
+}
+
+// environment is a map of env vars, set either as a map or as a list of single key maps.
+type environment map[string]string
+
+func (e *environment) UnmarshalYAML(value *yaml.Node) error {
+	*e = environment{}
+	if value.Kind == yaml.SequenceNode {
+		for _, item := range value.Content {
+			var env map[string]string
+			if err := item.Decode(&env); err != nil {
+				return err
+			}
+			for key, envValue := range env {
+				(*e)[key] = envValue
+			}
+		}
+		return nil
+	}
+
+	var env map[string]string
+	if err := value.Decode(&env); err != nil {
+		return err
+	}
+	for key, envValue := range env {
+		(*e)[key] = envValue
+	}
+	return nil
+}
+
+type runStep struct {
+	Name             string      `yaml:"name"`
+	Command          string      `yaml:"command"`
+	WorkingDirectory string      `yaml:"working_directory"`
+	Environment      environment `yaml:"environment"`
+	When             string      `yaml:"when"`
+	Shell            string      `yaml:"shell"`
+	Background       bool        `yaml:"background"`
+}
+
+func (s *runStep) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		s.Command = value.Value
+		return nil
+	}
+	type plain runStep
+	return value.Decode((*plain)(s))
+}
+
+func (s runStep) title() string {
+	if s.Name != "" {
+		return s.Name
+	}
+
+	title := strings.TrimSpace(strings.SplitN(strings.TrimSpace(s.Command), "\n", 2)[0])
+	if len(title) > 50 {
+		title = title[:47] + "..."
+	}
+	return title
+}
+
+type cacheStep struct {
+	Name  string   `yaml:"name"`
+	Key   string   `yaml:"key"`
+	Keys  []string `yaml:"keys"`
+	Paths []string `yaml:"paths"`
+	When  string   `yaml:"when"`
+}
+
+type artifactsStep struct {
+	Name        string `yaml:"name"`
+	Path        string `yaml:"path"`
+	Destination string `yaml:"destination"`
+}
+
+// workflow is a CircleCI workflow, its jobs are either job names or single key maps of the job name to its options.
+type workflow struct {
+	Jobs     []yaml.Node `yaml:"jobs"`
+	Triggers yaml.Node   `yaml:"triggers"`
+	When     yaml.Node   `yaml:"when"`
+	Unless   yaml.Node   `yaml:"unless"`
+}
+
+// workflowJob is a job of a workflow, the options which are not listed here are the arguments of the job parameters.
+type workflowJob struct {
+	Job      string
+	Name     string
+	Requires []string
+	Type     string
+	Args     map[string]*yaml.Node
+	Options  map[string]*yaml.Node
+}
+
+// workflowJobOptions are the workflow job keys which are not job parameter arguments.
+var workflowJobOptions = map[string]bool{
+	"requires":     true,
+	"name":         true,
+	"type":         true,
+	"filters":      true,
+	"context":      true,
+	"matrix":       true,
+	"pre-steps":    true,
+	"post-steps":   true,
+	"serial-group": true,
+}
+
+func (j *workflowJob) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		j.Job = value.Value
+		return nil
+	}
+
+	if value.Kind != yaml.MappingNode || len(value.Content) != 2 || value.Content[1].Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: invalid workflow job", value.Line)
+	}
+	j.Job = value.Content[0].Value
+	j.Args = map[string]*yaml.Node{}
+	j.Options = map[string]*yaml.Node{}
+	options := value.Content[1]
+	for i := 0; i+1 < len(options.Content); i += 2 {
+		key, option := options.Content[i].Value, options.Content[i+1]
+		if workflowJobOptions[key] {
+			j.Options[key] = option
+		} else {
+			j.Args[key] = option
+		}
+	}
+
+	if name := j.Options["name"]; name != nil {
+		j.Name = name.Value
+	}
+	if jobType := j.Options["type"]; jobType != nil {
+		j.Type = jobType.Value
+	}
+	if requires := j.Options["requires"]; requires != nil {
+		for _, item := range requires.Content {
+			if item.Kind == yaml.MappingNode {
+				// the required job with the status it should finish with
+				for i := 0; i < len(item.Content); i += 2 {
+					j.Requires = append(j.Requires, item.Content[i].Value)
+				}
+				continue
+			}
+			j.Requires = append(j.Requires, item.Value)
+		}
+	}
+	return nil
+}
+
+// reference is the name other jobs of the workflow require the job by.
+func (j workflowJob) reference() string {
+	if j.Name != "" {
+		return j.Name
+	}
+	return j.Job
+}
+
+// mappingNodes returns the value nodes of the mapping node by key.
+func mappingNodes(node *yaml.Node) map[string]*yaml.Node {
+	values := map[string]*yaml.Node{}
+	if node.Kind != yaml.MappingNode {
+		return values
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		values[node.Content[i].Value] = node.Content[i+1]
+	}
+	return values
+}
+
+// snippet returns the YAML source of the node, it is kept in the TODO steps.
+func snippet(node *yaml.Node) string {
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
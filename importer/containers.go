@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+)
+
+var imageTagPattern = regexp.MustCompile(`(@sha256)?:[^/:]+$`)
+
+// ContainerID converts an image reference to a container or service ID, like cimg/node:20.1 to cimg_node_20.1.
+func ContainerID(image string) string {
+	return WorkflowID(strings.TrimPrefix(strings.TrimPrefix(image, "docker.io/"), "library/"))
+}
+
+// ImageName returns the image reference without its tag or digest, like cimg/node for cimg/node:20.1.
+func ImageName(image string) string {
+	return imageTagPattern.ReplaceAllString(image, "")
+}
+
+// AddContainer adds the container definition under the given ID and returns the ID. An identical definition
+// already added under the ID is reused, a different one is added with a numbered ID.
+func AddContainer(containers map[string]bitriseModels.Container, id string, container bitriseModels.Container) string {
+	candidate := id
+	for i := 2; ; i++ {
+		existing, ok := containers[candidate]
+		if !ok {
+			containers[candidate] = container
+			return candidate
+		}
+		if reflect.DeepEqual(existing, container) {
+			return candidate
+		}
+		candidate = id + "_" + strconv.Itoa(i)
+	}
+}
+
+// WithGroupStepListItem returns a with group running the steps in the given container, next to the given services.
+// The with group can only hold steps, other step list items are dropped.
+func WithGroupStepListItem(containerID string, serviceIDs []string, stepList []bitriseModels.StepListItemModel) bitriseModels.StepListItemModel {
+	with := bitriseModels.WithModel{ContainerID: containerID, ServiceIDs: serviceIDs}
+	for _, item := range stepList {
+		key, itemType, err := item.GetKeyAndType()
+		if err != nil || itemType != bitriseModels.StepListItemTypeStep {
+			continue
+		}
+		step, err := item.GetStep()
+		if err != nil {
+			continue
+		}
+		with.Steps = append(with.Steps, bitriseModels.StepListStepItemModel{key: *step})
+	}
+	return bitriseModels.StepListItemModel{bitriseModels.StepListItemWithKey: with}
+}
@@ -0,0 +1,44 @@
+package importer
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var envReferencePattern = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// ReplaceEnvReferences replaces the $KEY and ${KEY} env var references with the value returned by replace.
+// Replace gets the reference as it is written and the key of the env var, it returns the reference to keep it.
+func ReplaceEnvReferences(s string, replace func(reference, key string) string) string {
+	return envReferencePattern.ReplaceAllStringFunc(s, func(reference string) string {
+		match := envReferencePattern.FindStringSubmatch(reference)
+		return replace(reference, match[1]+match[2])
+	})
+}
+
+// TranslateEnvReferences replaces the references of the source's predefined env vars (the ones starting with the prefix)
+// with their Bitrise equivalent, like $CI_COMMIT_SHA with $GIT_CLONE_COMMIT_HASH. The predefined env vars without
+// an equivalent are left as is and returned.
+func TranslateEnvReferences(s, prefix string, equivalents map[string]string) (string, []string) {
+	var untranslated []string
+	translated := ReplaceEnvReferences(s, func(reference, key string) string {
+		if !strings.HasPrefix(key, prefix) {
+			return reference
+		}
+
+		equivalent, ok := equivalents[key]
+		if !ok {
+			if !slices.Contains(untranslated, key) {
+				untranslated = append(untranslated, key)
+			}
+			return reference
+		}
+
+		if strings.HasPrefix(reference, "${") {
+			return "${" + equivalent + "}"
+		}
+		return "$" + equivalent
+	})
+	return translated, untranslated
+}
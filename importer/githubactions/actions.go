@@ -0,0 +1,256 @@
+package githubactions
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/importer"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/toolversions"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+type actionTranslator func(t *translator, location, workflowID string, step step) translatedStep
+
+// actionTranslators map the well-known actions to Bitrise steps.
+var actionTranslators = map[string]actionTranslator{
+	"actions/checkout":        translateCheckout,
+	"actions/cache":           translateCache,
+	"actions/cache/restore":   translateCache,
+	"actions/cache/save":      translateCache,
+	"actions/setup-node":      translateSetupNode,
+	"actions/setup-java":      translateSetupJava,
+	"actions/upload-artifact": translateUploadArtifact,
+	"subosito/flutter-action": translateFlutterAction,
+}
+
+// packageManagerCaches are the values of the setup actions' cache input, with the matching dependency manager.
+var packageManagerCaches = map[string]string{
+	"npm":    "npm",
+	"yarn":   "npm",
+	"pnpm":   "pnpm",
+	"gradle": "Gradle",
+}
+
+var (
+	hashFilesPattern = regexp.MustCompile(`^hashFiles\((.*)\)$`)
+	majorPattern     = regexp.MustCompile(`^(\d+)(?:\.(\d+))?`)
+)
+
+func translateCheckout(t *translator, location, workflowID string, step step) translatedStep {
+	var inputs []envmanModels.EnvironmentItemModel
+	for _, key := range maputil.SortedKeys(step.With) {
+		value := step.With[key]
+		switch key {
+		case "fetch-depth":
+			// 0 fetches the whole history
+			if depth, err := strconv.Atoi(value); err == nil && depth > 0 {
+				inputs = append(inputs, envmanModels.EnvironmentItemModel{"clone_depth": value})
+			}
+		case "submodules":
+			if value == "true" || value == "recursive" {
+				inputs = append(inputs, envmanModels.EnvironmentItemModel{"update_submodules": "yes"})
+			}
+		case "fetch-tags":
+			if value == "true" {
+				inputs = append(inputs, envmanModels.EnvironmentItemModel{"fetch_tags": "yes"})
+			}
+		default:
+			t.addTODO(workflowID, location+".with."+key, "the %s input of actions/checkout is not translated", key)
+		}
+	}
+
+	gitClone := initSteps.GitCloneStepListItem()
+	if len(inputs) > 0 {
+		importer.UpdateStep(gitClone, func(step *stepmanModels.StepModel) {
+			step.Inputs = inputs
+		})
+	}
+	return translatedStep{steps: []bitriseModels.StepListItemModel{gitClone}}
+}
+
+func translateCache(t *translator, location, workflowID string, step step) translatedStep {
+	action := strings.SplitN(step.Uses, "@", 2)[0]
+	unsupportedInputs(t, location, workflowID, action, step.With, "key", "path", "restore-keys")
+
+	key := translateCacheKey(t, location+".with.key", workflowID, step.With["key"])
+	paths := strings.TrimSpace(step.With["path"])
+
+	restoreKey := key
+	for _, restoreKeyLine := range strings.Split(step.With["restore-keys"], "\n") {
+		if restoreKeyLine = strings.TrimSpace(restoreKeyLine); restoreKeyLine != "" {
+			restoreKey += "\n" + translateCacheKey(t, location+".with.restore-keys", workflowID, restoreKeyLine)
+		}
+	}
+
+	title := step.Name
+	var translated translatedStep
+	if action != "actions/cache/save" {
+		if title == "" {
+			title = "Restore cache"
+		}
+		translated.steps = append(translated.steps, steps.RestoreCacheStepListItem(title, restoreKey))
+	}
+	if action != "actions/cache/restore" {
+		saveTitle := "Save cache"
+		if step.Name != "" {
+			saveTitle = "Save " + step.Name
+		}
+		saveStep := steps.SaveCacheStepListItem(saveTitle, key, paths)
+		if action == "actions/cache/save" {
+			translated.steps = append(translated.steps, saveStep)
+		} else {
+			// actions/cache saves the cache in its post step, at the end of the job
+			translated.saveSteps = append(translated.saveSteps, saveStep)
+		}
+	}
+	return translated
+}
+
+// translateCacheKey converts a cache key with GitHub expressions to a key-based cache key template.
+func translateCacheKey(t *translator, location, workflowID, key string) string {
+	translated := ""
+	last := 0
+	for _, match := range expressionPattern.FindAllStringSubmatchIndex(key, -1) {
+		translated += key[last:match[0]]
+		last = match[1]
+
+		expression := key[match[2]:match[3]]
+		switch {
+		case expression == "runner.os":
+			translated += "{{ .OS }}"
+		case expression == "runner.arch":
+			translated += "{{ .Arch }}"
+		case expression == "github.sha":
+			translated += "{{ .CommitHash }}"
+		case expression == "github.ref_name" || expression == "github.head_ref":
+			translated += "{{ .Branch }}"
+		case hashFilesPattern.MatchString(expression):
+			var patterns []string
+			for _, arg := range strings.Split(hashFilesPattern.FindStringSubmatch(expression)[1], ",") {
+				patterns = append(patterns, `"`+strings.Trim(strings.TrimSpace(arg), `'"`)+`"`)
+			}
+			translated += "{{ checksum " + strings.Join(patterns, " ") + " }}"
+		case contextEnvPattern.MatchString(expression):
+			translated += `{{ getenv "` + contextEnvPattern.FindStringSubmatch(expression)[1] + `" }}`
+		default:
+			t.addTODO(workflowID, location, "translate the %s expression in the cache key", key[match[0]:match[1]])
+			translated += key[match[0]:match[1]]
+		}
+	}
+	return translated + key[last:]
+}
+
+func translateSetupNode(t *translator, location, workflowID string, step step) translatedStep {
+	unsupportedInputs(t, location, workflowID, "actions/setup-node", step.With, "node-version", "node-version-file", "cache", "cache-dependency-path")
+
+	var translated translatedStep
+	version := strings.TrimPrefix(strings.TrimSuffix(step.With["node-version"], ".x"), "v")
+	switch {
+	case version != "" && majorPattern.MatchString(version):
+		translated.tools = append(translated.tools, toolversions.Tool{Name: toolversions.NodeJSToolName, Version: version, Source: "actions/setup-node"})
+	case step.With["node-version-file"] != "":
+		translated.tools = append(translated.tools, t.detectedTool(location, workflowID, toolversions.NodeJSToolName, step.With["node-version-file"])...)
+	case version != "":
+		t.addTODO(workflowID, location+".with.node-version", "Node.js version alias (%s) is not translated", step.With["node-version"])
+	}
+
+	translated.addPackageManagerCache(t, location, workflowID, step.With["cache"])
+	return translated
+}
+
+func translateSetupJava(t *translator, location, workflowID string, step step) translatedStep {
+	unsupportedInputs(t, location, workflowID, "actions/setup-java", step.With, "java-version", "java-version-file", "distribution", "cache")
+
+	var translated translatedStep
+	if match := majorPattern.FindStringSubmatch(step.With["java-version"]); match != nil {
+		version := match[1]
+		if version == "1" && match[2] != "" {
+			version = match[2]
+		}
+		translated.tools = append(translated.tools, toolversions.Tool{Name: toolversions.JavaToolName, Version: version, Source: "actions/setup-java"})
+	} else if step.With["java-version-file"] != "" {
+		translated.tools = append(translated.tools, t.detectedTool(location, workflowID, toolversions.JavaToolName, step.With["java-version-file"])...)
+	} else if step.With["java-version"] != "" {
+		t.addTODO(workflowID, location+".with.java-version", "Java version (%s) is not translated", step.With["java-version"])
+	}
+
+	translated.addPackageManagerCache(t, location, workflowID, step.With["cache"])
+	return translated
+}
+
+func translateFlutterAction(t *translator, location, workflowID string, step step) translatedStep {
+	unsupportedInputs(t, location, workflowID, "subosito/flutter-action", step.With, "flutter-version", "channel", "cache")
+
+	version := step.With["flutter-version"]
+	if version == "" {
+		version = step.With["channel"]
+	}
+	return translatedStep{steps: []bitriseModels.StepListItemModel{initSteps.FlutterInstallStepListItem(version, false)}}
+}
+
+func translateUploadArtifact(t *translator, location, workflowID string, step step) translatedStep {
+	unsupportedInputs(t, location, workflowID, "actions/upload-artifact", step.With, "name", "path", "retention-days", "if-no-files-found")
+
+	pth := strings.TrimSpace(step.With["path"])
+	if strings.Contains(pth, "\n") || strings.ContainsAny(pth, "*?!") {
+		t.addTODO(workflowID, location+".with.path", "multiple artifact paths are not translated, move the artifacts to $BITRISE_DEPLOY_DIR")
+		return translatedStep{steps: []bitriseModels.StepListItemModel{initSteps.DeployToBitriseIoStepListItem()}}
+	}
+
+	var inputs []envmanModels.EnvironmentItemModel
+	if pth != "" {
+		inputs = append(inputs, envmanModels.EnvironmentItemModel{"deploy_path": pth})
+	}
+	return translatedStep{steps: []bitriseModels.StepListItemModel{initSteps.DeployToBitriseIoStepListItem(inputs...)}}
+}
+
+// detectedTool returns the tool version read from the version file the setup action refers to.
+func (t *translator) detectedTool(location, workflowID, toolName, versionFile string) []toolversions.Tool {
+	for _, tool := range t.tools {
+		if tool.Name == toolName {
+			return []toolversions.Tool{tool}
+		}
+	}
+	t.addTODO(workflowID, location, "%s version file (%s) is not found", toolName, versionFile)
+	return nil
+}
+
+func (translated *translatedStep) addPackageManagerCache(t *translator, location, workflowID, cacheInput string) {
+	if cacheInput == "" {
+		return
+	}
+
+	managerName, ok := packageManagerCaches[cacheInput]
+	if !ok {
+		t.addTODO(workflowID, location+".with.cache", "%s dependency cache is not translated", cacheInput)
+		return
+	}
+
+	restoreStep, saveStep, ok := importer.CacheSteps(managerName, t.managers)
+	if !ok {
+		return
+	}
+	translated.steps = append(translated.steps, restoreStep)
+	translated.saveSteps = append(translated.saveSteps, saveStep)
+}
+
+func unsupportedInputs(t *translator, location, workflowID, action string, with map[string]string, supported ...string) {
+	for _, key := range maputil.SortedKeys(with) {
+		isSupported := false
+		for _, supportedKey := range supported {
+			if key == supportedKey {
+				isSupported = true
+				break
+			}
+		}
+		if !isSupported {
+			t.addTODO(workflowID, location+".with."+key, "the %s input of %s is not translated", key, action)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package githubactions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/cache"
+	"github.com/bitrise-io/bitrise-plugins-init/importer"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/toolversions"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// SourceName is the name of the GitHub Actions import source.
+const SourceName = "github-actions"
+
+const workflowsDir = ".github/workflows"
+
+// Source imports the GitHub Actions workflows of a repository.
+type Source struct{}
+
+// Name ...
+func (Source) Name() string {
+	return SourceName
+}
+
+// Detect ...
+func (Source) Detect(searchDir string) (bool, error) {
+	pths, err := workflowFilePaths(searchDir)
+	return len(pths) > 0, err
+}
+
+// Import translates the GitHub Actions workflow files: each job becomes a workflow and the jobs of a
+// workflow file with multiple jobs are grouped into a graph pipeline. The events of the workflow file
+// become the selective triggers of the pipeline (or the single workflow).
+func (Source) Import(searchDir string) (importer.Result, error) {
+	config, err := importer.NewConfig()
+	if err != nil {
+		return importer.Result{}, err
+	}
+
+	pths, err := workflowFilePaths(searchDir)
+	if err != nil {
+		return importer.Result{}, err
+	}
+
+	var files []workflowFile
+	jobCount := map[string]int{}
+	nameCount := map[string]int{}
+	for _, pth := range pths {
+		nameCount[workflowFileName(pth)]++
+		file, err := parseWorkflowFile(pth)
+		if err != nil {
+			return importer.Result{}, err
+		}
+		for jobID := range file.Jobs {
+			jobCount[importer.WorkflowID(jobID)]++
+		}
+		files = append(files, file)
+	}
+
+	tools, err := toolversions.Detect(searchDir)
+	if err != nil {
+		return importer.Result{}, err
+	}
+	managers, err := cache.Detect(searchDir)
+	if err != nil {
+		return importer.Result{}, err
+	}
+
+	t := translator{tools: tools, managers: managers}
+	for i, file := range files {
+		location, err := filepath.Rel(searchDir, pths[i])
+		if err != nil {
+			return importer.Result{}, err
+		}
+		fileID := importer.WorkflowID(workflowFileName(location))
+		if nameCount[workflowFileName(location)] > 1 {
+			// the extension tells apart the workflow files of the same name, like ci.yml and ci.yaml
+			fileID = importer.WorkflowID(filepath.Base(location))
+		}
+
+		// job IDs are unique within a workflow file only
+		workflowIDs := map[string]string{}
+		for jobID := range file.Jobs {
+			workflowID := importer.WorkflowID(jobID)
+			if jobCount[workflowID] > 1 {
+				workflowID = fileID + "_" + workflowID
+			}
+			workflowIDs[jobID] = workflowID
+		}
+
+		hasNeeds := false
+		for _, jobID := range maputil.SortedKeys(file.Jobs) {
+			job := file.Jobs[jobID]
+			if len(job.Needs) > 0 {
+				hasNeeds = true
+			}
+			config.Workflows[workflowIDs[jobID]] = t.translateJob(fmt.Sprintf("%s jobs.%s", location, jobID), workflowIDs[jobID], job, file.Env)
+		}
+
+		if len(file.Jobs) == 1 && !hasNeeds {
+			for _, workflowID := range workflowIDs {
+				workflow := config.Workflows[workflowID]
+				workflow.Triggers = t.translateTriggers(location, workflowID, file.On)
+				config.Workflows[workflowID] = workflow
+			}
+			continue
+		}
+
+		pipeline := bitriseModels.PipelineModel{
+			Title:     file.Name,
+			Triggers:  t.translateTriggers(location, "", file.On),
+			Workflows: bitriseModels.GraphPipelineWorkflowListItemModel{},
+		}
+		for _, jobID := range maputil.SortedKeys(file.Jobs) {
+			var dependsOn []string
+			for _, need := range file.Jobs[jobID].Needs {
+				dependency, ok := workflowIDs[need]
+				if !ok {
+					t.addTODO(workflowIDs[jobID], fmt.Sprintf("%s jobs.%s.needs", location, jobID), "the needed %s job is not found", need)
+					continue
+				}
+				dependsOn = append(dependsOn, dependency)
+			}
+			pipeline.Workflows[workflowIDs[jobID]] = bitriseModels.GraphPipelineWorkflowModel{DependsOn: dependsOn}
+		}
+		config.Pipelines[fileID] = pipeline
+	}
+
+	return importer.Result{Config: config, TODOs: t.todos}, nil
+}
+
+func workflowFilePaths(searchDir string) ([]string, error) {
+	var pths []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(searchDir, workflowsDir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		pths = append(pths, matches...)
+	}
+	return pths, nil
+}
+
+// workflowFileName returns the name of the workflow file without its extension.
+func workflowFileName(pth string) string {
+	return strings.TrimSuffix(filepath.Base(pth), filepath.Ext(pth))
+}
+
+func parseWorkflowFile(pth string) (workflowFile, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return workflowFile{}, err
+	}
+
+	var file workflowFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return workflowFile{}, fmt.Errorf("failed to parse %s: %s", pth, err)
+	}
+	return file, nil
+}
+
+type translator struct {
+	tools    []toolversions.Tool
+	managers []cache.DetectedManager
+	todos    []importer.TODO
+}
+
+func (t *translator) addTODO(workflowID, location, format string, args ...interface{}) importer.TODO {
+	todo := importer.TODO{WorkflowID: workflowID, Location: location, Message: fmt.Sprintf(format, args...)}
+	t.todos = append(t.todos, todo)
+	return todo
+}
+
+func (t *translator) translateJob(location, workflowID string, job job, fileEnv map[string]string) bitriseModels.WorkflowModel {
+	workflow := bitriseModels.WorkflowModel{
+		Title:   job.Name,
+		Summary: "Imported from " + strings.SplitN(location, " ", 2)[0],
+	}
+
+	for _, envs := range []map[string]string{fileEnv, job.Env} {
+		for _, key := range maputil.SortedKeys(envs) {
+			value, untranslated := translateExpressions(envs[key])
+			for _, expression := range untranslated {
+				t.addTODO(workflowID, location+".env."+key, "translate the %s expression", expression)
+			}
+			workflow.Environments = append(workflow.Environments, envmanModels.EnvironmentItemModel{key: value})
+		}
+	}
+
+	if runsOn := nodeString(job.RunsOn); runsOn != "" && !strings.HasPrefix(runsOn, "ubuntu") {
+		t.addTODO(workflowID, location+".runs-on", "the job runs on %s, select a matching stack and machine type for the workflow", runsOn)
+	}
+	if job.If != "" {
+		t.addTODO(workflowID, location+".if", "the job only runs if %s, add a run_if condition to the pipeline workflow", job.If)
+	}
+	if !job.Strategy.IsZero() {
+		t.addTODO(workflowID, location+".strategy", "matrix builds are not translated, duplicate the workflow or use parallel pipeline workflows")
+	}
+	if !job.Container.IsZero() || !job.Services.IsZero() {
+		t.addTODO(workflowID, location, "containers and services are not translated")
+	}
+	if job.Uses != "" {
+		todo := t.addTODO(workflowID, location+".uses", "translate the %s reusable workflow", job.Uses)
+		workflow.Steps = append(workflow.Steps, importer.TODOStepListItem(job.Uses, todo, ""))
+		return workflow
+	}
+
+	var stepList, saveSteps []bitriseModels.StepListItemModel
+	var tools []toolversions.Tool
+	setupIndex := -1
+	for i, step := range job.Steps {
+		stepLocation := fmt.Sprintf("%s.steps[%d]", location, i)
+
+		translated := t.translateStep(stepLocation, workflowID, step)
+		if len(translated.tools) > 0 && setupIndex == -1 {
+			setupIndex = len(stepList)
+		}
+		tools = append(tools, translated.tools...)
+		saveSteps = append(saveSteps, translated.saveSteps...)
+
+		t.applyConditions(stepLocation, workflowID, translated.steps, step)
+		stepList = append(stepList, translated.steps...)
+	}
+
+	if setupIndex != -1 {
+		stepList = steps.Insert(stepList, setupIndex, toolversions.SetupStepListItem(tools))
+	}
+	workflow.Steps = append(stepList, saveSteps...)
+
+	return workflow
+}
+
+func (t *translator) applyConditions(location, workflowID string, items []bitriseModels.StepListItemModel, step step) {
+	isSkippable := step.ContinueOnError
+	isAlwaysRun := false
+	runIf := ""
+
+	condition := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(step.If), "${{"), "}}"))
+	switch condition {
+	case "", "success()":
+	case "always()":
+		isAlwaysRun = true
+	case "failure()":
+		isAlwaysRun = true
+		runIf = "{{.IsBuildFailed}}"
+	default:
+		t.addTODO(workflowID, location+".if", "the step only runs if %s, add a run_if condition", condition)
+	}
+
+	for _, item := range items {
+		importer.UpdateStep(item, func(stepModel *stepmanModels.StepModel) {
+			if isSkippable {
+				stepModel.IsSkippable = pointers.NewBoolPtr(true)
+			}
+			if isAlwaysRun {
+				stepModel.IsAlwaysRun = pointers.NewBoolPtr(true)
+			}
+			if runIf != "" {
+				stepModel.RunIf = pointers.NewStringPtr(runIf)
+			}
+		})
+	}
+}
+
+type translatedStep struct {
+	steps     []bitriseModels.StepListItemModel
+	saveSteps []bitriseModels.StepListItemModel
+	tools     []toolversions.Tool
+}
+
+func (t *translator) translateStep(location, workflowID string, step step) translatedStep {
+	if step.Run != "" {
+		return translatedStep{steps: []bitriseModels.StepListItemModel{t.translateRunStep(location, workflowID, step)}}
+	}
+
+	action := strings.SplitN(step.Uses, "@", 2)[0]
+	translate, ok := actionTranslators[action]
+	if !ok {
+		todo := t.addTODO(workflowID, location, "translate the %s action", step.Uses)
+		return translatedStep{steps: []bitriseModels.StepListItemModel{importer.TODOStepListItem(step.title(), todo, step.snippet())}}
+	}
+
+	if len(step.Env) > 0 {
+		t.addTODO(workflowID, location+".env", "the envs of the %s action are not translated", action)
+	}
+	return translate(t, location, workflowID, step)
+}
+
+func (t *translator) translateRunStep(location, workflowID string, step step) bitriseModels.StepListItemModel {
+	shebang := "#!/usr/bin/env bash\nset -eo pipefail\n"
+	switch step.Shell {
+	case "", "bash":
+	case "sh":
+		shebang = "#!/usr/bin/env sh\nset -e\n"
+	default:
+		todo := t.addTODO(workflowID, location+".shell", "translate the %s script", step.Shell)
+		return importer.TODOStepListItem(step.title(), todo, step.Run)
+	}
+
+	content := shebang
+	for _, key := range maputil.SortedKeys(step.Env) {
+		content += fmt.Sprintf("export %s=%q\n", key, step.Env[key])
+	}
+	if step.WorkingDirectory != "" {
+		content += fmt.Sprintf("cd %q\n", step.WorkingDirectory)
+	}
+	if len(step.Env) > 0 || step.WorkingDirectory != "" {
+		content += "\n"
+	}
+	content += step.Run
+
+	content, untranslated := translateExpressions(content)
+	var todoComments string
+	for _, expression := range untranslated {
+		todo := t.addTODO(workflowID, location, "translate the %s expression", expression)
+		todoComments += "# " + todo.String() + "\n"
+	}
+	if todoComments != "" {
+		content = strings.Replace(content, shebang, shebang+todoComments, 1)
+	}
+
+	return initSteps.ScriptStepListItem(step.title(), content)
+}
+
+var expressionPattern = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
+var contextEnvPattern = regexp.MustCompile(`^(?:secrets|env|vars)\.([A-Za-z_][A-Za-z0-9_]*)$`)
+var identifierCharPattern = regexp.MustCompile(`^[A-Za-z0-9_]`)
+
+// contextEnvs are the GitHub context values with an equivalent Bitrise env var.
+var contextEnvs = map[string]string{
+	"github.sha":                       "GIT_CLONE_COMMIT_HASH",
+	"github.ref_name":                  "BITRISE_GIT_BRANCH",
+	"github.head_ref":                  "BITRISE_GIT_BRANCH",
+	"github.base_ref":                  "BITRISEIO_GIT_BRANCH_DEST",
+	"github.run_number":                "BITRISE_BUILD_NUMBER",
+	"github.workspace":                 "BITRISE_SOURCE_DIR",
+	"github.event.pull_request.number": "BITRISE_PULL_REQUEST",
+}
+
+// translateExpressions replaces the expressions referencing secrets, envs and well-known context values
+// with env var references. The expressions it could not translate are returned.
+func translateExpressions(s string) (string, []string) {
+	var untranslated []string
+	translated := ""
+	last := 0
+	for _, match := range expressionPattern.FindAllStringSubmatchIndex(s, -1) {
+		translated += s[last:match[0]]
+		last = match[1]
+
+		expression := s[match[2]:match[3]]
+		key := contextEnvs[expression]
+		if envMatch := contextEnvPattern.FindStringSubmatch(expression); envMatch != nil {
+			key = envMatch[1]
+		}
+		if key == "" {
+			untranslated = append(untranslated, s[match[0]:match[1]])
+			translated += s[match[0]:match[1]]
+			continue
+		}
+
+		if identifierCharPattern.MatchString(s[last:]) {
+			translated += "${" + key + "}"
+		} else {
+			translated += "$" + key
+		}
+	}
+	translated += s[last:]
+
+	return translated, untranslated
+}
+
+func nodeString(node yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value
+	case yaml.SequenceNode:
+		var values []string
+		for _, item := range node.Content {
+			values = append(values, item.Value)
+		}
+		return strings.Join(values, ", ")
+	}
+	return ""
+}
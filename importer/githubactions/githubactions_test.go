@@ -0,0 +1,155 @@
+package githubactions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	yamlv2 "gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-plugins-init/importer"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const ciWorkflow = `name: CI
+on:
+  push:
+    branches: [main]
+    tags: ['v*']
+  pull_request:
+    paths: ['src/**']
+  schedule:
+    - cron: '0 0 * * *'
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+    env:
+      TOKEN: ${{ secrets.NPM_TOKEN }}
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          fetch-depth: 1
+      - uses: actions/setup-node@v4
+        with:
+          node-version: 20.x
+          cache: npm
+      - run: npm run lint -- --sha=${{ github.sha }}_x
+  test:
+    needs: lint
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/cache@v4
+        with:
+          path: ~/.m2
+          key: ${{ runner.os }}-m2-${{ hashFiles('**/pom.xml') }}
+      - uses: docker/build-push-action@v5
+      - uses: actions/upload-artifact@v4
+        if: always()
+        with:
+          path: build/reports
+`
+
+func stepKeys(t *testing.T, workflow bitriseModels.WorkflowModel) []string {
+	var keys []string
+	for _, item := range workflow.Steps {
+		key, _, err := item.GetKeyAndType()
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, workflowsDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, workflowsDir, "ci.yml"), []byte(ciWorkflow), 0644))
+
+	detected, err := Source{}.Detect(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	result, err := Source{}.Import(dir)
+	require.NoError(t, err)
+
+	config := result.Config
+	require.Equal(t, []string{"git-clone@8", "script@1", "restore-npm-cache@2", "script@1", "save-npm-cache@1"}, stepKeys(t, config.Workflows["lint"]))
+	require.Equal(t, []string{"git-clone@8", "restore-cache@2", "script@1", "deploy-to-bitrise-io@2", "save-cache@1"}, stepKeys(t, config.Workflows["test"]))
+
+	lintScript, err := config.Workflows["lint"].Steps[3].GetStep()
+	require.NoError(t, err)
+	_, content, err := lintScript.Inputs[0].GetKeyValuePair()
+	require.NoError(t, err)
+	require.Contains(t, content, "npm run lint -- --sha=${GIT_CLONE_COMMIT_HASH}_x")
+	require.Equal(t, []string{"lint"}, config.Pipelines["ci"].Workflows["test"].DependsOn)
+
+	restoreCache, err := config.Workflows["test"].Steps[1].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, `{{ .OS }}-m2-{{ checksum "**/pom.xml" }}`, restoreCache.Inputs[0]["key"])
+
+	triggers := config.Pipelines["ci"].Triggers
+	require.Equal(t, []bitriseModels.PushGitEventTriggerItem{{Branch: "main"}}, triggers.PushTriggers)
+	require.Equal(t, []bitriseModels.PullRequestGitEventTriggerItem{{ChangedFiles: "src/**"}}, triggers.PullRequestTriggers)
+	require.Equal(t, []bitriseModels.TagGitEventTriggerItem{{Name: "v*"}}, triggers.TagTriggers)
+
+	var locations []string
+	for _, todo := range result.TODOs {
+		locations = append(locations, todo.Location)
+	}
+	require.Equal(t, []string{
+		".github/workflows/ci.yml jobs.test.steps[2]",
+		".github/workflows/ci.yml on.schedule",
+	}, locations)
+
+	t.Log("the generated config is valid")
+	{
+		out, err := importer.Marshal(result)
+		require.NoError(t, err)
+		require.Contains(t, string(out), "# TODO: translate the docker/build-push-action@v5 action")
+
+		var generated bitriseModels.BitriseDataModel
+		require.NoError(t, yamlv2.Unmarshal(out, &generated))
+		_, err = generated.Validate()
+		require.NoError(t, err)
+	}
+}
+
+func TestTranslateExpressions(t *testing.T) {
+	translated, untranslated := translateExpressions("${{ secrets.TOKEN }} ${{ env.A }}_b ${{ matrix.os }}")
+	require.Equal(t, "$TOKEN ${A}_b ${{ matrix.os }}", translated)
+	require.Equal(t, []string{"${{ matrix.os }}"}, untranslated)
+}
+
+func TestImportPipelineIDs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, workflowsDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, workflowsDir, "ci.yml"), []byte(ciWorkflow), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, workflowsDir, "ci.yaml"), []byte(`on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make build
+  release:
+    needs: [build, publish]
+    runs-on: ubuntu-latest
+    steps:
+      - run: make release
+`), 0644))
+
+	result, err := Source{}.Import(dir)
+	require.NoError(t, err)
+
+	config := result.Config
+	require.Contains(t, config.Pipelines, "ci.yml")
+	require.Contains(t, config.Pipelines, "ci.yaml")
+	require.Equal(t, []string{"lint"}, config.Pipelines["ci.yml"].Workflows["test"].DependsOn)
+	require.Equal(t, []string{"build"}, config.Pipelines["ci.yaml"].Workflows["release"].DependsOn)
+
+	require.Contains(t, result.TODOs, importer.TODO{
+		WorkflowID: "release",
+		Location:   ".github/workflows/ci.yaml jobs.release.needs",
+		Message:    "the needed publish job is not found",
+	})
+}
@@ -0,0 +1,100 @@
+package githubactions
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+)
+
+// workflowFile is the subset of the GitHub Actions workflow syntax the importer understands.
+// The yaml.v3 package is used, as yaml.v2 parses the `on` key as a bool.
+type workflowFile struct {
+	Name string            `yaml:"name"`
+	On   yaml.Node         `yaml:"on"`
+	Env  map[string]string `yaml:"env"`
+	Jobs map[string]job    `yaml:"jobs"`
+}
+
+type job struct {
+	Name      string            `yaml:"name"`
+	RunsOn    yaml.Node         `yaml:"runs-on"`
+	Needs     stringList        `yaml:"needs"`
+	If        string            `yaml:"if"`
+	Env       map[string]string `yaml:"env"`
+	Steps     []step            `yaml:"steps"`
+	Strategy  yaml.Node         `yaml:"strategy"`
+	Container yaml.Node         `yaml:"container"`
+	Services  yaml.Node         `yaml:"services"`
+	Uses      string            `yaml:"uses"`
+}
+
+type step struct {
+	Name             string            `yaml:"name"`
+	Uses             string            `yaml:"uses"`
+	Run              string            `yaml:"run"`
+	If               string            `yaml:"if"`
+	Shell            string            `yaml:"shell"`
+	WorkingDirectory string            `yaml:"working-directory"`
+	ContinueOnError  bool              `yaml:"continue-on-error"`
+	With             map[string]string `yaml:"with"`
+	Env              map[string]string `yaml:"env"`
+}
+
+func (s step) title() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	if s.Uses != "" {
+		return s.Uses
+	}
+
+	title := strings.TrimSpace(strings.SplitN(strings.TrimSpace(s.Run), "\n", 2)[0])
+	if len(title) > 50 {
+		title = title[:47] + "..."
+	}
+	return title
+}
+
+// snippet returns the action reference with its inputs, as written in the workflow file.
+func (s step) snippet() string {
+	snippet := "uses: " + s.Uses + "\n"
+	if len(s.With) > 0 {
+		snippet += "with:\n"
+		for _, key := range maputil.SortedKeys(s.With) {
+			snippet += fmt.Sprintf("  %s: %s\n", key, s.With[key])
+		}
+	}
+	return snippet
+}
+
+// stringList is a list of strings, which can be written as a single string too.
+type stringList []string
+
+// UnmarshalYAML ...
+func (l *stringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = []string{value.Value}
+		return nil
+	}
+
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// eventFilter is the filter of the push and pull_request events.
+type eventFilter struct {
+	Branches       []string `yaml:"branches"`
+	BranchesIgnore []string `yaml:"branches-ignore"`
+	Tags           []string `yaml:"tags"`
+	TagsIgnore     []string `yaml:"tags-ignore"`
+	Paths          []string `yaml:"paths"`
+	PathsIgnore    []string `yaml:"paths-ignore"`
+	Types          []string `yaml:"types"`
+}
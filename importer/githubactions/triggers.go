@@ -0,0 +1,93 @@
+package githubactions
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+)
+
+// translateTriggers converts the events of a workflow file to selective triggers. The push and pull request
+// branch, tag and path filters are supported, every combination of them becomes a separate trigger item.
+func (t *translator) translateTriggers(location, workflowID string, on yaml.Node) bitriseModels.Triggers {
+	var triggers bitriseModels.Triggers
+	location += " on"
+
+	events := map[string]yaml.Node{}
+	switch on.Kind {
+	case yaml.ScalarNode:
+		events[on.Value] = yaml.Node{}
+	case yaml.SequenceNode:
+		for _, event := range on.Content {
+			events[event.Value] = yaml.Node{}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(on.Content); i += 2 {
+			events[on.Content[i].Value] = *on.Content[i+1]
+		}
+	}
+
+	for _, event := range maputil.SortedKeys(events) {
+		eventLocation := location + "." + event
+
+		var filter eventFilter
+		if node := events[event]; node.Kind == yaml.MappingNode {
+			if err := node.Decode(&filter); err != nil {
+				t.addTODO(workflowID, eventLocation, "failed to parse the %s event: %s", event, err)
+				continue
+			}
+		}
+
+		switch event {
+		case "push":
+			t.checkIgnoreFilters(eventLocation, workflowID, filter)
+			if len(filter.Branches) > 0 || len(filter.Tags) == 0 {
+				for _, branch := range orAny(filter.Branches) {
+					for _, pth := range orAny(filter.Paths) {
+						triggers.PushTriggers = append(triggers.PushTriggers, bitriseModels.PushGitEventTriggerItem{Branch: branch, ChangedFiles: pth})
+					}
+				}
+			}
+			for _, tag := range filter.Tags {
+				triggers.TagTriggers = append(triggers.TagTriggers, bitriseModels.TagGitEventTriggerItem{Name: tag})
+			}
+		case "pull_request", "pull_request_target":
+			t.checkIgnoreFilters(eventLocation, workflowID, filter)
+			if len(filter.Types) > 0 {
+				t.addTODO(workflowID, eventLocation+".types", "pull request activity types are not translated")
+			}
+			for _, branch := range orAny(filter.Branches) {
+				for _, pth := range orAny(filter.Paths) {
+					triggers.PullRequestTriggers = append(triggers.PullRequestTriggers, bitriseModels.PullRequestGitEventTriggerItem{TargetBranch: branch, ChangedFiles: pth})
+				}
+			}
+		case "workflow_dispatch":
+			// builds can be started manually on bitrise.io
+		case "schedule":
+			t.addTODO(workflowID, eventLocation, "scheduled builds are configured on bitrise.io")
+		default:
+			t.addTODO(workflowID, eventLocation, "the %s event is not translated", event)
+		}
+	}
+
+	return triggers
+}
+
+func (t *translator) checkIgnoreFilters(location, workflowID string, filter eventFilter) {
+	if len(filter.BranchesIgnore) > 0 || len(filter.TagsIgnore) > 0 || len(filter.PathsIgnore) > 0 {
+		t.addTODO(workflowID, location, "ignore filters are not translated")
+	}
+}
+
+// orAny returns the filter values, or a single nil value matching anything if the filter is not set.
+func orAny(values []string) []any {
+	if len(values) == 0 {
+		return []any{nil}
+	}
+
+	var items []any
+	for _, value := range values {
+		items = append(items, value)
+	}
+	return items
+}
@@ -0,0 +1,666 @@
+package gitlabci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/importer"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// SourceName is the name of the GitLab CI import source.
+const SourceName = "gitlab-ci"
+
+const (
+	configFileName = ".gitlab-ci.yml"
+	pipelineID     = "gitlab-ci"
+	defaultStage   = "test"
+)
+
+var defaultStages = []string{"build", "test", "deploy"}
+
+// predefinedVariables are the GitLab predefined variables with an equivalent Bitrise env var.
+var predefinedVariables = map[string]string{
+	"CI_COMMIT_SHA":                       "GIT_CLONE_COMMIT_HASH",
+	"CI_COMMIT_REF_NAME":                  "BITRISE_GIT_BRANCH",
+	"CI_COMMIT_BRANCH":                    "BITRISE_GIT_BRANCH",
+	"CI_COMMIT_TAG":                       "BITRISE_GIT_TAG",
+	"CI_PROJECT_DIR":                      "BITRISE_SOURCE_DIR",
+	"CI_PIPELINE_IID":                     "BITRISE_BUILD_NUMBER",
+	"CI_MERGE_REQUEST_IID":                "BITRISE_PULL_REQUEST",
+	"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "BITRISEIO_GIT_BRANCH_DEST",
+}
+
+// cacheKeyVariables are the GitLab predefined variables with an equivalent cache key template value.
+var cacheKeyVariables = map[string]string{
+	"CI_COMMIT_REF_SLUG": "{{ .Branch }}",
+	"CI_COMMIT_REF_NAME": "{{ .Branch }}",
+	"CI_COMMIT_BRANCH":   "{{ .Branch }}",
+	"CI_COMMIT_SHA":      "{{ .CommitHash }}",
+}
+
+// Source imports the GitLab CI config of a repository.
+type Source struct{}
+
+// Name ...
+func (Source) Name() string {
+	return SourceName
+}
+
+// Detect ...
+func (Source) Detect(searchDir string) (bool, error) {
+	return pathutil.IsPathExists(filepath.Join(searchDir, configFileName))
+}
+
+// Import translates the GitLab CI config: each job becomes a workflow and the jobs are grouped into a graph pipeline.
+// A job depends on the jobs it needs, or on every job of the previous stage if its needs are not set. The job images
+// and services become containers and services, the job scripts run in a with group using them.
+func (Source) Import(searchDir string) (importer.Result, error) {
+	config, err := importer.NewConfig()
+	if err != nil {
+		return importer.Result{}, err
+	}
+	config.Containers = map[string]bitriseModels.Container{}
+	config.Services = map[string]bitriseModels.Container{}
+
+	content, err := os.ReadFile(filepath.Join(searchDir, configFileName))
+	if err != nil {
+		return importer.Result{}, err
+	}
+
+	t := translator{config: &config}
+
+	var doc yaml.Node
+	unresolved, err := importer.UnmarshalYAML(content, &doc)
+	if err != nil {
+		return importer.Result{}, fmt.Errorf("failed to parse %s: %s", configFileName, err)
+	}
+	for _, anchor := range unresolved {
+		t.addTODO("", configFileName, "the %s anchor is not defined, the values referring to it are left empty", anchor)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return importer.Result{}, fmt.Errorf("failed to parse %s: not a mapping", configFileName)
+	}
+	t.root = doc.Content[0]
+	t.resolveReferences(t.root, "", 0)
+
+	keys, values := mappingPairs(t.root)
+
+	var global struct {
+		Stages    []string  `yaml:"stages"`
+		Variables variables `yaml:"variables"`
+	}
+	if err := t.root.Decode(&global); err != nil {
+		return importer.Result{}, fmt.Errorf("failed to parse %s: %s", configFileName, err)
+	}
+	for _, key := range maputil.SortedKeys(global.Variables) {
+		value := t.translateVariables(configFileName+" variables."+key, "", global.Variables[key])
+		config.App.Environments = append(config.App.Environments, envmanModels.EnvironmentItemModel{key: value})
+	}
+
+	if _, ok := values["include"]; ok {
+		t.addTODO("", configFileName+" include", "the included configs are not imported, import them separately")
+	}
+	if _, ok := values["spec"]; ok {
+		t.addTODO("", configFileName+" spec", "the config inputs are not translated")
+	}
+	_, hasWorkflowRules := values["workflow"]
+	if hasWorkflowRules {
+		t.addTODO("", configFileName+" workflow", "the workflow rules are not translated, add the triggers of the %s pipeline", pipelineID)
+	}
+
+	defaults := t.defaults(values)
+
+	var jobNames []string
+	for _, name := range keys {
+		if globalKeywords[name] || strings.HasPrefix(name, ".") || values[name].Kind != yaml.MappingNode {
+			continue
+		}
+		jobNames = append(jobNames, name)
+	}
+	if len(jobNames) == 0 {
+		return importer.Result{}, fmt.Errorf("no jobs found in %s", configFileName)
+	}
+	t.workflowIDs = uniqueWorkflowIDs(jobNames)
+
+	stageJobs := map[string][]string{}
+	jobNeeds := map[string]*needs{}
+	pipelineWorkflows := bitriseModels.GraphPipelineWorkflowListItemModel{}
+	for _, name := range jobNames {
+		node := t.resolveExtends(name, values, map[string]bool{})
+		node = applyDefaults(node, defaults)
+
+		var j job
+		if err := node.Decode(&j); err != nil {
+			return importer.Result{}, fmt.Errorf("failed to parse the %s job of %s: %s", name, configFileName, err)
+		}
+
+		workflowID := t.workflowID(name)
+		workflow, pipelineWorkflow := t.translateJob(name, workflowID, j, node, global.Variables)
+		config.Workflows[workflowID] = workflow
+		pipelineWorkflows[workflowID] = pipelineWorkflow
+
+		stage := j.Stage
+		if stage == "" {
+			stage = defaultStage
+		}
+		stageJobs[stage] = append(stageJobs[stage], name)
+		jobNeeds[name] = j.Needs
+	}
+
+	if len(config.Containers) == 0 {
+		config.Containers = nil
+	}
+	if len(config.Services) == 0 {
+		config.Services = nil
+	}
+
+	var triggers bitriseModels.Triggers
+	if !hasWorkflowRules {
+		// without workflow rules, a pipeline runs for every branch and tag push
+		triggers = bitriseModels.Triggers{
+			PushTriggers: []bitriseModels.PushGitEventTriggerItem{{Branch: "*"}},
+			TagTriggers:  []bitriseModels.TagGitEventTriggerItem{{Name: "*"}},
+		}
+	}
+
+	if len(jobNames) == 1 && jobNeeds[jobNames[0]] == nil {
+		workflowID := t.workflowID(jobNames[0])
+		workflow := config.Workflows[workflowID]
+		workflow.Triggers = triggers
+		config.Workflows[workflowID] = workflow
+		return importer.Result{Config: config, TODOs: t.todos}, nil
+	}
+
+	stages := global.Stages
+	if len(stages) == 0 {
+		stages = defaultStages
+	}
+	stages = append(append([]string{".pre"}, stages...), ".post")
+	for _, stage := range maputil.SortedKeys(stageJobs) {
+		if !slices.Contains(stages, stage) {
+			t.addTODO("", configFileName, "the %s stage is not listed in the stages, its jobs are run after every other stage", stage)
+			stages = append(stages, stage)
+		}
+	}
+
+	var previousStageJobs []string
+	for _, stage := range stages {
+		for _, name := range stageJobs[stage] {
+			workflowID := t.workflowID(name)
+			pipelineWorkflow := pipelineWorkflows[workflowID]
+			if jobNeeds[name] == nil {
+				for _, dependency := range previousStageJobs {
+					pipelineWorkflow.DependsOn = append(pipelineWorkflow.DependsOn, t.workflowID(dependency))
+				}
+			} else {
+				pipelineWorkflow.DependsOn = t.translateNeeds(name, *jobNeeds[name])
+			}
+			pipelineWorkflows[workflowID] = pipelineWorkflow
+		}
+		if len(stageJobs[stage]) > 0 {
+			previousStageJobs = stageJobs[stage]
+		}
+	}
+
+	config.Pipelines[pipelineID] = bitriseModels.PipelineModel{
+		Triggers:  triggers,
+		Workflows: pipelineWorkflows,
+	}
+
+	return importer.Result{Config: config, TODOs: t.todos}, nil
+}
+
+type translator struct {
+	config *bitriseModels.BitriseDataModel
+	root   *yaml.Node
+	todos  []importer.TODO
+	// workflowIDs are the workflow IDs of the jobs by job name.
+	workflowIDs map[string]string
+}
+
+// uniqueWorkflowIDs returns the workflow IDs of the jobs by job name. The job names which only differ in the
+// characters a workflow ID can not have, like test:unit and test unit, get a numbered workflow ID.
+func uniqueWorkflowIDs(jobNames []string) map[string]string {
+	count := map[string]int{}
+	for _, name := range jobNames {
+		count[importer.WorkflowID(name)]++
+	}
+
+	workflowIDs := map[string]string{}
+	used := map[string]bool{}
+	for _, name := range jobNames {
+		baseID := importer.WorkflowID(name)
+		workflowID := baseID
+		for i := 2; used[workflowID] || (workflowID != baseID && count[workflowID] > 0); i++ {
+			workflowID = fmt.Sprintf("%s_%d", baseID, i)
+		}
+		used[workflowID] = true
+		workflowIDs[name] = workflowID
+	}
+	return workflowIDs
+}
+
+// workflowID returns the workflow ID of the job. The references are resolved before the workflow IDs are set,
+// until then the workflow ID is derived from the name.
+func (t *translator) workflowID(name string) string {
+	if workflowID, ok := t.workflowIDs[name]; ok {
+		return workflowID
+	}
+	return importer.WorkflowID(name)
+}
+
+func (t *translator) addTODO(workflowID, location, format string, args ...interface{}) importer.TODO {
+	todo := importer.TODO{WorkflowID: workflowID, Location: location, Message: fmt.Sprintf(format, args...)}
+	t.todos = append(t.todos, todo)
+	return todo
+}
+
+// resolveReferences replaces the !reference tags with the referenced values. The references which can not be
+// resolved are replaced with null values.
+func (t *translator) resolveReferences(node *yaml.Node, path string, depth int) {
+	if node.Tag == "!reference" && node.Kind == yaml.SequenceNode {
+		var referencePath []string
+		for _, item := range node.Content {
+			referencePath = append(referencePath, item.Value)
+		}
+
+		target := t.root
+		for _, key := range referencePath {
+			_, values := mappingPairs(target)
+			if target = values[key]; target == nil {
+				break
+			}
+		}
+		if target == nil || depth > 10 {
+			t.addTODO(t.workflowID(strings.SplitN(path, ".", 2)[0]), configFileName+" "+path, "the !reference [%s] tag is not resolved", strings.Join(referencePath, ", "))
+			*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+			return
+		}
+
+		*node = *target
+		t.resolveReferences(node, path, depth+1)
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			t.resolveReferences(node.Content[i+1], strings.TrimPrefix(path+"."+node.Content[i].Value, "."), depth)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			t.resolveReferences(item, fmt.Sprintf("%s[%d]", path, i), depth)
+		}
+	}
+}
+
+// resolveExtends returns the job merged with the jobs it extends, in the order they are listed.
+func (t *translator) resolveExtends(name string, values map[string]*yaml.Node, visiting map[string]bool) *yaml.Node {
+	node := values[name]
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	var extends stringList
+	if extendsNode := mappingValue(node, "extends"); extendsNode != nil {
+		if err := extendsNode.Decode(&extends); err != nil {
+			t.addTODO(t.workflowID(name), configFileName+" "+name+".extends", "failed to parse extends: %s", err)
+		}
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode}
+	for _, parent := range extends {
+		switch {
+		case visiting[parent]:
+			t.addTODO(t.workflowID(name), configFileName+" "+name+".extends", "the %s job is extended recursively", parent)
+		case values[parent] == nil || values[parent].Kind != yaml.MappingNode:
+			t.addTODO(t.workflowID(name), configFileName+" "+name+".extends", "the extended %s job is not found", parent)
+		default:
+			merged = mergeNodes(merged, t.resolveExtends(parent, values, visiting))
+		}
+	}
+	return mergeNodes(merged, node)
+}
+
+// defaults returns the job keywords set for every job, in the default section or at the top level.
+func (t *translator) defaults(values map[string]*yaml.Node) *yaml.Node {
+	defaults := &yaml.Node{Kind: yaml.MappingNode}
+	for _, key := range globalDefaultKeywords {
+		if value, ok := values[key]; ok {
+			defaults.Content = append(defaults.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+		}
+	}
+	if defaultNode := values["default"]; defaultNode != nil && defaultNode.Kind == yaml.MappingNode {
+		defaults = shallowMerge(defaults, defaultNode)
+	}
+	return defaults
+}
+
+// applyDefaults sets the default keywords the job does not set itself, respecting the job's inherit:default keyword.
+func applyDefaults(node, defaults *yaml.Node) *yaml.Node {
+	inherited := defaults
+	if inheritDefault := mappingValue(mappingValue(node, "inherit"), "default"); inheritDefault != nil {
+		switch inheritDefault.Kind {
+		case yaml.ScalarNode:
+			if inheritDefault.Value == "false" {
+				inherited = &yaml.Node{Kind: yaml.MappingNode}
+			}
+		case yaml.SequenceNode:
+			inherited = &yaml.Node{Kind: yaml.MappingNode}
+			for _, item := range inheritDefault.Content {
+				if value := mappingValue(defaults, item.Value); value != nil {
+					inherited.Content = append(inherited.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: item.Value}, value)
+				}
+			}
+		}
+	}
+	return shallowMerge(inherited, node)
+}
+
+func (t *translator) translateJob(name, workflowID string, j job, node *yaml.Node, globalVariables variables) (bitriseModels.WorkflowModel, bitriseModels.GraphPipelineWorkflowModel) {
+	location := configFileName + " " + name
+	workflow := bitriseModels.WorkflowModel{Summary: "Imported from " + configFileName}
+	if workflowID != name {
+		workflow.Title = name
+	}
+	var pipelineWorkflow bitriseModels.GraphPipelineWorkflowModel
+
+	keys, values := mappingPairs(node)
+	for _, key := range keys {
+		if !jobKeywords[key] {
+			t.addTODO(workflowID, location+"."+key, "the %s keyword is not translated", key)
+		}
+	}
+	for _, key := range []string{"rules", "only", "except"} {
+		if _, ok := values[key]; ok {
+			t.addTODO(workflowID, location+"."+key, "the job's %s are not translated, add a run_if condition to the pipeline workflow", key)
+		}
+	}
+	if inheritVariables := mappingValue(mappingValue(node, "inherit"), "variables"); inheritVariables != nil && inheritVariables.Value != "true" {
+		t.addTODO(workflowID, location+".inherit.variables", "the global variables are app envs, they are inherited by every workflow")
+	}
+	if len(j.Tags) > 0 {
+		t.addTODO(workflowID, location+".tags", "the job runs on the runners tagged with %s, select a matching stack and machine type for the workflow", strings.Join(j.Tags, ", "))
+	}
+
+	switch j.When {
+	case "", "on_success":
+	case "always":
+		pipelineWorkflow.ShouldAlwaysRun = bitriseModels.GraphPipelineAlwaysRunModeWorkflow
+	case "manual":
+		t.addTODO(workflowID, location+".when", "manual jobs are not supported, the workflow runs automatically")
+	default:
+		t.addTODO(workflowID, location+".when", "the job runs when %s, add a run_if condition to the pipeline workflow", j.When)
+	}
+
+	switch j.Parallel.Kind {
+	case 0:
+	case yaml.ScalarNode:
+		if _, err := strconv.Atoi(j.Parallel.Value); err == nil {
+			pipelineWorkflow.Parallel = j.Parallel.Value
+			break
+		}
+		fallthrough
+	default:
+		t.addTODO(workflowID, location+".parallel", "parallel matrix jobs are not translated, duplicate the workflow or use workflow variants")
+	}
+
+	for _, key := range maputil.SortedKeys(j.Variables) {
+		value := t.translateVariables(location+".variables."+key, workflowID, j.Variables[key])
+		workflow.Environments = append(workflow.Environments, envmanModels.EnvironmentItemModel{key: value})
+	}
+
+	if trigger := values["trigger"]; trigger != nil {
+		todo := t.addTODO(workflowID, location+".trigger", "translate the downstream pipeline trigger")
+		workflow.Steps = append(workflow.Steps, importer.TODOStepListItem("trigger", todo, snippet(trigger)))
+		return workflow, pipelineWorkflow
+	}
+
+	gitStrategy := globalVariables["GIT_STRATEGY"]
+	if value, ok := j.Variables["GIT_STRATEGY"]; ok {
+		gitStrategy = value
+	}
+	if gitStrategy != "none" {
+		workflow.Steps = append(workflow.Steps, initSteps.GitCloneStepListItem())
+	}
+
+	var saveSteps []bitriseModels.StepListItemModel
+	for i, c := range j.Cache {
+		restoreStep, saveStep := t.translateCache(fmt.Sprintf("%s.cache[%d]", location, i), workflowID, name, c)
+		if restoreStep != nil {
+			workflow.Steps = append(workflow.Steps, restoreStep)
+		}
+		if saveStep != nil {
+			saveSteps = append(saveSteps, saveStep)
+		}
+	}
+
+	var scriptSteps []bitriseModels.StepListItemModel
+	if commands := append(append([]string{}, j.BeforeScript...), j.Script...); len(commands) > 0 {
+		scriptSteps = append(scriptSteps, t.scriptStepListItem(location+".script", workflowID, "Script", commands))
+	} else {
+		t.addTODO(workflowID, location, "the job has no script")
+	}
+	if j.AllowFailure.Kind == yaml.MappingNode {
+		t.addTODO(workflowID, location+".allow_failure", "the allowed exit codes are not translated")
+	} else if j.AllowFailure.Value == "true" {
+		for _, item := range scriptSteps {
+			importer.UpdateStep(item, func(step *stepmanModels.StepModel) {
+				step.IsSkippable = pointers.NewBoolPtr(true)
+			})
+		}
+	}
+	if len(j.AfterScript) > 0 {
+		afterScript := t.scriptStepListItem(location+".after_script", workflowID, "After script", j.AfterScript)
+		importer.UpdateStep(afterScript, func(step *stepmanModels.StepModel) {
+			step.IsAlwaysRun = pointers.NewBoolPtr(true)
+			step.IsSkippable = pointers.NewBoolPtr(true)
+		})
+		scriptSteps = append(scriptSteps, afterScript)
+	}
+
+	if containerID, serviceIDs := t.translateContainers(location, workflowID, j); containerID != "" || len(serviceIDs) > 0 {
+		scriptSteps = []bitriseModels.StepListItemModel{importer.WithGroupStepListItem(containerID, serviceIDs, scriptSteps)}
+	}
+	workflow.Steps = append(workflow.Steps, scriptSteps...)
+
+	if deployStep := t.translateArtifacts(location+".artifacts", workflowID, j.Artifacts); deployStep != nil {
+		workflow.Steps = append(workflow.Steps, deployStep)
+	}
+	workflow.Steps = append(workflow.Steps, saveSteps...)
+
+	return workflow, pipelineWorkflow
+}
+
+func (t *translator) translateNeeds(name string, jobNeeds needs) []string {
+	var dependsOn []string
+	for i, need := range jobNeeds {
+		location := fmt.Sprintf("%s %s.needs[%d]", configFileName, name, i)
+		dependency, found := t.workflowIDs[need.Job]
+		switch {
+		case need.Pipeline != "" || need.Project != "":
+			t.addTODO(t.workflowID(name), location, "needs from other pipelines are not translated")
+		case !found && need.Optional:
+		case !found:
+			t.addTODO(t.workflowID(name), location, "the needed %s job is not found", need.Job)
+		case !slices.Contains(dependsOn, dependency):
+			dependsOn = append(dependsOn, dependency)
+		}
+	}
+	return dependsOn
+}
+
+// translateContainers adds the job image as a container and the job services as services. The IDs of the
+// services are the host names the job reaches them on.
+func (t *translator) translateContainers(location, workflowID string, j job) (string, []string) {
+	var containerID string
+	if j.Image.Name != "" {
+		if !j.Image.Entrypoint.IsZero() {
+			t.addTODO(workflowID, location+".image.entrypoint", "the image entrypoint is not translated")
+		}
+		containerID = importer.AddContainer(t.config.Containers, importer.ContainerID(j.Image.Name), bitriseModels.Container{Image: j.Image.Name})
+	}
+
+	var serviceIDs []string
+	for i, s := range j.Services {
+		serviceLocation := fmt.Sprintf("%s.services[%d]", location, i)
+		if !s.Command.IsZero() || !s.Entrypoint.IsZero() {
+			t.addTODO(workflowID, serviceLocation, "the service command and entrypoint are not translated")
+		}
+
+		hostName := strings.TrimSpace(strings.SplitN(s.Alias, ",", 2)[0])
+		if hostName == "" {
+			hostName = strings.ReplaceAll(importer.ImageName(s.Name), "/", "-")
+		}
+
+		service := bitriseModels.Container{Image: s.Name}
+		for _, key := range maputil.SortedKeys(s.Variables) {
+			service.Envs = append(service.Envs, envmanModels.EnvironmentItemModel{key: s.Variables[key]})
+		}
+
+		serviceID := importer.AddContainer(t.config.Services, importer.WorkflowID(hostName), service)
+		if serviceID != hostName {
+			t.addTODO(workflowID, serviceLocation, "the %s service is reachable on the %s host name", hostName, serviceID)
+		}
+		if !slices.Contains(serviceIDs, serviceID) {
+			serviceIDs = append(serviceIDs, serviceID)
+		}
+	}
+
+	return containerID, serviceIDs
+}
+
+func (t *translator) translateCache(location, workflowID, jobName string, c cacheConfig) (bitriseModels.StepListItemModel, bitriseModels.StepListItemModel) {
+	if c.Untracked {
+		t.addTODO(workflowID, location+".untracked", "caching the untracked files is not translated")
+	}
+
+	key := t.translateCacheKey(location+".key", workflowID, jobName, c.Key)
+	restoreKey := key
+	for _, fallbackKey := range c.FallbackKeys {
+		restoreKey += "\n" + t.translateCacheKey(location+".fallback_keys", workflowID, jobName, cacheKey{Value: fallbackKey})
+	}
+
+	var restoreStep, saveStep bitriseModels.StepListItemModel
+	switch c.Policy {
+	case "", "pull-push", "pull", "push":
+	default:
+		t.addTODO(workflowID, location+".policy", "the %s cache policy is not translated", c.Policy)
+	}
+	if c.Policy != "push" {
+		restoreStep = steps.RestoreCacheStepListItem("Restore cache", restoreKey)
+	}
+	if c.Policy != "pull" && len(c.Paths) > 0 {
+		saveStep = steps.SaveCacheStepListItem("Save cache", key, strings.Join(c.Paths, "\n"))
+		t.applyWhen(location+".when", workflowID, saveStep, c.When)
+	}
+	return restoreStep, saveStep
+}
+
+// translateCacheKey converts a GitLab cache key to a key-based cache key template.
+func (t *translator) translateCacheKey(location, workflowID, jobName string, key cacheKey) string {
+	if len(key.Files) > 0 {
+		var files []string
+		for _, file := range key.Files {
+			files = append(files, strconv.Quote(file))
+		}
+		checksum := "{{ checksum " + strings.Join(files, " ") + " }}"
+		if key.Prefix == "" {
+			return checksum
+		}
+		return t.translateCacheKey(location+".prefix", workflowID, jobName, cacheKey{Value: key.Prefix}) + "-" + checksum
+	}
+
+	if key.Value == "" {
+		return "default"
+	}
+
+	return importer.ReplaceEnvReferences(key.Value, func(reference, name string) string {
+		if name == "CI_JOB_NAME" {
+			return jobName
+		}
+		if value, ok := cacheKeyVariables[name]; ok {
+			return value
+		}
+		if strings.HasPrefix(name, "CI_") {
+			t.addTODO(workflowID, location, "translate the $%s predefined variable in the cache key", name)
+			return reference
+		}
+		return `{{ getenv "` + name + `" }}`
+	})
+}
+
+func (t *translator) translateArtifacts(location, workflowID string, a artifacts) bitriseModels.StepListItemModel {
+	if !a.Reports.IsZero() {
+		t.addTODO(workflowID, location+".reports", "the artifact reports are not translated")
+	}
+	if len(a.Paths) == 0 {
+		return nil
+	}
+
+	var inputs []envmanModels.EnvironmentItemModel
+	if len(a.Paths) > 1 || strings.ContainsAny(a.Paths[0], "*?") {
+		t.addTODO(workflowID, location+".paths", "multiple artifact paths are not translated, move the artifacts to $BITRISE_DEPLOY_DIR")
+	} else {
+		inputs = append(inputs, envmanModels.EnvironmentItemModel{"deploy_path": a.Paths[0]})
+	}
+
+	deployStep := initSteps.DeployToBitriseIoStepListItem(inputs...)
+	t.applyWhen(location+".when", workflowID, deployStep, a.When)
+	return deployStep
+}
+
+// applyWhen sets the step to run in the given build status: on_success (default), on_failure or always.
+func (t *translator) applyWhen(location, workflowID string, item bitriseModels.StepListItemModel, when string) {
+	switch when {
+	case "", "on_success":
+	case "always":
+		importer.UpdateStep(item, func(step *stepmanModels.StepModel) {
+			step.IsAlwaysRun = pointers.NewBoolPtr(true)
+		})
+	case "on_failure":
+		importer.UpdateStep(item, func(step *stepmanModels.StepModel) {
+			step.IsAlwaysRun = pointers.NewBoolPtr(true)
+			step.RunIf = pointers.NewStringPtr("{{.IsBuildFailed}}")
+		})
+	default:
+		t.addTODO(workflowID, location, "the %s condition is not translated", when)
+	}
+}
+
+func (t *translator) scriptStepListItem(location, workflowID, title string, commands []string) bitriseModels.StepListItemModel {
+	shebang := "#!/usr/bin/env bash\nset -eo pipefail\n"
+	content, untranslated := importer.TranslateEnvReferences(strings.Join(commands, "\n")+"\n", "CI_", predefinedVariables)
+
+	var todoComments string
+	for _, variable := range untranslated {
+		todo := t.addTODO(workflowID, location, "translate the $%s predefined variable", variable)
+		todoComments += "# " + todo.String() + "\n"
+	}
+	if todoComments != "" {
+		todoComments += "\n"
+	}
+
+	return initSteps.ScriptStepListItem(title, shebang+todoComments+content)
+}
+
+func (t *translator) translateVariables(location, workflowID, value string) string {
+	translated, untranslated := importer.TranslateEnvReferences(value, "CI_", predefinedVariables)
+	for _, variable := range untranslated {
+		t.addTODO(workflowID, location, "translate the $%s predefined variable", variable)
+	}
+	return translated
+}
@@ -0,0 +1,178 @@
+package gitlabci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	yamlv2 "gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-plugins-init/importer"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const gitlabConfig = `image: node:20
+variables:
+  NODE_ENV: test
+stages: [build, test, deploy]
+.setup: &setup
+  before_script:
+    - npm ci
+.cache:
+  cache:
+    key:
+      files: [package-lock.json]
+      prefix: npm-$CI_COMMIT_REF_SLUG
+    paths: [.npm/]
+build:
+  stage: build
+  extends: .cache
+  <<: *setup
+  script:
+    - npm run build -- --sha=$CI_COMMIT_SHA
+  artifacts:
+    paths: [dist]
+test:
+  stage: test
+  extends: [.cache, .missing]
+  services:
+    - postgres:15
+    - name: redis:7
+      alias: cache
+  script:
+    - !reference [.setup, before_script]
+    - npm test
+  after_script:
+    - echo $CI_JOB_TOKEN
+  allow_failure: true
+  rules:
+    - if: $CI_COMMIT_BRANCH == "main"
+lint:
+  stage: test
+  needs: []
+  image: python:3.12
+  script: ruff check
+  parallel: 3
+deploy:
+  stage: deploy
+  script: ./deploy.sh
+  when: manual
+  broken: *undefined
+`
+
+func stepKeys(t *testing.T, stepList []bitriseModels.StepListItemModel) []string {
+	var keys []string
+	for _, item := range stepList {
+		key, _, err := item.GetKeyAndType()
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, configFileName), []byte(gitlabConfig), 0644))
+
+	detected, err := Source{}.Detect(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	result, err := Source{}.Import(dir)
+	require.NoError(t, err)
+	config := result.Config
+
+	t.Log("stages and needs become dependencies")
+	{
+		pipeline := config.Pipelines[pipelineID]
+		require.Empty(t, pipeline.Workflows["build"].DependsOn)
+		require.Equal(t, []string{"build"}, pipeline.Workflows["test"].DependsOn)
+		require.Empty(t, pipeline.Workflows["lint"].DependsOn)
+		require.Equal(t, "3", pipeline.Workflows["lint"].Parallel)
+		require.Equal(t, []string{"test", "lint"}, pipeline.Workflows["deploy"].DependsOn)
+	}
+
+	t.Log("extends, anchors and references are resolved")
+	{
+		build := config.Workflows["build"]
+		require.Equal(t, []string{"git-clone@8", "restore-cache@2", "with", "deploy-to-bitrise-io@2", "save-cache@1"}, stepKeys(t, build.Steps))
+
+		restoreCache, err := build.Steps[1].GetStep()
+		require.NoError(t, err)
+		require.Equal(t, `npm-{{ .Branch }}-{{ checksum "package-lock.json" }}`, restoreCache.Inputs[0]["key"])
+
+		with := build.Steps[2][bitriseModels.StepListItemWithKey].(bitriseModels.WithModel)
+		require.Equal(t, "node_20", with.ContainerID)
+		script := with.Steps[0]["script@1"]
+		_, content, err := script.Inputs[0].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Contains(t, content, "npm ci\nnpm run build -- --sha=$GIT_CLONE_COMMIT_HASH\n")
+
+		test := config.Workflows["test"]
+		testWith := test.Steps[2][bitriseModels.StepListItemWithKey].(bitriseModels.WithModel)
+		require.Equal(t, []string{"postgres", "cache"}, testWith.ServiceIDs)
+		require.Len(t, testWith.Steps, 2)
+		require.True(t, *testWith.Steps[0]["script@1"].IsSkippable)
+		require.True(t, *testWith.Steps[1]["script@1"].IsAlwaysRun)
+		_, content, err = testWith.Steps[0]["script@1"].Inputs[0].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Contains(t, content, "npm ci\nnpm test\n")
+	}
+
+	t.Log("containers and services are added")
+	{
+		require.Equal(t, "node:20", config.Containers["node_20"].Image)
+		require.Equal(t, "python:3.12", config.Containers["python_3.12"].Image)
+		require.Equal(t, "postgres:15", config.Services["postgres"].Image)
+		require.Equal(t, "redis:7", config.Services["cache"].Image)
+	}
+
+	var locations []string
+	for _, todo := range result.TODOs {
+		locations = append(locations, todo.Location)
+	}
+	require.Equal(t, []string{
+		".gitlab-ci.yml",
+		".gitlab-ci.yml test.extends",
+		".gitlab-ci.yml test.rules",
+		".gitlab-ci.yml test.after_script",
+		".gitlab-ci.yml deploy.broken",
+		".gitlab-ci.yml deploy.when",
+	}, locations)
+
+	t.Log("the generated config is valid")
+	{
+		out, err := importer.Marshal(result)
+		require.NoError(t, err)
+		require.Contains(t, string(out), "# TODO: translate the $CI_JOB_TOKEN predefined variable")
+
+		var generated bitriseModels.BitriseDataModel
+		require.NoError(t, yamlv2.Unmarshal(out, &generated))
+		_, err = generated.Validate()
+		require.NoError(t, err)
+	}
+}
+
+func TestImport_collidingJobNames(t *testing.T) {
+	dir := t.TempDir()
+	config := `stages: [test, deploy]
+test:unit:
+  stage: test
+  script: [npm test]
+test unit:
+  stage: test
+  script: [npm test]
+deploy:
+  stage: deploy
+  needs: ["test unit"]
+  script: [npm publish]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, configFileName), []byte(config), 0644))
+
+	result, err := Source{}.Import(dir)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"test_unit", "test_unit_2", "deploy"}, maputil.SortedKeys(result.Config.Workflows))
+	require.Equal(t, []string{"test_unit_2"}, result.Config.Pipelines[pipelineID].Workflows["deploy"].DependsOn)
+}
@@ -0,0 +1,345 @@
+package gitlabci
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// globalKeywords are the top level keys of the config file which are not jobs.
+var globalKeywords = map[string]bool{
+	"default":       true,
+	"include":       true,
+	"stages":        true,
+	"variables":     true,
+	"workflow":      true,
+	"spec":          true,
+	"image":         true,
+	"services":      true,
+	"cache":         true,
+	"before_script": true,
+	"after_script":  true,
+	"types":         true,
+}
+
+// globalDefaultKeywords are the job keywords which can be set for every job at the top level too, not only in the default section.
+var globalDefaultKeywords = []string{"image", "services", "cache", "before_script", "after_script"}
+
+// jobKeywords are the job keywords the importer translates or deliberately ignores.
+var jobKeywords = map[string]bool{
+	"stage":         true,
+	"image":         true,
+	"services":      true,
+	"variables":     true,
+	"before_script": true,
+	"script":        true,
+	"after_script":  true,
+	"needs":         true,
+	"dependencies":  true,
+	"cache":         true,
+	"artifacts":     true,
+	"rules":         true,
+	"only":          true,
+	"except":        true,
+	"when":          true,
+	"allow_failure": true,
+	"parallel":      true,
+	"trigger":       true,
+	"extends":       true,
+	"tags":          true,
+	"interruptible": true,
+	"inherit":       true,
+}
+
+type job struct {
+	Stage        string     `yaml:"stage"`
+	Image        image      `yaml:"image"`
+	Services     []service  `yaml:"services"`
+	Variables    variables  `yaml:"variables"`
+	BeforeScript script     `yaml:"before_script"`
+	Script       script     `yaml:"script"`
+	AfterScript  script     `yaml:"after_script"`
+	Needs        *needs     `yaml:"needs"`
+	Cache        caches     `yaml:"cache"`
+	Artifacts    artifacts  `yaml:"artifacts"`
+	When         string     `yaml:"when"`
+	AllowFailure yaml.Node  `yaml:"allow_failure"`
+	Parallel     yaml.Node  `yaml:"parallel"`
+	Extends      stringList `yaml:"extends"`
+	Tags         []string   `yaml:"tags"`
+}
+
+// image is the image of a job, set either by its name or as an object.
+type image struct {
+	Name       string    `yaml:"name"`
+	Entrypoint yaml.Node `yaml:"entrypoint"`
+}
+
+func (i *image) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		i.Name = value.Value
+		return nil
+	}
+	type plain image
+	return value.Decode((*plain)(i))
+}
+
+// service is a service container of a job, set either by its image name or as an object.
+type service struct {
+	Name       string    `yaml:"name"`
+	Alias      string    `yaml:"alias"`
+	Variables  variables `yaml:"variables"`
+	Command    yaml.Node `yaml:"command"`
+	Entrypoint yaml.Node `yaml:"entrypoint"`
+}
+
+func (s *service) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		s.Name = value.Value
+		return nil
+	}
+	type plain service
+	return value.Decode((*plain)(s))
+}
+
+// variables are the variables of the config or a job, a variable is set either by its value or as an object.
+type variables map[string]string
+
+func (v *variables) UnmarshalYAML(value *yaml.Node) error {
+	var raw map[string]yaml.Node
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	*v = variables{}
+	for key, node := range raw {
+		if node.Kind == yaml.MappingNode {
+			var variable struct {
+				Value string `yaml:"value"`
+			}
+			if err := node.Decode(&variable); err != nil {
+				return err
+			}
+			(*v)[key] = variable.Value
+			continue
+		}
+		(*v)[key] = node.Value
+	}
+	return nil
+}
+
+// script is a list of commands, nested lists (coming from !reference tags) are flattened.
+type script []string
+
+func (s *script) UnmarshalYAML(value *yaml.Node) error {
+	*s = nil
+	return s.append(value)
+}
+
+func (s *script) append(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		if value.Tag != "!!null" {
+			*s = append(*s, value.Value)
+		}
+	case yaml.SequenceNode:
+		for _, item := range value.Content {
+			if err := s.append(item); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("line %d: invalid script", value.Line)
+	}
+	return nil
+}
+
+// needs are the jobs a job depends on, a need is set either by the job name or as an object.
+type needs []need
+
+type need struct {
+	Job      string `yaml:"job"`
+	Pipeline string `yaml:"pipeline"`
+	Project  string `yaml:"project"`
+	Optional bool   `yaml:"optional"`
+}
+
+func (n *need) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		n.Job = value.Value
+		return nil
+	}
+	type plain need
+	return value.Decode((*plain)(n))
+}
+
+// caches are the caches of a job, set either as a single cache or as a list.
+type caches []cacheConfig
+
+func (c *caches) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.MappingNode {
+		var single cacheConfig
+		if err := value.Decode(&single); err != nil {
+			return err
+		}
+		*c = caches{single}
+		return nil
+	}
+	var list []cacheConfig
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*c = list
+	return nil
+}
+
+type cacheConfig struct {
+	Key          cacheKey `yaml:"key"`
+	Paths        []string `yaml:"paths"`
+	Untracked    bool     `yaml:"untracked"`
+	Policy       string   `yaml:"policy"`
+	When         string   `yaml:"when"`
+	FallbackKeys []string `yaml:"fallback_keys"`
+}
+
+// cacheKey is a cache key, set either as a string or computed from the checksum of files.
+type cacheKey struct {
+	Value  string
+	Files  []string `yaml:"files"`
+	Prefix string   `yaml:"prefix"`
+}
+
+func (k *cacheKey) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		k.Value = value.Value
+		return nil
+	}
+	type plain cacheKey
+	return value.Decode((*plain)(k))
+}
+
+type artifacts struct {
+	Paths   []string  `yaml:"paths"`
+	When    string    `yaml:"when"`
+	Reports yaml.Node `yaml:"reports"`
+}
+
+// stringList is a list of strings, which can be set as a single string too.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = stringList{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// snippet returns the YAML source of the node, it is kept in the TODO steps.
+func snippet(node *yaml.Node) string {
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+// mappingPairs returns the keys of the mapping node in order, with the value nodes by key. Aliases are resolved
+// and the keys of the merged (<<) mappings are included, unless the mapping sets them itself.
+func mappingPairs(node *yaml.Node) ([]string, map[string]*yaml.Node) {
+	node = resolveAlias(node)
+	values := map[string]*yaml.Node{}
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, values
+	}
+
+	var keys []string
+	var merged []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], resolveAlias(node.Content[i+1])
+		if key.Tag == "!!merge" {
+			if value.Kind == yaml.SequenceNode {
+				merged = append(merged, value.Content...)
+			} else {
+				merged = append(merged, value)
+			}
+			continue
+		}
+		keys = append(keys, key.Value)
+		values[key.Value] = value
+	}
+
+	for _, mergedNode := range merged {
+		mergedKeys, mergedValues := mappingPairs(mergedNode)
+		for _, key := range mergedKeys {
+			if _, ok := values[key]; !ok {
+				keys = append(keys, key)
+				values[key] = mergedValues[key]
+			}
+		}
+	}
+	return keys, values
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	_, values := mappingPairs(node)
+	return values[key]
+}
+
+// mergeNodes deep merges the mapping nodes the way extends does: the maps are merged, every other value
+// of the base is overridden.
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	base, override = resolveAlias(base), resolveAlias(override)
+	if base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	_, overrideValues := mappingPairs(override)
+	baseKeys, baseValues := mappingPairs(base)
+	for _, key := range baseKeys {
+		value := baseValues[key]
+		if overrideValue, ok := overrideValues[key]; ok {
+			value = mergeNodes(value, overrideValue)
+		}
+		merged.Content = append(merged.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+	overrideKeys, _ := mappingPairs(override)
+	for _, key := range overrideKeys {
+		if _, ok := baseValues[key]; !ok {
+			merged.Content = append(merged.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, overrideValues[key])
+		}
+	}
+	return merged
+}
+
+// shallowMerge merges the mapping nodes the way the default keywords are applied: the keys of the override
+// replace the keys of the base.
+func shallowMerge(base, override *yaml.Node) *yaml.Node {
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	_, overrideValues := mappingPairs(override)
+	baseKeys, baseValues := mappingPairs(base)
+	for _, key := range baseKeys {
+		if _, ok := overrideValues[key]; !ok {
+			merged.Content = append(merged.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, baseValues[key])
+		}
+	}
+	overrideKeys, _ := mappingPairs(override)
+	for _, key := range overrideKeys {
+		merged.Content = append(merged.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, overrideValues[key])
+	}
+	return merged
+}
@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	yamlv2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/cache"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// Source is a CI service whose config files can be imported into a bitrise config.
+type Source interface {
+	// Name is the identifier of the source used on the command line.
+	Name() string
+	// Detect reports whether the search dir has config files of the source.
+	Detect(searchDir string) (bool, error)
+	// Import translates the config files of the source found in the search dir.
+	Import(searchDir string) (Result, error)
+}
+
+// Result is an imported bitrise config with the list of things the importer could not translate.
+type Result struct {
+	Config bitriseModels.BitriseDataModel
+	TODOs  []TODO
+}
+
+// TODO is a part of the imported config, which needs to be translated manually.
+type TODO struct {
+	// WorkflowID is the workflow the TODO belongs to, empty for config level TODOs.
+	WorkflowID string
+	// Location points to the untranslated part of the source config, like .github/workflows/ci.yml jobs.build.steps[2].
+	Location string
+	Message  string
+}
+
+// String returns the TODO as it is printed in the report and in the config comments.
+func (todo TODO) String() string {
+	return fmt.Sprintf("TODO: %s (%s)", todo.Message, todo.Location)
+}
+
+var invalidWorkflowIDCharPattern = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// NewConfig returns an empty bitrise config the importers fill with workflows.
+func NewConfig() (bitriseModels.BitriseDataModel, error) {
+	config, err := models.NewDefaultConfigBuilder().Generate(scanners.CustomProjectType)
+	if err != nil {
+		return bitriseModels.BitriseDataModel{}, err
+	}
+	config.Workflows = map[string]bitriseModels.WorkflowModel{}
+	config.Pipelines = map[string]bitriseModels.PipelineModel{}
+	return config, nil
+}
+
+// WorkflowID converts a job name of the source config to a valid workflow ID.
+func WorkflowID(name string) string {
+	return strings.Trim(invalidWorkflowIDCharPattern.ReplaceAllString(name, "_"), "_")
+}
+
+// TODOStepListItem returns a script step standing for a part of the source config the importer could not translate.
+// The original snippet is kept as a comment in the script content.
+func TODOStepListItem(title string, todo TODO, snippet string) bitriseModels.StepListItemModel {
+	content := "#!/usr/bin/env bash\n"
+	for _, line := range strings.Split(todo.String(), "\n") {
+		content += "# " + line + "\n"
+	}
+	if snippet != "" {
+		content += "#\n"
+		for _, line := range strings.Split(strings.TrimSuffix(snippet, "\n"), "\n") {
+			content += strings.TrimRight("# "+line, " ") + "\n"
+		}
+	}
+	return initSteps.ScriptStepListItem("TODO: "+title, content)
+}
+
+// CacheSteps returns the cache steps of the named dependency manager, using the detected lock files if any.
+func CacheSteps(managerName string, detected []cache.DetectedManager) (bitriseModels.StepListItemModel, bitriseModels.StepListItemModel, bool) {
+	for _, manager := range detected {
+		if manager.Name == managerName {
+			restoreStep, saveStep := manager.CacheSteps()
+			return restoreStep, saveStep, true
+		}
+	}
+
+	for _, manager := range cache.Managers {
+		if manager.Name == managerName {
+			restoreStep, saveStep := cache.DetectedManager{Manager: manager, LockFilePaths: manager.LockFiles[:1]}.CacheSteps()
+			return restoreStep, saveStep, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+// Marshal returns the imported config in YAML format. The TODOs are added as comments
+// above the workflow they belong to, the config level ones at the top of the document.
+func Marshal(result Result) ([]byte, error) {
+	out, err := yamlv2.Marshal(result.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		return nil, err
+	}
+	root := doc.Content[0]
+
+	workflowTODOs := map[string][]string{}
+	var configTODOs []string
+	for _, todo := range result.TODOs {
+		if _, ok := result.Config.Workflows[todo.WorkflowID]; ok {
+			workflowTODOs[todo.WorkflowID] = append(workflowTODOs[todo.WorkflowID], todo.String())
+		} else {
+			configTODOs = append(configTODOs, todo.String())
+		}
+	}
+
+	root.HeadComment = strings.Join(configTODOs, "\n")
+	if workflowsNode := mappingValue(root, "workflows"); workflowsNode != nil {
+		for i := 0; i+1 < len(workflowsNode.Content); i += 2 {
+			keyNode := workflowsNode.Content[i]
+			keyNode.HeadComment = strings.Join(workflowTODOs[keyNode.Value], "\n")
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// UpdateStep modifies the step of the step list item in place.
+func UpdateStep(item bitriseModels.StepListItemModel, update func(step *stepmanModels.StepModel)) {
+	key, itemType, err := item.GetKeyAndType()
+	if err != nil || itemType != bitriseModels.StepListItemTypeStep {
+		return
+	}
+	step, err := item.GetStep()
+	if err != nil {
+		return
+	}
+	update(step)
+	item[key] = *step
+}
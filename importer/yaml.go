@@ -0,0 +1,34 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+var unknownAnchorPattern = regexp.MustCompile(`unknown anchor '([^']+)' referenced`)
+
+// UnmarshalYAML parses the YAML document into the node. The aliases of anchors which are not defined in the document
+// are replaced with null values (or empty maps in merge keys), so that the rest of the document can still be translated.
+// The names of the anchors which could not be resolved are returned.
+func UnmarshalYAML(content []byte, node *yaml.Node) ([]string, error) {
+	var unresolved []string
+	for {
+		err := yaml.Unmarshal(content, node)
+		if err == nil {
+			return unresolved, nil
+		}
+
+		match := unknownAnchorPattern.FindStringSubmatch(err.Error())
+		if match == nil || slices.Contains(unresolved, match[1]) {
+			return unresolved, err
+		}
+		unresolved = append(unresolved, match[1])
+
+		anchor := regexp.QuoteMeta(match[1])
+		content = regexp.MustCompile(`<<:\s*\*`+anchor+`\b`).ReplaceAll(content, []byte("<<: {}"))
+		content = regexp.MustCompile(fmt.Sprintf(`(?m)(^|[\s\[{,])\*%s([\s\]},]|$)`, anchor)).ReplaceAll(content, []byte("${1}null${2}"))
+	}
+}
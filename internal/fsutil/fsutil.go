@@ -0,0 +1,36 @@
+// Package fsutil contains the file system helpers shared by the scanners and the config passes.
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadOptionalFile returns the content of the file, or an empty string if the file does not exist.
+func ReadOptionalFile(pth string) (string, error) {
+	content, err := os.ReadFile(pth)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read %s: %s", pth, err)
+	}
+	return string(content), nil
+}
+
+// RelPath returns the cleaned search dir relative path without the ./ prefix, or . for the search dir itself.
+func RelPath(pth string) string {
+	pth = strings.TrimPrefix(filepath.Clean(pth), "./")
+	if pth == "" {
+		return "."
+	}
+	return pth
+}
+
+// IsDir reports whether the path is an existing directory.
+func IsDir(pth string) bool {
+	info, err := os.Stat(pth)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,41 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOptionalFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Gemfile"), []byte("source 'https://rubygems.org'\n"), 0644))
+
+	content, err := ReadOptionalFile(filepath.Join(dir, "Gemfile"))
+	require.NoError(t, err)
+	require.Equal(t, "source 'https://rubygems.org'\n", content)
+
+	content, err = ReadOptionalFile(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	require.Empty(t, content)
+
+	_, err = ReadOptionalFile(dir)
+	require.Error(t, err)
+}
+
+func TestRelPath(t *testing.T) {
+	require.Equal(t, ".", RelPath(""))
+	require.Equal(t, ".", RelPath("./"))
+	require.Equal(t, "app", RelPath("./app/"))
+	require.Equal(t, "services/api", RelPath("services/./api"))
+}
+
+func TestIsDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), nil, 0644))
+
+	require.True(t, IsDir(dir))
+	require.False(t, IsDir(filepath.Join(dir, "file")))
+	require.False(t, IsDir(filepath.Join(dir, "missing")))
+}
@@ -0,0 +1,14 @@
+// Package maputil contains the map helpers shared by the scanners, the importers and the config passes.
+package maputil
+
+import "sort"
+
+// SortedKeys returns the keys of the map in order, to produce a stable output.
+func SortedKeys[T any](m map[string]T) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,12 @@
+package maputil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortedKeys(t *testing.T) {
+	require.Equal(t, []string{"a", "b", "c"}, SortedKeys(map[string]int{"c": 3, "a": 1, "b": 2}))
+	require.Nil(t, SortedKeys(map[string]bool{}))
+}
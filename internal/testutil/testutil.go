@@ -0,0 +1,17 @@
+// Package testutil contains the test helpers shared by the package tests.
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// WriteFile writes the test fixture file, creating its parent directories.
+func WriteFile(t *testing.T, pth, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+	require.NoError(t, os.WriteFile(pth, []byte(content), 0644))
+}
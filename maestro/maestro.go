@@ -0,0 +1,269 @@
+package maestro
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+)
+
+const (
+	// UITestWorkflowID is the ID of the workflow running the Maestro flows on an emulator or simulator.
+	UITestWorkflowID = "run_maestro_tests"
+	// UITestPipelineID is the ID of the pipeline running the Maestro flow shards in parallel.
+	UITestPipelineID   = "maestro_tests"
+	uiTestSummary      = "Run your Maestro UI test flows on an %s."
+	uiTestDescription  = "The workflow will first clone your Git repository, build a debug app for the %s, install it and run the Maestro flows of its shard. The flows to run are listed in its Run Maestro flows step."
+	shardedDescription = " Run it through the maestro_tests pipeline to split the flows between parallel shards."
+
+	// flowsPerShard is the number of flows a single workflow runs before the flows are split between parallel workflows.
+	flowsPerShard = 5
+	maxShards     = 4
+
+	debugVariant       = "Debug"
+	debugConfiguration = "Debug"
+	variantInputKey    = "variant"
+	buildTypeInputKey  = "build_type"
+	buildTypeAPK       = "apk"
+	workspaceConfig    = "config"
+)
+
+var (
+	// flowDirs are the directories Maestro flows are kept in by convention.
+	flowDirs = map[string]bool{".maestro": true, "maestro": true}
+	// skippedDirs are not searched for flows, they hold build outputs and dependencies.
+	skippedDirs = map[string]bool{"build": true, "node_modules": true, "Pods": true, "Carthage": true, "DerivedData": true}
+	// appIDPattern matches the appId of a flow's config section, like appId: com.example.app.
+	appIDPattern = regexp.MustCompile(`(?m)^appId:\s*["']?([^"'\s#]+)`)
+	// buildSetupStepIDs are the steps the preparing steps of the copied workflow end with.
+	buildSetupStepIDs = map[string]bool{
+		initSteps.AndroidLintID:                            true,
+		initSteps.AndroidUnitTestID:                        true,
+		initSteps.ChangeAndroidVersionCodeAndVersionNameID: true,
+		initSteps.AndroidBuildID:                           true,
+		initSteps.XcodeTestID:                              true,
+		initSteps.XcodeBuildForTestID:                      true,
+		initSteps.XcodeArchiveID:                           true,
+		initSteps.DeployToBitriseIoID:                      true,
+	}
+	platformTitles = map[string]string{android.ScannerName: "Android emulator", string(ios.XcodeProjectTypeIOS): "iOS simulator"}
+)
+
+// Flow is a Maestro flow file and the app it tests.
+type Flow struct {
+	// Path is the flow file relative to the search dir.
+	Path string
+	// AppID is the appId of the flow's config section, empty if it is not set or depends on env vars.
+	AppID string
+}
+
+// FindFlows returns the flows of the .maestro and maestro directories of the repository. Only the flows directly
+// in the directories are returned, Maestro does not run the subflows of their subdirectories by default.
+func FindFlows(searchDir string) ([]Flow, error) {
+	var flows []Flow
+	if err := filepath.WalkDir(searchDir, func(pth string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() || pth == searchDir {
+			return nil
+		}
+		name := entry.Name()
+		if !flowDirs[name] {
+			if strings.HasPrefix(name, ".") || skippedDirs[name] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		entries, err := os.ReadDir(pth)
+		if err != nil {
+			return err
+		}
+		for _, flowEntry := range entries {
+			ext := filepath.Ext(flowEntry.Name())
+			if flowEntry.IsDir() || (ext != ".yaml" && ext != ".yml") || strings.TrimSuffix(flowEntry.Name(), ext) == workspaceConfig {
+				continue
+			}
+			flowPath := filepath.Join(pth, flowEntry.Name())
+			content, err := os.ReadFile(flowPath)
+			if err != nil {
+				return err
+			}
+			relPath, err := filepath.Rel(searchDir, flowPath)
+			if err != nil {
+				return err
+			}
+			flows = append(flows, Flow{Path: relPath, AppID: appID(string(content))})
+		}
+		return filepath.SkipDir
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(flows, func(i, j int) bool { return flows[i].Path < flows[j].Path })
+	return flows, nil
+}
+
+// FlowsOf returns the flows testing the app. If none of the flows has the app's ID, the flows of its build types
+// and flavors with an application ID suffix, like io.bitrise.notes.debug, are returned.
+func FlowsOf(flows []Flow, appID string) []Flow {
+	if appID == "" {
+		return nil
+	}
+	var matching, suffixed []Flow
+	for _, flow := range flows {
+		if flow.AppID == appID {
+			matching = append(matching, flow)
+		} else if strings.HasPrefix(flow.AppID, appID+".") {
+			suffixed = append(suffixed, flow)
+		}
+	}
+	if len(matching) == 0 {
+		return suffixed
+	}
+	return matching
+}
+
+// Shards returns the number of parallel workflows the flows are split between.
+func Shards(flows []Flow) int {
+	shards := (len(flows) + flowsPerShard - 1) / flowsPerShard
+	if shards > maxShards {
+		return maxShards
+	}
+	return shards
+}
+
+// AddUITestWorkflow adds the Maestro UI test workflow of the flows to the native Android or iOS config, and the
+// pipeline running its shards in parallel if there are more flows than a single workflow runs.
+// The workflow prepares the project like the workflow building the app of the config and builds a debug app instead.
+func AddUITestWorkflow(config *bitriseModels.BitriseDataModel, flows []Flow) bool {
+	if len(flows) == 0 {
+		return false
+	}
+
+	var buildSteps []bitriseModels.StepListItemModel
+	switch config.ProjectType {
+	case android.ScannerName:
+		buildStep, ok := steps.Find(*config, initSteps.AndroidBuildID)
+		if !ok {
+			return false
+		}
+		buildStep = steps.WithInput(buildStep, variantInputKey, debugVariant)
+		buildStep = steps.WithInput(buildStep, buildTypeInputKey, buildTypeAPK)
+		buildSteps = []bitriseModels.StepListItemModel{
+			initSteps.AvdManagerStepListItem(),
+			buildStep,
+			initSteps.WaitForAndroidEmulatorStepListItem(),
+			steps.InstallAndroidAppStepListItem(),
+		}
+	case string(ios.XcodeProjectTypeIOS):
+		if steps.AppEnv(*config, ios.ProjectPathInputEnvKey) == "" || steps.AppEnv(*config, ios.SchemeInputEnvKey) == "" {
+			return false
+		}
+		buildSteps = []bitriseModels.StepListItemModel{
+			steps.XcodeBuildForSimulatorStepListItem(
+				envmanModels.EnvironmentItemModel{ios.ProjectPathInputKey: "$" + ios.ProjectPathInputEnvKey},
+				envmanModels.EnvironmentItemModel{ios.SchemeInputKey: "$" + ios.SchemeInputEnvKey},
+				envmanModels.EnvironmentItemModel{ios.ConfigurationInputKey: debugConfiguration},
+			),
+			steps.BootSimulatorAndInstallAppStepListItem(),
+		}
+	default:
+		return false
+	}
+
+	sourceWorkflowID := steps.WorkflowWithStep(*config, initSteps.AndroidBuildID, initSteps.XcodeTestID, initSteps.XcodeArchiveID)
+	if sourceWorkflowID == "" {
+		return false
+	}
+	var stepList []bitriseModels.StepListItemModel
+	for _, item := range config.Workflows[sourceWorkflowID].Steps {
+		if buildSetupStepIDs[steps.ID(item)] {
+			break
+		}
+		stepList = append(stepList, item)
+	}
+	stepList = append(stepList, buildSteps...)
+
+	var flowPaths []string
+	for _, flow := range flows {
+		flowPaths = append(flowPaths, strconv.Quote(flow.Path))
+	}
+	stepList = append(stepList,
+		initSteps.ScriptStepListItem("Run Maestro flows", fmt.Sprintf(flowsScript, strings.Join(flowPaths, "\n  "))+
+			steps.MaestroTestCommands("maestro_$shard_index", "Maestro (shard $shard_index)", `"${selected[@]}"`)),
+		initSteps.DeployToBitriseIoStepListItem(),
+	)
+
+	description := fmt.Sprintf(uiTestDescription, platformTitles[config.ProjectType])
+	shards := Shards(flows)
+	if shards > 1 {
+		description += shardedDescription
+	}
+	config.Workflows[UITestWorkflowID] = bitriseModels.WorkflowModel{
+		Summary:     fmt.Sprintf(uiTestSummary, platformTitles[config.ProjectType]),
+		Description: description,
+		Steps:       stepList,
+	}
+	if shards > 1 {
+		if config.Pipelines == nil {
+			config.Pipelines = map[string]bitriseModels.PipelineModel{}
+		}
+		config.Pipelines[UITestPipelineID] = bitriseModels.PipelineModel{
+			Workflows: bitriseModels.GraphPipelineWorkflowListItemModel{
+				UITestWorkflowID: {Parallel: strconv.Itoa(shards)},
+			},
+		}
+	}
+	return true
+}
+
+// appID returns the appId of the flow's config section, which precedes the --- separator of the commands.
+func appID(content string) string {
+	if idx := strings.Index(content, "\n---"); idx != -1 {
+		content = content[:idx]
+	}
+	match := appIDPattern.FindStringSubmatch(content)
+	if match == nil || strings.Contains(match[1], "$") {
+		return ""
+	}
+	return match[1]
+}
+
+// flowsScript selects every flow whose index matches the shard of the parallel workflow, it is completed with the flows
+// and followed by the Maestro test commands.
+const flowsScript = `#!/usr/bin/env bash
+set -eo pipefail
+
+flows=(
+  %s
+)
+
+shard_index="${BITRISE_IO_PARALLEL_INDEX:-0}"
+shard_total="${BITRISE_IO_PARALLEL_TOTAL:-1}"
+
+selected=()
+for index in "${!flows[@]}"; do
+  if [ $((index %% shard_total)) -eq "$shard_index" ]; then
+    selected+=("${flows[$index]}")
+  fi
+done
+
+if [ ${#selected[@]} -eq 0 ]; then
+  echo "No Maestro flows in shard $shard_index"
+  exit 0
+fi
+
+set -x
+`
@@ -0,0 +1,135 @@
+package maestro
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindFlows(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, ".maestro", "login.yaml"), "appId: io.bitrise.notes\n---\n- launchApp\n")
+	testutil.WriteFile(t, filepath.Join(dir, ".maestro", "config.yaml"), "flows:\n  - '*'\n")
+	testutil.WriteFile(t, filepath.Join(dir, ".maestro", "subflows", "logout.yaml"), "appId: io.bitrise.notes\n")
+	testutil.WriteFile(t, filepath.Join(dir, "ios", "maestro", "search.yml"), "# search\nappId: \"io.bitrise.Notes.debug\"\n---\n- launchApp\n")
+	testutil.WriteFile(t, filepath.Join(dir, "web", "maestro", "home.yaml"), "appId: ${APP_ID}\n")
+	testutil.WriteFile(t, filepath.Join(dir, "node_modules", "lib", "maestro", "flow.yaml"), "appId: io.bitrise.notes\n")
+
+	flows, err := FindFlows(dir)
+	require.NoError(t, err)
+	require.Equal(t, []Flow{
+		{Path: filepath.Join(".maestro", "login.yaml"), AppID: "io.bitrise.notes"},
+		{Path: filepath.Join("ios", "maestro", "search.yml"), AppID: "io.bitrise.Notes.debug"},
+		{Path: filepath.Join("web", "maestro", "home.yaml")},
+	}, flows)
+
+	require.Equal(t, flows[1:2], FlowsOf(flows, "io.bitrise.Notes"))
+	require.Equal(t, flows[:1], FlowsOf(append(flows, Flow{Path: "login.yaml", AppID: "io.bitrise.notes.debug"}), "io.bitrise.notes"))
+	require.Empty(t, FlowsOf(flows, "io.bitrise.notes.staging"))
+	require.Empty(t, FlowsOf(flows, ""))
+}
+
+func TestAddUITestWorkflow(t *testing.T) {
+	config := bitriseModels.BitriseDataModel{
+		ProjectType: android.ScannerName,
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			"build_apk": {Steps: []bitriseModels.StepListItemModel{
+				initSteps.GitCloneStepListItem(),
+				initSteps.InstallMissingAndroidToolsStepListItem(),
+				initSteps.AndroidLintStepListItem(),
+				initSteps.AndroidBuildStepListItem(
+					envmanModels.EnvironmentItemModel{android.ModuleInputKey: "$MODULE"},
+					envmanModels.EnvironmentItemModel{variantInputKey: "$VARIANT"},
+				),
+				initSteps.DeployToBitriseIoStepListItem(),
+			}},
+		},
+	}
+
+	require.False(t, AddUITestWorkflow(&config, nil))
+	require.True(t, AddUITestWorkflow(&config, []Flow{{Path: ".maestro/login.yaml", AppID: "io.bitrise.notes"}}))
+	require.Empty(t, config.Pipelines)
+
+	stepList := config.Workflows[UITestWorkflowID].Steps
+	require.Equal(t, []string{
+		initSteps.GitCloneID,
+		initSteps.InstallMissingAndroidToolsID,
+		initSteps.AvdManagerID,
+		initSteps.AndroidBuildID,
+		initSteps.WaitForAndroidEmulatorID,
+		initSteps.ScriptID,
+		initSteps.ScriptID,
+		initSteps.DeployToBitriseIoID,
+	}, stepIDs(stepList))
+	buildStep, err := stepList[3].GetStep()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []envmanModels.EnvironmentItemModel{
+		{android.ModuleInputKey: "$MODULE"},
+		{variantInputKey: debugVariant},
+		{buildTypeInputKey: buildTypeAPK},
+	}, buildStep.Inputs)
+	script, err := stepList[6].GetStep()
+	require.NoError(t, err)
+	require.Contains(t, script.Inputs[0]["content"], `maestro test "${selected[@]}" --format junit --output "$report_dir/report.xml"`)
+	require.Contains(t, script.Inputs[0]["content"], `report_dir="$BITRISE_TEST_RESULT_DIR/maestro_$shard_index"`)
+
+	var flows []Flow
+	for i := 0; i < 12; i++ {
+		flows = append(flows, Flow{Path: fmt.Sprintf(".maestro/flow_%d.yaml", i), AppID: "io.bitrise.notes"})
+	}
+	require.True(t, AddUITestWorkflow(&config, flows))
+	require.Equal(t, "3", config.Pipelines[UITestPipelineID].Workflows[UITestWorkflowID].Parallel)
+}
+
+func TestAddUITestWorkflowIOS(t *testing.T) {
+	config := bitriseModels.BitriseDataModel{
+		ProjectType: string(ios.XcodeProjectTypeIOS),
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			"primary": {Steps: []bitriseModels.StepListItemModel{
+				initSteps.GitCloneStepListItem(),
+				initSteps.CocoapodsInstallStepListItem(),
+				initSteps.XcodeTestStepListItem(),
+				initSteps.DeployToBitriseIoStepListItem(),
+			}},
+		},
+	}
+	flows := []Flow{{Path: ".maestro/login.yaml", AppID: "io.bitrise.Notes"}}
+	require.False(t, AddUITestWorkflow(&config, flows))
+
+	config.App.Environments = []envmanModels.EnvironmentItemModel{
+		{ios.ProjectPathInputEnvKey: "Notes.xcworkspace"},
+		{ios.SchemeInputEnvKey: "Notes"},
+	}
+	require.True(t, AddUITestWorkflow(&config, flows))
+	require.Equal(t, []string{
+		initSteps.GitCloneID,
+		initSteps.CocoapodsInstallID,
+		steps.XcodeBuildForSimulatorID,
+		initSteps.ScriptID,
+		initSteps.ScriptID,
+		initSteps.DeployToBitriseIoID,
+	}, stepIDs(config.Workflows[UITestWorkflowID].Steps))
+}
+
+func TestShards(t *testing.T) {
+	require.Equal(t, 1, Shards(make([]Flow, 5)))
+	require.Equal(t, 2, Shards(make([]Flow, 6)))
+	require.Equal(t, maxShards, Shards(make([]Flow, 100)))
+}
+
+func stepIDs(stepList []bitriseModels.StepListItemModel) []string {
+	var ids []string
+	for _, item := range stepList {
+		ids = append(ids, steps.ID(item))
+	}
+	return ids
+}
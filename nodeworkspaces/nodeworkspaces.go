@@ -0,0 +1,610 @@
+package nodeworkspaces
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-init/utility"
+	"github.com/bitrise-io/bitrise-plugins-init/cache"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ScannerName is the name of the bitrise-init Node.js scanner and the project type of its configs.
+	ScannerName = "node-js"
+	// ProjectDirEnvKey is the app env of the Node.js scanner's project directory option.
+	ProjectDirEnvKey = "NODEJS_PROJECT_DIR"
+
+	// ModeEnvKey is the app env of the workspace mode option.
+	ModeEnvKey  = "NODEJS_WORKSPACE_MODE"
+	modeTitle   = "Workspace workflows"
+	modeSummary = "Run the scripts of the whole workspace in a single workflow, or every package in its own workflow triggered by the changes of the package."
+	// ModeWholeRepo runs the scripts of every package in the run_tests workflow.
+	ModeWholeRepo = "whole-repo"
+	// ModePerPackage runs the scripts of every package in a workflow of its own.
+	ModePerPackage = "per-package"
+
+	// InstallBundleID is the ID of the step bundle installing Node.js and the dependencies of the workspace.
+	InstallBundleID    = "install_dependencies"
+	installBundleTitle = "Install dependencies"
+
+	runTestsWorkflowID = "run_tests"
+	nodeInstallTitle   = "Install Node.js"
+	workingDirInputKey = "working_dir"
+
+	npm  = "npm"
+	yarn = "yarn"
+	pnpm = "pnpm"
+
+	nx    = "nx"
+	turbo = "turbo"
+	lerna = "lerna"
+)
+
+var (
+	// tasks are the package scripts the workflows run, in the order they are run.
+	tasks = []string{"lint", "test", "build"}
+	// execCommands run the binaries of the dependencies, like turbo, with the package manager.
+	execCommands = map[string]string{npm: "npx", yarn: "yarn", pnpm: "pnpm exec"}
+	// lockFiles are the lock files of the package managers, the per-package workflows are triggered by their changes too.
+	lockFiles             = map[string]string{npm: "package-lock.json", yarn: "yarn.lock", pnpm: "pnpm-lock.yaml"}
+	workflowIDInvalidChar = regexp.MustCompile(`[^a-z0-9_-]+`)
+)
+
+// Package is a member package of a workspace.
+type Package struct {
+	Name string
+	// Dir is the directory of the package relative to the workspace root.
+	Dir string
+	// Tasks are the lint, test and build scripts of the package.
+	Tasks []string
+}
+
+// Workspace is a Node.js monorepo: the root package and the member packages its workspace globs match.
+type Workspace struct {
+	// Manager is the package manager of the workspace: npm, yarn or pnpm.
+	Manager string
+	// YarnBerry reports whether the workspace uses Yarn 2 or later.
+	YarnBerry bool
+	// Tool is the monorepo tool running the tasks of the packages: nx, turbo, lerna, or empty if none.
+	Tool string
+	// TurboTasks are the lint, test and build tasks of the Turborepo pipeline.
+	TurboTasks []string
+	Packages   []Package
+}
+
+type packageJSON struct {
+	Name       string            `json:"name"`
+	Scripts    map[string]string `json:"scripts"`
+	Workspaces json.RawMessage   `json:"workspaces"`
+}
+
+// Detect returns the workspace of the root dir, or false if the dir is not a workspace root with member packages.
+func Detect(rootDir string) (Workspace, bool, error) {
+	root, ok, err := readPackageJSON(filepath.Join(rootDir, "package.json"))
+	if err != nil || !ok {
+		return Workspace{}, false, err
+	}
+
+	var patterns []string
+	if len(root.Workspaces) > 0 {
+		var workspaces struct {
+			Packages []string `json:"packages"`
+		}
+		if err := json.Unmarshal(root.Workspaces, &patterns); err != nil {
+			if err := json.Unmarshal(root.Workspaces, &workspaces); err != nil {
+				return Workspace{}, false, fmt.Errorf("parse the workspaces of package.json: %s", err)
+			}
+			patterns = workspaces.Packages
+		}
+	}
+
+	if content, err := fsutil.ReadOptionalFile(filepath.Join(rootDir, "pnpm-workspace.yaml")); err != nil {
+		return Workspace{}, false, err
+	} else if content != "" {
+		var pnpmWorkspace struct {
+			Packages []string `yaml:"packages"`
+		}
+		if err := yaml.Unmarshal([]byte(content), &pnpmWorkspace); err != nil {
+			return Workspace{}, false, fmt.Errorf("parse pnpm-workspace.yaml: %s", err)
+		}
+		patterns = append(patterns, pnpmWorkspace.Packages...)
+	}
+
+	workspace := Workspace{Manager: npm}
+	switch {
+	case utility.FileExists(filepath.Join(rootDir, "pnpm-workspace.yaml")) || utility.FileExists(filepath.Join(rootDir, "pnpm-lock.yaml")):
+		workspace.Manager = pnpm
+	case utility.FileExists(filepath.Join(rootDir, "yarn.lock")):
+		workspace.Manager = yarn
+		workspace.YarnBerry = utility.FileExists(filepath.Join(rootDir, ".yarnrc.yml"))
+	}
+
+	switch {
+	case utility.FileExists(filepath.Join(rootDir, "nx.json")):
+		workspace.Tool = nx
+	case utility.FileExists(filepath.Join(rootDir, "turbo.json")):
+		workspace.Tool = turbo
+		if workspace.TurboTasks, err = turboTasks(filepath.Join(rootDir, "turbo.json")); err != nil {
+			return Workspace{}, false, err
+		}
+	case utility.FileExists(filepath.Join(rootDir, "lerna.json")):
+		workspace.Tool = lerna
+		content, err := os.ReadFile(filepath.Join(rootDir, "lerna.json"))
+		if err != nil {
+			return Workspace{}, false, err
+		}
+		var lernaConfig struct {
+			Packages []string `json:"packages"`
+		}
+		if err := json.Unmarshal(content, &lernaConfig); err != nil {
+			return Workspace{}, false, fmt.Errorf("parse lerna.json: %s", err)
+		}
+		if len(patterns) == 0 {
+			patterns = lernaConfig.Packages
+		}
+		if len(patterns) == 0 {
+			patterns = []string{"packages/*"}
+		}
+	}
+
+	if workspace.Packages, err = memberPackages(rootDir, patterns); err != nil {
+		return Workspace{}, false, err
+	}
+	if len(workspace.Packages) == 0 {
+		return Workspace{}, false, nil
+	}
+	return workspace, true, nil
+}
+
+// RefineOptions adds the workspace mode option to the Node.js projects which are workspace roots.
+func RefineOptions(scanResult *models.ScanResultModel, searchDir string) {
+	root, ok := scanResult.ScannerToOptionRoot[ScannerName]
+	if !ok {
+		return
+	}
+
+	for projectDir, packageManagerOption := range root.ChildOptionMap {
+		if packageManagerOption == nil {
+			continue
+		}
+		workspace, ok, err := Detect(filepath.Join(searchDir, projectDir))
+		if err != nil {
+			log.Warnf("Failed to detect the workspace of the Node.js project %s: %s", projectDir, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		for packageManager, configOption := range packageManagerOption.ChildOptionMap {
+			if configOption == nil || !configOption.IsConfigOption() {
+				continue
+			}
+			modeOption := models.NewOption(modeTitle, modeSummary, ModeEnvKey, models.TypeSelector)
+			for _, mode := range []string{ModeWholeRepo, ModePerPackage} {
+				modeOption.AddConfig(mode, models.NewConfigOption(configOption.Config, configOption.Icons))
+			}
+			packageManagerOption.AddOption(packageManager, modeOption)
+		}
+		log.Infof("Node.js workspace detected: %s (%s, %d packages)", projectDir, workspace.Description(), len(workspace.Packages))
+	}
+
+	scanResult.ScannerToOptionRoot[ScannerName] = root
+}
+
+// Description names the package manager and the monorepo tool of the workspace, like pnpm with Turborepo.
+func (w Workspace) Description() string {
+	switch w.Tool {
+	case nx:
+		return w.Manager + " with Nx"
+	case turbo:
+		return w.Manager + " with Turborepo"
+	case lerna:
+		return w.Manager + " with Lerna"
+	}
+	return w.Manager + " workspaces"
+}
+
+// Apply replaces the run_tests workflow of the Node.js workspace config with the workflows of the selected workspace
+// mode. The workflows install the dependencies of the whole workspace with a shared step bundle. It returns the IDs
+// of the workflows, or nil if the config has no workspace mode.
+func Apply(config *bitriseModels.BitriseDataModel, searchDir string) ([]string, error) {
+	mode := steps.AppEnv(*config, ModeEnvKey)
+	if config.ProjectType != ScannerName || mode == "" {
+		return nil, nil
+	}
+	runTests, ok := config.Workflows[runTestsWorkflowID]
+	if !ok {
+		return nil, nil
+	}
+
+	projectDir := steps.AppEnv(*config, ProjectDirEnvKey)
+	workspace, ok, err := Detect(filepath.Join(searchDir, projectDir))
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	var workingDir []envmanModels.EnvironmentItemModel
+	if projectDir != "" && projectDir != "." {
+		workingDir = append(workingDir, envmanModels.EnvironmentItemModel{workingDirInputKey: "$" + ProjectDirEnvKey})
+	}
+
+	// the prepare and the deploy steps are kept in the workflows, the Node.js and the dependency install steps
+	// are moved to the bundle
+	var prepareSteps, bundleSteps, deploySteps []bitriseModels.StepListItemModel
+	installed := false
+	for _, item := range runTests.Steps {
+		switch id := steps.ID(item); {
+		case id == initSteps.NpmID || id == initSteps.YarnID:
+			if installed || steps.Input(item, "command") != "install" {
+				continue
+			}
+			installed = true
+			if workspace.Manager == pnpm {
+				item = initSteps.ScriptStepListItem("pnpm install", pnpmInstallScript, workingDir...)
+			}
+			bundleSteps = append(bundleSteps, item)
+		case id == initSteps.CacheRestoreNPMID || id == initSteps.CacheSaveNPMID:
+			if workspace.Manager != pnpm {
+				bundleSteps = append(bundleSteps, item)
+			}
+		case id == initSteps.ScriptID && stepTitle(item) == nodeInstallTitle:
+			bundleSteps = append(bundleSteps, item)
+		case id == initSteps.DeployToBitriseIoID:
+			deploySteps = append(deploySteps, item)
+		case len(bundleSteps) == 0:
+			prepareSteps = append(prepareSteps, item)
+		}
+	}
+	if !installed {
+		return nil, nil
+	}
+	if workspace.Manager == pnpm {
+		restoreStep, saveStep := pnpmCacheSteps(filepath.Join(projectDir, lockFiles[pnpm]))
+		bundleSteps = steps.Insert(bundleSteps, len(bundleSteps)-1, restoreStep)
+		bundleSteps = append(bundleSteps, saveStep)
+	}
+
+	var bundleItems []bitriseModels.StepListItemStepOrBundleModel
+	for _, item := range bundleSteps {
+		bundleItems = append(bundleItems, bitriseModels.StepListItemStepOrBundleModel(item))
+	}
+	if config.StepBundles == nil {
+		config.StepBundles = map[string]bitriseModels.StepBundleModel{}
+	}
+	config.StepBundles[InstallBundleID] = bitriseModels.StepBundleModel{Title: installBundleTitle, Steps: bundleItems}
+	prepareSteps = append(prepareSteps, bitriseModels.StepListItemModel{bitriseModels.StepListItemStepBundleKeyPrefix + InstallBundleID: bitriseModels.StepBundleListItemModel{}})
+
+	if mode == ModeWholeRepo {
+		stepList := append([]bitriseModels.StepListItemModel{}, prepareSteps...)
+		if script := workspace.wholeRepoScript(); script != "" {
+			stepList = append(stepList, initSteps.ScriptStepListItem("Run the workspace tasks", script, workingDir...))
+		}
+		runTests.Steps = append(stepList, deploySteps...)
+		config.Workflows[runTestsWorkflowID] = runTests
+		return []string{runTestsWorkflowID}, nil
+	}
+
+	delete(config.Workflows, runTestsWorkflowID)
+	var workflowIDs []string
+	for _, pkg := range workspace.Packages {
+		if len(pkg.Tasks) == 0 {
+			continue
+		}
+		workflowID := packageWorkflowID(pkg, config.Workflows)
+		packageDir := filepath.ToSlash(filepath.Join(projectDir, pkg.Dir))
+
+		stepList := append([]bitriseModels.StepListItemModel{}, prepareSteps...)
+		for _, task := range pkg.Tasks {
+			stepList = append(stepList, workspace.taskStep(task, packageDir))
+		}
+		stepList = append(stepList, deploySteps...)
+		config.Workflows[workflowID] = bitriseModels.WorkflowModel{
+			Summary:     fmt.Sprintf("Run the %s scripts of the %s package.", strings.Join(pkg.Tasks, ", "), pkg.Name),
+			Description: fmt.Sprintf("The workflow will first clone your Git repository, install the dependencies of the workspace and run the %s scripts of the %s package. It is triggered by the changes of the package and the lock file.", strings.Join(pkg.Tasks, ", "), pkg.Name),
+			Triggers:    changedFilesTriggers(packageDir+"/**", filepath.ToSlash(filepath.Join(projectDir, lockFiles[workspace.Manager]))),
+			Steps:       stepList,
+		}
+		workflowIDs = append(workflowIDs, workflowID)
+	}
+	return workflowIDs, nil
+}
+
+// wholeRepoScript runs the lint, test and build tasks of every package: with the monorepo tool if the workspace has
+// one, or with the workspace commands of the package manager.
+func (w Workspace) wholeRepoScript() string {
+	packageTasks := w.packageTasks()
+	var commands []string
+	switch w.Tool {
+	case nx:
+		if len(packageTasks) > 0 {
+			return fmt.Sprintf(nxAffectedScript, execCommands[w.Manager], strings.Join(packageTasks, ","))
+		}
+	case turbo:
+		if len(w.TurboTasks) > 0 {
+			commands = append(commands, fmt.Sprintf("%s turbo run %s", execCommands[w.Manager], strings.Join(w.TurboTasks, " ")))
+		}
+	case lerna:
+		for _, task := range packageTasks {
+			commands = append(commands, fmt.Sprintf("%s lerna run %s", execCommands[w.Manager], task))
+		}
+	}
+	if len(commands) == 0 {
+		for _, task := range packageTasks {
+			commands = append(commands, w.workspaceCommands(task)...)
+		}
+	}
+	if len(commands) == 0 {
+		return ""
+	}
+	return "#!/usr/bin/env bash\nset -euxo pipefail\n\n" + strings.Join(commands, "\n") + "\n"
+}
+
+// workspaceCommands runs the script in every package which has it.
+func (w Workspace) workspaceCommands(task string) []string {
+	switch {
+	case w.Manager == pnpm:
+		return []string{"pnpm --recursive --if-present run " + task}
+	case w.Manager == yarn && w.YarnBerry:
+		return []string{"yarn workspaces foreach --all --topological run " + task}
+	case w.Manager == yarn:
+		// Yarn 1 fails the workspaces run command in the packages without the script
+		var commands []string
+		for _, pkg := range w.Packages {
+			if slices.Contains(pkg.Tasks, task) {
+				commands = append(commands, fmt.Sprintf("yarn workspace %s run %s", pkg.Name, task))
+			}
+		}
+		return commands
+	}
+	return []string{fmt.Sprintf("npm run %s --workspaces --if-present", task)}
+}
+
+// taskStep runs the script of the package in its directory.
+func (w Workspace) taskStep(task, packageDir string) bitriseModels.StepListItemModel {
+	switch w.Manager {
+	case yarn:
+		return initSteps.YarnStepListItem("run "+task, packageDir)
+	case pnpm:
+		return initSteps.ScriptStepListItem("pnpm run "+task, fmt.Sprintf(pnpmRunScript, task), envmanModels.EnvironmentItemModel{workingDirInputKey: packageDir})
+	}
+	return initSteps.NpmStepListItem("run "+task, packageDir)
+}
+
+// packageTasks returns the tasks any of the member packages has.
+func (w Workspace) packageTasks() []string {
+	var packageTasks []string
+	for _, task := range tasks {
+		for _, pkg := range w.Packages {
+			if slices.Contains(pkg.Tasks, task) {
+				packageTasks = append(packageTasks, task)
+				break
+			}
+		}
+	}
+	return packageTasks
+}
+
+const pnpmInstallScript = `#!/usr/bin/env bash
+set -euxo pipefail
+
+corepack enable
+pnpm install --frozen-lockfile
+`
+
+// pnpmRunScript is completed with the script name.
+const pnpmRunScript = `#!/usr/bin/env bash
+set -euxo pipefail
+
+pnpm run %s
+`
+
+// nxAffectedScript runs the tasks of the projects affected by the changes of the pull request, or of the pushed
+// commit. It is completed with the exec command and the tasks.
+const nxAffectedScript = `#!/usr/bin/env bash
+set -euxo pipefail
+
+# nx compares the commit with the base to find the affected projects, the git clone needs the history of the base
+if [ -n "${BITRISEIO_GIT_BRANCH_DEST:-}" ]; then
+  git fetch origin "$BITRISEIO_GIT_BRANCH_DEST"
+  base="origin/$BITRISEIO_GIT_BRANCH_DEST"
+else
+  git fetch --deepen=1
+  base="HEAD~1"
+fi
+
+%s nx affected --targets=%s --base="$base" --head=HEAD
+`
+
+// pnpmCacheSteps returns the key-based cache steps of the pnpm store, the same as the cache pass adds.
+func pnpmCacheSteps(lockFile string) (bitriseModels.StepListItemModel, bitriseModels.StepListItemModel) {
+	for _, manager := range cache.Managers {
+		if manager.Name == pnpm {
+			return cache.DetectedManager{Manager: manager, LockFilePaths: []string{lockFile}}.CacheSteps()
+		}
+	}
+	return initSteps.RestoreNPMCache(), initSteps.SaveNPMCache()
+}
+
+// changedFilesTriggers triggers the workflow by the pushes and pull requests changing any of the files.
+func changedFilesTriggers(patterns ...string) bitriseModels.Triggers {
+	var triggers bitriseModels.Triggers
+	for _, pattern := range patterns {
+		triggers.PushTriggers = append(triggers.PushTriggers, bitriseModels.PushGitEventTriggerItem{Branch: "*", ChangedFiles: pattern})
+		triggers.PullRequestTriggers = append(triggers.PullRequestTriggers, bitriseModels.PullRequestGitEventTriggerItem{SourceBranch: "*", ChangedFiles: pattern})
+	}
+	return triggers
+}
+
+// packageWorkflowID returns the run_tests_<package> ID of the package's workflow, the package name is used
+// without its scope, like run_tests_api for @acme/api.
+func packageWorkflowID(pkg Package, workflows map[string]bitriseModels.WorkflowModel) string {
+	name := pkg.Name
+	if idx := strings.LastIndex(name, "/"); idx != -1 {
+		name = name[idx+1:]
+	}
+	if name == "" {
+		name = filepath.Base(pkg.Dir)
+	}
+	workflowID := runTestsWorkflowID + "_" + strings.Trim(workflowIDInvalidChar.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if _, ok := workflows[workflowID]; !ok {
+		return workflowID
+	}
+	return runTestsWorkflowID + "_" + strings.Trim(workflowIDInvalidChar.ReplaceAllString(strings.ToLower(pkg.Dir), "_"), "_")
+}
+
+// memberPackages returns the packages the workspace globs match. The globs support the * and ** wildcards,
+// and the ! prefix excluding the matches of a glob.
+func memberPackages(rootDir string, patterns []string) ([]Package, error) {
+	var includes, excludes []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(pattern), "./"), "/")
+		if strings.HasPrefix(pattern, "!") {
+			excludes = append(excludes, strings.TrimPrefix(pattern, "!"))
+		} else if pattern != "" {
+			includes = append(includes, pattern)
+		}
+	}
+
+	dirs := map[string]bool{}
+	for _, pattern := range includes {
+		if base, ok := strings.CutSuffix(pattern, "/**"); ok {
+			if err := filepath.WalkDir(filepath.Join(rootDir, base), func(pth string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					if os.IsNotExist(err) {
+						return filepath.SkipDir
+					}
+					return err
+				}
+				if entry.IsDir() && entry.Name() == "node_modules" {
+					return filepath.SkipDir
+				}
+				if !entry.IsDir() && entry.Name() == "package.json" {
+					dir, err := filepath.Rel(rootDir, filepath.Dir(pth))
+					if err != nil {
+						return err
+					}
+					dirs[filepath.ToSlash(dir)] = true
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(rootDir, pattern, "package.json"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace glob %s: %s", pattern, err)
+		}
+		for _, match := range matches {
+			dir, err := filepath.Rel(rootDir, filepath.Dir(match))
+			if err != nil {
+				return nil, err
+			}
+			dirs[filepath.ToSlash(dir)] = true
+		}
+	}
+
+	var packages []Package
+	for dir := range dirs {
+		if dir == "." || excluded(dir, excludes) || strings.Contains(dir, "node_modules") {
+			continue
+		}
+		pkg, _, err := readPackageJSON(filepath.Join(rootDir, dir, "package.json"))
+		if err != nil {
+			return nil, err
+		}
+		name := pkg.Name
+		if name == "" {
+			name = filepath.Base(dir)
+		}
+		packages = append(packages, Package{Name: name, Dir: dir, Tasks: scriptTasks(pkg.Scripts)})
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Dir < packages[j].Dir })
+	return packages, nil
+}
+
+func excluded(dir string, excludes []string) bool {
+	for _, pattern := range excludes {
+		if base, ok := strings.CutSuffix(pattern, "/**"); ok && (dir == base || strings.HasPrefix(dir, base+"/")) {
+			return true
+		}
+		if match, err := filepath.Match(pattern, dir); err == nil && match {
+			return true
+		}
+	}
+	return false
+}
+
+// turboTasks returns the lint, test and build tasks of the Turborepo config, defined under tasks since Turborepo 2
+// and under pipeline before.
+func turboTasks(pth string) ([]string, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, err
+	}
+	var turboConfig struct {
+		Tasks    map[string]json.RawMessage `json:"tasks"`
+		Pipeline map[string]json.RawMessage `json:"pipeline"`
+	}
+	if err := json.Unmarshal(content, &turboConfig); err != nil {
+		return nil, fmt.Errorf("parse turbo.json: %s", err)
+	}
+
+	var configTasks []string
+	for _, task := range tasks {
+		_, inTasks := turboConfig.Tasks[task]
+		_, inPipeline := turboConfig.Pipeline[task]
+		if inTasks || inPipeline {
+			configTasks = append(configTasks, task)
+		}
+	}
+	return configTasks, nil
+}
+
+func scriptTasks(scripts map[string]string) []string {
+	var scriptTasks []string
+	for _, task := range tasks {
+		if _, ok := scripts[task]; ok {
+			scriptTasks = append(scriptTasks, task)
+		}
+	}
+	return scriptTasks
+}
+
+func readPackageJSON(pth string) (packageJSON, bool, error) {
+	content, err := fsutil.ReadOptionalFile(pth)
+	if err != nil || content == "" {
+		return packageJSON{}, false, err
+	}
+	var pkg packageJSON
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return packageJSON{}, false, fmt.Errorf("parse %s: %s", pth, err)
+	}
+	return pkg, true, nil
+}
+
+func stepTitle(item bitriseModels.StepListItemModel) string {
+	step, err := item.GetStep()
+	if err != nil || step.Title == nil {
+		return ""
+	}
+	return *step.Title
+}
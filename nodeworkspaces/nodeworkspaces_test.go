@@ -0,0 +1,236 @@
+package nodeworkspaces
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/toolversions"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+func npmWorkspace(t *testing.T) string {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "web", "package.json"), `{"name": "web", "workspaces": ["packages/*", "apps/**", "!packages/legacy"]}`)
+	testutil.WriteFile(t, filepath.Join(dir, "web", "package-lock.json"), "{}")
+	testutil.WriteFile(t, filepath.Join(dir, "web", "packages", "api", "package.json"), `{"name": "@acme/api", "scripts": {"test": "jest", "build": "tsc", "start": "node ."}}`)
+	testutil.WriteFile(t, filepath.Join(dir, "web", "packages", "legacy", "package.json"), `{"name": "legacy", "scripts": {"test": "mocha"}}`)
+	testutil.WriteFile(t, filepath.Join(dir, "web", "packages", "docs", "README.md"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "web", "apps", "site", "package.json"), `{"name": "site", "scripts": {"lint": "eslint ."}}`)
+	testutil.WriteFile(t, filepath.Join(dir, "web", "apps", "site", "node_modules", "dep", "package.json"), `{"name": "dep"}`)
+	return dir
+}
+
+func nodeConfig(envs ...envmanModels.EnvironmentItemModel) bitriseModels.BitriseDataModel {
+	config := bitriseModels.BitriseDataModel{
+		ProjectType: ScannerName,
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			runTestsWorkflowID: {Steps: []bitriseModels.StepListItemModel{
+				initSteps.GitCloneStepListItem(),
+				initSteps.ScriptStepListItem(nodeInstallTitle, "asdf install nodejs"),
+				initSteps.RestoreNPMCache(),
+				initSteps.NpmStepListItem("install", "$NODEJS_PROJECT_DIR"),
+				initSteps.NpmStepListItem("run test", "$NODEJS_PROJECT_DIR"),
+				initSteps.SaveNPMCache(),
+				initSteps.DeployToBitriseIoStepListItem(),
+			}},
+		},
+	}
+	config.App.Environments = envs
+	return config
+}
+
+func TestDetect(t *testing.T) {
+	dir := npmWorkspace(t)
+
+	workspace, ok, err := Detect(filepath.Join(dir, "web"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, Workspace{
+		Manager: npm,
+		Packages: []Package{
+			{Name: "site", Dir: "apps/site", Tasks: []string{"lint"}},
+			{Name: "@acme/api", Dir: "packages/api", Tasks: []string{"test", "build"}},
+		},
+	}, workspace)
+
+	_, ok, err = Detect(filepath.Join(dir, "web", "packages", "api"))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestDetectTools(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "package.json"), `{"name": "root"}`)
+	testutil.WriteFile(t, filepath.Join(dir, "pnpm-workspace.yaml"), "packages:\n  - 'libs/*'\n")
+	testutil.WriteFile(t, filepath.Join(dir, "turbo.json"), `{"tasks": {"build": {}, "test": {"dependsOn": ["build"]}, "dev": {}}}`)
+	testutil.WriteFile(t, filepath.Join(dir, "libs", "ui", "package.json"), `{"name": "ui", "scripts": {"test": "vitest"}}`)
+
+	workspace, ok, err := Detect(dir)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, pnpm, workspace.Manager)
+	require.Equal(t, turbo, workspace.Tool)
+	require.Equal(t, []string{"test", "build"}, workspace.TurboTasks)
+	require.Equal(t, "pnpm with Turborepo", workspace.Description())
+	require.Equal(t, "#!/usr/bin/env bash\nset -euxo pipefail\n\npnpm exec turbo run test build\n", workspace.wholeRepoScript())
+
+	dir = t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "package.json"), `{"name": "root"}`)
+	testutil.WriteFile(t, filepath.Join(dir, "yarn.lock"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "lerna.json"), `{"version": "independent"}`)
+	testutil.WriteFile(t, filepath.Join(dir, "packages", "core", "package.json"), `{"name": "core", "scripts": {"lint": "eslint ."}}`)
+
+	workspace, ok, err = Detect(dir)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, yarn, workspace.Manager)
+	require.Equal(t, lerna, workspace.Tool)
+	require.Equal(t, "#!/usr/bin/env bash\nset -euxo pipefail\n\nyarn lerna run lint\n", workspace.wholeRepoScript())
+}
+
+func TestWorkspaceCommands(t *testing.T) {
+	workspace := Workspace{Manager: yarn, Packages: []Package{
+		{Name: "a", Tasks: []string{"test"}},
+		{Name: "b", Tasks: []string{"lint"}},
+	}}
+	require.Equal(t, []string{"yarn workspace a run test"}, workspace.workspaceCommands("test"))
+
+	workspace.YarnBerry = true
+	require.Equal(t, []string{"yarn workspaces foreach --all --topological run test"}, workspace.workspaceCommands("test"))
+
+	workspace.Manager = npm
+	require.Equal(t, []string{"npm run test --workspaces --if-present"}, workspace.workspaceCommands("test"))
+}
+
+func TestRefineOptions(t *testing.T) {
+	dir := npmWorkspace(t)
+
+	packageManagerOption := func() *models.OptionNode {
+		option := models.NewOption("Package Manager", "", "", models.TypeSelector)
+		option.AddConfig("npm", models.NewConfigOption("node-js-npm-test-config", nil))
+		return option
+	}
+	root := models.NewOption("Project Directory", "", ProjectDirEnvKey, models.TypeSelector)
+	root.AddOption("web", packageManagerOption())
+	root.AddOption("web/packages/api", packageManagerOption())
+	scanResult := models.ScanResultModel{ScannerToOptionRoot: map[string]models.OptionNode{ScannerName: *root}}
+
+	RefineOptions(&scanResult, dir)
+
+	refined := scanResult.ScannerToOptionRoot[ScannerName]
+	modeOption := refined.ChildOptionMap["web"].ChildOptionMap["npm"]
+	require.Equal(t, ModeEnvKey, modeOption.EnvKey)
+	require.Equal(t, "node-js-npm-test-config", modeOption.ChildOptionMap[ModePerPackage].Config)
+	require.True(t, refined.ChildOptionMap["web/packages/api"].ChildOptionMap["npm"].IsConfigOption())
+}
+
+func TestApplyWholeRepo(t *testing.T) {
+	dir := npmWorkspace(t)
+	config := nodeConfig(
+		envmanModels.EnvironmentItemModel{ProjectDirEnvKey: "web"},
+		envmanModels.EnvironmentItemModel{ModeEnvKey: ModeWholeRepo},
+	)
+
+	workflowIDs, err := Apply(&config, dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{runTestsWorkflowID}, workflowIDs)
+
+	bundle := config.StepBundles[InstallBundleID]
+	require.Len(t, bundle.Steps, 4)
+	stepList := config.Workflows[runTestsWorkflowID].Steps
+	require.Equal(t, []string{initSteps.GitCloneID, InstallBundleID, initSteps.ScriptID, initSteps.DeployToBitriseIoID}, stepIDs(stepList))
+
+	script, err := stepList[2].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, "#!/usr/bin/env bash\nset -euxo pipefail\n\nnpm run lint --workspaces --if-present\nnpm run test --workspaces --if-present\nnpm run build --workspaces --if-present\n", script.Inputs[0]["content"])
+	require.Contains(t, script.Inputs, envmanModels.EnvironmentItemModel{workingDirInputKey: "$NODEJS_PROJECT_DIR"})
+}
+
+func TestApplyNodeVersionFile(t *testing.T) {
+	dir := npmWorkspace(t)
+	testutil.WriteFile(t, filepath.Join(dir, ".nvmrc"), "20.11.0\n")
+	config := nodeConfig(
+		envmanModels.EnvironmentItemModel{ProjectDirEnvKey: "web"},
+		envmanModels.EnvironmentItemModel{ModeEnvKey: ModeWholeRepo},
+	)
+
+	_, err := Apply(&config, dir)
+	require.NoError(t, err)
+	tools, err := toolversions.Detect(dir)
+	require.NoError(t, err)
+	toolversions.AddSetupStep(&config, tools)
+
+	// Node.js is installed by the tool setup step only, not by the bundle too
+	stepList := steps.WithBundleSteps(config.Workflows[runTestsWorkflowID].Steps, config.StepBundles)
+	var titles []string
+	for _, item := range stepList {
+		step, err := item.GetStep()
+		require.NoError(t, err)
+		if step.Title != nil {
+			titles = append(titles, *step.Title)
+		}
+	}
+	require.Contains(t, titles, toolversions.SetupStepTitle)
+	require.NotContains(t, titles, nodeInstallTitle)
+	require.Len(t, config.StepBundles[InstallBundleID].Steps, 3)
+}
+
+func TestApplyPerPackage(t *testing.T) {
+	dir := npmWorkspace(t)
+	config := nodeConfig(
+		envmanModels.EnvironmentItemModel{ProjectDirEnvKey: "web"},
+		envmanModels.EnvironmentItemModel{ModeEnvKey: ModePerPackage},
+	)
+
+	workflowIDs, err := Apply(&config, dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"run_tests_site", "run_tests_api"}, workflowIDs)
+	require.NotContains(t, config.Workflows, runTestsWorkflowID)
+
+	workflow := config.Workflows["run_tests_api"]
+	require.Equal(t, []string{initSteps.GitCloneID, InstallBundleID, initSteps.NpmID, initSteps.NpmID, initSteps.DeployToBitriseIoID}, stepIDs(workflow.Steps))
+	test, err := workflow.Steps[2].GetStep()
+	require.NoError(t, err)
+	require.Contains(t, test.Inputs, envmanModels.EnvironmentItemModel{"workdir": "web/packages/api"})
+	require.Equal(t, []bitriseModels.PushGitEventTriggerItem{
+		{Branch: "*", ChangedFiles: "web/packages/api/**"},
+		{Branch: "*", ChangedFiles: "web/package-lock.json"},
+	}, workflow.Triggers.PushTriggers)
+	require.Len(t, workflow.Triggers.PullRequestTriggers, 2)
+}
+
+func TestApplyPnpm(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "package.json"), `{"name": "root"}`)
+	testutil.WriteFile(t, filepath.Join(dir, "pnpm-workspace.yaml"), "packages:\n  - 'libs/*'\n")
+	testutil.WriteFile(t, filepath.Join(dir, "libs", "ui", "package.json"), `{"name": "ui", "scripts": {"test": "vitest"}}`)
+	config := nodeConfig(
+		envmanModels.EnvironmentItemModel{ProjectDirEnvKey: "."},
+		envmanModels.EnvironmentItemModel{ModeEnvKey: ModePerPackage},
+	)
+
+	_, err := Apply(&config, dir)
+	require.NoError(t, err)
+
+	var bundleIDs []string
+	for _, item := range config.StepBundles[InstallBundleID].Steps {
+		bundleIDs = append(bundleIDs, steps.ID(bitriseModels.StepListItemModel(item)))
+	}
+	require.Equal(t, []string{initSteps.ScriptID, steps.RestoreCacheID, initSteps.ScriptID, steps.SaveCacheID}, bundleIDs)
+	require.Equal(t, []string{initSteps.GitCloneID, InstallBundleID, initSteps.ScriptID, initSteps.DeployToBitriseIoID}, stepIDs(config.Workflows["run_tests_ui"].Steps))
+}
+
+func stepIDs(stepList []bitriseModels.StepListItemModel) []string {
+	var ids []string
+	for _, item := range stepList {
+		ids = append(ids, strings.TrimPrefix(steps.ID(item), bitriseModels.StepListItemStepBundleKeyPrefix))
+	}
+	return ids
+}
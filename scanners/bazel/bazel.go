@@ -0,0 +1,463 @@
+package bazel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	"github.com/bitrise-io/bitrise-init/scanners/java"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/scannerutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	ScannerName = "bazel"
+
+	configName          = "bazel-config"
+	buildOnlyConfigName = "bazel-build-config"
+	defaultConfigName   = "default-bazel-config"
+
+	workspaceDirInputTitle   = "Bazel workspace directory"
+	workspaceDirInputSummary = "The directory of the `MODULE.bazel` or `WORKSPACE` file, relative to the repository root."
+	workspaceDirInputEnvKey  = "BAZEL_WORKSPACE_DIR"
+
+	testTargetsInputTitle   = "Bazel test targets"
+	testTargetsInputSummary = "The target pattern `bazel test` runs, for example `//...` for every test target of the workspace."
+	testTargetsInputEnvKey  = "BAZEL_TEST_TARGETS"
+
+	buildTargetsInputTitle   = "Bazel build targets"
+	buildTargetsInputSummary = "The target pattern `bazel build` builds, for example `//...` for every target of the workspace."
+	buildTargetsInputEnvKey  = "BAZEL_BUILD_TARGETS"
+
+	testWorkflowID  = "run_tests"
+	buildWorkflowID = "build"
+
+	// defaultDiskCacheDir is used as the Bazel disk cache if the .bazelrc file does not set one.
+	defaultDiskCacheDir = "~/.cache/bazel-disk"
+	// ciConfigName is the .bazelrc config passed to every command if the .bazelrc file defines it.
+	ciConfigName = "ci"
+
+	searchDepth = 8
+)
+
+// workspaceFileNames mark the root of a Bazel workspace, in the order they are looked for.
+var workspaceFileNames = []string{"MODULE.bazel", "WORKSPACE.bazel", "WORKSPACE"}
+
+// cacheKeyFileNames are the workspace files the external dependencies are resolved from.
+var cacheKeyFileNames = []string{".bazelversion", "MODULE.bazel", "MODULE.bazel.lock", "WORKSPACE.bazel", "WORKSPACE"}
+
+var (
+	// a top-level rule or macro call of a BUILD file: `cc_test(`
+	ruleCallPattern = regexp.MustCompile(`(?m)^([A-Za-z_][A-Za-z0-9_.]*)\s*\(`)
+	// a command specific option of a .bazelrc config: `build:ci --disk_cache=...`
+	bazelrcConfigPattern    = regexp.MustCompile(`^\s*\w+:([\w-]+)\s`)
+	bazelrcDiskCachePattern = regexp.MustCompile(`--disk_cache[=\s]+"?([^"\s]+)"?`)
+)
+
+// nonTargetCalls are the BUILD file functions which do not declare a target.
+var nonTargetCalls = []string{"load", "package", "exports_files", "licenses", "package_group", "glob", "select"}
+
+// Workspace is a Bazel workspace with the top-level target patterns of its BUILD files.
+type Workspace struct {
+	// Dir is relative to the search dir, "." for the search dir itself.
+	Dir          string
+	BazelVersion string
+	// Configs are the named configs of the .bazelrc file.
+	Configs      []string
+	DiskCacheDir string
+	// TestTargets and BuildTargets are `//...` followed by the top-level packages with test and non-test targets.
+	TestTargets  []string
+	BuildTargets []string
+	// CacheKeyFiles are the workspace files present, relative to the search dir.
+	CacheKeyFiles []string
+	ruleKinds     []string
+}
+
+// Scanner detects Bazel workspaces.
+type Scanner struct {
+	workspace *Workspace
+}
+
+// NewScanner ...
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// Name ...
+func (*Scanner) Name() string {
+	return ScannerName
+}
+
+// DetectPlatform looks for the shallowest Bazel workspace of the search dir and inspects its BUILD files.
+func (s *Scanner) DetectPlatform(searchDir string) (bool, error) {
+	log.TInfof("Searching for Bazel workspace files...")
+
+	rootEntry, err := direntry.WalkDir(searchDir, searchDepth)
+	if err != nil {
+		return false, err
+	}
+
+	var workspaceEntry *direntry.DirEntry
+	for _, fileName := range workspaceFileNames {
+		entry := rootEntry.FindFirstEntryByName(fileName, false)
+		if entry == nil {
+			continue
+		}
+		if workspaceEntry == nil || depth(entry.RelPath) < depth(workspaceEntry.RelPath) {
+			workspaceEntry = entry
+		}
+	}
+	if workspaceEntry == nil {
+		log.TPrintf("platform not detected")
+		return false, nil
+	}
+
+	workspaceDir := workspaceEntry.Parent()
+	log.TPrintf("Bazel workspace found: %s", workspaceEntry.RelPath)
+
+	workspace, err := inspectWorkspace(*workspaceDir)
+	if err != nil {
+		return false, err
+	}
+	s.workspace = workspace
+
+	if workspace.BazelVersion != "" {
+		log.TPrintf("Bazel version: %s", workspace.BazelVersion)
+	}
+	if len(workspace.Configs) > 0 {
+		log.TPrintf(".bazelrc configs: %s", strings.Join(workspace.Configs, ", "))
+	}
+	log.TPrintf("Test target patterns: %s", strings.Join(workspace.TestTargets, ", "))
+	log.TPrintf("Build target patterns: %s", strings.Join(workspace.BuildTargets, ", "))
+
+	log.TSuccessf("Platform detected")
+	return true, nil
+}
+
+// ExcludedScannerNames returns the scanners of the platforms the Bazel workspace builds with its own rules,
+// the Xcode and Gradle projects of a Bazel managed repository are not built by xcodebuild or Gradle.
+func (s *Scanner) ExcludedScannerNames() []string {
+	if s.workspace == nil {
+		return nil
+	}
+
+	var excluded []string
+	if s.workspace.hasRules("ios_", "macos_", "apple_", "swift_") {
+		excluded = append(excluded, string(ios.XcodeProjectTypeIOS), string(ios.XcodeProjectTypeMacOS))
+	}
+	if s.workspace.hasRules("android_", "kt_android_") {
+		excluded = append(excluded, android.ScannerName)
+	}
+	if s.workspace.hasRules("android_", "kt_android_", "java_", "kt_jvm_") {
+		excluded = append(excluded, java.ProjectType)
+	}
+	return excluded
+}
+
+// Options ...
+func (s *Scanner) Options() (models.OptionNode, models.Warnings, models.Icons, error) {
+	workspace := s.workspace
+	workspaceDirOption := models.NewOption(workspaceDirInputTitle, workspaceDirInputSummary, workspaceDirInputEnvKey, models.TypeSelector)
+
+	if len(workspace.TestTargets) == 0 {
+		buildTargetsOption := models.NewOption(buildTargetsInputTitle, buildTargetsInputSummary, buildTargetsInputEnvKey, models.TypeSelector)
+		workspaceDirOption.AddOption(workspace.Dir, buildTargetsOption)
+		for _, pattern := range workspace.BuildTargets {
+			buildTargetsOption.AddConfig(pattern, models.NewConfigOption(buildOnlyConfigName, nil))
+		}
+		return *workspaceDirOption, nil, nil, nil
+	}
+
+	testTargetsOption := models.NewOption(testTargetsInputTitle, testTargetsInputSummary, testTargetsInputEnvKey, models.TypeSelector)
+	workspaceDirOption.AddOption(workspace.Dir, testTargetsOption)
+	for _, testPattern := range workspace.TestTargets {
+		buildTargetsOption := models.NewOption(buildTargetsInputTitle, buildTargetsInputSummary, buildTargetsInputEnvKey, models.TypeSelector)
+		testTargetsOption.AddOption(testPattern, buildTargetsOption)
+		for _, buildPattern := range workspace.BuildTargets {
+			buildTargetsOption.AddConfig(buildPattern, models.NewConfigOption(configName, nil))
+		}
+	}
+
+	return *workspaceDirOption, nil, nil, nil
+}
+
+// DefaultOptions ...
+func (*Scanner) DefaultOptions() models.OptionNode {
+	workspaceDirOption := models.NewOption(workspaceDirInputTitle, workspaceDirInputSummary, workspaceDirInputEnvKey, models.TypeUserInput)
+
+	testTargetsOption := models.NewOption(testTargetsInputTitle, testTargetsInputSummary, testTargetsInputEnvKey, models.TypeUserInput)
+	workspaceDirOption.AddOption(models.UserInputOptionDefaultValue, testTargetsOption)
+
+	buildTargetsOption := models.NewOption(buildTargetsInputTitle, buildTargetsInputSummary, buildTargetsInputEnvKey, models.TypeUserInput)
+	testTargetsOption.AddOption(models.UserInputOptionDefaultValue, buildTargetsOption)
+
+	buildTargetsOption.AddConfig(models.UserInputOptionDefaultValue, models.NewConfigOption(defaultConfigName, nil))
+
+	return *workspaceDirOption
+}
+
+// Configs ...
+func (s *Scanner) Configs(sshKeyActivation models.SSHKeyActivation) (models.BitriseConfigMap, error) {
+	workspace := *s.workspace
+	name := configName
+	if len(workspace.TestTargets) == 0 {
+		name = buildOnlyConfigName
+	}
+
+	config, err := generateConfig(workspace, sshKeyActivation, len(workspace.TestTargets) > 0)
+	if err != nil {
+		return models.BitriseConfigMap{}, err
+	}
+
+	return models.BitriseConfigMap{name: config}, nil
+}
+
+// DefaultConfigs ...
+func (*Scanner) DefaultConfigs() (models.BitriseConfigMap, error) {
+	workspace := Workspace{Dir: "$" + workspaceDirInputEnvKey, DiskCacheDir: defaultDiskCacheDir}
+	config, err := generateConfig(workspace, models.SSHKeyActivationConditional, true)
+	if err != nil {
+		return models.BitriseConfigMap{}, err
+	}
+
+	return models.BitriseConfigMap{defaultConfigName: config}, nil
+}
+
+// bazelCommand is a workflow running a single Bazel command on the target pattern set in the env.
+type bazelCommand struct {
+	workflowID models.WorkflowID
+	command    string
+	envKey     string
+}
+
+func generateConfig(workspace Workspace, sshKeyActivation models.SSHKeyActivation, withTests bool) (string, error) {
+	configBuilder := models.NewDefaultConfigBuilder()
+
+	var commands []bazelCommand
+	if withTests {
+		commands = append(commands, bazelCommand{workflowID: testWorkflowID, command: "test", envKey: testTargetsInputEnvKey})
+	}
+	commands = append(commands, bazelCommand{workflowID: buildWorkflowID, command: "build", envKey: buildTargetsInputEnvKey})
+
+	cacheKey := workspace.cacheKey()
+	for _, command := range commands {
+		configBuilder.AppendStepListItemsTo(command.workflowID, initSteps.DefaultPrepareStepList(initSteps.PrepareListParams{SSHKeyActivation: sshKeyActivation})...)
+		configBuilder.AppendStepListItemsTo(command.workflowID,
+			initSteps.ScriptStepListItem("Set up Bazelisk", setupScriptContent(workspace.BazelVersion)),
+			// the disk cache changes with every commit, the latest one of the same dependencies is restored
+			steps.RestoreCacheStepListItem("Restore Bazel cache", cacheKey+"-{{ .CommitHash }}\n"+cacheKey+"-\n{{ .OS }}-{{ .Arch }}-bazel-"),
+			initSteps.ScriptStepListItem("Bazel "+command.command, commandScriptContent(workspace, command),
+				envmanModels.EnvironmentItemModel{"working_dir": "$" + workspaceDirInputEnvKey},
+			),
+			steps.SaveCacheStepListItem("Save Bazel cache", cacheKey+"-{{ .CommitHash }}", workspace.DiskCacheDir),
+		)
+		configBuilder.AppendStepListItemsTo(command.workflowID, initSteps.DefaultDeployStepList()...)
+	}
+
+	return scannerutil.GenerateConfig(configBuilder, ScannerName)
+}
+
+func setupScriptContent(bazelVersion string) string {
+	versionComment := "# Bazelisk runs the latest Bazel release, as the workspace has no .bazelversion file"
+	if bazelVersion != "" {
+		versionComment = fmt.Sprintf("# Bazelisk runs Bazel %s, as set in the .bazelversion file", bazelVersion)
+	}
+
+	return `#!/usr/bin/env bash
+set -euxo pipefail
+
+` + versionComment + `
+if ! command -v bazelisk &> /dev/null; then
+  case "$(uname -s)" in
+    Darwin)
+      brew install bazelisk
+      ;;
+    *)
+      sudo curl -fsSL -o /usr/local/bin/bazelisk "https://github.com/bazelbuild/bazelisk/releases/latest/download/bazelisk-linux-$(dpkg --print-architecture)"
+      sudo chmod +x /usr/local/bin/bazelisk
+      ;;
+  esac
+fi
+bazelisk version
+`
+}
+
+func commandScriptContent(workspace Workspace, command bazelCommand) string {
+	var flags []string
+	for _, config := range workspace.Configs {
+		if config == ciConfigName {
+			flags = append(flags, "--config="+ciConfigName)
+		}
+	}
+	if workspace.DiskCacheDir == defaultDiskCacheDir {
+		flags = append(flags, `--disk_cache="$HOME/.cache/bazel-disk"`)
+	}
+
+	args := append([]string{command.command}, flags...)
+	args = append(args, "$"+command.envKey)
+	return fmt.Sprintf(`#!/usr/bin/env bash
+set -euxo pipefail
+
+bazelisk %s
+`, strings.Join(args, " "))
+}
+
+// cacheKey returns the key of the disk cache, which changes when the Bazel version or the external dependencies change.
+func (w Workspace) cacheKey() string {
+	if len(w.CacheKeyFiles) == 0 {
+		return "{{ .OS }}-{{ .Arch }}-bazel"
+	}
+
+	var quotedPaths []string
+	for _, pth := range w.CacheKeyFiles {
+		quotedPaths = append(quotedPaths, fmt.Sprintf("%q", pth))
+	}
+	return fmt.Sprintf("{{ .OS }}-{{ .Arch }}-bazel-{{ checksum %s }}", strings.Join(quotedPaths, " "))
+}
+
+func (w Workspace) hasRules(prefixes ...string) bool {
+	for _, kind := range w.ruleKinds {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(kind, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func inspectWorkspace(workspaceDir direntry.DirEntry) (*Workspace, error) {
+	workspace := Workspace{Dir: fsutil.RelPath(workspaceDir.RelPath), DiskCacheDir: defaultDiskCacheDir}
+
+	for _, fileName := range cacheKeyFileNames {
+		if workspaceDir.FindImmediateChildByName(fileName, false) != nil {
+			workspace.CacheKeyFiles = append(workspace.CacheKeyFiles, filepath.Join(workspace.Dir, fileName))
+		}
+	}
+
+	version, err := fsutil.ReadOptionalFile(filepath.Join(workspaceDir.AbsPath, ".bazelversion"))
+	if err != nil {
+		return nil, err
+	}
+	workspace.BazelVersion = strings.TrimSpace(version)
+
+	bazelrc, err := fsutil.ReadOptionalFile(filepath.Join(workspaceDir.AbsPath, ".bazelrc"))
+	if err != nil {
+		return nil, err
+	}
+	workspace.Configs, workspace.DiskCacheDir = parseBazelrc(bazelrc)
+	if workspace.DiskCacheDir == "" {
+		workspace.DiskCacheDir = defaultDiskCacheDir
+	} else if !strings.HasPrefix(workspace.DiskCacheDir, "~") && !filepath.IsAbs(workspace.DiskCacheDir) {
+		// relative to the workspace, the cache steps run in the repository root
+		workspace.DiskCacheDir = filepath.Join(workspace.Dir, workspace.DiskCacheDir)
+	}
+
+	var buildFiles []direntry.DirEntry
+	for _, name := range []string{"BUILD", "BUILD.bazel"} {
+		buildFiles = append(buildFiles, workspaceDir.FindAllEntriesByName(name, false)...)
+	}
+
+	testPackages, buildPackages := map[string]bool{}, map[string]bool{}
+	kinds := map[string]bool{}
+	for _, buildFile := range buildFiles {
+		content, err := os.ReadFile(buildFile.AbsPath)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %s", buildFile.RelPath, err)
+		}
+
+		pkg := topLevelPattern(workspaceDir.AbsPath, filepath.Dir(buildFile.AbsPath))
+		for _, kind := range parseRuleKinds(string(content)) {
+			kinds[kind] = true
+			if isTestRule(kind) {
+				testPackages[pkg] = true
+			} else {
+				buildPackages[pkg] = true
+			}
+		}
+	}
+
+	workspace.TestTargets = targetPatterns(testPackages)
+	workspace.BuildTargets = targetPatterns(buildPackages)
+	if len(workspace.BuildTargets) == 0 {
+		workspace.BuildTargets = []string{"//..."}
+	}
+	for kind := range kinds {
+		workspace.ruleKinds = append(workspace.ruleKinds, kind)
+	}
+	sort.Strings(workspace.ruleKinds)
+
+	return &workspace, nil
+}
+
+// parseBazelrc returns the named configs and the disk cache dir set in the .bazelrc file.
+func parseBazelrc(content string) ([]string, string) {
+	var configs []string
+	diskCacheDir := ""
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if match := bazelrcConfigPattern.FindStringSubmatch(line); match != nil && !slices.Contains(configs, match[1]) {
+			configs = append(configs, match[1])
+		}
+		if match := bazelrcDiskCachePattern.FindStringSubmatch(line); match != nil {
+			diskCacheDir = match[1]
+		}
+	}
+	return configs, diskCacheDir
+}
+
+// parseRuleKinds returns the rules and macros the BUILD file declares targets with.
+func parseRuleKinds(content string) []string {
+	var kinds []string
+	for _, match := range ruleCallPattern.FindAllStringSubmatch(content, -1) {
+		if !slices.Contains(nonTargetCalls, match[1]) {
+			kinds = append(kinds, match[1])
+		}
+	}
+	return kinds
+}
+
+func isTestRule(kind string) bool {
+	return strings.HasSuffix(kind, "_test") || kind == "test_suite"
+}
+
+// topLevelPattern returns the target pattern of the top-level package the package dir belongs to:
+// `//:all` for the root package and `//<dir>/...` for the packages under a top-level dir.
+func topLevelPattern(workspaceDir, packageDir string) string {
+	rel, err := filepath.Rel(workspaceDir, packageDir)
+	if err != nil || rel == "." {
+		return "//:all"
+	}
+	return "//" + strings.Split(filepath.ToSlash(rel), "/")[0] + "/..."
+}
+
+func targetPatterns(packages map[string]bool) []string {
+	if len(packages) == 0 {
+		return nil
+	}
+
+	var patterns []string
+	for pattern := range packages {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	return append([]string{"//..."}, patterns...)
+}
+
+func depth(relPth string) int {
+	return strings.Count(relPth, "/")
+}
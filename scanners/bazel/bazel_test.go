@@ -0,0 +1,100 @@
+package bazel
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "monorepo", "MODULE.bazel"), `module(name = "monorepo")`)
+	testutil.WriteFile(t, filepath.Join(dir, "monorepo", ".bazelversion"), "7.1.0\n")
+	testutil.WriteFile(t, filepath.Join(dir, "monorepo", ".bazelrc"), "# CI\nbuild:ci --disk_cache=.cache/bazel\ntest:ci --test_output=errors\nbuild:release -c opt\n")
+	testutil.WriteFile(t, filepath.Join(dir, "monorepo", "BUILD.bazel"), "exports_files([\"LICENSE\"])\n")
+	testutil.WriteFile(t, filepath.Join(dir, "monorepo", "app", "BUILD.bazel"), `load("@rules_apple//apple:ios.bzl", "ios_application")
+
+ios_application(
+    name = "App",
+)
+`)
+	testutil.WriteFile(t, filepath.Join(dir, "monorepo", "lib", "core", "BUILD"), "swift_library(name = \"core\")\nswift_test(name = \"core_test\")\n")
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	require.Equal(t, Workspace{
+		Dir:           "monorepo",
+		BazelVersion:  "7.1.0",
+		Configs:       []string{"ci", "release"},
+		DiskCacheDir:  "monorepo/.cache/bazel",
+		TestTargets:   []string{"//...", "//lib/..."},
+		BuildTargets:  []string{"//...", "//app/...", "//lib/..."},
+		CacheKeyFiles: []string{"monorepo/.bazelversion", "monorepo/MODULE.bazel"},
+		ruleKinds:     []string{"ios_application", "swift_library", "swift_test"},
+	}, *scanner.workspace)
+	require.Equal(t, []string{"ios", "macos"}, scanner.ExcludedScannerNames())
+
+	options, _, _, err := scanner.Options()
+	require.NoError(t, err)
+	testTargetsOption, ok := options.Child("monorepo", "//lib/...")
+	require.True(t, ok)
+	require.Equal(t, buildTargetsInputEnvKey, testTargetsOption.EnvKey)
+	require.ElementsMatch(t, []string{"//...", "//app/...", "//lib/..."}, testTargetsOption.GetValues())
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs[configName]), &config))
+
+	testStep, err := config.Workflows[testWorkflowID].Steps[3].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, "#!/usr/bin/env bash\nset -euxo pipefail\n\nbazelisk test --config=ci $BAZEL_TEST_TARGETS\n", testStep.Inputs[0]["content"])
+
+	saveCacheStep, err := config.Workflows[buildWorkflowID].Steps[4].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, `{{ .OS }}-{{ .Arch }}-bazel-{{ checksum "monorepo/.bazelversion" "monorepo/MODULE.bazel" }}-{{ .CommitHash }}`, saveCacheStep.Inputs[0]["key"])
+	require.Equal(t, "monorepo/.cache/bazel", saveCacheStep.Inputs[1]["paths"])
+}
+
+func TestScanner_buildOnly(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "WORKSPACE"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "BUILD"), "cc_binary(name = \"tool\")\n")
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+	require.Empty(t, scanner.ExcludedScannerNames())
+
+	options, _, _, err := scanner.Options()
+	require.NoError(t, err)
+	buildTargetsOption, ok := options.Child(".")
+	require.True(t, ok)
+	require.ElementsMatch(t, []string{"//...", "//:all"}, buildTargetsOption.GetValues())
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs[buildOnlyConfigName]), &config))
+	require.NotContains(t, config.Workflows, testWorkflowID)
+	require.Contains(t, config.Workflows, buildWorkflowID)
+}
+
+func TestScanner_notDetected(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "BUILD.bazel"), "")
+
+	detected, err := NewScanner().DetectPlatform(dir)
+	require.NoError(t, err)
+	require.False(t, detected)
+}
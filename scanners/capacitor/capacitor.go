@@ -0,0 +1,446 @@
+package capacitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-init/scanners/cordova"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-init/utility"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/scannerutil"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	ScannerName = "capacitor"
+
+	// ionicScannerName is the bitrise-init scanner which detects the Capacitor based Ionic projects and fails on the missing config.xml.
+	ionicScannerName = "ionic"
+
+	projectDirInputTitle   = "Capacitor project directory"
+	projectDirInputSummary = "The directory of the `capacitor.config` and `package.json` files, relative to the repository root."
+	projectDirInputEnvKey  = "CAPACITOR_PROJECT_DIR"
+
+	platformInputTitle   = "Platform"
+	platformInputSummary = "The native platform the app is synced and built for."
+	platformInputEnvKey  = "CAPACITOR_PLATFORM"
+
+	testWorkflowID = "run_tests"
+
+	platformAndroid = "android"
+	platformIOS     = "ios"
+
+	defaultModule  = "app"
+	defaultVariant = "Release"
+
+	// the native projects are at these paths of the project dir, unless the capacitor config moves them
+	iosProjectDir     = "ios/App"
+	androidProjectDir = "android"
+
+	searchDepth = 4
+)
+
+// configFileNames are the Capacitor config files, in the order the Capacitor CLI looks for them.
+var configFileNames = []string{"capacitor.config.ts", "capacitor.config.js", "capacitor.config.json"}
+
+var (
+	webDirPattern      = regexp.MustCompile(`["']?webDir["']?\s*:\s*["']([^"']+)["']`)
+	iosPathPattern     = regexp.MustCompile(`(?s)["']?ios["']?\s*:\s*\{[^}]*?["']?path["']?\s*:\s*["']([^"']+)["']`)
+	androidPathPattern = regexp.MustCompile(`(?s)["']?android["']?\s*:\s*\{[^}]*?["']?path["']?\s*:\s*["']([^"']+)["']`)
+)
+
+// Project is a Capacitor project with at least one native project.
+type Project struct {
+	// Dir is relative to the search dir, "." for the search dir itself.
+	Dir    string
+	WebDir string
+	// IOSProjects are the Xcode projects of the ios platform, their paths are relative to the search dir.
+	IOSProjects ios.DetectResult
+	// AndroidProjectDir is the Gradle project of the android platform relative to the search dir, empty if the project has none.
+	AndroidProjectDir string
+	HasWebBuild       bool
+	HasTest           bool
+	HasYarnLockFile   bool
+}
+
+type configDescriptor struct {
+	platform        string
+	hasPodfile      bool
+	hasWebBuild     bool
+	hasTest         bool
+	hasYarnLockFile bool
+}
+
+func (d configDescriptor) configName() string {
+	name := "capacitor-" + d.platform
+	if d.hasPodfile {
+		name += "-pod"
+	}
+	if !d.hasWebBuild {
+		name += "-no-web-build"
+	}
+	if d.hasTest {
+		name += "-test"
+	}
+	if d.hasYarnLockFile {
+		name += "-yarn"
+	}
+	return name + "-config"
+}
+
+// Scanner detects Capacitor projects, it replaces the output of the Ionic scanner which only supports Cordova projects.
+type Scanner struct {
+	projects    []Project
+	descriptors []configDescriptor
+}
+
+// NewScanner ...
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// Name ...
+func (*Scanner) Name() string {
+	return ScannerName
+}
+
+// DetectPlatform looks for the Capacitor config files and the native projects next to them.
+func (s *Scanner) DetectPlatform(searchDir string) (bool, error) {
+	log.TInfof("Searching for Capacitor config files...")
+
+	rootEntry, err := direntry.WalkDir(searchDir, searchDepth)
+	if err != nil {
+		return false, err
+	}
+
+	configPths := map[string]string{}
+	for _, name := range configFileNames {
+		for _, entry := range rootEntry.FindAllEntriesByName(name, false) {
+			dir := filepath.Dir(entry.AbsPath)
+			if _, ok := configPths[dir]; !ok {
+				configPths[dir] = entry.AbsPath
+			}
+		}
+	}
+
+	var dirs []string
+	for dir := range configPths {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		log.TPrintf("Checking: %s", configPths[dir])
+
+		project, err := readProject(searchDir, dir, configPths[dir])
+		if err != nil {
+			log.TWarnf("Failed to read the Capacitor project: %s", err)
+			continue
+		}
+		if len(project.IOSProjects.Projects) == 0 && project.AndroidProjectDir == "" {
+			log.TWarnf("No native project found, run npx cap add ios or npx cap add android and commit the native projects")
+			continue
+		}
+
+		log.TPrintf("Capacitor project found: %s", project.Dir)
+		s.projects = append(s.projects, project)
+	}
+
+	if len(s.projects) == 0 {
+		log.TPrintf("platform not detected")
+		return false, nil
+	}
+
+	log.TSuccessf("Platform detected")
+	return true, nil
+}
+
+// ExcludedScannerNames ...
+func (*Scanner) ExcludedScannerNames() []string {
+	return []string{
+		ionicScannerName,
+		cordova.ScannerName,
+		android.ScannerName,
+		string(ios.XcodeProjectTypeIOS),
+	}
+}
+
+// Options ...
+func (s *Scanner) Options() (models.OptionNode, models.Warnings, models.Icons, error) {
+	projectDirOption := models.NewOption(projectDirInputTitle, projectDirInputSummary, projectDirInputEnvKey, models.TypeSelector)
+
+	var warnings models.Warnings
+	for _, project := range s.projects {
+		platformOption := models.NewOption(platformInputTitle, platformInputSummary, platformInputEnvKey, models.TypeSelector)
+		projectDirOption.AddOption(project.Dir, platformOption)
+
+		if !project.HasWebBuild {
+			warnings = append(warnings, fmt.Sprintf("The package.json of %s has no build script, the committed %s dir is synced to the native projects.", project.Dir, project.WebDir))
+		}
+
+		if project.AndroidProjectDir != "" {
+			descriptor := configDescriptor{
+				platform:        platformAndroid,
+				hasWebBuild:     project.HasWebBuild,
+				hasTest:         project.HasTest,
+				hasYarnLockFile: project.HasYarnLockFile,
+			}
+			s.descriptors = append(s.descriptors, descriptor)
+
+			projectLocationOption := models.NewOption(android.ProjectLocationInputTitle, android.ProjectLocationInputSummary, android.ProjectLocationInputEnvKey, models.TypeSelector)
+			moduleOption := models.NewOption(android.ModuleInputTitle, android.ModuleInputSummary, android.ModuleInputEnvKey, models.TypeUserInput)
+			variantOption := models.NewOption(android.VariantInputTitle, android.VariantInputSummary, android.VariantInputEnvKey, models.TypeOptionalUserInput)
+
+			platformOption.AddOption(platformAndroid, projectLocationOption)
+			projectLocationOption.AddOption(project.AndroidProjectDir, moduleOption)
+			moduleOption.AddOption(defaultModule, variantOption)
+			variantOption.AddConfig(defaultVariant, models.NewConfigOption(descriptor.configName(), nil))
+		}
+
+		if len(project.IOSProjects.Projects) > 0 {
+			warnings = append(warnings, project.IOSProjects.Warnings...)
+
+			projectPathOption := models.NewOption(ios.ProjectPathInputTitle, ios.ProjectPathInputSummary, ios.ProjectPathInputEnvKey, models.TypeSelector)
+			platformOption.AddOption(platformIOS, projectPathOption)
+
+			for _, iosProject := range project.IOSProjects.Projects {
+				warnings = append(warnings, iosProject.Warnings...)
+
+				descriptor := configDescriptor{
+					platform:        platformIOS,
+					hasPodfile:      iosProject.IsPodWorkspace,
+					hasWebBuild:     project.HasWebBuild,
+					hasTest:         project.HasTest,
+					hasYarnLockFile: project.HasYarnLockFile,
+				}
+				s.descriptors = append(s.descriptors, descriptor)
+
+				schemeOption := models.NewOption(ios.SchemeInputTitle, ios.SchemeInputSummary, ios.SchemeInputEnvKey, models.TypeSelector)
+				projectPathOption.AddOption(iosProject.RelPath, schemeOption)
+
+				for _, scheme := range iosProject.Schemes {
+					distributionMethodOption := models.NewOption(ios.DistributionMethodInputTitle, ios.DistributionMethodInputSummary, ios.DistributionMethodEnvKey, models.TypeSelector)
+					schemeOption.AddOption(scheme.Name, distributionMethodOption)
+
+					for _, method := range ios.IosExportMethods {
+						distributionMethodOption.AddConfig(method, models.NewConfigOption(descriptor.configName(), nil))
+					}
+				}
+			}
+		}
+	}
+
+	return *projectDirOption, warnings, nil, nil
+}
+
+// DefaultOptions ...
+func (*Scanner) DefaultOptions() models.OptionNode {
+	projectDirOption := models.NewOption(projectDirInputTitle, projectDirInputSummary, projectDirInputEnvKey, models.TypeUserInput)
+
+	platformOption := models.NewOption(platformInputTitle, platformInputSummary, platformInputEnvKey, models.TypeSelector)
+	projectDirOption.AddOption(models.UserInputOptionDefaultValue, platformOption)
+
+	projectLocationOption := models.NewOption(android.ProjectLocationInputTitle, android.ProjectLocationInputSummary, android.ProjectLocationInputEnvKey, models.TypeUserInput)
+	moduleOption := models.NewOption(android.ModuleInputTitle, android.ModuleInputSummary, android.ModuleInputEnvKey, models.TypeUserInput)
+	variantOption := models.NewOption(android.VariantInputTitle, android.VariantInputSummary, android.VariantInputEnvKey, models.TypeOptionalUserInput)
+
+	platformOption.AddOption(platformAndroid, projectLocationOption)
+	projectLocationOption.AddOption(androidProjectDir, moduleOption)
+	moduleOption.AddOption(defaultModule, variantOption)
+	variantOption.AddConfig(defaultVariant, models.NewConfigOption(defaultConfigName(platformAndroid), nil))
+
+	projectPathOption := models.NewOption(ios.ProjectPathInputTitle, ios.ProjectPathInputSummary, ios.ProjectPathInputEnvKey, models.TypeUserInput)
+	schemeOption := models.NewOption(ios.SchemeInputTitle, ios.SchemeInputSummary, ios.SchemeInputEnvKey, models.TypeUserInput)
+	distributionMethodOption := models.NewOption(ios.DistributionMethodInputTitle, ios.DistributionMethodInputSummary, ios.DistributionMethodEnvKey, models.TypeSelector)
+
+	platformOption.AddOption(platformIOS, projectPathOption)
+	projectPathOption.AddOption(models.UserInputOptionDefaultValue, schemeOption)
+	schemeOption.AddOption(models.UserInputOptionDefaultValue, distributionMethodOption)
+	for _, method := range ios.IosExportMethods {
+		distributionMethodOption.AddConfig(method, models.NewConfigOption(defaultConfigName(platformIOS), nil))
+	}
+
+	return *projectDirOption
+}
+
+// Configs ...
+func (s *Scanner) Configs(sshKeyActivation models.SSHKeyActivation) (models.BitriseConfigMap, error) {
+	configs := models.BitriseConfigMap{}
+	for _, descriptor := range s.descriptors {
+		name := descriptor.configName()
+		if _, ok := configs[name]; ok {
+			continue
+		}
+
+		config, err := generateConfig(descriptor, sshKeyActivation)
+		if err != nil {
+			return models.BitriseConfigMap{}, err
+		}
+		configs[name] = config
+	}
+
+	return configs, nil
+}
+
+// DefaultConfigs ...
+func (*Scanner) DefaultConfigs() (models.BitriseConfigMap, error) {
+	configs := models.BitriseConfigMap{}
+	for _, platform := range []string{platformAndroid, platformIOS} {
+		descriptor := configDescriptor{platform: platform, hasWebBuild: true, hasTest: true}
+		config, err := generateConfig(descriptor, models.SSHKeyActivationConditional)
+		if err != nil {
+			return models.BitriseConfigMap{}, err
+		}
+		configs[defaultConfigName(platform)] = config
+	}
+
+	return configs, nil
+}
+
+func defaultConfigName(platform string) string {
+	return fmt.Sprintf("default-capacitor-%s-config", platform)
+}
+
+func generateConfig(descriptor configDescriptor, sshKeyActivation models.SSHKeyActivation) (string, error) {
+	configBuilder := models.NewDefaultConfigBuilder()
+
+	workDir := "$" + projectDirInputEnvKey
+	buildWorkflowID := models.WorkflowID("build_" + descriptor.platform)
+
+	var workflowIDs []models.WorkflowID
+	if descriptor.hasTest {
+		workflowIDs = append(workflowIDs, testWorkflowID)
+	}
+	workflowIDs = append(workflowIDs, buildWorkflowID)
+
+	for _, workflowID := range workflowIDs {
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultPrepareStepList(initSteps.PrepareListParams{SSHKeyActivation: sshKeyActivation})...)
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.RestoreNPMCache(), packageManagerStepListItem("install", workDir, descriptor.hasYarnLockFile))
+
+		if workflowID == testWorkflowID {
+			configBuilder.AppendStepListItemsTo(workflowID, packageManagerStepListItem("test", workDir, descriptor.hasYarnLockFile))
+		} else {
+			if descriptor.hasWebBuild {
+				configBuilder.AppendStepListItemsTo(workflowID, packageManagerStepListItem("run build", workDir, descriptor.hasYarnLockFile))
+			}
+			// cap sync copies the web assets to the native projects and installs the native dependencies, the pods too
+			configBuilder.AppendStepListItemsTo(workflowID, initSteps.ScriptStepListItem("npx cap sync", fmt.Sprintf(`#!/usr/bin/env bash
+set -euxo pipefail
+
+npx cap sync %s
+`, descriptor.platform), envmanModels.EnvironmentItemModel{"working_dir": workDir}))
+		}
+
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.SaveNPMCache())
+
+		if workflowID != testWorkflowID {
+			configBuilder.AppendStepListItemsTo(workflowID, nativeBuildStepListItems(descriptor.platform)...)
+		}
+
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultDeployStepList()...)
+	}
+
+	return scannerutil.GenerateConfig(configBuilder, ScannerName)
+}
+
+// nativeBuildStepListItems are the build steps the Android and iOS scanners generate for the native projects.
+func nativeBuildStepListItems(platform string) []bitriseModels.StepListItemModel {
+	if platform == platformAndroid {
+		return []bitriseModels.StepListItemModel{
+			initSteps.InstallMissingAndroidToolsStepListItem(
+				envmanModels.EnvironmentItemModel{android.GradlewPathInputKey: "$" + android.ProjectLocationInputEnvKey + "/gradlew"},
+			),
+			initSteps.AndroidBuildStepListItem(
+				envmanModels.EnvironmentItemModel{android.ProjectLocationInputKey: "$" + android.ProjectLocationInputEnvKey},
+				envmanModels.EnvironmentItemModel{android.ModuleInputKey: "$" + android.ModuleInputEnvKey},
+				envmanModels.EnvironmentItemModel{android.VariantInputKey: "$" + android.VariantInputEnvKey},
+			),
+		}
+	}
+
+	return []bitriseModels.StepListItemModel{
+		initSteps.XcodeArchiveStepListItem(
+			envmanModels.EnvironmentItemModel{ios.ProjectPathInputKey: "$" + ios.ProjectPathInputEnvKey},
+			envmanModels.EnvironmentItemModel{ios.SchemeInputKey: "$" + ios.SchemeInputEnvKey},
+			envmanModels.EnvironmentItemModel{ios.DistributionMethodInputKey: "$" + ios.DistributionMethodEnvKey},
+			envmanModels.EnvironmentItemModel{ios.ConfigurationInputKey: "Release"},
+			envmanModels.EnvironmentItemModel{ios.AutomaticCodeSigningInputKey: ios.AutomaticCodeSigningInputAPIKeyValue},
+		),
+	}
+}
+
+func packageManagerStepListItem(command, workDir string, hasYarnLockFile bool) bitriseModels.StepListItemModel {
+	if hasYarnLockFile {
+		return initSteps.YarnStepListItem(command, workDir)
+	}
+	return initSteps.NpmStepListItem(command, workDir)
+}
+
+func readProject(searchDir, dir, configPth string) (Project, error) {
+	content, err := os.ReadFile(configPth)
+	if err != nil {
+		return Project{}, err
+	}
+
+	relDir, err := utility.RelPath(searchDir, dir)
+	if err != nil {
+		return Project{}, err
+	}
+	project := Project{Dir: filepath.Clean(relDir), WebDir: "www"}
+	if match := webDirPattern.FindSubmatch(content); match != nil {
+		project.WebDir = string(match[1])
+	}
+
+	packageJSONPth := filepath.Join(dir, "package.json")
+	if utility.FileExists(packageJSONPth) {
+		packages, err := utility.ParsePackagesJSON(packageJSONPth)
+		if err != nil {
+			return Project{}, fmt.Errorf("parse %s: %s", packageJSONPth, err)
+		}
+		_, project.HasWebBuild = packages.Scripts["build"]
+		_, project.HasTest = packages.Scripts["test"]
+	}
+	project.HasYarnLockFile = utility.FileExists(filepath.Join(dir, "yarn.lock"))
+
+	iosDir := iosProjectDir
+	if match := iosPathPattern.FindSubmatch(content); match != nil {
+		iosDir = filepath.Join(string(match[1]), "App")
+	}
+	if fsutil.IsDir(filepath.Join(dir, iosDir)) {
+		result, err := ios.ParseProjects(ios.XcodeProjectTypeIOS, filepath.Join(dir, iosDir), true, true)
+		if err != nil {
+			log.TWarnf("Failed to parse the iOS project: %s", err)
+		}
+		// the project paths are relative to the parsed dir
+		for i := range result.Projects {
+			result.Projects[i].RelPath = filepath.Join(project.Dir, iosDir, result.Projects[i].RelPath)
+		}
+		project.IOSProjects = result
+	}
+
+	androidDir := androidProjectDir
+	if match := androidPathPattern.FindSubmatch(content); match != nil {
+		androidDir = string(match[1])
+	}
+	if fsutil.IsDir(filepath.Join(dir, androidDir)) {
+		androidScanner := android.NewScanner()
+		detected, err := androidScanner.DetectPlatform(filepath.Join(dir, androidDir))
+		if err != nil {
+			log.TWarnf("Failed to detect the Android project: %s", err)
+		} else if detected {
+			project.AndroidProjectDir = filepath.Join(project.Dir, androidDir)
+		}
+	}
+
+	return project, nil
+}
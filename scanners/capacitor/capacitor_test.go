@@ -0,0 +1,124 @@
+package capacitor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const capacitorConfig = `import type { CapacitorConfig } from '@capacitor/cli';
+
+const config: CapacitorConfig = {
+  appId: 'io.bitrise.notes',
+  appName: 'Notes',
+  webDir: 'dist',
+  android: {
+    path: 'native/android',
+  },
+};
+
+export default config;
+`
+
+const packageJSON = `{
+  "name": "notes",
+  "scripts": {
+    "build": "vite build",
+    "test": "vitest run"
+  },
+  "dependencies": {
+    "@capacitor/android": "^6.0.0",
+    "@capacitor/core": "^6.0.0"
+  }
+}`
+
+func TestScanner(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "app", "capacitor.config.ts"), capacitorConfig)
+	testutil.WriteFile(t, filepath.Join(dir, "app", "package.json"), packageJSON)
+	testutil.WriteFile(t, filepath.Join(dir, "app", "yarn.lock"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "app", "native", "android", "gradlew"), "#!/bin/sh\n")
+	testutil.WriteFile(t, filepath.Join(dir, "app", "native", "android", "settings.gradle"), "include ':app'\n")
+	testutil.WriteFile(t, filepath.Join(dir, "app", "native", "android", "build.gradle"), "buildscript {}\n")
+	testutil.WriteFile(t, filepath.Join(dir, "app", "native", "android", "app", "build.gradle"), "apply plugin: 'com.android.application'\n")
+	testutil.WriteFile(t, filepath.Join(dir, "web", "capacitor.config.json"), `{"appId": "io.bitrise.web", "webDir": "www"}`)
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	require.Equal(t, []Project{{
+		Dir:               "app",
+		WebDir:            "dist",
+		AndroidProjectDir: "app/native/android",
+		HasWebBuild:       true,
+		HasTest:           true,
+		HasYarnLockFile:   true,
+	}}, scanner.projects)
+
+	options, warnings, _, err := scanner.Options()
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+
+	variantOption, ok := options.Child("app", platformAndroid, "app/native/android", defaultModule)
+	require.True(t, ok)
+	require.Equal(t, "capacitor-android-test-yarn-config", variantOption.ChildOptionMap[defaultVariant].Config)
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs["capacitor-android-test-yarn-config"]), &config))
+	require.Contains(t, config.Workflows, testWorkflowID)
+	require.Contains(t, config.Workflows, "build_android")
+	require.Contains(t, configs["capacitor-android-test-yarn-config"], "npx cap sync android")
+	require.Contains(t, configs["capacitor-android-test-yarn-config"], "android-build@")
+}
+
+func TestOptions_ios(t *testing.T) {
+	scanner := NewScanner()
+	scanner.projects = []Project{{
+		Dir:    ".",
+		WebDir: "www",
+		IOSProjects: ios.DetectResult{Projects: []ios.Project{{
+			RelPath:        "ios/App/App.xcworkspace",
+			IsWorkspace:    true,
+			IsPodWorkspace: true,
+			Schemes:        []ios.Scheme{{Name: "App"}},
+		}}},
+	}}
+
+	options, warnings, _, err := scanner.Options()
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+
+	distributionMethodOption, ok := options.Child(".", platformIOS, "ios/App/App.xcworkspace", "App")
+	require.True(t, ok)
+	require.ElementsMatch(t, ios.IosExportMethods, distributionMethodOption.GetValues())
+	require.Equal(t, "capacitor-ios-pod-no-web-build-config", distributionMethodOption.ChildOptionMap["app-store"].Config)
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	require.Contains(t, configs["capacitor-ios-pod-no-web-build-config"], "npx cap sync ios")
+	require.Contains(t, configs["capacitor-ios-pod-no-web-build-config"], "xcode-archive@")
+	require.NotContains(t, configs["capacitor-ios-pod-no-web-build-config"], "run build")
+}
+
+func TestDetectPlatform_notDetected(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "capacitor.config.json"), `{"webDir": "www"}`)
+	testutil.WriteFile(t, filepath.Join(dir, "package.json"), packageJSON)
+
+	detected, err := NewScanner().DetectPlatform(dir)
+	require.NoError(t, err)
+	require.False(t, detected)
+}
@@ -0,0 +1,665 @@
+package dotnet
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-init/utility"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/scannerutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	ScannerName = "dotnet"
+
+	solutionInputTitle   = "Solution"
+	solutionInputSummary = "The `.sln` file of the app, or the `.csproj` file if the app project is not part of a solution."
+	solutionInputEnvKey  = "DOTNET_SOLUTION"
+
+	projectInputTitle   = "App project"
+	projectInputSummary = "The `.csproj` file of the .NET MAUI or Xamarin app."
+	projectInputEnvKey  = "DOTNET_PROJECT"
+
+	configurationInputTitle   = "Build configuration"
+	configurationInputSummary = "The configuration the tests and the app are built with."
+	configurationInputEnvKey  = "DOTNET_CONFIGURATION"
+
+	targetFrameworkInputTitle   = "Target platform"
+	targetFrameworkInputSummary = "The target framework the app is published for, for example `net8.0-android` or `net8.0-ios`."
+	targetFrameworkInputEnvKey  = "DOTNET_TARGET_FRAMEWORK"
+
+	testWorkflowID = "run_tests"
+
+	kindMAUI    = "maui"
+	kindXamarin = "xamarin"
+
+	platformAndroid = "android"
+	platformIOS     = "ios"
+
+	// the target framework values of the legacy Xamarin projects, which are built with msbuild instead of dotnet publish
+	xamarinAndroidTargetFramework = "MonoAndroid"
+	xamarinIOSTargetFramework     = "Xamarin.iOS"
+
+	searchDepth = 5
+)
+
+var (
+	defaultConfigurations   = []string{"Release", "Debug"}
+	defaultTargetFrameworks = []string{"net10.0-android", "net10.0-ios"}
+)
+
+// testFrameworkPackages are the package references of the test projects, matched case-insensitively by prefix.
+var testFrameworkPackages = []string{"xunit", "nunit", "mstest"}
+
+// ignoredDirNames are not searched for solutions and projects.
+var ignoredDirNames = []string{"bin", "obj", "packages", "node_modules", "build"}
+
+var (
+	slnProjectPattern        = regexp.MustCompile(`(?m)^Project\("\{[^}]+\}"\)\s*=\s*"[^"]*",\s*"([^"]+\.csproj)"`)
+	slnxProjectPattern       = regexp.MustCompile(`<Project\s+Path="([^"]+\.csproj)"`)
+	slnConfigurationPattern  = regexp.MustCompile(`(?m)^\s*([^|\s][^|]*)\|[^=]+=`)
+	slnConfigurationSection  = regexp.MustCompile(`(?s)GlobalSection\(SolutionConfigurationPlatforms\)[^\n]*\n(.*?)EndGlobalSection`)
+	slnxConfigurationPattern = regexp.MustCompile(`<BuildType\s+Name="([^"]+)"`)
+)
+
+// signingSecrets are the secrets the signing of the app expects, by platform.
+var signingSecrets = map[string][]string{
+	platformAndroid: steps.AndroidKeystoreSecrets,
+	platformIOS: {
+		"BITRISE_CERTIFICATE_URL",
+		"BITRISE_CERTIFICATE_PASSPHRASE",
+		"BITRISE_PROVISION_PROFILE_URL",
+	},
+}
+
+// Project is a .NET MAUI or Xamarin app project.
+type Project struct {
+	// Solution is the solution of the project relative to the search dir, or Path if the project is not in a solution.
+	Solution string
+	// Path is the .csproj file relative to the search dir.
+	Path string
+	// Kind is maui for the SDK-style projects and xamarin for the legacy Xamarin projects.
+	Kind string
+	// TargetFrameworks are the Android and iOS target frameworks of the project.
+	TargetFrameworks []string
+	Configurations   []string
+	// HasTests is set if the solution has a project referencing xUnit, NUnit or MSTest.
+	HasTests    bool
+	HasLockFile bool
+}
+
+// csproj is the part of a .csproj file the scanner reads, the legacy projects declare the MSBuild namespace,
+// the SDK-style projects do not.
+type csproj struct {
+	Sdk            string `xml:"Sdk,attr"`
+	PropertyGroups []struct {
+		OutputType         string   `xml:"OutputType"`
+		TargetFramework    []string `xml:"TargetFramework"`
+		TargetFrameworks   []string `xml:"TargetFrameworks"`
+		AndroidApplication string   `xml:"AndroidApplication"`
+	} `xml:"PropertyGroup"`
+	ItemGroups []struct {
+		PackageReferences []struct {
+			Include string `xml:"Include,attr"`
+		} `xml:"PackageReference"`
+		References []struct {
+			Include string `xml:"Include,attr"`
+		} `xml:"Reference"`
+	} `xml:"ItemGroup"`
+	Imports []struct {
+		Project string `xml:"Project,attr"`
+	} `xml:"Import"`
+}
+
+// Scanner detects .NET MAUI and Xamarin app projects.
+type Scanner struct {
+	projects []Project
+}
+
+// NewScanner ...
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// Name ...
+func (*Scanner) Name() string {
+	return ScannerName
+}
+
+// DetectPlatform looks for the app projects of the solutions, and the app projects which are not part of a solution.
+func (s *Scanner) DetectPlatform(searchDir string) (bool, error) {
+	log.TInfof("Searching for .sln and .csproj files...")
+
+	solutionPths, projectPths, err := collectFiles(searchDir)
+	if err != nil {
+		return false, err
+	}
+
+	inSolution := map[string]bool{}
+	for _, solutionPth := range solutionPths {
+		content, err := os.ReadFile(filepath.Join(searchDir, solutionPth))
+		if err != nil {
+			return false, fmt.Errorf("read %s: %s", solutionPth, err)
+		}
+
+		var apps []Project
+		hasTests := false
+		for _, projectPth := range solutionProjects(solutionPth, string(content)) {
+			inSolution[projectPth] = true
+			project, isApp, isTest, err := readProject(searchDir, projectPth)
+			if err != nil {
+				log.TWarnf("Failed to read %s: %s", projectPth, err)
+				continue
+			}
+			hasTests = hasTests || isTest
+			if isApp {
+				project.Solution = solutionPth
+				project.Configurations = solutionConfigurations(solutionPth, string(content))
+				apps = append(apps, project)
+			}
+		}
+
+		for _, app := range apps {
+			app.HasTests = hasTests
+			log.TPrintf("%s app found: %s (%s)", kindTitle(app.Kind), app.Path, app.Solution)
+			s.projects = append(s.projects, app)
+		}
+	}
+
+	for _, projectPth := range projectPths {
+		if inSolution[projectPth] {
+			continue
+		}
+		project, isApp, _, err := readProject(searchDir, projectPth)
+		if err != nil {
+			log.TWarnf("Failed to read %s: %s", projectPth, err)
+			continue
+		}
+		if !isApp {
+			continue
+		}
+
+		project.Solution = projectPth
+		project.Configurations = defaultConfigurations
+		log.TPrintf("%s app found: %s", kindTitle(project.Kind), project.Path)
+		s.projects = append(s.projects, project)
+	}
+
+	if len(s.projects) == 0 {
+		log.TPrintf("platform not detected")
+		return false, nil
+	}
+
+	log.TSuccessf("Platform detected")
+	return true, nil
+}
+
+// ExcludedScannerNames ...
+func (*Scanner) ExcludedScannerNames() []string {
+	return nil
+}
+
+// Options ...
+func (s *Scanner) Options() (models.OptionNode, models.Warnings, models.Icons, error) {
+	solutionOption := models.NewOption(solutionInputTitle, solutionInputSummary, solutionInputEnvKey, models.TypeSelector)
+
+	var warnings models.Warnings
+	for _, project := range s.projects {
+		if project.Kind == kindXamarin {
+			warnings = append(warnings, fmt.Sprintf("%s is a legacy Xamarin project, it is built with msbuild and needs a stack with the Mono toolchain installed. Xamarin support ended on May 1, 2024, consider migrating the app to .NET MAUI.", project.Path))
+		}
+
+		projectOption, ok := solutionOption.Child(project.Solution)
+		if !ok {
+			projectOption = models.NewOption(projectInputTitle, projectInputSummary, projectInputEnvKey, models.TypeSelector)
+			solutionOption.AddOption(project.Solution, projectOption)
+		}
+
+		configurationOption := models.NewOption(configurationInputTitle, configurationInputSummary, configurationInputEnvKey, models.TypeSelector)
+		projectOption.AddOption(project.Path, configurationOption)
+
+		for _, configuration := range project.Configurations {
+			targetFrameworkOption := models.NewOption(targetFrameworkInputTitle, targetFrameworkInputSummary, targetFrameworkInputEnvKey, models.TypeSelector)
+			configurationOption.AddOption(configuration, targetFrameworkOption)
+
+			for _, targetFramework := range project.TargetFrameworks {
+				name := projectConfigName(project, platformOf(targetFramework))
+				targetFrameworkOption.AddConfig(targetFramework, models.NewConfigOption(name, nil))
+			}
+		}
+	}
+
+	return *solutionOption, warnings, nil, nil
+}
+
+// DefaultOptions ...
+func (*Scanner) DefaultOptions() models.OptionNode {
+	solutionOption := models.NewOption(solutionInputTitle, solutionInputSummary, solutionInputEnvKey, models.TypeUserInput)
+
+	projectOption := models.NewOption(projectInputTitle, projectInputSummary, projectInputEnvKey, models.TypeUserInput)
+	solutionOption.AddOption(models.UserInputOptionDefaultValue, projectOption)
+
+	configurationOption := models.NewOption(configurationInputTitle, configurationInputSummary, configurationInputEnvKey, models.TypeUserInput)
+	projectOption.AddOption(models.UserInputOptionDefaultValue, configurationOption)
+
+	targetFrameworkOption := models.NewOption(targetFrameworkInputTitle, targetFrameworkInputSummary, targetFrameworkInputEnvKey, models.TypeSelector)
+	configurationOption.AddOption(models.UserInputOptionDefaultValue, targetFrameworkOption)
+
+	for _, targetFramework := range defaultTargetFrameworks {
+		targetFrameworkOption.AddConfig(targetFramework, models.NewConfigOption(defaultConfigName(platformOf(targetFramework)), nil))
+	}
+
+	return *solutionOption
+}
+
+// Configs ...
+func (s *Scanner) Configs(sshKeyActivation models.SSHKeyActivation) (models.BitriseConfigMap, error) {
+	configs := models.BitriseConfigMap{}
+	for _, project := range s.projects {
+		for _, targetFramework := range project.TargetFrameworks {
+			platform := platformOf(targetFramework)
+			name := projectConfigName(project, platform)
+			if _, ok := configs[name]; ok {
+				continue
+			}
+
+			config, err := generateConfig(project, platform, sshKeyActivation)
+			if err != nil {
+				return models.BitriseConfigMap{}, err
+			}
+			configs[name] = config
+		}
+	}
+
+	return configs, nil
+}
+
+// DefaultConfigs ...
+func (*Scanner) DefaultConfigs() (models.BitriseConfigMap, error) {
+	configs := models.BitriseConfigMap{}
+	project := Project{Kind: kindMAUI, HasTests: true}
+	for _, targetFramework := range defaultTargetFrameworks {
+		platform := platformOf(targetFramework)
+		config, err := generateConfig(project, platform, models.SSHKeyActivationConditional)
+		if err != nil {
+			return models.BitriseConfigMap{}, err
+		}
+		configs[defaultConfigName(platform)] = config
+	}
+
+	return configs, nil
+}
+
+// SigningSecretEnvs returns the signing secrets of the platform the dotnet config publishes the app for,
+// as empty secret envs. It returns nil for the configs of the other scanners.
+func SigningSecretEnvs(config bitriseModels.BitriseDataModel) []envmanModels.EnvironmentItemModel {
+	if config.ProjectType != ScannerName {
+		return nil
+	}
+
+	var envs []envmanModels.EnvironmentItemModel
+	for _, platform := range []string{platformAndroid, platformIOS} {
+		if _, ok := config.Workflows[buildWorkflowID(platform)]; !ok {
+			continue
+		}
+		for _, key := range signingSecrets[platform] {
+			envs = append(envs, steps.SecretEnv(key, fmt.Sprintf("Required by the %s code signing", platformTitle(platform))))
+		}
+	}
+	return envs
+}
+
+func buildWorkflowID(platform string) string {
+	return "build_" + platform
+}
+
+// projectConfigName returns the name of the project's config, the configs differ in the build tool,
+// the target platform, the test workflow and the cache key.
+func projectConfigName(project Project, platform string) string {
+	name := fmt.Sprintf("dotnet-%s-%s", project.Kind, platform)
+	if project.HasTests {
+		name += "-tests"
+	}
+	if project.HasLockFile {
+		name += "-lockfile"
+	}
+	return name + "-config"
+}
+
+func defaultConfigName(platform string) string {
+	return fmt.Sprintf("default-dotnet-%s-config", platform)
+}
+
+func generateConfig(project Project, platform string, sshKeyActivation models.SSHKeyActivation) (string, error) {
+	configBuilder := models.NewDefaultConfigBuilder()
+
+	// the projects without a lock file resolve the floating package versions in every build
+	cacheKey := `{{ .OS }}-{{ .Arch }}-nuget-{{ checksum "**/*.csproj" }}`
+	if project.HasLockFile {
+		cacheKey = `{{ .OS }}-{{ .Arch }}-nuget-{{ checksum "**/packages.lock.json" }}`
+	}
+
+	var workflowIDs []models.WorkflowID
+	if project.HasTests {
+		workflowIDs = append(workflowIDs, testWorkflowID)
+	}
+	workflowIDs = append(workflowIDs, models.WorkflowID(buildWorkflowID(platform)))
+
+	for _, workflowID := range workflowIDs {
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultPrepareStepList(initSteps.PrepareListParams{SSHKeyActivation: sshKeyActivation})...)
+		if workflowID != testWorkflowID && platform == platformIOS {
+			configBuilder.AppendStepListItemsTo(workflowID, initSteps.CertificateAndProfileInstallerStepListItem())
+		}
+		configBuilder.AppendStepListItemsTo(workflowID,
+			initSteps.ScriptStepListItem("Install .NET SDK", installScriptContent),
+			steps.RestoreCacheStepListItem("Restore NuGet cache", cacheKey+"\n{{ .OS }}-{{ .Arch }}-nuget-"),
+		)
+		if project.Kind == kindMAUI {
+			configBuilder.AppendStepListItemsTo(workflowID,
+				initSteps.ScriptStepListItem("dotnet workload restore", scannerutil.CommandScriptContent(`dotnet workload restore "$DOTNET_PROJECT"`)),
+			)
+		}
+
+		if workflowID == testWorkflowID {
+			configBuilder.AppendStepListItemsTo(workflowID, initSteps.ScriptStepListItem("dotnet test", testScriptContent))
+		} else {
+			title, content := buildStep(project.Kind, platform)
+			configBuilder.AppendStepListItemsTo(workflowID, initSteps.ScriptStepListItem(title, content))
+		}
+
+		configBuilder.AppendStepListItemsTo(workflowID, steps.SaveCacheStepListItem("Save NuGet cache", cacheKey, "~/.nuget/packages"))
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultDeployStepList()...)
+	}
+
+	return scannerutil.GenerateConfig(configBuilder, ScannerName)
+}
+
+// buildStep returns the title and the script of the step building the signed app into the deploy dir.
+func buildStep(kind, platform string) (string, string) {
+	switch {
+	case kind == kindMAUI && platform == platformAndroid:
+		return "dotnet publish", scannerutil.CommandScriptContent(androidSigningArgs + `
+dotnet publish "$DOTNET_PROJECT" -f "$DOTNET_TARGET_FRAMEWORK" -c "$DOTNET_CONFIGURATION" -o "$BITRISE_DEPLOY_DIR" ${signing_args[@]+"${signing_args[@]}"}`)
+	case kind == kindMAUI:
+		return "dotnet publish", scannerutil.CommandScriptContent(`# the signing identity and the provisioning profile installed by the Certificate and profile installer step are picked automatically
+dotnet publish "$DOTNET_PROJECT" -f "$DOTNET_TARGET_FRAMEWORK" -c "$DOTNET_CONFIGURATION" -p:ArchiveOnBuild=true -p:RuntimeIdentifier=ios-arm64 -o "$BITRISE_DEPLOY_DIR"`)
+	case platform == platformAndroid:
+		return "msbuild SignAndroidPackage", scannerutil.CommandScriptContent(androidSigningArgs + `
+nuget restore "$DOTNET_SOLUTION"
+msbuild "$DOTNET_PROJECT" -t:SignAndroidPackage -p:Configuration="$DOTNET_CONFIGURATION" ${signing_args[@]+"${signing_args[@]}"}
+find "$(dirname "$DOTNET_PROJECT")/bin/$DOTNET_CONFIGURATION" -name "*-Signed.apk" -exec cp {} "$BITRISE_DEPLOY_DIR" \;`)
+	default:
+		return "msbuild BuildIpa", scannerutil.CommandScriptContent(`nuget restore "$DOTNET_SOLUTION"
+msbuild "$DOTNET_PROJECT" -p:Configuration="$DOTNET_CONFIGURATION" -p:Platform=iPhone -p:BuildIpa=true
+find "$(dirname "$DOTNET_PROJECT")/bin/iPhone/$DOTNET_CONFIGURATION" -name "*.ipa" -exec cp {} "$BITRISE_DEPLOY_DIR" \;`)
+	}
+}
+
+// androidSigningArgs downloads the keystore and sets the signing properties, the passwords are passed as env references
+// so that they are not printed.
+const androidSigningArgs = `signing_args=()
+if [ -n "${BITRISEIO_ANDROID_KEYSTORE_URL:-}" ]; then
+  keystore="$(mktemp -d)/release.keystore"
+  curl -fsSL -o "$keystore" "$BITRISEIO_ANDROID_KEYSTORE_URL"
+  signing_args=(
+    -p:AndroidKeyStore=true
+    -p:AndroidSigningKeyStore="$keystore"
+    -p:AndroidSigningKeyAlias="$BITRISEIO_ANDROID_KEYSTORE_ALIAS"
+    -p:AndroidSigningStorePass=env:BITRISEIO_ANDROID_KEYSTORE_PASSWORD
+    -p:AndroidSigningKeyPass=env:BITRISEIO_ANDROID_KEYSTORE_PRIVATE_KEY_PASSWORD
+  )
+fi
+`
+
+const installScriptContent = `#!/usr/bin/env bash
+set -euxo pipefail
+
+# dotnet-install.sh installs the SDK of the global.json file, the SDK of the stack is used otherwise
+global_json="$(dirname "$DOTNET_SOLUTION")/global.json"
+if [ ! -f "$global_json" ]; then
+  global_json="global.json"
+fi
+if [ -f "$global_json" ] || ! command -v dotnet &> /dev/null; then
+  export DOTNET_ROOT="$HOME/.dotnet"
+  if [ -f "$global_json" ]; then
+    curl -fsSL https://dot.net/v1/dotnet-install.sh | bash -s -- --jsonfile "$global_json" --install-dir "$DOTNET_ROOT"
+  else
+    curl -fsSL https://dot.net/v1/dotnet-install.sh | bash -s -- --channel LTS --install-dir "$DOTNET_ROOT"
+  fi
+  export PATH="$DOTNET_ROOT:$PATH"
+  envman add --key DOTNET_ROOT --value "$DOTNET_ROOT"
+  envman add --key PATH --value "$PATH"
+fi
+dotnet --info
+`
+
+// testScriptContent runs the test projects of the solution one by one, testing the solution would build the app project
+// for every platform.
+const testScriptContent = `#!/usr/bin/env bash
+set -euxo pipefail
+
+solution_dir="$(dirname "$DOTNET_SOLUTION")"
+dotnet sln "$DOTNET_SOLUTION" list | tail -n +3 | while IFS= read -r project; do
+  project="$solution_dir/${project//\\//}"
+  if grep -qiE 'Include="(xunit|nunit|mstest)' "$project"; then
+    dotnet test "$project" -c "$DOTNET_CONFIGURATION"
+  fi
+done
+`
+
+// collectFiles returns the solution and project files relative to the search dir.
+func collectFiles(searchDir string) ([]string, []string, error) {
+	var solutionPths, projectPths []string
+	err := filepath.WalkDir(searchDir, func(pth string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(searchDir, pth)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if rel == "." {
+				return nil
+			}
+			if strings.HasPrefix(entry.Name(), ".") || slices.Contains(ignoredDirNames, strings.ToLower(entry.Name())) {
+				return filepath.SkipDir
+			}
+			if strings.Count(rel, string(filepath.Separator))+1 >= searchDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch filepath.Ext(entry.Name()) {
+		case ".sln", ".slnx":
+			solutionPths = append(solutionPths, filepath.ToSlash(rel))
+		case ".csproj":
+			projectPths = append(projectPths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Strings(solutionPths)
+	sort.Strings(projectPths)
+	return solutionPths, projectPths, nil
+}
+
+// solutionProjects returns the C# projects of a solution relative to the search dir,
+// the project paths of a solution are relative to the solution and use Windows separators.
+func solutionProjects(solutionPth, content string) []string {
+	pattern := slnProjectPattern
+	if filepath.Ext(solutionPth) == ".slnx" {
+		pattern = slnxProjectPattern
+	}
+
+	var projectPths []string
+	for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+		projectPth := strings.ReplaceAll(match[1], `\`, "/")
+		projectPths = append(projectPths, filepath.ToSlash(filepath.Join(filepath.Dir(solutionPth), projectPth)))
+	}
+	return projectPths
+}
+
+// solutionConfigurations returns the build configurations of a solution, Release first.
+func solutionConfigurations(solutionPth, content string) []string {
+	var matches [][]string
+	if filepath.Ext(solutionPth) == ".slnx" {
+		matches = slnxConfigurationPattern.FindAllStringSubmatch(content, -1)
+	} else if section := slnConfigurationSection.FindStringSubmatch(content); section != nil {
+		matches = slnConfigurationPattern.FindAllStringSubmatch(section[1], -1)
+	}
+
+	var configurations []string
+	for _, match := range matches {
+		configuration := strings.TrimSpace(match[1])
+		if !slices.Contains(configurations, configuration) {
+			configurations = append(configurations, configuration)
+		}
+	}
+	if len(configurations) == 0 {
+		return defaultConfigurations
+	}
+
+	sort.SliceStable(configurations, func(i, j int) bool {
+		return configurations[i] == "Release" && configurations[j] != "Release"
+	})
+	return configurations
+}
+
+// readProject reads a .csproj file and tells if it is an Android or iOS app project or a test project.
+func readProject(searchDir, projectPth string) (Project, bool, bool, error) {
+	content, err := os.ReadFile(filepath.Join(searchDir, projectPth))
+	if err != nil {
+		return Project{}, false, false, err
+	}
+
+	var proj csproj
+	if err := xml.Unmarshal(content, &proj); err != nil {
+		return Project{}, false, false, err
+	}
+
+	isTest := false
+	for _, itemGroup := range proj.ItemGroups {
+		for _, reference := range itemGroup.PackageReferences {
+			isTest = isTest || isTestFramework(reference.Include)
+		}
+		for _, reference := range itemGroup.References {
+			isTest = isTest || isTestFramework(reference.Include)
+		}
+	}
+
+	// the conditional TargetFrameworks properties extend the list, for example with the Windows framework on Windows
+	var outputType, androidApplication string
+	var targetFrameworks []string
+	for _, group := range proj.PropertyGroups {
+		outputType = firstNonEmpty(outputType, group.OutputType)
+		androidApplication = firstNonEmpty(androidApplication, group.AndroidApplication)
+		for _, value := range append(group.TargetFrameworks, group.TargetFramework...) {
+			for _, targetFramework := range strings.Split(value, ";") {
+				targetFramework = strings.TrimSpace(targetFramework)
+				if targetFramework != "" && !strings.HasPrefix(targetFramework, "$(") && !slices.Contains(targetFrameworks, targetFramework) {
+					targetFrameworks = append(targetFrameworks, targetFramework)
+				}
+			}
+		}
+	}
+
+	project := Project{
+		Path:        projectPth,
+		HasLockFile: utility.FileExists(filepath.Join(searchDir, filepath.Dir(projectPth), "packages.lock.json")),
+	}
+
+	if proj.Sdk == "" {
+		// the legacy Xamarin app projects import the Xamarin targets, the Android app is marked by AndroidApplication
+		project.Kind = kindXamarin
+		for _, imp := range proj.Imports {
+			switch {
+			case strings.Contains(imp.Project, "Xamarin.Android.CSharp.targets") && strings.EqualFold(androidApplication, "true"):
+				project.TargetFrameworks = append(project.TargetFrameworks, xamarinAndroidTargetFramework)
+			case strings.Contains(imp.Project, "Xamarin.iOS.CSharp.targets") && strings.EqualFold(outputType, "Exe"):
+				project.TargetFrameworks = append(project.TargetFrameworks, xamarinIOSTargetFramework)
+			}
+		}
+		return project, len(project.TargetFrameworks) > 0, isTest, nil
+	}
+
+	if !strings.EqualFold(outputType, "Exe") {
+		return project, false, isTest, nil
+	}
+
+	project.Kind = kindMAUI
+	for _, targetFramework := range targetFrameworks {
+		lower := strings.ToLower(targetFramework)
+		switch {
+		case strings.HasPrefix(lower, "monoandroid"), strings.HasPrefix(lower, "xamarin.ios"):
+			// the SDK-style Xamarin projects of MSBuild.Sdk.Extras
+			project.Kind = kindXamarin
+			project.TargetFrameworks = append(project.TargetFrameworks, targetFramework)
+		case platformOf(targetFramework) != "":
+			project.TargetFrameworks = append(project.TargetFrameworks, targetFramework)
+		}
+	}
+	return project, len(project.TargetFrameworks) > 0, isTest, nil
+}
+
+// platformOf returns the platform of an Android or iOS target framework, or an empty string for the other frameworks.
+func platformOf(targetFramework string) string {
+	lower := strings.ToLower(targetFramework)
+	switch {
+	case strings.Contains(lower, "-android"), strings.HasPrefix(lower, "monoandroid"):
+		return platformAndroid
+	case strings.Contains(lower, "-ios"), strings.HasPrefix(lower, "xamarin.ios"):
+		return platformIOS
+	}
+	return ""
+}
+
+func isTestFramework(reference string) bool {
+	reference = strings.ToLower(reference)
+	for _, pkg := range testFrameworkPackages {
+		if strings.HasPrefix(reference, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+func kindTitle(kind string) string {
+	if kind == kindXamarin {
+		return "Xamarin"
+	}
+	return ".NET MAUI"
+}
+
+func platformTitle(platform string) string {
+	if platform == platformIOS {
+		return "iOS"
+	}
+	return "Android"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
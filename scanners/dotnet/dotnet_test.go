@@ -0,0 +1,160 @@
+package dotnet
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const solution = `
+Microsoft Visual Studio Solution File, Format Version 12.00
+# Visual Studio Version 17
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Weather", "src\Weather\Weather.csproj", "{2E0B7A2D-0C6A-4E1B-9C55-6C2A4B1D0F11}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Weather.Core", "src\Weather.Core\Weather.Core.csproj", "{5A0C8F34-1E2B-4B5D-8D2E-3F4A5B6C7D81}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Weather.Tests", "tests\Weather.Tests\Weather.Tests.csproj", "{9B1D2E3F-4A5B-4C6D-8E7F-8091A2B3C4D5}"
+EndProject
+Global
+	GlobalSection(SolutionConfigurationPlatforms) = preSolution
+		Debug|Any CPU = Debug|Any CPU
+		Release|Any CPU = Release|Any CPU
+		AppStore|Any CPU = AppStore|Any CPU
+	EndGlobalSection
+EndGlobal
+`
+
+const mauiProject = `<Project Sdk="Microsoft.NET.Sdk">
+	<PropertyGroup>
+		<TargetFrameworks>net8.0-android;net8.0-ios;net8.0-maccatalyst</TargetFrameworks>
+		<TargetFrameworks Condition="$([MSBuild]::IsOSPlatform('windows'))">$(TargetFrameworks);net8.0-windows10.0.19041.0</TargetFrameworks>
+		<OutputType>Exe</OutputType>
+		<UseMaui>true</UseMaui>
+	</PropertyGroup>
+</Project>
+`
+
+const libraryProject = `<Project Sdk="Microsoft.NET.Sdk">
+	<PropertyGroup>
+		<TargetFramework>net8.0</TargetFramework>
+	</PropertyGroup>
+</Project>
+`
+
+const testProject = `<Project Sdk="Microsoft.NET.Sdk">
+	<PropertyGroup>
+		<TargetFramework>net8.0</TargetFramework>
+	</PropertyGroup>
+	<ItemGroup>
+		<PackageReference Include="Microsoft.NET.Test.Sdk" Version="17.8.0" />
+		<PackageReference Include="xunit" Version="2.6.2" />
+	</ItemGroup>
+</Project>
+`
+
+const xamarinAndroidProject = `<?xml version="1.0" encoding="utf-8"?>
+<Project ToolsVersion="15.0" xmlns="http://schemas.microsoft.com/developer/msbuild/2003">
+  <PropertyGroup>
+    <OutputType>Library</OutputType>
+    <AndroidApplication>True</AndroidApplication>
+    <TargetFrameworkVersion>v12.0</TargetFrameworkVersion>
+  </PropertyGroup>
+  <Import Project="$(MSBuildExtensionsPath)\Xamarin\Android\Xamarin.Android.CSharp.targets" />
+</Project>
+`
+
+func TestScanner(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "Weather.sln"), solution)
+	testutil.WriteFile(t, filepath.Join(dir, "src", "Weather", "Weather.csproj"), mauiProject)
+	testutil.WriteFile(t, filepath.Join(dir, "src", "Weather", "packages.lock.json"), "{}")
+	testutil.WriteFile(t, filepath.Join(dir, "src", "Weather", "obj", "Weather.csproj"), mauiProject)
+	testutil.WriteFile(t, filepath.Join(dir, "src", "Weather.Core", "Weather.Core.csproj"), libraryProject)
+	testutil.WriteFile(t, filepath.Join(dir, "tests", "Weather.Tests", "Weather.Tests.csproj"), testProject)
+	testutil.WriteFile(t, filepath.Join(dir, "legacy", "Weather.Droid.csproj"), xamarinAndroidProject)
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	require.Equal(t, []Project{
+		{
+			Solution:         "Weather.sln",
+			Path:             "src/Weather/Weather.csproj",
+			Kind:             kindMAUI,
+			TargetFrameworks: []string{"net8.0-android", "net8.0-ios"},
+			Configurations:   []string{"Release", "Debug", "AppStore"},
+			HasTests:         true,
+			HasLockFile:      true,
+		},
+		{
+			Solution:         "legacy/Weather.Droid.csproj",
+			Path:             "legacy/Weather.Droid.csproj",
+			Kind:             kindXamarin,
+			TargetFrameworks: []string{xamarinAndroidTargetFramework},
+			Configurations:   []string{"Release", "Debug"},
+		},
+	}, scanner.projects)
+
+	options, warnings, _, err := scanner.Options()
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	require.ElementsMatch(t, []string{"Weather.sln", "legacy/Weather.Droid.csproj"}, options.GetValues())
+
+	targetFrameworkOption, ok := options.Child("Weather.sln", "src/Weather/Weather.csproj", "Release")
+	require.True(t, ok)
+	require.ElementsMatch(t, []string{"net8.0-android", "net8.0-ios"}, targetFrameworkOption.GetValues())
+	require.Equal(t, "dotnet-maui-ios-tests-lockfile-config", targetFrameworkOption.ChildOptionMap["net8.0-ios"].Config)
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	require.Len(t, configs, 3)
+
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs["dotnet-maui-android-tests-lockfile-config"]), &config))
+	require.Contains(t, config.Workflows, testWorkflowID)
+	require.Contains(t, config.Workflows, "build_android")
+	require.Contains(t, configs["dotnet-maui-android-tests-lockfile-config"], "dotnet workload restore")
+	require.Contains(t, configs["dotnet-maui-android-tests-lockfile-config"], `checksum "**/packages.lock.json"`)
+
+	var xamarinConfig bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs["dotnet-xamarin-android-config"]), &xamarinConfig))
+	require.NotContains(t, xamarinConfig.Workflows, testWorkflowID)
+	require.Contains(t, configs["dotnet-xamarin-android-config"], "-t:SignAndroidPackage")
+}
+
+func TestSigningSecretEnvs(t *testing.T) {
+	configs, err := NewScanner().DefaultConfigs()
+	require.NoError(t, err)
+
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs[defaultConfigName(platformIOS)]), &config))
+
+	var keys []string
+	for _, env := range SigningSecretEnvs(config) {
+		key, value, err := env.GetKeyValuePair()
+		require.NoError(t, err)
+		require.Empty(t, value)
+		keys = append(keys, key)
+	}
+	require.Equal(t, signingSecrets[platformIOS], keys)
+
+	config.ProjectType = "other"
+	require.Nil(t, SigningSecretEnvs(config))
+}
+
+func TestDetectPlatform_notDetected(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "Library", "Library.csproj"), libraryProject)
+
+	detected, err := NewScanner().DetectPlatform(dir)
+	require.NoError(t, err)
+	require.False(t, detected)
+}
@@ -0,0 +1,540 @@
+package golang
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	"github.com/bitrise-io/bitrise-init/models"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/scannerutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	ScannerName = "go"
+
+	configName          = "go-config"
+	buildOnlyConfigName = "go-build-config"
+	defaultConfigName   = "default-go-config"
+
+	moduleDirInputTitle   = "Go module directory"
+	moduleDirInputSummary = "The directory of the `go.mod` file, relative to the repository root."
+	moduleDirInputEnvKey  = "GO_MODULE_DIR"
+
+	lintCommandInputTitle   = "Lint command"
+	lintCommandInputSummary = "The command checking the code of the module, for example `golangci-lint run ./...` or `go vet ./...`."
+	lintCommandInputEnvKey  = "GO_LINT_COMMAND"
+
+	buildCommandInputTitle   = "Build command"
+	buildCommandInputSummary = "The command building the module, for example `go build ./...` or a Makefile target."
+	buildCommandInputEnvKey  = "GO_BUILD_COMMAND"
+
+	testWorkflowID  = "run_tests"
+	buildWorkflowID = "build"
+
+	golangciLintCommand = "golangci-lint run ./..."
+	vetCommand          = "go vet ./..."
+	buildCommand        = "go build ./..."
+	// the ./... pattern does not match the packages of a go.work workspace, only the packages of its modules
+	workspacePackages     = "$(go list -f '{{.Dir}}/...' -m)"
+	workspaceVetCommand   = "go vet " + workspacePackages
+	workspaceBuildCommand = "go build " + workspacePackages
+
+	// the Go caches are moved to the same place on every stack, so that the cache steps find them
+	modCacheDir   = "~/go/pkg/mod"
+	buildCacheDir = "~/.cache/go-build"
+
+	searchDepth = 6
+)
+
+// golangciLintConfigNames are the config files golangci-lint looks for in the module dir and its parents.
+var golangciLintConfigNames = []string{".golangci.yml", ".golangci.yaml", ".golangci.toml", ".golangci.json"}
+
+// skippedDirs are not searched for modules and test files.
+var skippedDirs = []string{"vendor", "testdata"}
+
+var (
+	goDirectivePattern = regexp.MustCompile(`(?m)^go\s+(\d+(?:\.\d+)*)`)
+	modulePattern      = regexp.MustCompile(`(?m)^module\s+"?([^"\s]+)"?`)
+	// a go.work use directive, either a single dir or a block of dirs: `use ./api` or `use (`
+	useBlockPattern = regexp.MustCompile(`(?s)\buse\s*\((.*?)\)`)
+	usePattern      = regexp.MustCompile(`(?m)^\s*use\s+([^\s(]+)`)
+	// a Makefile rule: `test:` or `build lint: deps`, but not a variable assignment: `GO := go`
+	makeTargetPattern = regexp.MustCompile(`(?m)^([A-Za-z0-9_.\- ]+):([^=]|$)`)
+)
+
+// Module is a Go module or a go.work workspace with the commands its Makefile and linter config provide.
+type Module struct {
+	// Dir is relative to the search dir, "." for the search dir itself.
+	Dir string
+	// Path is the module path, empty for a workspace.
+	Path      string
+	GoVersion string
+	// HasTests is set for a workspace if any of its modules has tests.
+	HasTests bool
+	// LintCommands and BuildCommands are the commands the module can be checked and built with, the preferred one first.
+	LintCommands  []string
+	BuildCommands []string
+}
+
+// Scanner detects Go modules and go.work workspaces.
+type Scanner struct {
+	modules         []Module
+	hasDependencies bool
+}
+
+// NewScanner ...
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// Name ...
+func (*Scanner) Name() string {
+	return ScannerName
+}
+
+// DetectPlatform looks for the go.mod files and the go.work workspaces of the search dir.
+func (s *Scanner) DetectPlatform(searchDir string) (bool, error) {
+	log.TInfof("Searching for go.mod files...")
+
+	rootEntry, err := direntry.WalkDir(searchDir, searchDepth)
+	if err != nil {
+		return false, err
+	}
+
+	for _, modEntry := range rootEntry.FindAllEntriesByName("go.mod", false) {
+		if isSkipped(modEntry.RelPath) {
+			continue
+		}
+
+		module, err := inspectModule(searchDir, *modEntry.Parent())
+		if err != nil {
+			return false, err
+		}
+		s.modules = append(s.modules, module)
+
+		if modEntry.Parent().FindImmediateChildByName("go.sum", false) != nil {
+			s.hasDependencies = true
+		}
+
+		log.TPrintf("Go module found: %s (%s)", module.Path, module.Dir)
+		if module.GoVersion != "" {
+			log.TPrintf("Go version: %s", module.GoVersion)
+		}
+	}
+
+	for _, workEntry := range rootEntry.FindAllEntriesByName("go.work", false) {
+		if isSkipped(workEntry.RelPath) {
+			continue
+		}
+
+		workspace, err := inspectWorkspace(*workEntry.Parent(), s.modules)
+		if err != nil {
+			return false, err
+		}
+		log.TPrintf("go.work workspace found: %s", workspace.Dir)
+		// the tests of a module next to the go.work file already run for the whole workspace
+		if slices.ContainsFunc(s.modules, func(module Module) bool { return module.Dir == workspace.Dir }) {
+			continue
+		}
+		s.modules = append(s.modules, workspace)
+	}
+
+	if len(s.modules) == 0 {
+		log.TPrintf("platform not detected")
+		return false, nil
+	}
+
+	log.TSuccessf("Platform detected")
+	return true, nil
+}
+
+// ExcludedScannerNames ...
+func (*Scanner) ExcludedScannerNames() []string {
+	return nil
+}
+
+// Options ...
+func (s *Scanner) Options() (models.OptionNode, models.Warnings, models.Icons, error) {
+	var warnings models.Warnings
+	moduleDirOption := models.NewOption(moduleDirInputTitle, moduleDirInputSummary, moduleDirInputEnvKey, models.TypeSelector)
+
+	for _, module := range s.modules {
+		name := configName
+		if !module.HasTests {
+			name = buildOnlyConfigName
+			warnings = append(warnings, fmt.Sprintf("No _test.go files found in the %s module, the generated workflows do not run tests", module.Dir))
+		}
+
+		lintCommandOption := models.NewOption(lintCommandInputTitle, lintCommandInputSummary, lintCommandInputEnvKey, models.TypeSelector)
+		moduleDirOption.AddOption(module.Dir, lintCommandOption)
+		for _, lintCommand := range module.LintCommands {
+			buildCommandOption := models.NewOption(buildCommandInputTitle, buildCommandInputSummary, buildCommandInputEnvKey, models.TypeSelector)
+			lintCommandOption.AddOption(lintCommand, buildCommandOption)
+			for _, buildCommand := range module.BuildCommands {
+				buildCommandOption.AddConfig(buildCommand, models.NewConfigOption(name, nil))
+			}
+		}
+	}
+
+	return *moduleDirOption, warnings, nil, nil
+}
+
+// DefaultOptions ...
+func (*Scanner) DefaultOptions() models.OptionNode {
+	moduleDirOption := models.NewOption(moduleDirInputTitle, moduleDirInputSummary, moduleDirInputEnvKey, models.TypeUserInput)
+
+	lintCommandOption := models.NewOption(lintCommandInputTitle, lintCommandInputSummary, lintCommandInputEnvKey, models.TypeUserInput)
+	moduleDirOption.AddOption(models.UserInputOptionDefaultValue, lintCommandOption)
+
+	buildCommandOption := models.NewOption(buildCommandInputTitle, buildCommandInputSummary, buildCommandInputEnvKey, models.TypeUserInput)
+	lintCommandOption.AddOption(models.UserInputOptionDefaultValue, buildCommandOption)
+
+	buildCommandOption.AddConfig(models.UserInputOptionDefaultValue, models.NewConfigOption(defaultConfigName, nil))
+
+	return *moduleDirOption
+}
+
+// Configs ...
+func (s *Scanner) Configs(sshKeyActivation models.SSHKeyActivation) (models.BitriseConfigMap, error) {
+	goVersion := ""
+	for _, module := range s.modules {
+		if compareVersions(module.GoVersion, goVersion) > 0 {
+			goVersion = module.GoVersion
+		}
+	}
+
+	configs := models.BitriseConfigMap{}
+	for _, module := range s.modules {
+		name := configName
+		if !module.HasTests {
+			name = buildOnlyConfigName
+		}
+		if _, ok := configs[name]; ok {
+			continue
+		}
+
+		config, err := generateConfig(goVersion, s.hasDependencies, sshKeyActivation, module.HasTests)
+		if err != nil {
+			return models.BitriseConfigMap{}, err
+		}
+		configs[name] = config
+	}
+
+	return configs, nil
+}
+
+// DefaultConfigs ...
+func (*Scanner) DefaultConfigs() (models.BitriseConfigMap, error) {
+	config, err := generateConfig("", true, models.SSHKeyActivationConditional, true)
+	if err != nil {
+		return models.BitriseConfigMap{}, err
+	}
+
+	return models.BitriseConfigMap{defaultConfigName: config}, nil
+}
+
+func generateConfig(goVersion string, hasDependencies bool, sshKeyActivation models.SSHKeyActivation, withTests bool) (string, error) {
+	configBuilder := models.NewDefaultConfigBuilder()
+
+	workingDir := envmanModels.EnvironmentItemModel{"working_dir": "$" + moduleDirInputEnvKey}
+	cacheKey := "{{ .OS }}-{{ .Arch }}-go-{{ checksum \"**/go.mod\" }}"
+	if hasDependencies {
+		cacheKey = "{{ .OS }}-{{ .Arch }}-go-{{ checksum \"**/go.sum\" }}"
+	}
+
+	workflows := []models.WorkflowID{buildWorkflowID}
+	if withTests {
+		workflows = []models.WorkflowID{testWorkflowID, buildWorkflowID}
+	}
+	for _, workflowID := range workflows {
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultPrepareStepList(initSteps.PrepareListParams{SSHKeyActivation: sshKeyActivation})...)
+		configBuilder.AppendStepListItemsTo(workflowID,
+			initSteps.ScriptStepListItem("Set up Go", setupScriptContent(goVersion), workingDir),
+			steps.RestoreCacheStepListItem("Restore Go cache", cacheKey+"\n{{ .OS }}-{{ .Arch }}-go-"),
+		)
+
+		if workflowID == testWorkflowID {
+			configBuilder.AppendStepListItemsTo(workflowID,
+				initSteps.ScriptStepListItem("Lint", lintScriptContent, workingDir),
+				initSteps.ScriptStepListItem("Go test", testScriptContent, workingDir),
+			)
+		} else {
+			configBuilder.AppendStepListItemsTo(workflowID,
+				initSteps.ScriptStepListItem("Go build", buildScriptContent, workingDir),
+			)
+		}
+
+		configBuilder.AppendStepListItemsTo(workflowID,
+			steps.SaveCacheStepListItem("Save Go cache", cacheKey, modCacheDir+"\n"+buildCacheDir),
+		)
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultDeployStepList()...)
+	}
+
+	return scannerutil.GenerateConfig(configBuilder, ScannerName)
+}
+
+func setupScriptContent(goVersion string) string {
+	versionComment := "# Go 1.21 and later download the Go toolchain the go.mod file requires"
+	if goVersion != "" {
+		versionComment = fmt.Sprintf("# the go.mod files require Go %s, Go 1.21 and later download the required toolchain", goVersion)
+	}
+
+	return `#!/usr/bin/env bash
+set -euxo pipefail
+
+if ! command -v go &> /dev/null; then
+  case "$(uname -s)" in
+    Darwin)
+      brew install go
+      ;;
+    *)
+      version="$(curl -fsSL "https://go.dev/VERSION?m=text" | head -n 1)"
+      curl -fsSL "https://go.dev/dl/${version}.linux-$(dpkg --print-architecture).tar.gz" | sudo tar -C /usr/local -xz
+      export PATH="/usr/local/go/bin:$HOME/go/bin:$PATH"
+      envman add --key PATH --value "$PATH"
+      ;;
+  esac
+fi
+
+` + versionComment + `
+envman add --key GOTOOLCHAIN --value auto
+envman add --key GOMODCACHE --value "$HOME/go/pkg/mod"
+envman add --key GOCACHE --value "$HOME/.cache/go-build"
+GOTOOLCHAIN=auto go version
+`
+}
+
+const lintScriptContent = `#!/usr/bin/env bash
+set -euxo pipefail
+
+case "$GO_LINT_COMMAND" in
+  golangci-lint*)
+    if ! command -v golangci-lint &> /dev/null; then
+      curl -sSfL https://raw.githubusercontent.com/golangci/golangci-lint/HEAD/install.sh | sh -s -- -b "$(go env GOPATH)/bin"
+    fi
+    ;;
+esac
+eval "$GO_LINT_COMMAND"
+`
+
+// testScriptContent runs the tests with gotestsum, which converts the go test output
+// to a JUnit report in the test result dir of the build.
+const testScriptContent = `#!/usr/bin/env bash
+set -euxo pipefail
+
+report_dir="$BITRISE_TEST_RESULT_DIR/go-test"
+mkdir -p "$report_dir"
+echo '{"test-name": "go test"}' > "$report_dir/test-info.json"
+
+packages="./..."
+if [ -f go.work ]; then
+  # the packages of every module of the go.work workspace
+  packages="` + workspacePackages + `"
+fi
+go run gotest.tools/gotestsum@latest --junitfile "$report_dir/report.xml" -- $packages
+`
+
+const buildScriptContent = `#!/usr/bin/env bash
+set -euxo pipefail
+
+eval "$GO_BUILD_COMMAND"
+`
+
+func inspectModule(searchDir string, moduleDir direntry.DirEntry) (Module, error) {
+	module := Module{Dir: fsutil.RelPath(moduleDir.RelPath)}
+
+	goMod, err := os.ReadFile(filepath.Join(moduleDir.AbsPath, "go.mod"))
+	if err != nil {
+		return Module{}, fmt.Errorf("read %s: %s", filepath.Join(module.Dir, "go.mod"), err)
+	}
+	if match := modulePattern.FindSubmatch(goMod); match != nil {
+		module.Path = string(match[1])
+	}
+	if match := goDirectivePattern.FindSubmatch(goMod); match != nil {
+		module.GoVersion = string(match[1])
+	}
+
+	module.HasTests, err = hasTestFiles(moduleDir.AbsPath)
+	if err != nil {
+		return Module{}, err
+	}
+
+	makeTargets, err := parseMakeTargets(filepath.Join(moduleDir.AbsPath, "Makefile"))
+	if err != nil {
+		return Module{}, err
+	}
+
+	if hasGolangciLintConfig(searchDir, moduleDir.AbsPath) {
+		module.LintCommands = append(module.LintCommands, golangciLintCommand)
+	}
+	if slices.Contains(makeTargets, "lint") {
+		module.LintCommands = append(module.LintCommands, "make lint")
+	}
+	module.LintCommands = append(module.LintCommands, vetCommand)
+
+	if slices.Contains(makeTargets, "build") {
+		module.BuildCommands = append(module.BuildCommands, "make build")
+	}
+	module.BuildCommands = append(module.BuildCommands, buildCommand)
+
+	return module, nil
+}
+
+// inspectWorkspace returns the go.work workspace of the dir, it has tests if any of the modules it uses has tests.
+func inspectWorkspace(workspaceDir direntry.DirEntry, modules []Module) (Module, error) {
+	workspace := Module{Dir: fsutil.RelPath(workspaceDir.RelPath)}
+
+	goWork, err := os.ReadFile(filepath.Join(workspaceDir.AbsPath, "go.work"))
+	if err != nil {
+		return Module{}, fmt.Errorf("read %s: %s", filepath.Join(workspace.Dir, "go.work"), err)
+	}
+	if match := goDirectivePattern.FindSubmatch(goWork); match != nil {
+		workspace.GoVersion = string(match[1])
+	}
+
+	for _, useDir := range parseUseDirs(string(goWork)) {
+		moduleDir := fsutil.RelPath(filepath.Join(workspace.Dir, useDir))
+		for _, module := range modules {
+			if module.Dir == moduleDir && module.HasTests {
+				workspace.HasTests = true
+			}
+		}
+	}
+
+	makeTargets, err := parseMakeTargets(filepath.Join(workspaceDir.AbsPath, "Makefile"))
+	if err != nil {
+		return Module{}, err
+	}
+
+	if slices.Contains(makeTargets, "lint") {
+		workspace.LintCommands = append(workspace.LintCommands, "make lint")
+	}
+	workspace.LintCommands = append(workspace.LintCommands, workspaceVetCommand)
+
+	if slices.Contains(makeTargets, "build") {
+		workspace.BuildCommands = append(workspace.BuildCommands, "make build")
+	}
+	workspace.BuildCommands = append(workspace.BuildCommands, workspaceBuildCommand)
+
+	return workspace, nil
+}
+
+// parseUseDirs returns the module dirs of a go.work file.
+func parseUseDirs(content string) []string {
+	var dirs []string
+	for _, match := range useBlockPattern.FindAllStringSubmatch(content, -1) {
+		for _, line := range strings.Split(match[1], "\n") {
+			line = strings.TrimSpace(strings.SplitN(line, "//", 2)[0])
+			if line != "" {
+				dirs = append(dirs, strings.Trim(line, `"`))
+			}
+		}
+	}
+	for _, match := range usePattern.FindAllStringSubmatch(content, -1) {
+		dirs = append(dirs, strings.Trim(match[1], `"`))
+	}
+	return dirs
+}
+
+// parseMakeTargets returns the rule targets of the Makefile, nil if there is no Makefile.
+func parseMakeTargets(pth string) ([]string, error) {
+	content, err := os.ReadFile(pth)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %s", pth, err)
+	}
+
+	var targets []string
+	for _, match := range makeTargetPattern.FindAllStringSubmatch(string(content), -1) {
+		targets = append(targets, strings.Fields(match[1])...)
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+// hasTestFiles reports whether the module has _test.go files, the nested modules are not part of the module.
+func hasTestFiles(moduleDir string) (bool, error) {
+	found := false
+	err := filepath.WalkDir(moduleDir, func(pth string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if pth == moduleDir {
+				return nil
+			}
+			if strings.HasPrefix(entry.Name(), ".") || strings.HasPrefix(entry.Name(), "_") || slices.Contains(skippedDirs, entry.Name()) {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(pth, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(entry.Name(), "_test.go") {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found, err
+}
+
+// hasGolangciLintConfig reports whether the module dir or one of its parents in the search dir has a golangci-lint config.
+func hasGolangciLintConfig(searchDir, moduleDir string) bool {
+	searchDir = filepath.Clean(searchDir)
+	for dir := filepath.Clean(moduleDir); ; dir = filepath.Dir(dir) {
+		for _, name := range golangciLintConfigNames {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return true
+			}
+		}
+		if dir == searchDir || dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
+
+// compareVersions compares two dot separated versions, an empty version is lower than any other.
+func compareVersions(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aPart, bPart := -1, -1
+		if i < len(aParts) && aParts[i] != "" {
+			aPart, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) && bParts[i] != "" {
+			bPart, _ = strconv.Atoi(bParts[i])
+		}
+		if aPart != bPart {
+			if aPart < bPart {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func isSkipped(relPth string) bool {
+	for _, component := range strings.Split(filepath.ToSlash(relPth), "/") {
+		if slices.Contains(skippedDirs, component) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package golang
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "backend", "go.work"), "go 1.22\n\nuse (\n\t./api // the public API\n\t./worker\n)\n")
+	testutil.WriteFile(t, filepath.Join(dir, "backend", ".golangci.yml"), "linters:\n  enable: [gofmt]\n")
+	testutil.WriteFile(t, filepath.Join(dir, "backend", "api", "go.mod"), "module github.com/org/backend/api\n\ngo 1.22.3\n")
+	testutil.WriteFile(t, filepath.Join(dir, "backend", "api", "go.sum"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "backend", "api", "Makefile"), "GO := go\n\n.PHONY: build lint\nbuild:\n\t$(GO) build ./...\n\nlint: deps\n\tgolangci-lint run\n")
+	testutil.WriteFile(t, filepath.Join(dir, "backend", "api", "server", "server_test.go"), "package server\n")
+	testutil.WriteFile(t, filepath.Join(dir, "backend", "worker", "go.mod"), "module github.com/org/backend/worker\n\ngo 1.21\n")
+	testutil.WriteFile(t, filepath.Join(dir, "backend", "worker", "tools", "go.mod"), "module github.com/org/backend/worker/tools\n\ngo 1.21\n")
+	testutil.WriteFile(t, filepath.Join(dir, "backend", "worker", "tools", "gen_test.go"), "package tools\n")
+	testutil.WriteFile(t, filepath.Join(dir, "backend", "worker", "vendor", "example.com", "lib", "go.mod"), "module example.com/lib\n")
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	require.Equal(t, []Module{
+		{
+			Dir:           "backend/api",
+			Path:          "github.com/org/backend/api",
+			GoVersion:     "1.22.3",
+			HasTests:      true,
+			LintCommands:  []string{golangciLintCommand, "make lint", vetCommand},
+			BuildCommands: []string{"make build", buildCommand},
+		},
+		{
+			Dir:           "backend/worker",
+			Path:          "github.com/org/backend/worker",
+			GoVersion:     "1.21",
+			LintCommands:  []string{golangciLintCommand, vetCommand},
+			BuildCommands: []string{buildCommand},
+		},
+		{
+			Dir:           "backend/worker/tools",
+			Path:          "github.com/org/backend/worker/tools",
+			GoVersion:     "1.21",
+			HasTests:      true,
+			LintCommands:  []string{golangciLintCommand, vetCommand},
+			BuildCommands: []string{buildCommand},
+		},
+		{
+			Dir:           "backend",
+			GoVersion:     "1.22",
+			HasTests:      true,
+			LintCommands:  []string{workspaceVetCommand},
+			BuildCommands: []string{workspaceBuildCommand},
+		},
+	}, scanner.modules)
+
+	options, warnings, _, err := scanner.Options()
+	require.NoError(t, err)
+	require.Equal(t, models.Warnings{"No _test.go files found in the backend/worker module, the generated workflows do not run tests"}, warnings)
+	require.ElementsMatch(t, []string{"backend/api", "backend/worker", "backend/worker/tools", "backend"}, options.GetValues())
+	buildCommandOption, ok := options.Child("backend/api", golangciLintCommand)
+	require.True(t, ok)
+	require.Equal(t, buildCommandInputEnvKey, buildCommandOption.EnvKey)
+	require.ElementsMatch(t, []string{"make build", buildCommand}, buildCommandOption.GetValues())
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs[configName]), &config))
+	var ids []string
+	for _, item := range config.Workflows[testWorkflowID].Steps {
+		ids = append(ids, steps.ID(item))
+	}
+	require.Equal(t, []string{
+		initSteps.GitCloneID,
+		initSteps.ScriptID,
+		steps.RestoreCacheID,
+		initSteps.ScriptID,
+		initSteps.ScriptID,
+		steps.SaveCacheID,
+		initSteps.DeployToBitriseIoID,
+	}, ids)
+
+	setupStep, err := config.Workflows[buildWorkflowID].Steps[1].GetStep()
+	require.NoError(t, err)
+	require.Contains(t, setupStep.Inputs[0]["content"], "# the go.mod files require Go 1.22.3")
+	saveCacheStep, err := config.Workflows[buildWorkflowID].Steps[4].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, `{{ .OS }}-{{ .Arch }}-go-{{ checksum "**/go.sum" }}`, saveCacheStep.Inputs[0]["key"])
+
+	var buildOnlyConfig bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs[buildOnlyConfigName]), &buildOnlyConfig))
+	require.NotContains(t, buildOnlyConfig.Workflows, testWorkflowID)
+}
+
+func TestScanner_notDetected(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "vendor", "go.mod"), "module example.com/vendored\n")
+
+	detected, err := NewScanner().DetectPlatform(dir)
+	require.NoError(t, err)
+	require.False(t, detected)
+}
+
+func TestCompareVersions(t *testing.T) {
+	require.Equal(t, 1, compareVersions("1.22", "1.21.5"))
+	require.Equal(t, -1, compareVersions("1.21", "1.21.0"))
+	require.Equal(t, 0, compareVersions("1.22.3", "1.22.3"))
+	require.Equal(t, 1, compareVersions("1.9", ""))
+}
@@ -0,0 +1,435 @@
+package java
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	"github.com/bitrise-io/bitrise-init/detectors/gradle"
+	"github.com/bitrise-io/bitrise-init/models"
+	initJava "github.com/bitrise-io/bitrise-init/scanners/java"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-init/utility"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/scannerutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	ScannerName = initJava.ProjectType
+
+	gradleConfigName        = "java-gradle-config"
+	defaultGradleConfigName = "default-java-gradle-config"
+	defaultMavenConfigName  = "default-java-maven-config"
+
+	buildToolInputTitle   = "Build tool"
+	buildToolInputSummary = "The build tool used in the project. Supported options: Gradle, Maven."
+	buildToolGradle       = "Gradle"
+	buildToolMaven        = "Maven"
+
+	projectRootDirInputEnvKey     = "PROJECT_ROOT_DIR"
+	gradleProjectRootDirTitle     = "The root directory of the Gradle project."
+	gradleProjectRootDirSummary   = "The root directory of the Gradle project, which contains all source files from your project, as well as Gradle files, including the Gradle Wrapper (`gradlew`) file."
+	mavenProjectRootDirTitle      = "The root directory of the Maven project."
+	mavenProjectRootDirSummary    = "The directory of the root `pom.xml` file of the Maven project, relative to the repository root."
+	mavenProjectsArgsInputTitle   = "Maven modules"
+	mavenProjectsArgsInputSummary = "The modules the tests and the package build run on, `--fail-at-end` for every module of the reactor, `--projects <module> --also-make` for a module and the modules it depends on."
+	mavenProjectsArgsInputEnvKey  = "MAVEN_PROJECTS_ARGS"
+
+	testWorkflowID  = "run_tests"
+	buildWorkflowID = "build"
+
+	reactorArgs = "--fail-at-end"
+
+	searchDepth = 6
+)
+
+// MavenProject is a Maven project with its root pom.xml, and the modules of the reactor if it is a multi-module build.
+type MavenProject struct {
+	// Dir is relative to the search dir, "." for the search dir itself.
+	Dir string
+	// Modules are the module dirs relative to Dir, the aggregator modules which only list other modules are left out.
+	Modules []string
+	// HasWrapper is set if the project has the Maven wrapper (mvnw), the system Maven is used otherwise.
+	HasWrapper bool
+}
+
+// pom is the part of a pom.xml the scanner reads.
+type pom struct {
+	Packaging string   `xml:"packaging"`
+	Modules   []string `xml:"modules>module"`
+}
+
+// Scanner detects Gradle and Maven JVM projects. It replaces the bitrise-init Java scanner, which handles
+// a single Maven project only if it has the Maven wrapper, and does not export the test reports.
+type Scanner struct {
+	// gradleProjectDir is set if a Gradle project is found, a Gradle project takes precedence over the Maven projects.
+	gradleProjectDir string
+	mavenProjects    []MavenProject
+}
+
+// NewScanner ...
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// Name ...
+func (*Scanner) Name() string {
+	return ScannerName
+}
+
+// DetectPlatform looks for a Gradle project with the Gradle wrapper, and for the Maven projects if there is none.
+func (s *Scanner) DetectPlatform(searchDir string) (bool, error) {
+	log.TInfof("Searching for Gradle project files...")
+
+	rootEntry, err := direntry.WalkDir(searchDir, searchDepth)
+	if err != nil {
+		return false, err
+	}
+
+	gradleWrapperScripts := rootEntry.FindAllEntriesByName("gradlew", false)
+	if len(gradleWrapperScripts) > 0 {
+		projectRootDir := gradleWrapperScripts[0].Parent()
+		if projectRootDir == nil {
+			return false, fmt.Errorf("failed to get parent directory of %s", gradleWrapperScripts[0].AbsPath)
+		}
+		gradleProject, err := gradle.ScanProject(*projectRootDir)
+		if err != nil {
+			return false, err
+		}
+		if gradleProject != nil {
+			s.gradleProjectDir = fsutil.RelPath(gradleProject.RootDirEntry.RelPath)
+			log.TPrintf("Gradle project found: %s", s.gradleProjectDir)
+			log.TSuccessf("Platform detected")
+			return true, nil
+		}
+		log.TWarnf("No Gradle project found in %s", projectRootDir.AbsPath)
+	}
+
+	log.TInfof("Searching for Maven project files...")
+
+	var dirs []string
+	for _, entry := range rootEntry.FindAllEntriesByName("pom.xml", false) {
+		if isInTargetDir(entry.RelPath) {
+			continue
+		}
+		dirs = append(dirs, fsutil.RelPath(filepath.Dir(entry.RelPath)))
+	}
+	// the parent projects are visited before their modules
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return dirDepth(dirs[i]) < dirDepth(dirs[j])
+	})
+
+	members := map[string]bool{}
+	for _, dir := range dirs {
+		if members[dir] {
+			continue
+		}
+		members[dir] = true
+
+		project := MavenProject{Dir: dir, HasWrapper: utility.FileExists(filepath.Join(searchDir, dir, "mvnw"))}
+		moduleDirs, err := modules(searchDir, dir, members)
+		if err != nil {
+			return false, err
+		}
+		for _, moduleDir := range moduleDirs {
+			rel, err := filepath.Rel(dir, moduleDir)
+			if err != nil {
+				return false, err
+			}
+			project.Modules = append(project.Modules, filepath.ToSlash(rel))
+		}
+
+		if len(project.Modules) > 0 {
+			log.TPrintf("Maven project found: %s (%s)", project.Dir, strings.Join(project.Modules, ", "))
+		} else {
+			log.TPrintf("Maven project found: %s", project.Dir)
+		}
+		if !project.HasWrapper {
+			log.TWarnf("No Maven wrapper (mvnw) found in %s, the system Maven is used", project.Dir)
+		}
+		s.mavenProjects = append(s.mavenProjects, project)
+	}
+
+	if len(s.mavenProjects) == 0 {
+		log.TPrintf("platform not detected")
+		return false, nil
+	}
+
+	sort.SliceStable(s.mavenProjects, func(i, j int) bool { return s.mavenProjects[i].Dir < s.mavenProjects[j].Dir })
+	log.TSuccessf("Platform detected")
+	return true, nil
+}
+
+// ExcludedScannerNames excludes the bitrise-init Java scanner, this scanner's output replaces it.
+func (*Scanner) ExcludedScannerNames() []string {
+	return []string{initJava.ProjectType}
+}
+
+// Options ...
+func (s *Scanner) Options() (models.OptionNode, models.Warnings, models.Icons, error) {
+	if s.gradleProjectDir != "" {
+		projectRootDirOption := models.NewOption(gradleProjectRootDirTitle, gradleProjectRootDirSummary, projectRootDirInputEnvKey, models.TypeSelector)
+		projectRootDirOption.AddConfig(s.gradleProjectDir, models.NewConfigOption(gradleConfigName, nil))
+		return *projectRootDirOption, nil, nil, nil
+	}
+
+	var warnings models.Warnings
+	projectRootDirOption := models.NewOption(mavenProjectRootDirTitle, mavenProjectRootDirSummary, projectRootDirInputEnvKey, models.TypeSelector)
+	for _, project := range s.mavenProjects {
+		if !project.HasWrapper {
+			warnings = append(warnings, fmt.Sprintf("No Maven wrapper (mvnw) found in %s, the workflows run the Maven installed on the stack. Add the Maven wrapper to the project to pin the Maven version.", project.Dir))
+		}
+
+		name := mavenConfigName(project)
+		if len(project.Modules) == 0 {
+			projectRootDirOption.AddConfig(project.Dir, models.NewConfigOption(name, nil))
+			continue
+		}
+
+		projectsArgsOption := models.NewOption(mavenProjectsArgsInputTitle, mavenProjectsArgsInputSummary, mavenProjectsArgsInputEnvKey, models.TypeSelector)
+		projectRootDirOption.AddOption(project.Dir, projectsArgsOption)
+		projectsArgsOption.AddConfig(reactorArgs, models.NewConfigOption(name, nil))
+		for _, module := range project.Modules {
+			projectsArgsOption.AddConfig(fmt.Sprintf("--projects %s --also-make", module), models.NewConfigOption(name, nil))
+		}
+	}
+
+	return *projectRootDirOption, warnings, nil, nil
+}
+
+// DefaultOptions ...
+func (*Scanner) DefaultOptions() models.OptionNode {
+	buildToolOption := models.NewOption(buildToolInputTitle, buildToolInputSummary, "", models.TypeSelector)
+
+	gradleProjectRootDirOption := models.NewOption(gradleProjectRootDirTitle, gradleProjectRootDirSummary, projectRootDirInputEnvKey, models.TypeUserInput)
+	buildToolOption.AddOption(buildToolGradle, gradleProjectRootDirOption)
+	gradleProjectRootDirOption.AddConfig(models.UserInputOptionDefaultValue, models.NewConfigOption(defaultGradleConfigName, nil))
+
+	mavenProjectRootDirOption := models.NewOption(mavenProjectRootDirTitle, mavenProjectRootDirSummary, projectRootDirInputEnvKey, models.TypeUserInput)
+	buildToolOption.AddOption(buildToolMaven, mavenProjectRootDirOption)
+	mavenProjectRootDirOption.AddConfig(models.UserInputOptionDefaultValue, models.NewConfigOption(defaultMavenConfigName, nil))
+
+	return *buildToolOption
+}
+
+// Configs ...
+func (s *Scanner) Configs(sshKeyActivation models.SSHKeyActivation) (models.BitriseConfigMap, error) {
+	if s.gradleProjectDir != "" {
+		config, err := generateGradleConfig(sshKeyActivation)
+		if err != nil {
+			return models.BitriseConfigMap{}, err
+		}
+		return models.BitriseConfigMap{gradleConfigName: config}, nil
+	}
+
+	configs := models.BitriseConfigMap{}
+	for _, project := range s.mavenProjects {
+		name := mavenConfigName(project)
+		if _, ok := configs[name]; ok {
+			continue
+		}
+
+		config, err := generateMavenConfig(project, sshKeyActivation)
+		if err != nil {
+			return models.BitriseConfigMap{}, err
+		}
+		configs[name] = config
+	}
+
+	return configs, nil
+}
+
+// DefaultConfigs ...
+func (*Scanner) DefaultConfigs() (models.BitriseConfigMap, error) {
+	gradleConfig, err := generateGradleConfig(models.SSHKeyActivationConditional)
+	if err != nil {
+		return models.BitriseConfigMap{}, err
+	}
+
+	mavenConfig, err := generateMavenConfig(MavenProject{HasWrapper: true}, models.SSHKeyActivationConditional)
+	if err != nil {
+		return models.BitriseConfigMap{}, err
+	}
+
+	return models.BitriseConfigMap{
+		defaultGradleConfigName: gradleConfig,
+		defaultMavenConfigName:  mavenConfig,
+	}, nil
+}
+
+// mavenConfigName returns the name of the Maven project's config, the configs differ in the Maven command
+// and in the modules the build runs on.
+func mavenConfigName(project MavenProject) string {
+	name := "java-maven"
+	if !project.HasWrapper {
+		name += "-no-wrapper"
+	}
+	if len(project.Modules) > 0 {
+		name += "-multi-module"
+	}
+	return name + "-config"
+}
+
+func generateGradleConfig(sshKeyActivation models.SSHKeyActivation) (string, error) {
+	configBuilder := models.NewDefaultConfigBuilder()
+
+	configBuilder.AppendStepListItemsTo(testWorkflowID, initSteps.DefaultPrepareStepList(initSteps.PrepareListParams{SSHKeyActivation: sshKeyActivation})...)
+	configBuilder.AppendStepListItemsTo(testWorkflowID,
+		initSteps.GradleUnitTestStepListItem("$"+projectRootDirInputEnvKey),
+		testReportsStepListItem(gradleTestReportsScriptContent),
+	)
+	configBuilder.AppendStepListItemsTo(testWorkflowID, initSteps.DefaultDeployStepList()...)
+
+	return scannerutil.GenerateConfig(configBuilder, ScannerName)
+}
+
+func generateMavenConfig(project MavenProject, sshKeyActivation models.SSHKeyActivation) (string, error) {
+	configBuilder := models.NewDefaultConfigBuilder()
+
+	workingDir := envmanModels.EnvironmentItemModel{"working_dir": "$" + projectRootDirInputEnvKey}
+	// the modules depend on each other through the reactor, any pom.xml change can change the resolved dependencies
+	cacheKey := `{{ .OS }}-{{ .Arch }}-maven-{{ checksum "**/pom.xml" }}`
+
+	mvn := "./mvnw"
+	if !project.HasWrapper {
+		mvn = "mvn"
+	}
+	mvn += " --batch-mode"
+	if len(project.Modules) > 0 {
+		mvn += " $" + mavenProjectsArgsInputEnvKey
+	}
+
+	for _, workflowID := range []models.WorkflowID{testWorkflowID, buildWorkflowID} {
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultPrepareStepList(initSteps.PrepareListParams{SSHKeyActivation: sshKeyActivation})...)
+		configBuilder.AppendStepListItemsTo(workflowID, steps.RestoreCacheStepListItem("Restore Maven cache", cacheKey+"\n{{ .OS }}-{{ .Arch }}-maven-"))
+
+		if workflowID == testWorkflowID {
+			configBuilder.AppendStepListItemsTo(workflowID,
+				// verify runs the Failsafe integration tests after the Surefire unit tests
+				initSteps.ScriptStepListItem("Run Maven tests", scannerutil.CommandScriptContent(mvn+" verify"), workingDir),
+				testReportsStepListItem(mavenTestReportsScriptContent),
+			)
+		} else {
+			configBuilder.AppendStepListItemsTo(workflowID,
+				initSteps.ScriptStepListItem("Maven package", scannerutil.CommandScriptContent(mvn+" package -DskipTests")+packageArtifactsScript, workingDir),
+			)
+		}
+
+		configBuilder.AppendStepListItemsTo(workflowID, steps.SaveCacheStepListItem("Save Maven cache", cacheKey, "~/.m2/repository"))
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultDeployStepList()...)
+	}
+
+	return scannerutil.GenerateConfig(configBuilder, ScannerName)
+}
+
+// testReportsStepListItem returns the step exporting the JUnit XML reports as Bitrise test reports,
+// it runs after failed tests too.
+func testReportsStepListItem(content string) bitriseModels.StepListItemModel {
+	return steps.AlwaysRun(initSteps.ScriptStepListItem("Export test reports", content, envmanModels.EnvironmentItemModel{"working_dir": "$" + projectRootDirInputEnvKey}))
+}
+
+var (
+	mavenTestReportsScriptContent  = testReportsScriptContent(`\( -path '*/target/surefire-reports/*' -o -path '*/target/failsafe-reports/*' \)`, "target")
+	gradleTestReportsScriptContent = testReportsScriptContent(`-path '*/build/test-results/*'`, "build/test-results")
+)
+
+// testReportsScriptContent exports the reports of every module's test task as a separate test run,
+// named after the module dir and the report dir.
+func testReportsScriptContent(pathCondition, buildDir string) string {
+	return `#!/usr/bin/env bash
+set -euxo pipefail
+
+find . -name 'TEST-*.xml' ` + pathCondition + ` -exec dirname {} \; | sort -u | while read -r reports_dir; do
+  module_dir="${reports_dir%/` + buildDir + `/*}"
+  module_name="${module_dir#./}"
+  if [ "$module_name" = "." ]; then
+    module_name="$(basename "$PWD")"
+  fi
+  test_name="$(echo "$module_name" | tr '/' '-') $(basename "$reports_dir")"
+  test_run_dir="$BITRISE_TEST_RESULT_DIR/$(echo "$test_name" | tr ' ' '_')"
+  mkdir -p "$test_run_dir"
+  cp "$reports_dir"/TEST-*.xml "$test_run_dir/"
+  echo "{\"test-name\": \"$test_name\"}" > "$test_run_dir/test-info.json"
+done
+`
+}
+
+// packageArtifactsScript copies the packaged archives of the modules to the deploy dir.
+const packageArtifactsScript = `find . -path '*/target/*' -not -path '*/target/*/*' \( -name '*.jar' -o -name '*.war' -o -name '*.ear' \) -exec cp {} "$BITRISE_DEPLOY_DIR/" \;
+`
+
+// modules returns the module dirs of the project relative to the search dir, with the modules of the modules,
+// and marks them as members of the project. The aggregator modules are left out, they have no sources to build.
+func modules(searchDir, dir string, members map[string]bool) ([]string, error) {
+	project, err := readPOM(filepath.Join(searchDir, dir, "pom.xml"))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %s", filepath.Join(dir, "pom.xml"), err)
+	}
+
+	var moduleDirs []string
+	for _, module := range project.Modules {
+		moduleDir := fsutil.RelPath(filepath.Join(dir, strings.TrimSpace(module)))
+		if members[moduleDir] {
+			continue
+		}
+		members[moduleDir] = true
+
+		pomPth := filepath.Join(searchDir, moduleDir, "pom.xml")
+		if !utility.FileExists(pomPth) {
+			log.TWarnf("Module %s of %s has no pom.xml", module, dir)
+			continue
+		}
+		moduleProject, err := readPOM(pomPth)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %s", filepath.Join(moduleDir, "pom.xml"), err)
+		}
+		if moduleProject.Packaging != "pom" {
+			moduleDirs = append(moduleDirs, moduleDir)
+		}
+
+		subModuleDirs, err := modules(searchDir, moduleDir, members)
+		if err != nil {
+			return nil, err
+		}
+		moduleDirs = append(moduleDirs, subModuleDirs...)
+	}
+	return moduleDirs, nil
+}
+
+func readPOM(pth string) (pom, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return pom{}, err
+	}
+
+	var project pom
+	if err := xml.Unmarshal(content, &project); err != nil {
+		return pom{}, err
+	}
+	project.Packaging = strings.TrimSpace(project.Packaging)
+	return project, nil
+}
+
+func dirDepth(dir string) int {
+	if dir == "." {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+func isInTargetDir(relPth string) bool {
+	for _, component := range strings.Split(filepath.ToSlash(relPth), "/") {
+		if component == "target" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package java
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScannerMaven(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "pom.xml"), `<project>
+  <artifactId>shop</artifactId>
+  <packaging>pom</packaging>
+  <modules>
+    <module>core</module>
+    <module>services</module>
+    <module>missing</module>
+  </modules>
+</project>`)
+	testutil.WriteFile(t, filepath.Join(dir, "mvnw"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "core", "pom.xml"), "<project><artifactId>core</artifactId></project>")
+	testutil.WriteFile(t, filepath.Join(dir, "core", "target", "classes", "pom.xml"), "<project></project>")
+	testutil.WriteFile(t, filepath.Join(dir, "services", "pom.xml"), `<project>
+  <packaging>pom</packaging>
+  <modules><module> api </module></modules>
+</project>`)
+	testutil.WriteFile(t, filepath.Join(dir, "services", "api", "pom.xml"), "<project><packaging>war</packaging></project>")
+	testutil.WriteFile(t, filepath.Join(dir, "tools", "cli", "pom.xml"), "<project><artifactId>cli</artifactId></project>")
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	require.Equal(t, []MavenProject{
+		{Dir: ".", Modules: []string{"core", "services/api"}, HasWrapper: true},
+		{Dir: "tools/cli"},
+	}, scanner.mavenProjects)
+
+	options, warnings, _, err := scanner.Options()
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	require.ElementsMatch(t, []string{".", "tools/cli"}, options.GetValues())
+	require.Equal(t, "java-maven-no-wrapper-config", options.ChildOptionMap["tools/cli"].Config)
+	projectsArgsOption, ok := options.Child(".")
+	require.True(t, ok)
+	require.Equal(t, mavenProjectsArgsInputEnvKey, projectsArgsOption.EnvKey)
+	require.ElementsMatch(t, []string{"--fail-at-end", "--projects core --also-make", "--projects services/api --also-make"}, projectsArgsOption.GetValues())
+	require.Equal(t, "java-maven-multi-module-config", projectsArgsOption.ChildOptionMap["--fail-at-end"].Config)
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs["java-maven-multi-module-config"]), &config))
+	testSteps := config.Workflows[testWorkflowID].Steps
+	require.Equal(t, []string{
+		initSteps.GitCloneID,
+		steps.RestoreCacheID,
+		initSteps.ScriptID,
+		initSteps.ScriptID,
+		steps.SaveCacheID,
+		initSteps.DeployToBitriseIoID,
+	}, stepIDs(testSteps))
+
+	restoreStep, err := testSteps[1].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, "{{ .OS }}-{{ .Arch }}-maven-{{ checksum \"**/pom.xml\" }}\n{{ .OS }}-{{ .Arch }}-maven-", restoreStep.Inputs[0]["key"])
+	testStep, err := testSteps[2].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, "#!/usr/bin/env bash\nset -euxo pipefail\n\n./mvnw --batch-mode $MAVEN_PROJECTS_ARGS verify\n", testStep.Inputs[0]["content"])
+	reportsStep, err := testSteps[3].GetStep()
+	require.NoError(t, err)
+	require.True(t, *reportsStep.IsAlwaysRun)
+	require.Contains(t, reportsStep.Inputs[0]["content"], "-path '*/target/failsafe-reports/*'")
+
+	config = bitriseModels.BitriseDataModel{}
+	require.NoError(t, yaml.Unmarshal([]byte(configs["java-maven-no-wrapper-config"]), &config))
+	packageStep, err := config.Workflows[buildWorkflowID].Steps[2].GetStep()
+	require.NoError(t, err)
+	require.Contains(t, packageStep.Inputs[0]["content"], "\nmvn --batch-mode package -DskipTests\n")
+}
+
+func TestScannerGradle(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "backend", "gradlew"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "backend", "settings.gradle.kts"), "include(\":api\")\n")
+	testutil.WriteFile(t, filepath.Join(dir, "backend", "build.gradle.kts"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "pom.xml"), "<project></project>")
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+	require.Equal(t, "backend", scanner.gradleProjectDir)
+	require.Empty(t, scanner.mavenProjects)
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs[gradleConfigName]), &config))
+	testSteps := config.Workflows[testWorkflowID].Steps
+	require.Equal(t, []string{initSteps.GitCloneID, initSteps.GradleUnitTestID, initSteps.ScriptID, initSteps.DeployToBitriseIoID}, stepIDs(testSteps))
+	reportsStep, err := testSteps[2].GetStep()
+	require.NoError(t, err)
+	require.True(t, *reportsStep.IsAlwaysRun)
+	require.Contains(t, reportsStep.Inputs[0]["content"], `module_dir="${reports_dir%/build/test-results/*}"`)
+}
+
+func stepIDs(stepList []bitriseModels.StepListItemModel) []string {
+	var ids []string
+	for _, item := range stepList {
+		ids = append(ids, steps.ID(item))
+	}
+	return ids
+}
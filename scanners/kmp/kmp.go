@@ -0,0 +1,430 @@
+package kmp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	"github.com/bitrise-io/bitrise-init/detectors/gradle"
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	"github.com/bitrise-io/bitrise-init/scanners/java"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/androidvariants"
+	"github.com/bitrise-io/bitrise-plugins-init/gradlescript"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/scannerutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	// ScannerName is the name of the bitrise-init Kotlin Multiplatform scanner, the output of this scanner replaces it.
+	ScannerName = "kotlin-multiplatform"
+
+	configName           = "kotlin-multiplatform-config"
+	defaultConfigName    = "default-kotlin-multiplatform-config"
+	defaultIOSConfigName = "default-kotlin-multiplatform-ios-config"
+
+	projectRootDirInputTitle   = "The root directory of the Gradle project."
+	projectRootDirInputSummary = "The root directory of the Gradle project, which contains all source files from your project, as well as Gradle files, including the Gradle Wrapper (`gradlew`) file."
+	projectRootDirInputEnvKey  = "PROJECT_ROOT_DIR"
+
+	iosInputTitle   = "iOS app"
+	iosInputSummary = "Build and test the iOS app consuming the shared framework, next to the shared module tests and the Android build."
+	includeIOS      = "yes"
+	excludeIOS      = "no"
+
+	testWorkflowID    = "run_tests"
+	androidWorkflowID = "build_android"
+	iosWorkflowID     = "build_ios"
+	PipelineID        = "build_all"
+	debugVariant      = "Debug"
+
+	searchDepth = 6
+)
+
+// kotlinMultiplatformDependencies are the plugin IDs of the Kotlin Multiplatform Gradle plugin.
+var kotlinMultiplatformDependencies = []string{
+	"org.jetbrains.kotlin.multiplatform",
+	`kotlin("multiplatform")`,
+}
+
+// Project is a Kotlin Multiplatform Gradle project, with the iOS apps consuming its shared framework.
+type Project struct {
+	// Dir is relative to the search dir, "." for the search dir itself.
+	Dir string
+	// AndroidAppModules are the module dirs applying the Android application plugin, relative to Dir.
+	AndroidAppModules []string
+	// IOSProjects are the Xcode projects in the project dir, their paths are relative to the search dir.
+	IOSProjects ios.DetectResult
+	// HasXCFramework is set if a build script declares an XCFramework, the iOS app consumes the assembled XCFramework
+	// instead of the framework the embedAndSignAppleFrameworkForXcode task builds in the app's build phase.
+	HasXCFramework bool
+}
+
+type configDescriptor struct {
+	hasAndroidApp  bool
+	hasPodfile     bool
+	hasXCTests     bool
+	hasXCFramework bool
+}
+
+func (d configDescriptor) configName() string {
+	name := "kotlin-multiplatform-ios"
+	if d.hasAndroidApp {
+		name += "-android"
+	}
+	if d.hasPodfile {
+		name += "-pod"
+	}
+	if d.hasXCTests {
+		name += "-test"
+	}
+	if d.hasXCFramework {
+		name += "-xcframework"
+	}
+	return name + "-config"
+}
+
+// Scanner detects Kotlin Multiplatform projects and their iOS apps. It replaces the bitrise-init Kotlin Multiplatform
+// scanner, which generates the Gradle tests only.
+type Scanner struct {
+	project     *Project
+	descriptors []configDescriptor
+}
+
+// NewScanner ...
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// Name ...
+func (*Scanner) Name() string {
+	return ScannerName
+}
+
+// DetectPlatform looks for a Gradle project applying the Kotlin Multiplatform plugin, and for the Xcode projects in it.
+func (s *Scanner) DetectPlatform(searchDir string) (bool, error) {
+	log.TInfof("Searching for Gradle project files...")
+
+	rootEntry, err := direntry.WalkDir(searchDir, searchDepth)
+	if err != nil {
+		return false, err
+	}
+
+	gradleWrapperScripts := rootEntry.FindAllEntriesByName("gradlew", false)
+	if len(gradleWrapperScripts) == 0 {
+		log.TPrintf("platform not detected")
+		return false, nil
+	}
+	projectRootDir := gradleWrapperScripts[0].Parent()
+	if projectRootDir == nil {
+		return false, fmt.Errorf("failed to get parent directory of %s", gradleWrapperScripts[0].AbsPath)
+	}
+	gradleProject, err := gradle.ScanProject(*projectRootDir)
+	if err != nil {
+		return false, err
+	}
+	if gradleProject == nil {
+		log.TWarnf("No Gradle project found in %s", projectRootDir.AbsPath)
+		return false, nil
+	}
+
+	detected, err := gradleProject.DetectAnyDependencies(kotlinMultiplatformDependencies)
+	if err != nil {
+		return false, err
+	}
+	if !detected {
+		log.TPrintf("platform not detected")
+		return false, nil
+	}
+
+	project, err := inspectProject(searchDir, *gradleProject)
+	if err != nil {
+		return false, err
+	}
+	log.TPrintf("Kotlin Multiplatform project found: %s", project.Dir)
+	for _, iosProject := range project.IOSProjects.Projects {
+		log.TPrintf("iOS app found: %s", iosProject.RelPath)
+	}
+	s.project = &project
+
+	log.TSuccessf("Platform detected")
+	return true, nil
+}
+
+// ExcludedScannerNames excludes the bitrise-init Kotlin Multiplatform scanner, this scanner's output replaces it.
+// The scanners detecting the platform projects of the Kotlin Multiplatform project are excluded only if the project
+// is the whole repository: the exclusion drops every project of those scanners, so for a project in a subdirectory
+// they are kept, with the project's own Android app and Java modules also offered as separate configs.
+func (s *Scanner) ExcludedScannerNames() []string {
+	if s.project == nil || s.project.Dir != "." {
+		return []string{ScannerName}
+	}
+	return []string{
+		ScannerName,
+		android.ScannerName,
+		string(ios.XcodeProjectTypeIOS),
+		java.ProjectType,
+	}
+}
+
+// Options ...
+func (s *Scanner) Options() (models.OptionNode, models.Warnings, models.Icons, error) {
+	project := s.project
+	projectRootDirOption := models.NewOption(projectRootDirInputTitle, projectRootDirInputSummary, projectRootDirInputEnvKey, models.TypeSelector)
+
+	if len(project.IOSProjects.Projects) == 0 {
+		projectRootDirOption.AddConfig(project.Dir, models.NewConfigOption(configName, nil))
+		return *projectRootDirOption, project.IOSProjects.Warnings, nil, nil
+	}
+
+	iosOption := models.NewOption(iosInputTitle, iosInputSummary, "", models.TypeSelector)
+	projectRootDirOption.AddOption(project.Dir, iosOption)
+	iosOption.AddConfig(excludeIOS, models.NewConfigOption(configName, nil))
+
+	projectPathOption := models.NewOption(ios.ProjectPathInputTitle, ios.ProjectPathInputSummary, ios.ProjectPathInputEnvKey, models.TypeSelector)
+	iosOption.AddOption(includeIOS, projectPathOption)
+
+	warnings := project.IOSProjects.Warnings
+	for _, iosProject := range project.IOSProjects.Projects {
+		warnings = append(warnings, iosProject.Warnings...)
+
+		schemeOption := models.NewOption(ios.SchemeInputTitle, ios.SchemeInputSummary, ios.SchemeInputEnvKey, models.TypeSelector)
+		projectPathOption.AddOption(iosProject.RelPath, schemeOption)
+
+		for _, scheme := range iosProject.Schemes {
+			descriptor := configDescriptor{
+				hasAndroidApp:  len(project.AndroidAppModules) > 0,
+				hasPodfile:     iosProject.IsPodWorkspace,
+				hasXCTests:     scheme.HasXCTests,
+				hasXCFramework: project.HasXCFramework,
+			}
+			s.descriptors = append(s.descriptors, descriptor)
+
+			if len(project.AndroidAppModules) == 0 {
+				schemeOption.AddConfig(scheme.Name, models.NewConfigOption(descriptor.configName(), nil))
+				continue
+			}
+
+			moduleOption := models.NewOption(android.ModuleInputTitle, android.ModuleInputSummary, android.ModuleInputEnvKey, models.TypeSelector)
+			schemeOption.AddOption(scheme.Name, moduleOption)
+			for _, module := range project.AndroidAppModules {
+				moduleOption.AddConfig(module, models.NewConfigOption(descriptor.configName(), nil))
+			}
+		}
+	}
+
+	return *projectRootDirOption, warnings, nil, nil
+}
+
+// DefaultOptions ...
+func (*Scanner) DefaultOptions() models.OptionNode {
+	projectRootDirOption := models.NewOption(projectRootDirInputTitle, projectRootDirInputSummary, projectRootDirInputEnvKey, models.TypeUserInput)
+
+	iosOption := models.NewOption(iosInputTitle, iosInputSummary, "", models.TypeSelector)
+	projectRootDirOption.AddOption(models.UserInputOptionDefaultValue, iosOption)
+	iosOption.AddConfig(excludeIOS, models.NewConfigOption(defaultConfigName, nil))
+
+	projectPathOption := models.NewOption(ios.ProjectPathInputTitle, ios.ProjectPathInputSummary, ios.ProjectPathInputEnvKey, models.TypeUserInput)
+	schemeOption := models.NewOption(ios.SchemeInputTitle, ios.SchemeInputSummary, ios.SchemeInputEnvKey, models.TypeUserInput)
+	moduleOption := models.NewOption(android.ModuleInputTitle, android.ModuleInputSummary, android.ModuleInputEnvKey, models.TypeUserInput)
+
+	iosOption.AddOption(includeIOS, projectPathOption)
+	projectPathOption.AddOption(models.UserInputOptionDefaultValue, schemeOption)
+	schemeOption.AddOption(models.UserInputOptionDefaultValue, moduleOption)
+	moduleOption.AddConfig(models.UserInputOptionDefaultValue, models.NewConfigOption(defaultIOSConfigName, nil))
+
+	return *projectRootDirOption
+}
+
+// Configs ...
+func (s *Scanner) Configs(sshKeyActivation models.SSHKeyActivation) (models.BitriseConfigMap, error) {
+	config, err := generateTestConfig(sshKeyActivation)
+	if err != nil {
+		return models.BitriseConfigMap{}, err
+	}
+	configs := models.BitriseConfigMap{configName: config}
+
+	for _, descriptor := range s.descriptors {
+		name := descriptor.configName()
+		if _, ok := configs[name]; ok {
+			continue
+		}
+
+		config, err := generateConfig(descriptor, sshKeyActivation)
+		if err != nil {
+			return models.BitriseConfigMap{}, err
+		}
+		configs[name] = config
+	}
+
+	return configs, nil
+}
+
+// DefaultConfigs ...
+func (*Scanner) DefaultConfigs() (models.BitriseConfigMap, error) {
+	testConfig, err := generateTestConfig(models.SSHKeyActivationConditional)
+	if err != nil {
+		return models.BitriseConfigMap{}, err
+	}
+
+	iosConfig, err := generateConfig(configDescriptor{hasAndroidApp: true, hasXCTests: true}, models.SSHKeyActivationConditional)
+	if err != nil {
+		return models.BitriseConfigMap{}, err
+	}
+
+	return models.BitriseConfigMap{
+		defaultConfigName:    testConfig,
+		defaultIOSConfigName: iosConfig,
+	}, nil
+}
+
+// generateTestConfig generates the config of the bitrise-init scanner, running the Gradle tests.
+func generateTestConfig(sshKeyActivation models.SSHKeyActivation) (string, error) {
+	configBuilder := models.NewDefaultConfigBuilder()
+
+	configBuilder.AppendStepListItemsTo(testWorkflowID, initSteps.DefaultPrepareStepList(initSteps.PrepareListParams{SSHKeyActivation: sshKeyActivation})...)
+	configBuilder.AppendStepListItemsTo(testWorkflowID, initSteps.GradleUnitTestStepListItem("$"+projectRootDirInputEnvKey))
+	configBuilder.AppendStepListItemsTo(testWorkflowID, initSteps.DefaultDeployStepList()...)
+
+	config, err := configBuilder.Generate(ScannerName)
+	if err != nil {
+		return "", err
+	}
+	return scannerutil.MarshalConfig(config)
+}
+
+// generateConfig generates the workflows of the shared module tests and the platform builds,
+// and the pipeline running the platform builds after the shared module tests.
+func generateConfig(descriptor configDescriptor, sshKeyActivation models.SSHKeyActivation) (string, error) {
+	configBuilder := models.NewDefaultConfigBuilder()
+
+	workingDir := envmanModels.EnvironmentItemModel{"working_dir": "$" + projectRootDirInputEnvKey}
+
+	workflowIDs := []models.WorkflowID{testWorkflowID}
+	if descriptor.hasAndroidApp {
+		workflowIDs = append(workflowIDs, androidWorkflowID)
+	}
+	workflowIDs = append(workflowIDs, iosWorkflowID)
+
+	for _, workflowID := range workflowIDs {
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultPrepareStepList(initSteps.PrepareListParams{SSHKeyActivation: sshKeyActivation})...)
+
+		switch workflowID {
+		case testWorkflowID:
+			// allTests runs the tests of every target of the multiplatform modules the stack can run
+			configBuilder.AppendStepListItemsTo(workflowID, initSteps.ScriptStepListItem("Run shared module tests", scannerutil.CommandScriptContent("./gradlew allTests"), workingDir))
+		case androidWorkflowID:
+			configBuilder.AppendStepListItemsTo(workflowID,
+				initSteps.InstallMissingAndroidToolsStepListItem(
+					envmanModels.EnvironmentItemModel{android.GradlewPathInputKey: "$" + projectRootDirInputEnvKey + "/gradlew"},
+				),
+				initSteps.AndroidBuildStepListItem(
+					envmanModels.EnvironmentItemModel{android.ProjectLocationInputKey: "$" + projectRootDirInputEnvKey},
+					envmanModels.EnvironmentItemModel{android.ModuleInputKey: "$" + android.ModuleInputEnvKey},
+					envmanModels.EnvironmentItemModel{android.VariantInputKey: debugVariant},
+				),
+			)
+		case iosWorkflowID:
+			configBuilder.AppendStepListItemsTo(workflowID, initSteps.ScriptStepListItem("Build shared framework", frameworkScriptContent(descriptor.hasXCFramework), workingDir))
+			if descriptor.hasPodfile {
+				configBuilder.AppendStepListItemsTo(workflowID, initSteps.CocoapodsInstallStepListItem())
+			}
+			configBuilder.AppendStepListItemsTo(workflowID, xcodeStepListItem(descriptor.hasXCTests))
+		}
+
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultDeployStepList()...)
+	}
+
+	config, err := configBuilder.Generate(ScannerName)
+	if err != nil {
+		return "", err
+	}
+
+	pipelineWorkflows := bitriseModels.GraphPipelineWorkflowListItemModel{}
+	for _, workflowID := range workflowIDs {
+		if workflowID == testWorkflowID {
+			pipelineWorkflows[string(workflowID)] = bitriseModels.GraphPipelineWorkflowModel{}
+		} else {
+			pipelineWorkflows[string(workflowID)] = bitriseModels.GraphPipelineWorkflowModel{DependsOn: []string{testWorkflowID}}
+		}
+	}
+	config.Pipelines = map[string]bitriseModels.PipelineModel{PipelineID: {Workflows: pipelineWorkflows}}
+
+	return scannerutil.MarshalConfig(config)
+}
+
+// xcodeStepListItem tests the app on a simulator, or builds it for a simulator if the scheme has no tests.
+func xcodeStepListItem(hasXCTests bool) bitriseModels.StepListItemModel {
+	if hasXCTests {
+		return initSteps.XcodeTestStepListItem(
+			envmanModels.EnvironmentItemModel{ios.ProjectPathInputKey: "$" + ios.ProjectPathInputEnvKey},
+			envmanModels.EnvironmentItemModel{ios.SchemeInputKey: "$" + ios.SchemeInputEnvKey},
+			envmanModels.EnvironmentItemModel{ios.TestRepetitionModeKey: ios.TestRepetitionModeRetryOnFailureValue},
+			envmanModels.EnvironmentItemModel{ios.CacheLevelKey: ios.CacheLevelNone},
+		)
+	}
+	return steps.XcodeBuildForSimulatorStepListItem(
+		envmanModels.EnvironmentItemModel{ios.ProjectPathInputKey: "$" + ios.ProjectPathInputEnvKey},
+		envmanModels.EnvironmentItemModel{ios.SchemeInputKey: "$" + ios.SchemeInputEnvKey},
+		envmanModels.EnvironmentItemModel{ios.ConfigurationInputKey: debugVariant},
+	)
+}
+
+// frameworkScriptContent builds the shared framework with Gradle before the Xcode steps, so that a Kotlin compile error
+// fails the build in a Gradle step. The app's build phase runs the embedAndSignAppleFrameworkForXcode task again,
+// it finds the framework up to date.
+func frameworkScriptContent(hasXCFramework bool) string {
+	if hasXCFramework {
+		return scannerutil.CommandScriptContent("./gradlew assembleXCFramework")
+	}
+
+	return scannerutil.CommandScriptContent(`# the task reads the build settings Xcode passes to the app's build phase
+export CONFIGURATION=Debug SDK_NAME=iphonesimulator ARCHS=arm64
+export TARGET_BUILD_DIR="$(mktemp -d)" FRAMEWORKS_FOLDER_PATH=Frameworks
+./gradlew embedAndSignAppleFrameworkForXcode`)
+}
+
+func inspectProject(searchDir string, gradleProject gradle.Project) (Project, error) {
+	dir := fsutil.RelPath(gradleProject.RootDirEntry.RelPath)
+	project := Project{Dir: dir}
+	absDir := filepath.Join(searchDir, dir)
+
+	modules, err := androidvariants.Detect(absDir)
+	if err != nil {
+		return Project{}, err
+	}
+	for _, module := range modules {
+		project.AndroidAppModules = append(project.AndroidAppModules, module.Path)
+	}
+
+	for _, buildScript := range gradleProject.AllBuildScriptFileEntries {
+		content, err := os.ReadFile(buildScript.AbsPath)
+		if err != nil {
+			return Project{}, fmt.Errorf("read %s: %s", buildScript.RelPath, err)
+		}
+		if strings.Contains(gradlescript.StripComments(string(content)), "XCFramework(") {
+			project.HasXCFramework = true
+		}
+	}
+
+	result, err := ios.ParseProjects(ios.XcodeProjectTypeIOS, absDir, true, true)
+	if err != nil {
+		// the project is still built without the iOS app, the error is shown with the options
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Failed to parse the iOS project: %s", err))
+	}
+	// the project paths are relative to the parsed dir
+	for i := range result.Projects {
+		result.Projects[i].RelPath = filepath.Join(dir, result.Projects[i].RelPath)
+	}
+	project.IOSProjects = result
+
+	return project, nil
+}
@@ -0,0 +1,140 @@
+package kmp
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	"github.com/bitrise-io/bitrise-init/scanners/java"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "gradlew"), "#!/bin/sh\n")
+	testutil.WriteFile(t, filepath.Join(dir, "settings.gradle.kts"), "include(\":composeApp\")\ninclude(\":shared\")\n")
+	testutil.WriteFile(t, filepath.Join(dir, "build.gradle.kts"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "composeApp", "build.gradle.kts"), "plugins {\n    id(\"com.android.application\")\n}\n")
+	testutil.WriteFile(t, filepath.Join(dir, "shared", "build.gradle.kts"), `plugins {
+    kotlin("multiplatform")
+}
+
+kotlin {
+    // val xcf = XCFramework()
+    iosSimulatorArm64 { binaries.framework { baseName = "Shared" } }
+}
+`)
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+	require.Equal(t, "composeApp", scanner.project.AndroidAppModules[0])
+	require.False(t, scanner.project.HasXCFramework)
+	require.Empty(t, scanner.project.IOSProjects.Projects)
+
+	options, _, _, err := scanner.Options()
+	require.NoError(t, err)
+	require.Equal(t, configName, options.ChildOptionMap["."].Config)
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	require.Contains(t, configs[configName], "gradle-unit-test@")
+	require.Equal(t, []string{ScannerName, android.ScannerName, string(ios.XcodeProjectTypeIOS), java.ProjectType}, scanner.ExcludedScannerNames())
+
+	t.Log("the platform scanners are kept for a project in a subdirectory")
+	{
+		scanner.project.Dir = "mobile"
+		require.Equal(t, []string{ScannerName}, scanner.ExcludedScannerNames())
+	}
+}
+
+func TestOptions_ios(t *testing.T) {
+	scanner := NewScanner()
+	scanner.project = &Project{
+		Dir:               ".",
+		AndroidAppModules: []string{"composeApp"},
+		IOSProjects: ios.DetectResult{Projects: []ios.Project{{
+			RelPath: "iosApp/iosApp.xcodeproj",
+			Schemes: []ios.Scheme{{Name: "iosApp", HasXCTests: true}},
+		}}},
+	}
+
+	options, _, _, err := scanner.Options()
+	require.NoError(t, err)
+	iosOption, ok := options.Child(".")
+	require.True(t, ok)
+	require.ElementsMatch(t, []string{includeIOS, excludeIOS}, iosOption.GetValues())
+	require.Equal(t, configName, iosOption.ChildOptionMap[excludeIOS].Config)
+
+	moduleOption, ok := options.Child(".", includeIOS, "iosApp/iosApp.xcodeproj", "iosApp")
+	require.True(t, ok)
+	require.Equal(t, "kotlin-multiplatform-ios-android-test-config", moduleOption.ChildOptionMap["composeApp"].Config)
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs["kotlin-multiplatform-ios-android-test-config"]), &config))
+	require.Equal(t, []string{initSteps.GitCloneID, initSteps.InstallMissingAndroidToolsID, initSteps.AndroidBuildID, initSteps.DeployToBitriseIoID}, stepIDs(config.Workflows[androidWorkflowID].Steps))
+
+	iosSteps := config.Workflows[iosWorkflowID].Steps
+	require.Equal(t, []string{initSteps.GitCloneID, initSteps.ScriptID, initSteps.XcodeTestID, initSteps.DeployToBitriseIoID}, stepIDs(iosSteps))
+	frameworkStep, err := iosSteps[1].GetStep()
+	require.NoError(t, err)
+	require.Contains(t, frameworkStep.Inputs[0]["content"], "./gradlew embedAndSignAppleFrameworkForXcode")
+
+	pipeline := config.Pipelines[PipelineID]
+	require.Len(t, pipeline.Workflows, 3)
+	require.Empty(t, pipeline.Workflows[testWorkflowID].DependsOn)
+	require.Equal(t, []string{testWorkflowID}, pipeline.Workflows[iosWorkflowID].DependsOn)
+}
+
+func TestOptions_iosXCFramework(t *testing.T) {
+	scanner := NewScanner()
+	scanner.project = &Project{
+		Dir: "mobile",
+		IOSProjects: ios.DetectResult{Projects: []ios.Project{{
+			RelPath:        "mobile/iosApp/iosApp.xcworkspace",
+			IsPodWorkspace: true,
+			Schemes:        []ios.Scheme{{Name: "iosApp"}},
+		}}},
+		HasXCFramework: true,
+	}
+
+	options, _, _, err := scanner.Options()
+	require.NoError(t, err)
+	schemeOption, ok := options.Child("mobile", includeIOS, "mobile/iosApp/iosApp.xcworkspace")
+	require.True(t, ok)
+	require.Equal(t, "kotlin-multiplatform-ios-pod-xcframework-config", schemeOption.ChildOptionMap["iosApp"].Config)
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs["kotlin-multiplatform-ios-pod-xcframework-config"]), &config))
+	require.NotContains(t, config.Workflows, androidWorkflowID)
+	iosSteps := config.Workflows[iosWorkflowID].Steps
+	require.Equal(t, []string{initSteps.GitCloneID, initSteps.ScriptID, initSteps.CocoapodsInstallID, steps.XcodeBuildForSimulatorID, initSteps.DeployToBitriseIoID}, stepIDs(iosSteps))
+	frameworkStep, err := iosSteps[1].GetStep()
+	require.NoError(t, err)
+	require.Contains(t, frameworkStep.Inputs[0]["content"], "./gradlew assembleXCFramework")
+}
+
+func stepIDs(stepList []bitriseModels.StepListItemModel) []string {
+	var ids []string
+	for _, item := range stepList {
+		ids = append(ids, steps.ID(item))
+	}
+	return ids
+}
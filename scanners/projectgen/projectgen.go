@@ -0,0 +1,184 @@
+// Package projectgen contains the parts shared by the scanners of projects whose Xcode project is generated
+// by a tool (XcodeGen, Tuist) instead of being committed to the repository.
+package projectgen
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-init/utility"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+)
+
+// Target is an Xcode target declared in the project manifest.
+type Target struct {
+	Name      string
+	Platform  ios.XcodeProjectType
+	IsApp     bool
+	IsTest    bool
+	IsAppClip bool
+}
+
+// Scheme is an Xcode scheme the generated project will have, with the targets it builds and tests.
+type Scheme struct {
+	Name         string
+	BuildTargets []string
+	TestTargets  []string
+}
+
+// Manifest is the project manifest of an Xcode project generator.
+type Manifest struct {
+	// ProjectPath is the path of the Xcode project or workspace the generator creates, relative to the search dir.
+	ProjectPath string
+	IsWorkspace bool
+	Targets     []Target
+	Schemes     []Scheme
+	// HasSPMDependencies is set if the manifest declares Swift packages.
+	HasSPMDependencies bool
+}
+
+// ProjectType returns the project type of the manifest: macOS if it only has macOS apps, iOS otherwise.
+func (m Manifest) ProjectType() ios.XcodeProjectType {
+	hasMacOSApp := false
+	for _, target := range m.Targets {
+		if !target.IsApp {
+			continue
+		}
+		if target.Platform == ios.XcodeProjectTypeIOS {
+			return ios.XcodeProjectTypeIOS
+		}
+		if target.Platform == ios.XcodeProjectTypeMacOS {
+			hasMacOSApp = true
+		}
+	}
+	if hasMacOSApp {
+		return ios.XcodeProjectTypeMacOS
+	}
+	return ios.XcodeProjectTypeIOS
+}
+
+// DetectResult converts the manifest to the iOS scanner's detect result, the same one the iOS scanner creates
+// from a committed Xcode project. searchDir is used to look up the Podfile and the Cartfile next to the project.
+func (m Manifest) DetectResult(searchDir string) ios.DetectResult {
+	projectType := m.ProjectType()
+	targets := map[string]Target{}
+	for _, target := range m.Targets {
+		targets[target.Name] = target
+	}
+
+	var schemes []ios.Scheme
+	for _, scheme := range m.Schemes {
+		hasPlatformTarget := false
+		hasAppClip := false
+		for _, name := range scheme.BuildTargets {
+			target, ok := targets[name]
+			if !ok || target.Platform != projectType {
+				continue
+			}
+			hasPlatformTarget = true
+			if target.IsAppClip {
+				hasAppClip = true
+			}
+		}
+		if !hasPlatformTarget {
+			continue
+		}
+
+		schemes = append(schemes, ios.Scheme{
+			Name:       scheme.Name,
+			HasXCTests: len(scheme.TestTargets) > 0,
+			HasAppClip: hasAppClip,
+		})
+	}
+
+	var warnings models.Warnings
+	projectPth := filepath.Join(searchDir, m.ProjectPath)
+	carthageCommand := ""
+	if ios.HasCartfileInDirectoryOf(projectPth) {
+		carthageCommand = "update"
+		if ios.HasCartfileResolvedInDirectoryOf(projectPth) {
+			carthageCommand = "bootstrap"
+		} else {
+			warnings = append(warnings, fmt.Sprintf("Cartfile found next to %s, but no Cartfile.resolved exists in the same directory", m.ProjectPath))
+		}
+	}
+
+	project := ios.Project{
+		RelPath:         m.ProjectPath,
+		IsWorkspace:     m.IsWorkspace,
+		CarthageCommand: carthageCommand,
+		Warnings:        warnings,
+		Schemes:         schemes,
+	}
+	if utility.FileExists(filepath.Join(filepath.Dir(projectPth), "Podfile")) {
+		// pod install creates the workspace from the generated project
+		project.RelPath = trimExt(m.ProjectPath) + ".xcworkspace"
+		project.IsWorkspace = true
+		project.IsPodWorkspace = true
+	}
+
+	return ios.DetectResult{
+		Projects:           []ios.Project{project},
+		HasSPMDependencies: m.HasSPMDependencies,
+	}
+}
+
+// ExcludedScannerNames are the scanners of the committed Xcode projects, a generated project
+// is regenerated from the manifest in every build.
+func ExcludedScannerNames() []string {
+	return []string{string(ios.XcodeProjectTypeIOS), string(ios.XcodeProjectTypeMacOS)}
+}
+
+// GenerateConfigs generates the iOS scanner's configs and inserts the project generation steps right after
+// the git clone step of every workflow, before the dependency install, build, test and archive steps.
+func GenerateConfigs(projectType ios.XcodeProjectType, configDescriptors []ios.ConfigDescriptor, sshKeyActivation models.SSHKeyActivation, generateSteps ...bitriseModels.StepListItemModel) (models.BitriseConfigMap, error) {
+	configs, err := ios.GenerateConfig(projectType, configDescriptors, sshKeyActivation)
+	if err != nil {
+		return models.BitriseConfigMap{}, err
+	}
+	return insertGenerateSteps(configs, generateSteps)
+}
+
+// GenerateDefaultConfigs is GenerateConfigs for the default configs of the project type.
+func GenerateDefaultConfigs(projectType ios.XcodeProjectType, generateSteps ...bitriseModels.StepListItemModel) (models.BitriseConfigMap, error) {
+	configs, err := ios.GenerateDefaultConfig(projectType)
+	if err != nil {
+		return models.BitriseConfigMap{}, err
+	}
+	return insertGenerateSteps(configs, generateSteps)
+}
+
+func insertGenerateSteps(configs models.BitriseConfigMap, generateSteps []bitriseModels.StepListItemModel) (models.BitriseConfigMap, error) {
+	for name, configStr := range configs {
+		var config bitriseModels.BitriseDataModel
+		if err := yaml.Unmarshal([]byte(configStr), &config); err != nil {
+			return models.BitriseConfigMap{}, err
+		}
+
+		for workflowID, workflow := range config.Workflows {
+			// the workflows running the tests of another workflow's build do not clone the repository
+			if !steps.Contains(workflow.Steps, initSteps.GitCloneID) {
+				continue
+			}
+			workflow.Steps = steps.InsertAfterPrepare(workflow.Steps, generateSteps...)
+			config.Workflows[workflowID] = workflow
+		}
+
+		data, err := yaml.Marshal(config)
+		if err != nil {
+			return models.BitriseConfigMap{}, err
+		}
+		configs[name] = string(data)
+	}
+	return configs, nil
+}
+
+func trimExt(pth string) string {
+	return pth[:len(pth)-len(filepath.Ext(pth))]
+}
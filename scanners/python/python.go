@@ -0,0 +1,232 @@
+package python
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/utility"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	ScannerName = "python"
+
+	runTestsWorkflowID = models.WorkflowID("run_tests")
+
+	projectDirInputTitle   = "Project Directory"
+	projectDirInputSummary = "The directory containing the pyproject.toml, requirements.txt or Pipfile file"
+	projectDirInputEnvKey  = "PYTHON_PROJECT_DIR"
+
+	packageManagerInputTitle   = "Package Manager"
+	packageManagerInputSummary = "The package manager used in the project"
+
+	searchDepth = 4
+)
+
+type packageManager struct {
+	name string
+	// lockFile marks the projects of the package manager, relative to the project dir. It is empty if the package
+	// manager has no lock file.
+	lockFile string
+	// dependencyFiles are the files the dependency cache is keyed on, the lock file if the package manager has one,
+	// otherwise the files declaring the dependencies.
+	dependencyFiles []string
+	// runPrefix runs a command in the environment of the project.
+	runPrefix string
+	// cacheDir is the download cache of the package manager, besides the pip cache.
+	cacheDir string
+}
+
+// pkgManagers are the supported package managers, in the order their lock files are looked for.
+var pkgManagers = []packageManager{
+	{name: "poetry", lockFile: "poetry.lock", dependencyFiles: []string{"poetry.lock"}, runPrefix: "poetry run ", cacheDir: "~/.cache/pypoetry"},
+	{name: "pdm", lockFile: "pdm.lock", dependencyFiles: []string{"pdm.lock"}, runPrefix: "pdm run ", cacheDir: "~/.cache/pdm"},
+	{name: "pipenv", lockFile: "Pipfile.lock", dependencyFiles: []string{"Pipfile.lock"}, runPrefix: "pipenv run ", cacheDir: "~/.cache/pipenv"},
+	// the dependencies of the hatch environments are declared in the pyproject.toml or the hatch.toml file
+	{name: "hatch", dependencyFiles: []string{"pyproject.toml", "hatch.toml"}, runPrefix: "hatch run ", cacheDir: "~/.cache/hatch"},
+	{name: "pip", dependencyFiles: []string{"requirements*.txt", "pyproject.toml", "setup.py"}},
+}
+
+// packageMetadataFileNames declare a Python package, markerFileNames mark the root of a Python project without one.
+var (
+	packageMetadataFileNames = []string{"pyproject.toml", "setup.py", "Pipfile"}
+	markerFileNames          = []string{"requirements.txt", "tox.ini", "noxfile.py"}
+)
+
+// ignoredDirNames hold installed packages and caches, the virtualenvs are recognized by their pyvenv.cfg file.
+var ignoredDirNames = []string{"node_modules", "site-packages", "__pycache__", "venv"}
+
+type project struct {
+	projectRelDir  string
+	packageManager string
+	pythonVersion  string
+	testRunner     tool
+	linters        []tool
+}
+
+// Scanner implements the Scanner interface for Python projects
+type Scanner struct {
+	projects []project
+}
+
+// NewScanner creates a new scanner instance.
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// Name returns the name of the scanner
+func (*Scanner) Name() string {
+	return ScannerName
+}
+
+// DetectPlatform checks if the given search directory contains a Python project
+func (s *Scanner) DetectPlatform(searchDir string) (bool, error) {
+	projectDirs, err := collectProjectDirs(searchDir)
+	if err != nil {
+		return false, err
+	}
+
+	for _, projectDir := range projectDirs {
+		log.TPrintf("Checking: %s", projectDir)
+
+		metadata, err := readMetadata(projectDir)
+		if err != nil {
+			log.TWarnf("Failed to read project metadata: %s", err)
+			continue
+		}
+
+		projectRelDir, err := utility.RelPath(searchDir, projectDir)
+		if err != nil {
+			log.TWarnf("failed to get relative project dir path: %s", err)
+			continue
+		}
+
+		testRunner, linters := checkTools(metadata)
+		s.projects = append(s.projects, project{
+			projectRelDir:  projectRelDir,
+			packageManager: checkPackageManager(projectDir, metadata),
+			pythonVersion:  checkPythonVersion(metadata),
+			testRunner:     testRunner,
+			linters:        linters,
+		})
+	}
+
+	if len(s.projects) == 0 {
+		log.TPrintf("Platform not detected")
+		return false, nil
+	}
+
+	log.TSuccessf("Platform detected")
+	return true, nil
+}
+
+// ExcludedScannerNames ...
+func (*Scanner) ExcludedScannerNames() []string {
+	return nil
+}
+
+// Options returns the options for the scanner
+func (s *Scanner) Options() (models.OptionNode, models.Warnings, models.Icons, error) {
+	return generateOptions(s.projects)
+}
+
+// Configs returns the configurations for the scanner
+func (s *Scanner) Configs(sshKeyActivation models.SSHKeyActivation) (models.BitriseConfigMap, error) {
+	return generateConfigs(s.projects, sshKeyActivation)
+}
+
+// DefaultOptions returns the default options for the scanner
+func (*Scanner) DefaultOptions() models.OptionNode {
+	projectRootOption := models.NewOption(projectDirInputTitle, projectDirInputSummary, projectDirInputEnvKey, models.TypeUserInput)
+	packageManagerOption := models.NewOption(packageManagerInputTitle, packageManagerInputSummary, "", models.TypeSelector)
+
+	for _, pkgMgr := range pkgManagers {
+		defaultDescriptor := createDefaultConfigDescriptor(pkgMgr.name)
+		configOption := models.NewConfigOption(configName(defaultDescriptor), nil)
+		packageManagerOption.AddConfig(pkgMgr.name, configOption)
+	}
+
+	projectRootOption.AddOption(models.UserInputOptionDefaultValue, packageManagerOption)
+
+	return *projectRootOption
+}
+
+// DefaultConfigs returns the default configurations for the scanner
+func (*Scanner) DefaultConfigs() (models.BitriseConfigMap, error) {
+	configs := models.BitriseConfigMap{}
+
+	for _, pkgMgr := range pkgManagers {
+		defaultDescriptor := createDefaultConfigDescriptor(pkgMgr.name)
+		config, err := generateConfigBasedOn(defaultDescriptor, models.SSHKeyActivationConditional)
+		if err != nil {
+			return nil, err
+		}
+		configs[configName(defaultDescriptor)] = config
+	}
+
+	return configs, nil
+}
+
+// collectProjectDirs returns the dirs with Python project files. A dir inside another project is only a separate
+// project if it has its own package metadata, the requirements files of a docs dir belong to the enclosing project.
+func collectProjectDirs(searchDir string) ([]string, error) {
+	rootEntry, err := direntry.WalkDir(searchDir, searchDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	packageDirs := map[string]bool{}
+	var dirs []string
+	for _, names := range [][]string{packageMetadataFileNames, markerFileNames} {
+		for _, name := range names {
+			for _, entry := range rootEntry.FindAllEntriesByName(name, false) {
+				dir := entry.Parent()
+				if isIgnored(*dir) {
+					continue
+				}
+				if slices.Contains(packageMetadataFileNames, name) {
+					packageDirs[dir.AbsPath] = true
+				}
+				if !slices.Contains(dirs, dir.AbsPath) {
+					dirs = append(dirs, dir.AbsPath)
+				}
+			}
+		}
+	}
+	sort.Strings(dirs)
+
+	var projectDirs []string
+	for _, dir := range dirs {
+		if !packageDirs[dir] && isInProject(dir, projectDirs) {
+			continue
+		}
+		projectDirs = append(projectDirs, dir)
+	}
+	return projectDirs, nil
+}
+
+// isIgnored reports whether the dir or one of its parents is hidden, holds installed packages or is a virtualenv.
+func isIgnored(dir direntry.DirEntry) bool {
+	for entry := &dir; entry != nil && entry.Parent() != nil; entry = entry.Parent() {
+		if strings.HasPrefix(entry.Name, ".") || slices.Contains(ignoredDirNames, entry.Name) {
+			return true
+		}
+		if entry.FindImmediateChildByName("pyvenv.cfg", false) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isInProject(dir string, projectDirs []string) bool {
+	for _, projectDir := range projectDirs {
+		if strings.HasPrefix(dir, projectDir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package python
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const poetryPyproject = `[tool.poetry]
+name = "api"
+
+[tool.poetry.dependencies]
+python = "^3.11"
+fastapi = "^0.110"
+
+[tool.poetry.group.dev.dependencies]
+pytest = "^8.0"
+ruff = "^0.4"
+
+[tool.ruff]
+line-length = 100
+
+[tool.mypy]
+plugins = ["pydantic.mypy"]
+
+[build-system]
+requires = ["poetry-core"]
+build-backend = "poetry.core.masonry.api"
+`
+
+func TestScanner(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "api", "pyproject.toml"), poetryPyproject)
+	testutil.WriteFile(t, filepath.Join(dir, "api", "poetry.lock"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "api", "docs", "requirements.txt"), "sphinx\n")
+	testutil.WriteFile(t, filepath.Join(dir, "api", ".venv", "pyvenv.cfg"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "api", ".venv", "lib", "requirements.txt"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "scripts", "requirements.txt"), "requests==2.31.0\n")
+	testutil.WriteFile(t, filepath.Join(dir, "scripts", "requirements-dev.txt"), "flake8>=7\n")
+	testutil.WriteFile(t, filepath.Join(dir, "scripts", "tox.ini"), "[tox]\nenvlist = py312\n\n[testenv]\ndeps = pytest\ncommands = pytest\n")
+	testutil.WriteFile(t, filepath.Join(dir, "scripts", "setup.cfg"), "[flake8]\nmax-line-length = 100\n\n[mypy]\nstrict = True\n")
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	require.Equal(t, []project{
+		{
+			projectRelDir:  "api",
+			packageManager: "poetry",
+			pythonVersion:  "3.11",
+			testRunner:     tool{name: "pytest", command: pytestCommand, declared: true},
+			linters: []tool{
+				{name: "ruff", command: "ruff check .", declared: true},
+				{name: "mypy", command: "mypy ."},
+			},
+		},
+		{
+			projectRelDir:  "scripts",
+			packageManager: "pip",
+			testRunner:     tool{name: "tox", command: "tox"},
+			linters: []tool{
+				{name: "flake8", command: "flake8", declared: true},
+				{name: "mypy", command: "mypy ."},
+			},
+		},
+	}, scanner.projects)
+
+	options, warnings, _, err := scanner.Options()
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+	require.ElementsMatch(t, []string{"api", "scripts"}, options.GetValues())
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs["python-poetry-3.11-ruff-mypy-pytest-config"]), &config))
+	workflowSteps := config.Workflows[string(runTestsWorkflowID)].Steps
+
+	installPythonStep, err := workflowSteps[1].GetStep()
+	require.NoError(t, err)
+	require.Contains(t, installPythonStep.Inputs[0]["content"], `requested_version="3.11"`)
+	require.Contains(t, installPythonStep.Inputs[0]["content"], `envman add --key POETRY_CACHE_DIR --value "$HOME/.cache/pypoetry"`)
+
+	restoreCacheStep, err := workflowSteps[2].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, "{{ .OS }}-{{ .Arch }}-python-poetry-{{ checksum \"**/poetry.lock\" }}\n{{ .OS }}-{{ .Arch }}-python-poetry-", restoreCacheStep.Inputs[0]["key"])
+
+	installDepsStep, err := workflowSteps[3].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, "#!/usr/bin/env bash\nset -euxo pipefail\n\npython -m pip install --upgrade pip\npython -m pip install poetry\npoetry install --no-interaction\n\n# not declared as a dependency of the project\npython -m pip install mypy\n", installDepsStep.Inputs[0]["content"])
+
+	ruffStep, err := workflowSteps[4].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, "#!/usr/bin/env bash\nset -euxo pipefail\n\npoetry run ruff check .\n", ruffStep.Inputs[0]["content"])
+
+	pytestStep, err := workflowSteps[6].GetStep()
+	require.NoError(t, err)
+	require.Contains(t, pytestStep.Inputs[0]["content"], `poetry run pytest --junitxml="$report_dir/report.xml"`)
+}
+
+func TestScanner_notDetected(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "package.json"), "{}")
+
+	detected, err := NewScanner().DetectPlatform(dir)
+	require.NoError(t, err)
+	require.False(t, detected)
+}
+
+func TestScanner_sourceDirNamedEnv(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "env", "requirements.txt"), "pytest\n")
+	testutil.WriteFile(t, filepath.Join(dir, "env", ".venv", "pyvenv.cfg"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "env", ".venv", "lib", "requirements.txt"), "")
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+	require.Len(t, scanner.projects, 1)
+	require.Equal(t, "env", scanner.projects[0].projectRelDir)
+}
+
+func TestParseTOMLTables(t *testing.T) {
+	tables := parseTOMLTables(`name = "root"
+[project]
+requires-python = ">=3.10"  # the lowest supported
+
+[[tool.hatch.envs.test.matrix]]
+python = ["3.10", "3.11"]
+`)
+	require.Equal(t, "root", tables[""]["name"])
+	require.Equal(t, ">=3.10", tables["project"]["requires-python"])
+	require.True(t, tables.hasTable("tool.hatch"))
+	require.False(t, tables.hasTable("tool.hatc"))
+}
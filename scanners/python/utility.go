@@ -0,0 +1,518 @@
+package python
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-init/utility"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/scannerutil"
+	pluginSteps "github.com/bitrise-io/bitrise-plugins-init/steps"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	installPythonScriptStepTitle = "Install Python"
+	installDepsScriptStepTitle   = "Install dependencies"
+
+	defaultPythonVersion = "3"
+
+	pipCacheDir = "~/.cache/pip"
+
+	pytestCommand = `pytest --junitxml="$report_dir/report.xml"`
+)
+
+var (
+	tomlTablePattern  = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?\s*(#.*)?$`)
+	tomlStringPattern = regexp.MustCompile(`^\s*"?([\w.-]+)"?\s*=\s*["']([^"']*)["']`)
+	iniSectionPattern = regexp.MustCompile(`(?m)^\s*\[([^\]]+)\]`)
+	versionPattern    = regexp.MustCompile(`\d+\.\d+`)
+	// dependencyNamePattern matches the package names in the files declaring the dependencies, along with the other
+	// words of the files, like the version specifiers.
+	dependencyNamePattern = regexp.MustCompile(`[\w.-]+`)
+)
+
+// toolDefinition is a test runner or a linter, detected by its config or by being declared as a dependency.
+type toolDefinition struct {
+	name    string
+	command string
+	// configFiles are looked for in the project dir.
+	configFiles []string
+	// pyprojectTables are the pyproject.toml tables configuring the tool, their sub-tables included.
+	pyprojectTables []string
+	// iniSections are the setup.cfg and tox.ini sections configuring the tool.
+	iniSections []string
+}
+
+// testRunners are in the order of precedence, tox and nox run pytest in their own environments.
+var testRunners = []toolDefinition{
+	{name: "tox", command: "tox", configFiles: []string{"tox.ini"}, pyprojectTables: []string{"tool.tox"}},
+	{name: "nox", command: "nox", configFiles: []string{"noxfile.py"}},
+	{name: "pytest", command: pytestCommand, configFiles: []string{"pytest.ini", "conftest.py"}, pyprojectTables: []string{"tool.pytest.ini_options"}, iniSections: []string{"tool:pytest", "pytest"}},
+}
+
+var linters = []toolDefinition{
+	{name: "ruff", command: "ruff check .", configFiles: []string{"ruff.toml", ".ruff.toml"}, pyprojectTables: []string{"tool.ruff"}},
+	{name: "flake8", command: "flake8", configFiles: []string{".flake8"}, iniSections: []string{"flake8"}},
+	{name: "mypy", command: "mypy .", configFiles: []string{"mypy.ini", ".mypy.ini"}, pyprojectTables: []string{"tool.mypy"}, iniSections: []string{"mypy"}},
+}
+
+// tool is a detected test runner or linter.
+type tool struct {
+	name    string
+	command string
+	// declared tools are installed with the project dependencies and run in the project environment,
+	// the others are installed with pip.
+	declared bool
+}
+
+// tomlTables are the tables of a TOML file with their single-line string values.
+type tomlTables map[string]map[string]string
+
+// metadata is the content of the project files the package manager, the Python version and the tools are read from.
+type metadata struct {
+	dir         string
+	pyproject   tomlTables
+	pipfile     tomlTables
+	iniSections []string
+	// dependencies are the names in the files declaring the project dependencies.
+	dependencies map[string]bool
+}
+
+func readMetadata(projectDir string) (metadata, error) {
+	meta := metadata{dir: projectDir}
+
+	pyproject, err := fsutil.ReadOptionalFile(filepath.Join(projectDir, "pyproject.toml"))
+	if err != nil {
+		return metadata{}, err
+	}
+	meta.pyproject = parseTOMLTables(pyproject)
+
+	pipfile, err := fsutil.ReadOptionalFile(filepath.Join(projectDir, "Pipfile"))
+	if err != nil {
+		return metadata{}, err
+	}
+	meta.pipfile = parseTOMLTables(pipfile)
+
+	iniContents := map[string]string{}
+	for _, name := range []string{"setup.cfg", "tox.ini"} {
+		content, err := fsutil.ReadOptionalFile(filepath.Join(projectDir, name))
+		if err != nil {
+			return metadata{}, err
+		}
+		iniContents[name] = content
+		for _, match := range iniSectionPattern.FindAllStringSubmatch(content, -1) {
+			meta.iniSections = append(meta.iniSections, strings.TrimSpace(match[1]))
+		}
+	}
+
+	dependencyFiles, err := filepath.Glob(filepath.Join(projectDir, "requirements*.txt"))
+	if err != nil {
+		return metadata{}, err
+	}
+	var dependencies []string
+	for _, pth := range append(dependencyFiles, filepath.Join(projectDir, "setup.py")) {
+		content, err := fsutil.ReadOptionalFile(pth)
+		if err != nil {
+			return metadata{}, err
+		}
+		dependencies = append(dependencies, content)
+	}
+	// the table and section headers name the tools they configure, only the keys and values declare dependencies.
+	// The deps of tox.ini are installed in the tox environments, not in the project environment.
+	dependencies = append(dependencies, withoutTableHeaders(pyproject), withoutTableHeaders(pipfile), withoutTableHeaders(iniContents["setup.cfg"]))
+	meta.dependencies = map[string]bool{}
+	for _, name := range dependencyNamePattern.FindAllString(strings.Join(dependencies, "\n"), -1) {
+		meta.dependencies[name] = true
+	}
+
+	return meta, nil
+}
+
+func checkPackageManager(projectDir string, meta metadata) string {
+	log.TPrintf("Checking package manager lock files")
+	for _, pkgMgr := range pkgManagers {
+		if pkgMgr.lockFile == "" {
+			continue
+		}
+		if !utility.FileExists(filepath.Join(projectDir, pkgMgr.lockFile)) {
+			log.TPrintf("- %s - not found", pkgMgr.lockFile)
+			continue
+		}
+
+		log.TPrintf("- %s - found", pkgMgr.lockFile)
+		log.TPrintf("Package manager: %s", pkgMgr.name)
+		return pkgMgr.name
+	}
+
+	log.TPrintf("Checking pyproject.toml build system")
+	buildBackend := meta.pyproject["build-system"]["build-backend"]
+	for _, pkgMgr := range []struct{ name, table, buildBackend string }{
+		{"poetry", "tool.poetry", "poetry"},
+		{"pdm", "tool.pdm", "pdm"},
+		{"hatch", "tool.hatch", "hatchling"},
+	} {
+		if meta.pyproject.hasTable(pkgMgr.table) || (buildBackend != "" && strings.HasPrefix(buildBackend, pkgMgr.buildBackend)) {
+			log.TPrintf("Package manager: %s", pkgMgr.name)
+			return pkgMgr.name
+		}
+	}
+
+	if utility.FileExists(filepath.Join(projectDir, "Pipfile")) {
+		log.TPrintf("Package manager: pipenv")
+		return "pipenv"
+	}
+
+	log.TPrintf("Package manager: pip")
+	return "pip"
+}
+
+// checkPythonVersion returns the lowest Python version the project metadata allows, the .python-version file
+// is read by the install step.
+func checkPythonVersion(meta metadata) string {
+	for _, requirement := range []string{
+		meta.pyproject["project"]["requires-python"],
+		meta.pyproject["tool.poetry.dependencies"]["python"],
+		meta.pipfile["requires"]["python_version"],
+		meta.pipfile["requires"]["python_full_version"],
+	} {
+		if version := versionPattern.FindString(requirement); version != "" {
+			log.TPrintf("Python version: %s", version)
+			return version
+		}
+	}
+	return ""
+}
+
+func checkTools(meta metadata) (tool, []tool) {
+	log.TPrintf("Checking test runners")
+	var testRunner tool
+	for _, definition := range testRunners {
+		if detected, ok := detectTool(meta, definition); ok {
+			log.TPrintf("- %s - found", definition.name)
+			testRunner = detected
+			break
+		}
+		log.TPrintf("- %s - not found", definition.name)
+	}
+
+	log.TPrintf("Checking linters")
+	var detectedLinters []tool
+	for _, definition := range linters {
+		if detected, ok := detectTool(meta, definition); ok {
+			log.TPrintf("- %s - found", definition.name)
+			detectedLinters = append(detectedLinters, detected)
+		} else {
+			log.TPrintf("- %s - not found", definition.name)
+		}
+	}
+
+	return testRunner, detectedLinters
+}
+
+func detectTool(meta metadata, definition toolDefinition) (tool, bool) {
+	declared := meta.dependencies[definition.name]
+	detected := declared
+	for _, name := range definition.configFiles {
+		if utility.FileExists(filepath.Join(meta.dir, name)) {
+			detected = true
+		}
+	}
+	for _, table := range definition.pyprojectTables {
+		if meta.pyproject.hasTable(table) {
+			detected = true
+		}
+	}
+	for _, section := range definition.iniSections {
+		if slices.Contains(meta.iniSections, section) {
+			detected = true
+		}
+	}
+
+	if !detected {
+		return tool{}, false
+	}
+	return tool{name: definition.name, command: definition.command, declared: declared}, true
+}
+
+type configDescriptor struct {
+	pkgManager    string
+	pythonVersion string
+	testRunner    tool
+	linters       []tool
+	isDefault     bool
+}
+
+func createConfigDescriptor(project project, isDefault bool) configDescriptor {
+	return configDescriptor{
+		pkgManager:    project.packageManager,
+		pythonVersion: project.pythonVersion,
+		testRunner:    project.testRunner,
+		linters:       project.linters,
+		isDefault:     isDefault,
+	}
+}
+
+func createDefaultConfigDescriptor(packageManager string) configDescriptor {
+	return createConfigDescriptor(project{
+		projectRelDir:  "$" + projectDirInputEnvKey,
+		packageManager: packageManager,
+		testRunner:     tool{name: "pytest", command: pytestCommand},
+	}, true)
+}
+
+func configName(params configDescriptor) string {
+	name := "python-" + params.pkgManager
+
+	if params.isDefault {
+		return "default-" + name + "-config"
+	}
+
+	if params.pythonVersion != "" {
+		name = name + "-" + params.pythonVersion
+	}
+
+	for _, linter := range params.linters {
+		name = name + "-" + linter.name
+	}
+
+	if params.testRunner.name != "" {
+		name = name + "-" + params.testRunner.name
+	}
+
+	return name + "-config"
+}
+
+func generateOptions(projects []project) (models.OptionNode, models.Warnings, models.Icons, error) {
+	if len(projects) == 0 {
+		return models.OptionNode{}, nil, nil, fmt.Errorf("no Python projects found")
+	}
+
+	var warnings models.Warnings
+	projectRootOption := models.NewOption(projectDirInputTitle, projectDirInputSummary, projectDirInputEnvKey, models.TypeSelector)
+	for _, project := range projects {
+		if project.testRunner.name == "" {
+			warnings = append(warnings, fmt.Sprintf("No test runner found in the %s project, the generated workflow does not run tests", project.projectRelDir))
+		}
+
+		descriptor := createConfigDescriptor(project, false)
+		packageManagerOption := models.NewOption(packageManagerInputTitle, packageManagerInputSummary, "", models.TypeSelector)
+		packageManagerOption.AddConfig(project.packageManager, models.NewConfigOption(configName(descriptor), nil))
+		projectRootOption.AddOption(project.projectRelDir, packageManagerOption)
+	}
+
+	return *projectRootOption, warnings, nil, nil
+}
+
+func generateConfigs(projects []project, sshKeyActivation models.SSHKeyActivation) (models.BitriseConfigMap, error) {
+	configs := models.BitriseConfigMap{}
+
+	if len(projects) == 0 {
+		return models.BitriseConfigMap{}, fmt.Errorf("no Python projects found")
+	}
+
+	for _, project := range projects {
+		descriptor := createConfigDescriptor(project, false)
+		config, err := generateConfigBasedOn(descriptor, sshKeyActivation)
+		if err != nil {
+			return nil, err
+		}
+		configs[configName(descriptor)] = config
+	}
+
+	return configs, nil
+}
+
+func generateConfigBasedOn(descriptor configDescriptor, sshKey models.SSHKeyActivation) (string, error) {
+	pkgMgr := findPackageManager(descriptor.pkgManager)
+	workdir := envmanModels.EnvironmentItemModel{"working_dir": "$" + projectDirInputEnvKey}
+
+	configBuilder := models.NewDefaultConfigBuilder()
+	prepareSteps := steps.DefaultPrepareStepList(steps.PrepareListParams{SSHKeyActivation: sshKey})
+	configBuilder.AppendStepListItemsTo(runTestsWorkflowID, prepareSteps...)
+
+	cacheKey := fmt.Sprintf("{{ .OS }}-{{ .Arch }}-python-%s-", pkgMgr.name)
+	cachePaths := []string{pipCacheDir}
+	if pkgMgr.cacheDir != "" {
+		cachePaths = append(cachePaths, pkgMgr.cacheDir)
+	}
+
+	configBuilder.AppendStepListItemsTo(runTestsWorkflowID,
+		steps.ScriptStepListItem(installPythonScriptStepTitle, installPythonScriptContent(descriptor.pythonVersion, pkgMgr), workdir),
+		pluginSteps.RestoreCacheStepListItem("Restore Python cache", cacheKey+dependencyFilesChecksum(pkgMgr)+"\n"+cacheKey),
+		steps.ScriptStepListItem(installDepsScriptStepTitle, installDepsScriptContent(descriptor, pkgMgr), workdir),
+	)
+
+	for _, linter := range descriptor.linters {
+		configBuilder.AppendStepListItemsTo(runTestsWorkflowID,
+			steps.ScriptStepListItem("Run "+linter.name, runScriptContent(linter, pkgMgr), workdir),
+		)
+	}
+	if descriptor.testRunner.name != "" {
+		configBuilder.AppendStepListItemsTo(runTestsWorkflowID,
+			steps.ScriptStepListItem("Run "+descriptor.testRunner.name, runScriptContent(descriptor.testRunner, pkgMgr), workdir),
+		)
+	}
+
+	configBuilder.AppendStepListItemsTo(runTestsWorkflowID,
+		pluginSteps.SaveCacheStepListItem("Save Python cache", cacheKey+dependencyFilesChecksum(pkgMgr), strings.Join(cachePaths, "\n")),
+	)
+	configBuilder.AppendStepListItemsTo(runTestsWorkflowID, steps.DefaultDeployStepList()...)
+
+	return scannerutil.GenerateConfig(configBuilder, ScannerName)
+}
+
+// dependencyFilesChecksum keys the cache on the lock file, or on every file declaring the dependencies
+// if the package manager has no lock file.
+func dependencyFilesChecksum(pkgMgr packageManager) string {
+	var patterns []string
+	for _, name := range pkgMgr.dependencyFiles {
+		patterns = append(patterns, fmt.Sprintf(`"**/%s"`, name))
+	}
+	return fmt.Sprintf(`{{ checksum %s }}`, strings.Join(patterns, " "))
+}
+
+func installPythonScriptContent(pythonVersion string, pkgMgr packageManager) string {
+	if pythonVersion == "" {
+		pythonVersion = defaultPythonVersion
+	}
+
+	cacheEnvs := fmt.Sprintf(`envman add --key PIP_CACHE_DIR --value "$HOME/%s"`, strings.TrimPrefix(pipCacheDir, "~/"))
+	if pkgMgr.cacheDir != "" {
+		cacheEnvs += fmt.Sprintf("\nenvman add --key %s_CACHE_DIR --value \"$HOME/%s\"", strings.ToUpper(pkgMgr.name), strings.TrimPrefix(pkgMgr.cacheDir, "~/"))
+	}
+
+	return fmt.Sprintf(`#!/usr/bin/env bash
+set -euxo pipefail
+
+# Bitrise stacks come with asdf pre-installed to help auto-switch between various software versions
+# the version in the .python-version file takes precedence over the one the project metadata requires
+requested_version="%s"
+if [ -f .python-version ]; then
+  requested_version="$(head -n 1 .python-version)"
+fi
+version="$(asdf latest python "$requested_version")"
+asdf install python "$version"
+envman add --key ASDF_PYTHON_VERSION --value "$version"
+export ASDF_PYTHON_VERSION="$version"
+python --version
+
+# the package manager caches are moved to the same place on every stack, so that the cache steps find them
+%s
+`, pythonVersion, cacheEnvs)
+}
+
+func installDepsScriptContent(descriptor configDescriptor, pkgMgr packageManager) string {
+	var install string
+	switch pkgMgr.name {
+	case "poetry":
+		install = "python -m pip install poetry\npoetry install --no-interaction"
+	case "pdm":
+		install = "python -m pip install pdm\npdm install"
+	case "pipenv":
+		install = "python -m pip install pipenv\npipenv install --dev"
+	case "hatch":
+		install = "python -m pip install hatch\nhatch env create"
+	default:
+		install = `shopt -s nullglob
+for requirements_file in requirements*.txt; do
+  python -m pip install -r "$requirements_file"
+done
+if [ -f pyproject.toml ] || [ -f setup.py ]; then
+  python -m pip install -e .
+fi`
+	}
+
+	var undeclaredTools []string
+	for _, t := range append([]tool{descriptor.testRunner}, descriptor.linters...) {
+		if t.name != "" && !t.declared {
+			undeclaredTools = append(undeclaredTools, t.name)
+		}
+	}
+	if len(undeclaredTools) > 0 {
+		install += "\n\n# not declared as a dependency of the project\npython -m pip install " + strings.Join(undeclaredTools, " ")
+	}
+
+	return `#!/usr/bin/env bash
+set -euxo pipefail
+
+python -m pip install --upgrade pip
+` + install + "\n"
+}
+
+// runScriptContent runs a declared tool in the project environment, pytest writes a JUnit report to the test result dir.
+func runScriptContent(t tool, pkgMgr packageManager) string {
+	command := t.command
+	if t.declared {
+		command = pkgMgr.runPrefix + command
+	}
+
+	reportDir := ""
+	if t.name == "pytest" {
+		reportDir = `report_dir="$BITRISE_TEST_RESULT_DIR/pytest"
+mkdir -p "$report_dir"
+echo '{"test-name": "pytest"}' > "$report_dir/test-info.json"
+`
+	}
+
+	return `#!/usr/bin/env bash
+set -euxo pipefail
+
+` + reportDir + command + "\n"
+}
+
+func findPackageManager(name string) packageManager {
+	for _, pkgMgr := range pkgManagers {
+		if pkgMgr.name == name {
+			return pkgMgr
+		}
+	}
+	return pkgManagers[len(pkgManagers)-1]
+}
+
+// parseTOMLTables reads the table names and the single-line string values of a TOML file,
+// which is all the scanner needs of the pyproject.toml and Pipfile files.
+func parseTOMLTables(content string) tomlTables {
+	tables := tomlTables{}
+	table := ""
+	tables[table] = map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		if match := tomlTablePattern.FindStringSubmatch(line); match != nil {
+			table = strings.ReplaceAll(match[1], `"`, "")
+			if _, ok := tables[table]; !ok {
+				tables[table] = map[string]string{}
+			}
+			continue
+		}
+		if match := tomlStringPattern.FindStringSubmatch(line); match != nil {
+			tables[table][match[1]] = match[2]
+		}
+	}
+	return tables
+}
+
+// hasTable reports whether the table or one of its sub-tables is defined.
+func (t tomlTables) hasTable(name string) bool {
+	for table := range t {
+		if table == name || strings.HasPrefix(table, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutTableHeaders(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if !tomlTablePattern.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
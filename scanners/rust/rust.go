@@ -0,0 +1,454 @@
+package rust
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	"github.com/bitrise-io/bitrise-init/models"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-init/utility"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/scannerutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+const (
+	ScannerName = "rust"
+
+	configName        = "rust-config"
+	defaultConfigName = "default-rust-config"
+
+	projectDirInputTitle   = "Cargo project directory"
+	projectDirInputSummary = "The directory of the crate or workspace `Cargo.toml` file, relative to the repository root."
+	projectDirInputEnvKey  = "RUST_PROJECT_DIR"
+
+	packageArgsInputTitle   = "Cargo packages"
+	packageArgsInputSummary = "The packages `cargo clippy`, `cargo test` and `cargo build` run on, `--workspace` for every member of the workspace."
+	packageArgsInputEnvKey  = "CARGO_PACKAGE_ARGS"
+
+	testWorkflowID  = "run_tests"
+	buildWorkflowID = "build"
+
+	workspaceArgs = "--workspace"
+
+	searchDepth = 6
+)
+
+// rustfmtConfigNames and clippyConfigNames are looked for in the project dir.
+var (
+	rustfmtConfigNames = []string{"rustfmt.toml", ".rustfmt.toml"}
+	clippyConfigNames  = []string{"clippy.toml", ".clippy.toml"}
+)
+
+var (
+	tomlTablePattern     = regexp.MustCompile(`(?m)^\s*\[\[?\s*([^\]]+?)\s*\]\]?\s*(?:#.*)?$`)
+	tomlNamePattern      = regexp.MustCompile(`(?m)^\s*name\s*=\s*"([^"]+)"`)
+	tomlChannelPattern   = regexp.MustCompile(`(?m)^\s*channel\s*=\s*"([^"]+)"`)
+	tomlMembersPattern   = regexp.MustCompile(`(?s)\bmembers\s*=\s*\[(.*?)\]`)
+	tomlExcludePattern   = regexp.MustCompile(`(?s)\bexclude\s*=\s*\[(.*?)\]`)
+	tomlQuotedPattern    = regexp.MustCompile(`"([^"]+)"`)
+	tomlCommentPattern   = regexp.MustCompile(`(?m)#.*$`)
+	clippyLintsTableName = regexp.MustCompile(`^(workspace\.)?lints\.clippy$`)
+)
+
+// Project is a Cargo workspace or a standalone crate.
+type Project struct {
+	// Dir is relative to the search dir, "." for the search dir itself.
+	Dir string
+	// Packages are the workspace member packages, or the package of the crate.
+	Packages    []string
+	IsWorkspace bool
+	// Toolchain is the channel of the rust-toolchain.toml file, empty if the project has no toolchain file.
+	Toolchain string
+	// HasRustfmtConfig and HasClippyConfig are set if the project configures the formatter or the linter,
+	// their checks fail the build only if they are configured.
+	HasRustfmtConfig bool
+	HasClippyConfig  bool
+	HasLockFile      bool
+}
+
+// Scanner detects Cargo workspaces and crates.
+type Scanner struct {
+	projects []Project
+}
+
+// NewScanner ...
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// Name ...
+func (*Scanner) Name() string {
+	return ScannerName
+}
+
+// DetectPlatform looks for the Cargo workspaces and the crates which are not members of a workspace.
+func (s *Scanner) DetectPlatform(searchDir string) (bool, error) {
+	log.TInfof("Searching for Cargo.toml files...")
+
+	rootEntry, err := direntry.WalkDir(searchDir, searchDepth)
+	if err != nil {
+		return false, err
+	}
+
+	manifests := map[string]string{}
+	var dirs []string
+	for _, entry := range rootEntry.FindAllEntriesByName("Cargo.toml", false) {
+		if isInTargetDir(entry.RelPath) {
+			continue
+		}
+		content, err := os.ReadFile(entry.AbsPath)
+		if err != nil {
+			return false, fmt.Errorf("read %s: %s", entry.RelPath, err)
+		}
+		dir := fsutil.RelPath(filepath.Dir(entry.RelPath))
+		manifests[dir] = string(content)
+		dirs = append(dirs, dir)
+	}
+
+	members := map[string]bool{}
+	for _, dir := range dirs {
+		tables := parseTables(manifests[dir])
+		if _, ok := tables["workspace"]; !ok {
+			continue
+		}
+
+		project := Project{Dir: dir, IsWorkspace: true}
+		if _, ok := tables["package"]; ok {
+			project.Packages = append(project.Packages, packageName(tables))
+		}
+		for _, memberDir := range workspaceMembers(searchDir, dir, tables["workspace"]) {
+			members[memberDir] = true
+			if manifest, ok := manifests[memberDir]; ok {
+				if name := packageName(parseTables(manifest)); name != "" {
+					project.Packages = append(project.Packages, name)
+				}
+			}
+		}
+		members[dir] = true
+
+		if err := inspectProject(searchDir, &project, tables); err != nil {
+			return false, err
+		}
+		log.TPrintf("Cargo workspace found: %s (%s)", project.Dir, strings.Join(project.Packages, ", "))
+		s.projects = append(s.projects, project)
+	}
+
+	for _, dir := range dirs {
+		if members[dir] {
+			continue
+		}
+		tables := parseTables(manifests[dir])
+		if _, ok := tables["package"]; !ok {
+			continue
+		}
+
+		project := Project{Dir: dir, Packages: []string{packageName(tables)}}
+		if err := inspectProject(searchDir, &project, tables); err != nil {
+			return false, err
+		}
+		log.TPrintf("Crate found: %s (%s)", project.Dir, project.Packages[0])
+		s.projects = append(s.projects, project)
+	}
+
+	if len(s.projects) == 0 {
+		log.TPrintf("platform not detected")
+		return false, nil
+	}
+
+	sort.SliceStable(s.projects, func(i, j int) bool { return s.projects[i].Dir < s.projects[j].Dir })
+	log.TSuccessf("Platform detected")
+	return true, nil
+}
+
+// ExcludedScannerNames ...
+func (*Scanner) ExcludedScannerNames() []string {
+	return nil
+}
+
+// Options ...
+func (s *Scanner) Options() (models.OptionNode, models.Warnings, models.Icons, error) {
+	projectDirOption := models.NewOption(projectDirInputTitle, projectDirInputSummary, projectDirInputEnvKey, models.TypeSelector)
+
+	for _, project := range s.projects {
+		packageArgsOption := models.NewOption(packageArgsInputTitle, packageArgsInputSummary, packageArgsInputEnvKey, models.TypeSelector)
+		projectDirOption.AddOption(project.Dir, packageArgsOption)
+
+		name := projectConfigName(project)
+		if project.IsWorkspace {
+			packageArgsOption.AddConfig(workspaceArgs, models.NewConfigOption(name, nil))
+		}
+		for _, pkg := range project.Packages {
+			packageArgsOption.AddConfig("--package "+pkg, models.NewConfigOption(name, nil))
+		}
+	}
+
+	return *projectDirOption, nil, nil, nil
+}
+
+// DefaultOptions ...
+func (*Scanner) DefaultOptions() models.OptionNode {
+	projectDirOption := models.NewOption(projectDirInputTitle, projectDirInputSummary, projectDirInputEnvKey, models.TypeUserInput)
+
+	packageArgsOption := models.NewOption(packageArgsInputTitle, packageArgsInputSummary, packageArgsInputEnvKey, models.TypeUserInput)
+	projectDirOption.AddOption(models.UserInputOptionDefaultValue, packageArgsOption)
+
+	packageArgsOption.AddConfig(models.UserInputOptionDefaultValue, models.NewConfigOption(defaultConfigName, nil))
+
+	return *projectDirOption
+}
+
+// Configs ...
+func (s *Scanner) Configs(sshKeyActivation models.SSHKeyActivation) (models.BitriseConfigMap, error) {
+	configs := models.BitriseConfigMap{}
+	for _, project := range s.projects {
+		name := projectConfigName(project)
+		if _, ok := configs[name]; ok {
+			continue
+		}
+
+		config, err := generateConfig(project, sshKeyActivation)
+		if err != nil {
+			return models.BitriseConfigMap{}, err
+		}
+		configs[name] = config
+	}
+
+	return configs, nil
+}
+
+// DefaultConfigs ...
+func (*Scanner) DefaultConfigs() (models.BitriseConfigMap, error) {
+	project := Project{HasRustfmtConfig: true, HasClippyConfig: true, HasLockFile: true}
+	config, err := generateConfig(project, models.SSHKeyActivationConditional)
+	if err != nil {
+		return models.BitriseConfigMap{}, err
+	}
+
+	return models.BitriseConfigMap{defaultConfigName: config}, nil
+}
+
+// projectConfigName returns the name of the project's config, the configs differ in the enforced checks and the cache key.
+func projectConfigName(project Project) string {
+	name := "rust"
+	if project.HasRustfmtConfig {
+		name += "-rustfmt"
+	}
+	if project.HasClippyConfig {
+		name += "-clippy"
+	}
+	if !project.HasLockFile {
+		name += "-no-lockfile"
+	}
+	if name == "rust" {
+		return configName
+	}
+	return name + "-config"
+}
+
+func generateConfig(project Project, sshKeyActivation models.SSHKeyActivation) (string, error) {
+	configBuilder := models.NewDefaultConfigBuilder()
+
+	workingDir := envmanModels.EnvironmentItemModel{"working_dir": "$" + projectDirInputEnvKey}
+	// the crates without a lock file resolve the latest compatible dependencies in every build
+	cacheKey := `{{ .OS }}-{{ .Arch }}-cargo-{{ checksum "**/Cargo.lock" }}`
+	if !project.HasLockFile {
+		cacheKey = `{{ .OS }}-{{ .Arch }}-cargo-{{ checksum "**/Cargo.toml" }}`
+	}
+	cachePaths := strings.Join([]string{"~/.cargo/registry", "~/.cargo/git", "$" + projectDirInputEnvKey + "/target"}, "\n")
+
+	for _, workflowID := range []models.WorkflowID{testWorkflowID, buildWorkflowID} {
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultPrepareStepList(initSteps.PrepareListParams{SSHKeyActivation: sshKeyActivation})...)
+		configBuilder.AppendStepListItemsTo(workflowID,
+			initSteps.ScriptStepListItem("Install Rust toolchain", toolchainScriptContent(project.Toolchain), workingDir),
+			steps.RestoreCacheStepListItem("Restore Cargo cache", cacheKey+"\n{{ .OS }}-{{ .Arch }}-cargo-"),
+		)
+
+		if workflowID == testWorkflowID {
+			configBuilder.AppendStepListItemsTo(workflowID,
+				checkStepListItem(initSteps.ScriptStepListItem("cargo fmt", scannerutil.CommandScriptContent("cargo fmt --all --check"), workingDir), project.HasRustfmtConfig),
+				checkStepListItem(initSteps.ScriptStepListItem("cargo clippy", scannerutil.CommandScriptContent("cargo clippy $CARGO_PACKAGE_ARGS --all-targets -- -D warnings"), workingDir), project.HasClippyConfig),
+				initSteps.ScriptStepListItem("cargo test", scannerutil.CommandScriptContent("cargo test $CARGO_PACKAGE_ARGS"), workingDir),
+			)
+		} else {
+			configBuilder.AppendStepListItemsTo(workflowID,
+				initSteps.ScriptStepListItem("cargo build", scannerutil.CommandScriptContent("cargo build $CARGO_PACKAGE_ARGS --release"), workingDir),
+			)
+		}
+
+		configBuilder.AppendStepListItemsTo(workflowID, steps.SaveCacheStepListItem("Save Cargo cache", cacheKey, cachePaths))
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultDeployStepList()...)
+	}
+
+	return scannerutil.GenerateConfig(configBuilder, ScannerName)
+}
+
+// checkStepListItem makes a formatter or linter step skippable if the project does not configure the tool,
+// the check runs but its findings do not fail the build.
+func checkStepListItem(item bitriseModels.StepListItemModel, isConfigured bool) bitriseModels.StepListItemModel {
+	if isConfigured {
+		return item
+	}
+	for id, value := range item {
+		if step, ok := value.(stepmanModels.StepModel); ok {
+			step.IsSkippable = pointers.NewBoolPtr(true)
+			item[id] = step
+		}
+	}
+	return item
+}
+
+func toolchainScriptContent(toolchain string) string {
+	toolchainComment := "# rustup installs the toolchain, components and targets of the rust-toolchain.toml file, or the stable toolchain"
+	if toolchain != "" {
+		toolchainComment = fmt.Sprintf("# rustup installs the %s toolchain, as set in the rust-toolchain.toml file", toolchain)
+	}
+
+	return `#!/usr/bin/env bash
+set -euxo pipefail
+
+if ! command -v rustup &> /dev/null; then
+  curl --proto '=https' --tlsv1.2 -sSf https://sh.rustup.rs | sh -s -- -y --default-toolchain none
+  export PATH="$HOME/.cargo/bin:$PATH"
+  envman add --key PATH --value "$PATH"
+fi
+
+` + toolchainComment + `
+if [ -f rust-toolchain.toml ] || [ -f rust-toolchain ]; then
+  rustup toolchain install
+else
+  rustup toolchain install stable --profile minimal
+  rustup default stable
+fi
+rustup component add rustfmt clippy
+cargo --version
+`
+}
+
+func inspectProject(searchDir string, project *Project, tables map[string]string) error {
+	dir := filepath.Join(searchDir, project.Dir)
+
+	project.HasLockFile = utility.FileExists(filepath.Join(dir, "Cargo.lock"))
+	for _, name := range rustfmtConfigNames {
+		if utility.FileExists(filepath.Join(dir, name)) {
+			project.HasRustfmtConfig = true
+		}
+	}
+	for _, name := range clippyConfigNames {
+		if utility.FileExists(filepath.Join(dir, name)) {
+			project.HasClippyConfig = true
+		}
+	}
+	for table := range tables {
+		if clippyLintsTableName.MatchString(table) {
+			project.HasClippyConfig = true
+		}
+	}
+
+	for _, name := range []string{"rust-toolchain.toml", "rust-toolchain"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("read %s: %s", filepath.Join(project.Dir, name), err)
+		}
+
+		if match := tomlChannelPattern.FindStringSubmatch(string(content)); match != nil {
+			project.Toolchain = match[1]
+		} else if name == "rust-toolchain" {
+			// the legacy toolchain file only contains the channel
+			project.Toolchain = strings.TrimSpace(string(content))
+		}
+		log.TPrintf("Rust toolchain: %s", project.Toolchain)
+		break
+	}
+
+	return nil
+}
+
+// parseTables splits a TOML file into its tables, the keys before the first table header belong to the "" table.
+func parseTables(content string) map[string]string {
+	content = tomlCommentPattern.ReplaceAllString(content, "")
+	tables := map[string]string{}
+
+	locations := tomlTablePattern.FindAllStringSubmatchIndex(content, -1)
+	if len(locations) == 0 {
+		tables[""] = content
+		return tables
+	}
+	tables[""] = content[:locations[0][0]]
+	for i, location := range locations {
+		end := len(content)
+		if i+1 < len(locations) {
+			end = locations[i+1][0]
+		}
+		name := strings.ReplaceAll(content[location[2]:location[3]], `"`, "")
+		tables[name] += content[location[1]:end]
+	}
+	return tables
+}
+
+// workspaceMembers returns the member dirs of a workspace relative to the search dir, with the glob members expanded.
+func workspaceMembers(searchDir, workspaceDir, workspaceTable string) []string {
+	excluded := map[string]bool{}
+	if match := tomlExcludePattern.FindStringSubmatch(workspaceTable); match != nil {
+		for _, quoted := range tomlQuotedPattern.FindAllStringSubmatch(match[1], -1) {
+			excluded[fsutil.RelPath(filepath.Join(workspaceDir, quoted[1]))] = true
+		}
+	}
+
+	match := tomlMembersPattern.FindStringSubmatch(workspaceTable)
+	if match == nil {
+		return nil
+	}
+
+	var members []string
+	for _, quoted := range tomlQuotedPattern.FindAllStringSubmatch(match[1], -1) {
+		pattern := filepath.Join(searchDir, workspaceDir, quoted[1])
+		pths, err := filepath.Glob(pattern)
+		if err != nil {
+			log.TWarnf("Invalid workspace member pattern %s: %s", quoted[1], err)
+			continue
+		}
+		for _, pth := range pths {
+			if !utility.FileExists(filepath.Join(pth, "Cargo.toml")) {
+				continue
+			}
+			rel, err := filepath.Rel(searchDir, pth)
+			if err != nil {
+				continue
+			}
+			if member := fsutil.RelPath(rel); !excluded[member] {
+				members = append(members, member)
+			}
+		}
+	}
+	return members
+}
+
+func packageName(tables map[string]string) string {
+	if match := tomlNamePattern.FindStringSubmatch(tables["package"]); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+func isInTargetDir(relPth string) bool {
+	for _, component := range strings.Split(filepath.ToSlash(relPth), "/") {
+		if component == "target" {
+			return true
+		}
+	}
+	return false
+}
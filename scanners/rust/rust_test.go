@@ -0,0 +1,90 @@
+package rust
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "core", "Cargo.toml"), `[workspace]
+resolver = "2"
+members = [
+    "crates/*", # every crate
+    "ffi",
+]
+exclude = ["crates/experimental"]
+
+[workspace.lints.clippy]
+unwrap_used = "deny"
+`)
+	testutil.WriteFile(t, filepath.Join(dir, "core", "Cargo.lock"), "")
+	testutil.WriteFile(t, filepath.Join(dir, "core", "rust-toolchain.toml"), "[toolchain]\nchannel = \"1.78.0\"\ncomponents = [\"clippy\"]\n")
+	testutil.WriteFile(t, filepath.Join(dir, "core", "crates", "model", "Cargo.toml"), "[package]\nname = \"model\"\n\n[dependencies]\nserde = { version = \"1\", features = [\"derive\"] }\n")
+	testutil.WriteFile(t, filepath.Join(dir, "core", "crates", "experimental", "Cargo.toml"), "[package]\nname = \"experimental\"\n")
+	testutil.WriteFile(t, filepath.Join(dir, "core", "ffi", "Cargo.toml"), "[package]\nname = \"core-ffi\"\n\n[lib]\ncrate-type = [\"staticlib\", \"cdylib\"]\n")
+	testutil.WriteFile(t, filepath.Join(dir, "core", "target", "package", "Cargo.toml"), "[package]\nname = \"packaged\"\n")
+	testutil.WriteFile(t, filepath.Join(dir, "tools", "Cargo.toml"), "[package]\nname = \"tools\"\n")
+	testutil.WriteFile(t, filepath.Join(dir, "tools", "rustfmt.toml"), "max_width = 120\n")
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	require.Equal(t, []Project{
+		{Dir: "core", Packages: []string{"model", "core-ffi"}, IsWorkspace: true, Toolchain: "1.78.0", HasClippyConfig: true, HasLockFile: true},
+		{Dir: "core/crates/experimental", Packages: []string{"experimental"}},
+		{Dir: "tools", Packages: []string{"tools"}, HasRustfmtConfig: true},
+	}, scanner.projects)
+
+	options, _, _, err := scanner.Options()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"core", "core/crates/experimental", "tools"}, options.GetValues())
+	packageArgsOption, ok := options.Child("core")
+	require.True(t, ok)
+	require.Equal(t, packageArgsInputEnvKey, packageArgsOption.EnvKey)
+	require.ElementsMatch(t, []string{"--workspace", "--package model", "--package core-ffi"}, packageArgsOption.GetValues())
+	require.Equal(t, "rust-clippy-config", packageArgsOption.ChildOptionMap["--workspace"].Config)
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	require.Len(t, configs, 3)
+
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs["rust-clippy-config"]), &config))
+	testSteps := config.Workflows[testWorkflowID].Steps
+
+	toolchainStep, err := testSteps[1].GetStep()
+	require.NoError(t, err)
+	require.Contains(t, toolchainStep.Inputs[0]["content"], "# rustup installs the 1.78.0 toolchain")
+
+	fmtStep, err := testSteps[3].GetStep()
+	require.NoError(t, err)
+	require.True(t, *fmtStep.IsSkippable)
+	clippyStep, err := testSteps[4].GetStep()
+	require.NoError(t, err)
+	require.Nil(t, clippyStep.IsSkippable)
+	require.Equal(t, "#!/usr/bin/env bash\nset -euxo pipefail\n\ncargo clippy $CARGO_PACKAGE_ARGS --all-targets -- -D warnings\n", clippyStep.Inputs[0]["content"])
+
+	saveCacheStep, err := testSteps[6].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, `{{ .OS }}-{{ .Arch }}-cargo-{{ checksum "**/Cargo.lock" }}`, saveCacheStep.Inputs[0]["key"])
+	require.Equal(t, "~/.cargo/registry\n~/.cargo/git\n$RUST_PROJECT_DIR/target", saveCacheStep.Inputs[1]["paths"])
+}
+
+func TestScanner_notDetected(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "target", "Cargo.toml"), "[package]\nname = \"generated\"\n")
+
+	detected, err := NewScanner().DetectPlatform(dir)
+	require.NoError(t, err)
+	require.False(t, detected)
+}
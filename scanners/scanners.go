@@ -0,0 +1,176 @@
+package scanners
+
+import (
+	"fmt"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	initScanners "github.com/bitrise-io/bitrise-init/scanners"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/bazel"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/capacitor"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/dotnet"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/golang"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/java"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/kmp"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/python"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/rust"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/tuist"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/unity"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/xcodegen"
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/sliceutil"
+)
+
+// ProjectScanners are the project scanners of the plugin. They implement the bitrise-init scanner interface
+// and their outputs are merged into the bitrise-init scan result.
+func ProjectScanners() []initScanners.ScannerInterface {
+	return []initScanners.ScannerInterface{
+		bazel.NewScanner(),
+		xcodegen.NewScanner(),
+		tuist.NewScanner(),
+		golang.NewScanner(),
+		python.NewScanner(),
+		rust.NewScanner(),
+		kmp.NewScanner(),
+		java.NewScanner(),
+		dotnet.NewScanner(),
+		unity.NewScanner(),
+		capacitor.NewScanner(),
+	}
+}
+
+// Result is the output of the plugin scanners, in the shape of the bitrise-init scan result,
+// with the names of the scanners the detected plugin scanners exclude.
+type Result struct {
+	models.ScanResultModel
+	ExcludedScannerNames []string
+}
+
+// Run runs the scanners on the search dir, the same way bitrise-init runs its own scanners:
+// a scanner excluded by a previously detected scanner is skipped.
+func Run(scannerList []initScanners.ScannerInterface, searchDir string, isPrivateRepo bool) Result {
+	result := Result{
+		ScanResultModel: models.ScanResultModel{
+			ScannerToOptionRoot:       map[string]models.OptionNode{},
+			ScannerToBitriseConfigMap: map[string]models.BitriseConfigMap{},
+			ScannerToWarnings:         map[string]models.Warnings{},
+			ScannerToErrors:           map[string]models.Errors{},
+		},
+	}
+
+	var sshKeyActivation models.SSHKeyActivation
+	if isPrivateRepo {
+		sshKeyActivation = models.SSHKeyActivationMandatory
+	} else {
+		sshKeyActivation = models.SSHKeyActivationNone
+	}
+
+	for _, scanner := range scannerList {
+		log.TInfof("Scanner: %s", colorstring.Blue(scanner.Name()))
+		if sliceutil.IsStringInSlice(scanner.Name(), result.ExcludedScannerNames) {
+			log.TWarnf("scanner is marked as excluded, skipping...")
+			fmt.Println()
+			continue
+		}
+
+		detected, err := scanner.DetectPlatform(searchDir)
+		if err != nil {
+			log.TErrorf("Scanner failed, error: %s", err)
+			result.ScannerToWarnings[scanner.Name()] = models.Warnings{err.Error()}
+			fmt.Println()
+			continue
+		}
+		if !detected {
+			fmt.Println()
+			continue
+		}
+
+		options, warnings, icons, err := scanner.Options()
+		result.ScannerToWarnings[scanner.Name()] = warnings
+		if err != nil {
+			log.TErrorf("Analyzer failed, error: %s", err)
+			result.ScannerToWarnings[scanner.Name()] = append(warnings, err.Error())
+			fmt.Println()
+			continue
+		}
+
+		configs, err := scanner.Configs(sshKeyActivation)
+		if err != nil {
+			log.TErrorf("Failed to generate config, error: %s", err)
+			result.ScannerToErrors[scanner.Name()] = models.Errors{err.Error()}
+			fmt.Println()
+			continue
+		}
+		fmt.Println()
+
+		result.ScannerToOptionRoot[scanner.Name()] = options
+		result.ScannerToBitriseConfigMap[scanner.Name()] = configs
+		result.Icons = append(result.Icons, icons...)
+		result.ExcludedScannerNames = append(result.ExcludedScannerNames, scanner.ExcludedScannerNames()...)
+	}
+
+	return result
+}
+
+// Merge adds the plugin scanner outputs to the bitrise-init scan result
+// and drops the outputs of the bitrise-init scanners the plugin scanners exclude.
+// A plugin scanner replacing a bitrise-init scanner is dropped if a detected bitrise-init scanner excludes it.
+func Merge(scanResult *models.ScanResultModel, result Result) {
+	initExcludedScannerNames := map[string]bool{}
+	for _, scanner := range initScanners.ProjectScanners() {
+		if _, ok := scanResult.ScannerToOptionRoot[scanner.Name()]; !ok {
+			continue
+		}
+		for _, name := range scanner.ExcludedScannerNames() {
+			initExcludedScannerNames[name] = true
+		}
+	}
+	for name := range result.ScannerToOptionRoot {
+		if initExcludedScannerNames[name] {
+			log.TWarnf("%s scanner output excluded", name)
+			delete(result.ScannerToOptionRoot, name)
+			delete(result.ScannerToBitriseConfigMap, name)
+			delete(result.ScannerToWarnings, name)
+			delete(result.ScannerToErrors, name)
+		}
+	}
+
+	for _, name := range result.ExcludedScannerNames {
+		if _, ok := scanResult.ScannerToOptionRoot[name]; ok {
+			log.TWarnf("%s scanner output excluded", name)
+		}
+		delete(scanResult.ScannerToOptionRoot, name)
+		delete(scanResult.ScannerToBitriseConfigMap, name)
+		delete(scanResult.ScannerToWarnings, name)
+		delete(scanResult.ScannerToErrors, name)
+		delete(scanResult.ScannerToWarningsWithRecommendations, name)
+		delete(scanResult.ScannerToErrorsWithRecommendations, name)
+	}
+
+	if scanResult.ScannerToOptionRoot == nil {
+		scanResult.ScannerToOptionRoot = map[string]models.OptionNode{}
+	}
+	if scanResult.ScannerToBitriseConfigMap == nil {
+		scanResult.ScannerToBitriseConfigMap = map[string]models.BitriseConfigMap{}
+	}
+	if scanResult.ScannerToWarnings == nil {
+		scanResult.ScannerToWarnings = map[string]models.Warnings{}
+	}
+	if scanResult.ScannerToErrors == nil {
+		scanResult.ScannerToErrors = map[string]models.Errors{}
+	}
+
+	for name, options := range result.ScannerToOptionRoot {
+		scanResult.ScannerToOptionRoot[name] = options
+	}
+	for name, configs := range result.ScannerToBitriseConfigMap {
+		scanResult.ScannerToBitriseConfigMap[name] = configs
+	}
+	for name, warnings := range result.ScannerToWarnings {
+		scanResult.ScannerToWarnings[name] = warnings
+	}
+	for name, errors := range result.ScannerToErrors {
+		scanResult.ScannerToErrors[name] = errors
+	}
+	scanResult.Icons = append(scanResult.Icons, result.Icons...)
+}
@@ -0,0 +1,48 @@
+package scanners
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	scanResult := models.ScanResultModel{
+		ScannerToOptionRoot:       map[string]models.OptionNode{"ios": {}, "node-js": {}},
+		ScannerToBitriseConfigMap: map[string]models.BitriseConfigMap{"ios": {}, "node-js": {}},
+		ScannerToWarnings:         map[string]models.Warnings{"ios": {"warning"}},
+	}
+
+	Merge(&scanResult, Result{
+		ScanResultModel: models.ScanResultModel{
+			ScannerToOptionRoot:       map[string]models.OptionNode{"bazel": {}},
+			ScannerToBitriseConfigMap: map[string]models.BitriseConfigMap{"bazel": {"bazel-config": ""}},
+		},
+		ExcludedScannerNames: []string{"ios", "macos"},
+	})
+
+	require.Equal(t, map[string]models.OptionNode{"node-js": {}, "bazel": {}}, scanResult.ScannerToOptionRoot)
+	require.Equal(t, map[string]models.BitriseConfigMap{"node-js": {}, "bazel": {"bazel-config": ""}}, scanResult.ScannerToBitriseConfigMap)
+	require.Empty(t, scanResult.ScannerToWarnings)
+}
+
+func TestMergeInitExclusions(t *testing.T) {
+	scanResult := models.ScanResultModel{
+		ScannerToOptionRoot:       map[string]models.OptionNode{"android": {}},
+		ScannerToBitriseConfigMap: map[string]models.BitriseConfigMap{"android": {}},
+	}
+
+	Merge(&scanResult, Result{
+		ScanResultModel: models.ScanResultModel{
+			ScannerToOptionRoot:       map[string]models.OptionNode{"java": {}, "rust": {}},
+			ScannerToBitriseConfigMap: map[string]models.BitriseConfigMap{"java": {"java-gradle-config": ""}, "rust": {}},
+			ScannerToWarnings:         map[string]models.Warnings{"java": {"warning"}},
+		},
+		ExcludedScannerNames: []string{"java"},
+	})
+
+	require.Equal(t, map[string]models.OptionNode{"android": {}, "rust": {}}, scanResult.ScannerToOptionRoot)
+	require.Equal(t, map[string]models.BitriseConfigMap{"android": {}, "rust": {}}, scanResult.ScannerToBitriseConfigMap)
+	require.Empty(t, scanResult.ScannerToWarnings)
+}
@@ -0,0 +1,39 @@
+// Package scannerutil contains the config generation helpers shared by the plugin scanners.
+package scannerutil
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+)
+
+// GenerateConfig generates the config of the scanner from the config builder, in the bitrise.yml format.
+func GenerateConfig(configBuilder *models.ConfigBuilderModel, scannerName string) (string, error) {
+	config, err := configBuilder.Generate(scannerName)
+	if err != nil {
+		return "", err
+	}
+	return MarshalConfig(config)
+}
+
+// MarshalConfig returns the config in the bitrise.yml format.
+func MarshalConfig(config bitriseModels.BitriseDataModel) (string, error) {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// CommandScriptContent returns the content of a Script step running the command, the script fails on the first error
+// and traces the commands.
+func CommandScriptContent(command string) string {
+	return fmt.Sprintf(`#!/usr/bin/env bash
+set -euxo pipefail
+
+%s
+`, command)
+}
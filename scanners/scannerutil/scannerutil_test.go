@@ -0,0 +1,19 @@
+package scannerutil
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateConfig(t *testing.T) {
+	configBuilder := models.NewDefaultConfigBuilder()
+	configBuilder.AppendStepListItemsTo(models.PrimaryWorkflowID, initSteps.ScriptStepListItem("Test", CommandScriptContent("make test")))
+
+	config, err := GenerateConfig(configBuilder, "make")
+	require.NoError(t, err)
+	require.Contains(t, config, "project_type: make\n")
+	require.Contains(t, config, "- content: |\n            #!/usr/bin/env bash\n            set -euxo pipefail\n\n            make test\n")
+}
@@ -0,0 +1,319 @@
+package tuist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-init/utility"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/projectgen"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	ScannerName = "tuist"
+
+	projectManifestName   = "Project.swift"
+	workspaceManifestName = "Workspace.swift"
+	searchDepth           = 4
+)
+
+var (
+	declarationPattern = regexp.MustCompile(`(?:\.target|\bTarget|\.scheme|\bScheme)\s*\(`)
+	namePattern        = regexp.MustCompile(`\bname:\s*"([^"]+)"`)
+	productPattern     = regexp.MustCompile(`\bproduct:\s*\.(\w+)`)
+	platformPattern    = regexp.MustCompile(`\b(?:destinations|platform):\s*([^\n]+)`)
+	workspacePattern   = regexp.MustCompile(`Workspace\s*\(\s*name:\s*"([^"]+)"`)
+	projectPattern     = regexp.MustCompile(`Project\s*\(\s*name:\s*"([^"]+)"`)
+)
+
+// Scanner detects Tuist projects. The Swift manifests are not evaluated, the targets and schemes
+// are read from the literal declarations of the manifest.
+type Scanner struct {
+	rootDir           string
+	manifest          projectgen.Manifest
+	configDescriptors []ios.ConfigDescriptor
+	searchDir         string
+	warnings          models.Warnings
+}
+
+// NewScanner ...
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// Name ...
+func (*Scanner) Name() string {
+	return ScannerName
+}
+
+// DetectPlatform looks for the shallowest Tuist workspace or project manifest.
+func (s *Scanner) DetectPlatform(searchDir string) (bool, error) {
+	log.TInfof("Searching for Tuist manifests...")
+
+	rootEntry, err := direntry.WalkDir(searchDir, searchDepth)
+	if err != nil {
+		return false, err
+	}
+
+	manifestEntry := rootEntry.FindFirstEntryByName(workspaceManifestName, false)
+	if manifestEntry == nil || !isTuistManifest(manifestEntry.AbsPath) {
+		manifestEntry = nil
+		for _, entry := range rootEntry.FindAllEntriesByName(projectManifestName, false) {
+			if isTuistManifest(entry.AbsPath) {
+				manifestEntry = &entry
+				break
+			}
+		}
+	}
+	if manifestEntry == nil {
+		log.TPrintf("platform not detected")
+		return false, nil
+	}
+
+	rootDir := strings.TrimPrefix(filepath.Dir(manifestEntry.RelPath), "./")
+	log.TPrintf("Tuist manifest found: %s", strings.TrimPrefix(manifestEntry.RelPath, "./"))
+
+	manifest, warnings, err := parseManifests(filepath.Join(searchDir, rootDir), rootDir)
+	if err != nil {
+		return false, err
+	}
+
+	s.rootDir = rootDir
+	s.manifest = manifest
+	s.searchDir = searchDir
+	s.warnings = warnings
+	log.TSuccessf("Platform detected")
+	return true, nil
+}
+
+// ExcludedScannerNames ...
+func (*Scanner) ExcludedScannerNames() []string {
+	return projectgen.ExcludedScannerNames()
+}
+
+// Options ...
+func (s *Scanner) Options() (models.OptionNode, models.Warnings, models.Icons, error) {
+	options, configDescriptors, icons, warnings, err := ios.GenerateOptions(s.manifest.ProjectType(), s.manifest.DetectResult(s.searchDir))
+	s.configDescriptors = configDescriptors
+	return options, append(s.warnings, warnings...), icons, err
+}
+
+// DefaultOptions ...
+func (*Scanner) DefaultOptions() models.OptionNode {
+	return ios.GenerateDefaultOptions(ios.XcodeProjectTypeIOS)
+}
+
+// Configs ...
+func (s *Scanner) Configs(sshKeyActivation models.SSHKeyActivation) (models.BitriseConfigMap, error) {
+	return projectgen.GenerateConfigs(s.manifest.ProjectType(), s.configDescriptors, sshKeyActivation, GenerateStepListItem(s.rootDir))
+}
+
+// DefaultConfigs ...
+func (*Scanner) DefaultConfigs() (models.BitriseConfigMap, error) {
+	return projectgen.GenerateDefaultConfigs(ios.XcodeProjectTypeIOS, GenerateStepListItem("."))
+}
+
+// GenerateStepListItem returns a script step installing the Swift package dependencies
+// and generating the Xcode workspace in the Tuist root dir.
+func GenerateStepListItem(rootDir string) bitriseModels.StepListItemModel {
+	return initSteps.ScriptStepListItem("Generate Xcode workspace", `#!/usr/bin/env bash
+set -euxo pipefail
+
+if ! command -v tuist &> /dev/null; then
+  brew tap tuist/tuist
+  if [ -f .tuist-version ]; then
+    brew install --formula "tuist@$(cat .tuist-version)"
+  else
+    brew install --formula tuist
+  fi
+fi
+if [ -f Tuist/Package.swift ]; then
+  tuist install
+fi
+tuist generate --no-open
+`, envmanModels.EnvironmentItemModel{"working_dir": rootDir})
+}
+
+// parseManifests reads the workspace manifest and the project manifests of the Tuist root dir.
+// Tuist generates a workspace named after the workspace manifest, or after the root project if there is none.
+func parseManifests(rootPth, rootDir string) (projectgen.Manifest, models.Warnings, error) {
+	var warnings models.Warnings
+	manifest := projectgen.Manifest{
+		IsWorkspace:        true,
+		HasSPMDependencies: utility.FileExists(filepath.Join(rootPth, "Tuist", "Package.swift")),
+	}
+
+	workspaceName := ""
+	if content, err := os.ReadFile(filepath.Join(rootPth, workspaceManifestName)); err == nil {
+		if match := workspacePattern.FindStringSubmatch(string(content)); match != nil {
+			workspaceName = match[1]
+		}
+	} else if !os.IsNotExist(err) {
+		return projectgen.Manifest{}, nil, err
+	}
+
+	manifestPths, err := filepath.Glob(filepath.Join(rootPth, projectManifestName))
+	if err != nil {
+		return projectgen.Manifest{}, nil, err
+	}
+	for _, pattern := range []string{"*", "*/*", "*/*/*"} {
+		pths, err := filepath.Glob(filepath.Join(rootPth, pattern, projectManifestName))
+		if err != nil {
+			return projectgen.Manifest{}, nil, err
+		}
+		manifestPths = append(manifestPths, pths...)
+	}
+
+	for _, pth := range manifestPths {
+		content, err := os.ReadFile(pth)
+		if err != nil {
+			return projectgen.Manifest{}, nil, err
+		}
+
+		projectName, targets, schemes := parseProject(string(content))
+		if workspaceName == "" && projectName != "" {
+			workspaceName = projectName
+		}
+		if strings.Contains(string(content), "packages:") {
+			manifest.HasSPMDependencies = true
+		}
+		manifest.Targets = append(manifest.Targets, targets...)
+		manifest.Schemes = append(manifest.Schemes, schemes...)
+	}
+
+	if workspaceName == "" {
+		return projectgen.Manifest{}, nil, fmt.Errorf("no workspace or project name found in the Tuist manifests")
+	}
+	manifest.ProjectPath = filepath.Join(rootDir, workspaceName+".xcworkspace")
+
+	if len(manifest.Schemes) == 0 {
+		warnings = append(warnings, "no app targets found in the Tuist manifests, the targets might be declared by project description helpers")
+	}
+
+	return manifest, warnings, nil
+}
+
+// parseProject reads the project name, the targets and the schemes of a project manifest. The manifest is split
+// at the target and scheme declarations, the nested declarations without a product belong to the preceding target.
+// Tuist creates a scheme for every target, the app target schemes are returned with the tests of the app,
+// followed by the schemes the manifest declares.
+func parseProject(content string) (string, []projectgen.Target, []projectgen.Scheme) {
+	projectName := ""
+	if match := projectPattern.FindStringSubmatch(content); match != nil {
+		projectName = match[1]
+	}
+
+	type declaration struct {
+		isScheme bool
+		content  string
+	}
+	var declarations []declaration
+	locations := declarationPattern.FindAllStringIndex(content, -1)
+	for i, location := range locations {
+		end := len(content)
+		if i+1 < len(locations) {
+			end = locations[i+1][0]
+		}
+		chunk := content[location[0]:end]
+		isScheme := strings.Contains(strings.ToLower(content[location[0]:location[1]]), "scheme")
+
+		if !isScheme && !productPattern.MatchString(chunk) && len(declarations) > 0 {
+			declarations[len(declarations)-1].content += chunk
+			continue
+		}
+		declarations = append(declarations, declaration{isScheme: isScheme, content: chunk})
+	}
+
+	var targets []projectgen.Target
+	var schemeChunks []string
+	for _, declaration := range declarations {
+		if declaration.isScheme {
+			schemeChunks = append(schemeChunks, declaration.content)
+			continue
+		}
+
+		nameMatch := namePattern.FindStringSubmatch(declaration.content)
+		productMatch := productPattern.FindStringSubmatch(declaration.content)
+		if nameMatch == nil || productMatch == nil {
+			continue
+		}
+
+		targets = append(targets, projectgen.Target{
+			Name:      nameMatch[1],
+			Platform:  platform(declaration.content),
+			IsApp:     productMatch[1] == "app",
+			IsTest:    productMatch[1] == "unitTests" || productMatch[1] == "uiTests",
+			IsAppClip: productMatch[1] == "appClip",
+		})
+	}
+
+	var schemes []projectgen.Scheme
+	for _, target := range targets {
+		if !target.IsApp {
+			continue
+		}
+		scheme := projectgen.Scheme{Name: target.Name, BuildTargets: []string{target.Name}}
+		for _, testTarget := range targets {
+			if testTarget.IsTest && strings.HasPrefix(testTarget.Name, target.Name) {
+				scheme.TestTargets = append(scheme.TestTargets, testTarget.Name)
+			}
+		}
+		schemes = append(schemes, scheme)
+	}
+
+	for _, chunk := range schemeChunks {
+		nameMatch := namePattern.FindStringSubmatch(chunk)
+		if nameMatch == nil {
+			continue
+		}
+
+		scheme := projectgen.Scheme{Name: nameMatch[1]}
+		for _, target := range targets {
+			if !strings.Contains(chunk, `"`+target.Name+`"`) {
+				continue
+			}
+			if target.IsTest {
+				scheme.TestTargets = append(scheme.TestTargets, target.Name)
+			} else {
+				scheme.BuildTargets = append(scheme.BuildTargets, target.Name)
+			}
+		}
+		schemes = append(schemes, scheme)
+	}
+
+	return projectName, targets, schemes
+}
+
+// platform returns the platform of a target declaration, iOS if it has no destinations or platform.
+func platform(declaration string) ios.XcodeProjectType {
+	match := platformPattern.FindStringSubmatch(declaration)
+	if match == nil {
+		return ios.XcodeProjectTypeIOS
+	}
+
+	value := strings.ToLower(match[1])
+	if strings.Contains(value, "iphone") || strings.Contains(value, "ipad") || strings.Contains(value, "ios") {
+		return ios.XcodeProjectTypeIOS
+	}
+	if strings.Contains(value, "mac") {
+		return ios.XcodeProjectTypeMacOS
+	}
+	return ""
+}
+
+func isTuistManifest(pth string) bool {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(content), "import ProjectDescription")
+}
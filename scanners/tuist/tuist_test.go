@@ -0,0 +1,109 @@
+package tuist
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/projectgen"
+	"github.com/stretchr/testify/require"
+)
+
+const projectManifest = `import ProjectDescription
+
+let project = Project(
+    name: "Weather",
+    packages: [
+        .remote(url: "https://github.com/Alamofire/Alamofire", requirement: .upToNextMajor(from: "5.8.0")),
+    ],
+    targets: [
+        .target(
+            name: "Weather",
+            destinations: [.iPhone, .iPad],
+            product: .app,
+            bundleId: "io.bitrise.weather",
+            sources: ["Sources/**"],
+            dependencies: [
+                .target(name: "WeatherKit"),
+            ]
+        ),
+        .target(
+            name: "WeatherKit",
+            destinations: .iOS,
+            product: .framework,
+            bundleId: "io.bitrise.weather.kit"
+        ),
+        .target(
+            name: "WeatherTests",
+            destinations: .iOS,
+            product: .unitTests,
+            bundleId: "io.bitrise.weather.tests",
+            dependencies: [.target(name: "Weather")]
+        ),
+        .target(
+            name: "WeatherMac",
+            destinations: [.mac],
+            product: .app,
+            bundleId: "io.bitrise.weather.mac"
+        ),
+    ],
+    schemes: [
+        .scheme(
+            name: "Weather-Release",
+            buildAction: .buildAction(targets: ["Weather"]),
+            testAction: .targets(["WeatherTests"])
+        ),
+    ]
+)
+`
+
+func TestScanner(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "ios", "Project.swift"), projectManifest)
+	testutil.WriteFile(t, filepath.Join(dir, "ios", "Tuist", "Package.swift"), "// swift-tools-version: 5.9\n")
+	testutil.WriteFile(t, filepath.Join(dir, "Project.swift"), "// not a Tuist manifest\n")
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	require.Equal(t, "ios", scanner.rootDir)
+	require.Equal(t, projectgen.Manifest{
+		ProjectPath: "ios/Weather.xcworkspace",
+		IsWorkspace: true,
+		Targets: []projectgen.Target{
+			{Name: "Weather", Platform: ios.XcodeProjectTypeIOS, IsApp: true},
+			{Name: "WeatherKit", Platform: ios.XcodeProjectTypeIOS},
+			{Name: "WeatherTests", Platform: ios.XcodeProjectTypeIOS, IsTest: true},
+			{Name: "WeatherMac", Platform: ios.XcodeProjectTypeMacOS, IsApp: true},
+		},
+		Schemes: []projectgen.Scheme{
+			{Name: "Weather", BuildTargets: []string{"Weather"}, TestTargets: []string{"WeatherTests"}},
+			{Name: "WeatherMac", BuildTargets: []string{"WeatherMac"}},
+			{Name: "Weather-Release", BuildTargets: []string{"Weather"}, TestTargets: []string{"WeatherTests"}},
+		},
+		HasSPMDependencies: true,
+	}, scanner.manifest)
+
+	options, _, _, err := scanner.Options()
+	require.NoError(t, err)
+	schemeOption, ok := options.Child("ios/Weather.xcworkspace")
+	require.True(t, ok)
+	require.ElementsMatch(t, []string{"Weather", "Weather-Release"}, schemeOption.GetValues())
+
+	generateStep := GenerateStepListItem(scanner.rootDir)
+	step, err := generateStep.GetStep()
+	require.NoError(t, err)
+	require.Equal(t, "ios", step.Inputs[1]["working_dir"])
+}
+
+func TestDetectPlatform_notDetected(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "Package.swift"), "import PackageDescription\n")
+
+	detected, err := NewScanner().DetectPlatform(dir)
+	require.NoError(t, err)
+	require.False(t, detected)
+}
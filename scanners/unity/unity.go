@@ -0,0 +1,582 @@
+package unity
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/scannerutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	ScannerName = "unity"
+
+	projectPathInputTitle   = "Unity project path"
+	projectPathInputSummary = "The directory of the Unity project, the one with the `Assets` and `ProjectSettings` directories, relative to the repository root."
+	projectPathInputEnvKey  = "UNITY_PROJECT_PATH"
+
+	buildTargetInputTitle   = "Build target"
+	buildTargetInputSummary = "The platform the Unity player is built for, the value of the `-buildTarget` command line argument."
+	buildTargetInputEnvKey  = "UNITY_BUILD_TARGET"
+
+	testWorkflowID = "run_tests"
+
+	targetAndroid    = "Android"
+	targetIOS        = "iOS"
+	targetStandalone = "OSXUniversal"
+
+	editModeTests = "EditMode"
+	playModeTests = "PlayMode"
+
+	// xcodeProjectDir is where the iOS player is exported to, relative to the project path, Unity names the project and its scheme Unity-iPhone.
+	xcodeProjectDir = "Builds/iOS"
+	xcodeScheme     = "Unity-iPhone"
+
+	searchDepth = 4
+)
+
+// buildTargets are the supported build targets, the keys are the platform names of the applicationIdentifier setting.
+var buildTargets = []struct {
+	settingsName string
+	target       string
+}{
+	{settingsName: "Android", target: targetAndroid},
+	{settingsName: "iPhone", target: targetIOS},
+	{settingsName: "Standalone", target: targetStandalone},
+}
+
+// licenseSecrets are the secrets the Unity license activation expects.
+var licenseSecrets = []string{"UNITY_SERIAL", "UNITY_USERNAME", "UNITY_PASSWORD"}
+
+// the GUID references of the test runner assemblies, the asmdef files reference the assemblies by name or by GUID.
+var testRunnerReferences = []string{
+	"UnityEngine.TestRunner",
+	"UnityEditor.TestRunner",
+	"GUID:27619889b8ba8c24980f49ee34dbb44a",
+	"GUID:0acc523941302664db1f4e527237feb3",
+}
+
+var (
+	editorVersionPattern         = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+	editorRevisionPattern        = regexp.MustCompile(`(?m)^m_EditorVersionWithRevision:\s*\S+\s*\(([0-9a-f]+)\)`)
+	applicationIdentifierPattern = regexp.MustCompile(`(?m)^\s*applicationIdentifier:\s*\n((?:\s{4,}\w+:.*\n?)*)`)
+	platformKeyPattern           = regexp.MustCompile(`(?m)^\s+(\w+):\s*\S+`)
+	enabledScenePattern          = regexp.MustCompile(`(?m)^\s*-\s*enabled:\s*1\s*$`)
+)
+
+// Project is a Unity project.
+type Project struct {
+	// Path is the project dir relative to the search dir, "." for the search dir itself.
+	Path string
+	// EditorVersion and EditorRevision are the Unity editor the project is made with, the Unity Hub
+	// installs the editor by its version and revision.
+	EditorVersion  string
+	EditorRevision string
+	// BuildTargets are the targets the project has application identifiers for, every target if it has none.
+	BuildTargets  []string
+	EnabledScenes int
+	// TestPlatforms are the test modes of the project's Unity Test Framework test assemblies.
+	TestPlatforms []string
+}
+
+// asmdef is the part of an assembly definition file the scanner reads.
+type asmdef struct {
+	References              []string `json:"references"`
+	IncludePlatforms        []string `json:"includePlatforms"`
+	DefineConstraints       []string `json:"defineConstraints"`
+	OptionalUnityReferences []string `json:"optionalUnityReferences"`
+}
+
+// Scanner detects Unity projects.
+type Scanner struct {
+	projects []Project
+}
+
+// NewScanner ...
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// Name ...
+func (*Scanner) Name() string {
+	return ScannerName
+}
+
+// DetectPlatform looks for the ProjectSettings/ProjectVersion.txt files next to an Assets directory.
+func (s *Scanner) DetectPlatform(searchDir string) (bool, error) {
+	log.TInfof("Searching for ProjectSettings/ProjectVersion.txt files...")
+
+	rootEntry, err := direntry.WalkDir(searchDir, searchDepth)
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range rootEntry.FindAllEntriesByName("ProjectVersion.txt", false) {
+		settingsDir := entry.Parent()
+		if settingsDir == nil || settingsDir.Name != "ProjectSettings" {
+			continue
+		}
+		projectDir := filepath.Dir(settingsDir.AbsPath)
+		if !fsutil.IsDir(filepath.Join(projectDir, "Assets")) {
+			continue
+		}
+
+		project, err := readProject(projectDir)
+		if err != nil {
+			return false, err
+		}
+		rel, err := filepath.Rel(searchDir, projectDir)
+		if err != nil {
+			return false, err
+		}
+		project.Path = filepath.ToSlash(rel)
+
+		log.TPrintf("Unity project found: %s (Unity %s)", project.Path, project.EditorVersion)
+		s.projects = append(s.projects, project)
+	}
+
+	if len(s.projects) == 0 {
+		log.TPrintf("platform not detected")
+		return false, nil
+	}
+
+	log.TSuccessf("Platform detected")
+	return true, nil
+}
+
+// ExcludedScannerNames ...
+func (*Scanner) ExcludedScannerNames() []string {
+	return nil
+}
+
+// Options ...
+func (s *Scanner) Options() (models.OptionNode, models.Warnings, models.Icons, error) {
+	projectPathOption := models.NewOption(projectPathInputTitle, projectPathInputSummary, projectPathInputEnvKey, models.TypeSelector)
+
+	var warnings models.Warnings
+	for _, project := range s.projects {
+		if project.EditorRevision == "" {
+			warnings = append(warnings, fmt.Sprintf("The ProjectVersion.txt of %s has no editor revision, the Unity Hub can not install Unity %s without it. Use a stack with the editor installed.", project.Path, project.EditorVersion))
+		}
+		if project.EnabledScenes == 0 {
+			warnings = append(warnings, fmt.Sprintf("%s has no enabled scene in the build settings, the player builds fail without one.", project.Path))
+		}
+
+		buildTargetOption := models.NewOption(buildTargetInputTitle, buildTargetInputSummary, buildTargetInputEnvKey, models.TypeSelector)
+		projectPathOption.AddOption(project.Path, buildTargetOption)
+
+		for _, target := range project.BuildTargets {
+			addBuildTargetConfig(buildTargetOption, target, projectConfigName(project, target))
+		}
+	}
+
+	return *projectPathOption, warnings, nil, nil
+}
+
+// DefaultOptions ...
+func (*Scanner) DefaultOptions() models.OptionNode {
+	projectPathOption := models.NewOption(projectPathInputTitle, projectPathInputSummary, projectPathInputEnvKey, models.TypeUserInput)
+
+	buildTargetOption := models.NewOption(buildTargetInputTitle, buildTargetInputSummary, buildTargetInputEnvKey, models.TypeSelector)
+	projectPathOption.AddOption(models.UserInputOptionDefaultValue, buildTargetOption)
+
+	for _, buildTarget := range buildTargets {
+		addBuildTargetConfig(buildTargetOption, buildTarget.target, defaultConfigName(buildTarget.target))
+	}
+
+	return *projectPathOption
+}
+
+// addBuildTargetConfig adds the config of a build target, the iOS configs ask for the distribution method of the archive step.
+func addBuildTargetConfig(buildTargetOption *models.OptionNode, target, name string) {
+	if target != targetIOS {
+		buildTargetOption.AddConfig(target, models.NewConfigOption(name, nil))
+		return
+	}
+
+	distributionMethodOption := models.NewOption(ios.DistributionMethodInputTitle, ios.DistributionMethodInputSummary, ios.DistributionMethodEnvKey, models.TypeSelector)
+	buildTargetOption.AddOption(target, distributionMethodOption)
+	for _, method := range ios.IosExportMethods {
+		distributionMethodOption.AddConfig(method, models.NewConfigOption(name, nil))
+	}
+}
+
+// Configs ...
+func (s *Scanner) Configs(sshKeyActivation models.SSHKeyActivation) (models.BitriseConfigMap, error) {
+	configs := models.BitriseConfigMap{}
+	for _, project := range s.projects {
+		for _, target := range project.BuildTargets {
+			name := projectConfigName(project, target)
+			if _, ok := configs[name]; ok {
+				continue
+			}
+
+			config, err := generateConfig(target, project.TestPlatforms, sshKeyActivation)
+			if err != nil {
+				return models.BitriseConfigMap{}, err
+			}
+			configs[name] = config
+		}
+	}
+
+	return configs, nil
+}
+
+// DefaultConfigs ...
+func (*Scanner) DefaultConfigs() (models.BitriseConfigMap, error) {
+	configs := models.BitriseConfigMap{}
+	for _, buildTarget := range buildTargets {
+		config, err := generateConfig(buildTarget.target, []string{editModeTests, playModeTests}, models.SSHKeyActivationConditional)
+		if err != nil {
+			return models.BitriseConfigMap{}, err
+		}
+		configs[defaultConfigName(buildTarget.target)] = config
+	}
+
+	return configs, nil
+}
+
+// LicenseSecretEnvs returns the secrets of the Unity license activation as empty secret envs,
+// it returns nil for the configs of the other scanners.
+func LicenseSecretEnvs(config bitriseModels.BitriseDataModel) []envmanModels.EnvironmentItemModel {
+	if config.ProjectType != ScannerName {
+		return nil
+	}
+
+	var envs []envmanModels.EnvironmentItemModel
+	for _, key := range licenseSecrets {
+		envs = append(envs, steps.SecretEnv(key, "Required by the Unity license activation"))
+	}
+	return envs
+}
+
+func buildWorkflowID(target string) string {
+	return "build_" + targetName(target)
+}
+
+// targetName is the build target in the config and workflow names.
+func targetName(target string) string {
+	if target == targetStandalone {
+		return "standalone"
+	}
+	return strings.ToLower(target)
+}
+
+// projectConfigName returns the name of the project's config, the configs differ in the build target and the test modes.
+func projectConfigName(project Project, target string) string {
+	name := "unity-" + targetName(target)
+	for _, testPlatform := range project.TestPlatforms {
+		name += "-" + strings.ToLower(testPlatform)
+	}
+	return name + "-config"
+}
+
+func defaultConfigName(target string) string {
+	return fmt.Sprintf("default-unity-%s-config", targetName(target))
+}
+
+func generateConfig(target string, testPlatforms []string, sshKeyActivation models.SSHKeyActivation) (string, error) {
+	configBuilder := models.NewDefaultConfigBuilder()
+
+	// the Library dir holds the imported assets, they are imported for the build target
+	cacheKey := `{{ .OS }}-{{ .Arch }}-unity-library-{{ getenv "UNITY_BUILD_TARGET" }}-{{ checksum "**/Packages/packages-lock.json" }}`
+	cachePath := "$" + projectPathInputEnvKey + "/Library"
+
+	var workflowIDs []models.WorkflowID
+	if len(testPlatforms) > 0 {
+		workflowIDs = append(workflowIDs, testWorkflowID)
+	}
+	workflowIDs = append(workflowIDs, models.WorkflowID(buildWorkflowID(target)))
+
+	for _, workflowID := range workflowIDs {
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultPrepareStepList(initSteps.PrepareListParams{SSHKeyActivation: sshKeyActivation})...)
+		configBuilder.AppendStepListItemsTo(workflowID,
+			initSteps.ScriptStepListItem("Install Unity Editor", installScriptContent(target)),
+			initSteps.ScriptStepListItem("Activate Unity license", activateLicenseScriptContent),
+			steps.RestoreCacheStepListItem("Restore Unity Library cache", cacheKey+"\n"+`{{ .OS }}-{{ .Arch }}-unity-library-{{ getenv "UNITY_BUILD_TARGET" }}-`),
+		)
+
+		if workflowID == testWorkflowID {
+			configBuilder.AppendStepListItemsTo(workflowID, initSteps.ScriptStepListItem("Run Unity tests", testScriptContent(testPlatforms)))
+		} else {
+			configBuilder.AppendStepListItemsTo(workflowID, initSteps.ScriptStepListItem("Build Unity player", buildScriptContent(target)))
+		}
+
+		configBuilder.AppendStepListItemsTo(workflowID,
+			steps.SaveCacheStepListItem("Save Unity Library cache", cacheKey, cachePath),
+			// the license is returned after a failed build too
+			steps.AlwaysRun(initSteps.ScriptStepListItem("Return Unity license", returnLicenseScriptContent)),
+		)
+
+		if workflowID != testWorkflowID && target == targetIOS {
+			// the exported Xcode project is archived the same way as the native iOS projects
+			configBuilder.AppendStepListItemsTo(workflowID,
+				initSteps.ScriptStepListItem("Prepare Xcode project", xcodeProjectScriptContent),
+				initSteps.XcodeArchiveStepListItem(
+					envmanModels.EnvironmentItemModel{ios.ProjectPathInputKey: "$" + ios.ProjectPathInputEnvKey},
+					envmanModels.EnvironmentItemModel{ios.SchemeInputKey: xcodeScheme},
+					envmanModels.EnvironmentItemModel{ios.DistributionMethodInputKey: "$" + ios.DistributionMethodEnvKey},
+					envmanModels.EnvironmentItemModel{ios.AutomaticCodeSigningInputKey: ios.AutomaticCodeSigningInputAPIKeyValue},
+				),
+			)
+		}
+
+		configBuilder.AppendStepListItemsTo(workflowID, initSteps.DefaultDeployStepList()...)
+	}
+
+	return scannerutil.GenerateConfig(configBuilder, ScannerName)
+}
+
+// installScriptContent installs the editor of the ProjectVersion.txt file with the modules of the build target.
+func installScriptContent(target string) string {
+	var modules string
+	switch target {
+	case targetAndroid:
+		modules = " --module android --module android-sdk-ndk-tools --module android-open-jdk"
+	case targetIOS:
+		modules = " --module ios"
+	}
+
+	return `#!/usr/bin/env bash
+set -euxo pipefail
+
+version_file="$UNITY_PROJECT_PATH/ProjectSettings/ProjectVersion.txt"
+version="$(sed -n 's/^m_EditorVersion: *//p' "$version_file" | tr -d '\r')"
+changeset="$(sed -n 's/^m_EditorVersionWithRevision: .*(\(.*\)).*/\1/p' "$version_file" | tr -d '\r')"
+editor="/Applications/Unity/Hub/Editor/$version/Unity.app/Contents/MacOS/Unity"
+
+# a preinstalled editor is expected to have the modules of the build target
+if [ ! -x "$editor" ]; then
+  if [ ! -d "/Applications/Unity Hub.app" ]; then
+    brew install --cask unity-hub
+  fi
+  "/Applications/Unity Hub.app/Contents/MacOS/Unity Hub" -- --headless install --version "$version" --changeset "$changeset"` + modules + `
+fi
+envman add --key UNITY_EDITOR --value "$editor"
+`
+}
+
+// the license scripts do not trace the commands, the traces would print the credentials
+const activateLicenseScriptContent = `#!/usr/bin/env bash
+set -euo pipefail
+
+"$UNITY_EDITOR" -batchmode -nographics -quit -logFile - -serial "$UNITY_SERIAL" -username "$UNITY_USERNAME" -password "$UNITY_PASSWORD"
+`
+
+const returnLicenseScriptContent = `#!/usr/bin/env bash
+set -euo pipefail
+
+"$UNITY_EDITOR" -batchmode -nographics -quit -logFile - -returnlicense -username "$UNITY_USERNAME" -password "$UNITY_PASSWORD"
+`
+
+// testScriptContent runs the tests of every test mode, Unity writes the results in the NUnit XML format.
+// The results of each test mode are exported as a separate test run of the test result dir.
+func testScriptContent(testPlatforms []string) string {
+	content := `#!/usr/bin/env bash
+set -euxo pipefail
+`
+	for _, testPlatform := range testPlatforms {
+		content += fmt.Sprintf(`
+report_dir="$BITRISE_TEST_RESULT_DIR/unity-%s"
+mkdir -p "$report_dir"
+echo '{"test-name": "Unity %s tests"}' > "$report_dir/test-info.json"
+"$UNITY_EDITOR" -batchmode -nographics -logFile - -projectPath "$UNITY_PROJECT_PATH" -runTests -testPlatform %s -testResults "$report_dir/results.xml"
+`, strings.ToLower(testPlatform), testPlatform, testPlatform)
+	}
+	return content
+}
+
+// buildScriptContent adds the batchmode build script to the project, unless the project has its own BitriseBuild class,
+// and builds the player of the build target.
+func buildScriptContent(target string) string {
+	var buildPath, afterBuild string
+	switch target {
+	case targetAndroid:
+		buildPath = `$BITRISE_DEPLOY_DIR/app.apk`
+	case targetIOS:
+		buildPath = `$UNITY_PROJECT_PATH/` + xcodeProjectDir
+	default:
+		buildPath = `$UNITY_PROJECT_PATH/Builds/macOS/app.app`
+		afterBuild = `
+ditto -c -k --keepParent "$UNITY_BUILD_PATH" "$BITRISE_DEPLOY_DIR/app.zip"`
+	}
+
+	return `#!/usr/bin/env bash
+set -euxo pipefail
+
+build_script="$UNITY_PROJECT_PATH/Assets/Editor/BitriseBuild.cs"
+if ! grep -rqs --include '*.cs' 'class BitriseBuild' "$UNITY_PROJECT_PATH/Assets"; then
+  mkdir -p "$(dirname "$build_script")"
+  cat > "$build_script" <<'EOF'
+` + buildScript + `EOF
+fi
+
+export UNITY_BUILD_PATH="` + buildPath + `"
+"$UNITY_EDITOR" -batchmode -nographics -logFile - -projectPath "$UNITY_PROJECT_PATH" -buildTarget "$UNITY_BUILD_TARGET" -executeMethod BitriseBuild.Build` + afterBuild + `
+`
+}
+
+// buildScript builds the enabled scenes of the build settings into UNITY_BUILD_PATH.
+const buildScript = `using System;
+using System.Linq;
+using UnityEditor;
+using UnityEditor.Build.Reporting;
+
+public static class BitriseBuild
+{
+    public static void Build()
+    {
+        var options = new BuildPlayerOptions
+        {
+            scenes = EditorBuildSettings.scenes.Where(scene => scene.enabled).Select(scene => scene.path).ToArray(),
+            locationPathName = Environment.GetEnvironmentVariable("UNITY_BUILD_PATH"),
+            target = EditorUserBuildSettings.activeBuildTarget,
+        };
+        var report = BuildPipeline.BuildPlayer(options);
+        EditorApplication.Exit(report.summary.result == BuildResult.Succeeded ? 0 : 1);
+    }
+}
+`
+
+// xcodeProjectScriptContent installs the pods of the exported Xcode project and sets the project path of the archive step.
+const xcodeProjectScriptContent = `#!/usr/bin/env bash
+set -euxo pipefail
+
+xcode_project_dir="$UNITY_PROJECT_PATH/` + xcodeProjectDir + `"
+if [ -f "$xcode_project_dir/Podfile" ] && [ ! -d "$xcode_project_dir/` + xcodeScheme + `.xcworkspace" ]; then
+  (cd "$xcode_project_dir" && pod install)
+fi
+if [ -d "$xcode_project_dir/` + xcodeScheme + `.xcworkspace" ]; then
+  envman add --key BITRISE_PROJECT_PATH --value "$xcode_project_dir/` + xcodeScheme + `.xcworkspace"
+else
+  envman add --key BITRISE_PROJECT_PATH --value "$xcode_project_dir/` + xcodeScheme + `.xcodeproj"
+fi
+`
+
+func readProject(projectDir string) (Project, error) {
+	var project Project
+
+	versionPth := filepath.Join(projectDir, "ProjectSettings", "ProjectVersion.txt")
+	content, err := os.ReadFile(versionPth)
+	if err != nil {
+		return Project{}, fmt.Errorf("read %s: %s", versionPth, err)
+	}
+	if match := editorVersionPattern.FindSubmatch(content); match != nil {
+		project.EditorVersion = string(match[1])
+	}
+	if match := editorRevisionPattern.FindSubmatch(content); match != nil {
+		project.EditorRevision = string(match[1])
+	}
+
+	settings, err := fsutil.ReadOptionalFile(filepath.Join(projectDir, "ProjectSettings", "ProjectSettings.asset"))
+	if err != nil {
+		return Project{}, err
+	}
+	project.BuildTargets = applicationTargets(settings)
+
+	buildSettings, err := fsutil.ReadOptionalFile(filepath.Join(projectDir, "ProjectSettings", "EditorBuildSettings.asset"))
+	if err != nil {
+		return Project{}, err
+	}
+	project.EnabledScenes = len(enabledScenePattern.FindAllString(buildSettings, -1))
+
+	project.TestPlatforms, err = testPlatforms(projectDir)
+	if err != nil {
+		return Project{}, err
+	}
+
+	return project, nil
+}
+
+// applicationTargets returns the build targets the project has an application identifier for, in the order of buildTargets,
+// a project without application identifiers can be built for every target.
+func applicationTargets(settings string) []string {
+	platforms := map[string]bool{}
+	if match := applicationIdentifierPattern.FindStringSubmatch(settings); match != nil {
+		for _, platformMatch := range platformKeyPattern.FindAllStringSubmatch(match[1], -1) {
+			platforms[platformMatch[1]] = true
+		}
+	}
+
+	var targets []string
+	for _, buildTarget := range buildTargets {
+		if len(platforms) == 0 || platforms[buildTarget.settingsName] {
+			targets = append(targets, buildTarget.target)
+		}
+	}
+	return targets
+}
+
+// testPlatforms returns the test modes of the test assemblies in the Assets and Packages dirs,
+// the assemblies referencing the test runner are edit mode tests if they only run in the editor.
+func testPlatforms(projectDir string) ([]string, error) {
+	hasEditMode, hasPlayMode := false, false
+	for _, dir := range []string{"Assets", "Packages"} {
+		root := filepath.Join(projectDir, dir)
+		if !fsutil.IsDir(root) {
+			continue
+		}
+
+		err := filepath.WalkDir(root, func(pth string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || filepath.Ext(pth) != ".asmdef" {
+				return nil
+			}
+
+			content, err := os.ReadFile(pth)
+			if err != nil {
+				return err
+			}
+			var definition asmdef
+			if err := json.Unmarshal(content, &definition); err != nil {
+				log.TWarnf("Failed to parse %s: %s", pth, err)
+				return nil
+			}
+			if !isTestAssembly(definition) {
+				return nil
+			}
+
+			if len(definition.IncludePlatforms) == 1 && definition.IncludePlatforms[0] == "Editor" {
+				hasEditMode = true
+			} else {
+				hasPlayMode = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var platforms []string
+	if hasEditMode {
+		platforms = append(platforms, editModeTests)
+	}
+	if hasPlayMode {
+		platforms = append(platforms, playModeTests)
+	}
+	return platforms, nil
+}
+
+func isTestAssembly(definition asmdef) bool {
+	isTestRunnerReference := func(reference string) bool { return slices.Contains(testRunnerReferences, reference) }
+	return slices.ContainsFunc(definition.References, isTestRunnerReference) ||
+		slices.Contains(definition.DefineConstraints, "UNITY_INCLUDE_TESTS") ||
+		slices.Contains(definition.OptionalUnityReferences, "TestAssemblies")
+}
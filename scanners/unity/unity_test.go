@@ -0,0 +1,127 @@
+package unity
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const projectVersion = `m_EditorVersion: 2022.3.10f1
+m_EditorVersionWithRevision: 2022.3.10f1 (ff3792e53c62)
+`
+
+const projectSettings = `%YAML 1.1
+%TAG !u! tag:unity3d.com,2011:
+--- !u!129 &1
+PlayerSettings:
+  productName: Runner
+  applicationIdentifier:
+    Android: io.bitrise.runner
+    iPhone: io.bitrise.runner
+  buildNumber:
+    Standalone: 0
+`
+
+const editorBuildSettings = `%YAML 1.1
+%TAG !u! tag:unity3d.com,2011:
+--- !u!1045 &1
+EditorBuildSettings:
+  m_Scenes:
+  - enabled: 1
+    path: Assets/Scenes/Main.unity
+  - enabled: 0
+    path: Assets/Scenes/Sandbox.unity
+`
+
+const editModeAsmdef = `{
+    "name": "Runner.Tests.Editor",
+    "references": ["UnityEngine.TestRunner", "UnityEditor.TestRunner"],
+    "includePlatforms": ["Editor"],
+    "defineConstraints": ["UNITY_INCLUDE_TESTS"]
+}`
+
+const runtimeAsmdef = `{
+    "name": "Runner",
+    "references": [],
+    "includePlatforms": []
+}`
+
+func TestScanner(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "game", "ProjectSettings", "ProjectVersion.txt"), projectVersion)
+	testutil.WriteFile(t, filepath.Join(dir, "game", "ProjectSettings", "ProjectSettings.asset"), projectSettings)
+	testutil.WriteFile(t, filepath.Join(dir, "game", "ProjectSettings", "EditorBuildSettings.asset"), editorBuildSettings)
+	testutil.WriteFile(t, filepath.Join(dir, "game", "Assets", "Scripts", "Runner.asmdef"), runtimeAsmdef)
+	testutil.WriteFile(t, filepath.Join(dir, "game", "Assets", "Tests", "Editor", "Runner.Tests.Editor.asmdef"), editModeAsmdef)
+	testutil.WriteFile(t, filepath.Join(dir, "docs", "ProjectSettings", "ProjectVersion.txt"), projectVersion)
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	require.Equal(t, []Project{{
+		Path:           "game",
+		EditorVersion:  "2022.3.10f1",
+		EditorRevision: "ff3792e53c62",
+		BuildTargets:   []string{targetAndroid, targetIOS},
+		EnabledScenes:  1,
+		TestPlatforms:  []string{editModeTests},
+	}}, scanner.projects)
+
+	options, warnings, _, err := scanner.Options()
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+
+	buildTargetOption, ok := options.Child("game")
+	require.True(t, ok)
+	require.ElementsMatch(t, []string{targetAndroid, targetIOS}, buildTargetOption.GetValues())
+	require.Equal(t, "unity-android-editmode-config", buildTargetOption.ChildOptionMap[targetAndroid].Config)
+
+	distributionMethodOption, ok := buildTargetOption.Child(targetIOS)
+	require.True(t, ok)
+	require.Equal(t, "unity-ios-editmode-config", distributionMethodOption.ChildOptionMap["app-store"].Config)
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(configs["unity-ios-editmode-config"]), &config))
+	require.Contains(t, config.Workflows, testWorkflowID)
+	require.Contains(t, config.Workflows, "build_ios")
+	require.Contains(t, configs["unity-ios-editmode-config"], "xcode-archive@")
+	require.Contains(t, configs["unity-ios-editmode-config"], "-testPlatform EditMode")
+	require.NotContains(t, configs["unity-ios-editmode-config"], "-testPlatform PlayMode")
+	require.Contains(t, configs["unity-ios-editmode-config"], `-testResults "$report_dir/results.xml"`)
+	require.Contains(t, configs["unity-ios-editmode-config"], `report_dir="$BITRISE_TEST_RESULT_DIR/unity-editmode"`)
+
+	var keys []string
+	for _, env := range LicenseSecretEnvs(config) {
+		key, value, err := env.GetKeyValuePair()
+		require.NoError(t, err)
+		require.Empty(t, value)
+		keys = append(keys, key)
+	}
+	require.Equal(t, licenseSecrets, keys)
+}
+
+func TestApplicationTargets(t *testing.T) {
+	require.Equal(t, []string{targetAndroid, targetIOS, targetStandalone}, applicationTargets("PlayerSettings:\n  applicationIdentifier: {}\n"))
+	require.Equal(t, []string{targetStandalone}, applicationTargets("PlayerSettings:\n  applicationIdentifier:\n    Standalone: com.company.game\n  buildNumber: {}\n"))
+}
+
+func TestDetectPlatform_notDetected(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "ProjectSettings", "ProjectVersion.txt"), projectVersion)
+
+	detected, err := NewScanner().DetectPlatform(dir)
+	require.NoError(t, err)
+	require.False(t, detected)
+}
@@ -0,0 +1,295 @@
+package xcodegen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/projectgen"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	ScannerName = "xcodegen"
+
+	specFileName = "project.yml"
+	searchDepth  = 4
+)
+
+// platforms maps the XcodeGen target platforms to the project types of the iOS scanner.
+var platforms = map[string]ios.XcodeProjectType{
+	"iOS":   ios.XcodeProjectTypeIOS,
+	"macOS": ios.XcodeProjectTypeMacOS,
+}
+
+// spec is the part of the XcodeGen project spec the scanner reads.
+type spec struct {
+	Name     string               `yaml:"name"`
+	Include  yaml.Node            `yaml:"include"`
+	Packages map[string]yaml.Node `yaml:"packages"`
+	Targets  map[string]target    `yaml:"targets"`
+	Schemes  map[string]scheme    `yaml:"schemes"`
+}
+
+type target struct {
+	Type                  string        `yaml:"type"`
+	Platform              stringList    `yaml:"platform"`
+	SupportedDestinations stringList    `yaml:"supportedDestinations"`
+	Scheme                *targetScheme `yaml:"scheme"`
+}
+
+// targetScheme is the scheme XcodeGen generates for a target, named after the target.
+type targetScheme struct {
+	TestTargets []testTarget `yaml:"testTargets"`
+}
+
+type scheme struct {
+	Build struct {
+		Targets map[string]yaml.Node `yaml:"targets"`
+	} `yaml:"build"`
+	Test struct {
+		Targets []testTarget `yaml:"targets"`
+	} `yaml:"test"`
+}
+
+// testTarget is a test target of a scheme, set either by its name or as a map with the name.
+type testTarget struct {
+	Name string `yaml:"name"`
+}
+
+func (t *testTarget) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		t.Name = value.Value
+		return nil
+	}
+	type plain testTarget
+	return value.Decode((*plain)(t))
+}
+
+// stringList is a string or a list of strings.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = stringList{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// Scanner detects projects generated by XcodeGen.
+type Scanner struct {
+	specPath          string
+	manifest          projectgen.Manifest
+	configDescriptors []ios.ConfigDescriptor
+	searchDir         string
+	warnings          models.Warnings
+}
+
+// NewScanner ...
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// Name ...
+func (*Scanner) Name() string {
+	return ScannerName
+}
+
+// DetectPlatform looks for the shallowest XcodeGen project spec with at least one target.
+func (s *Scanner) DetectPlatform(searchDir string) (bool, error) {
+	log.TInfof("Searching for XcodeGen project spec...")
+
+	rootEntry, err := direntry.WalkDir(searchDir, searchDepth)
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range rootEntry.FindAllEntriesByName(specFileName, false) {
+		content, err := os.ReadFile(entry.AbsPath)
+		if err != nil {
+			return false, fmt.Errorf("read %s: %s", entry.RelPath, err)
+		}
+
+		specPath := strings.TrimPrefix(entry.RelPath, "./")
+		manifest, warnings, err := parseSpec(content, specPath)
+		if err != nil {
+			log.TWarnf("Skipping %s, not an XcodeGen project spec: %s", specPath, err)
+			continue
+		}
+		if len(manifest.Targets) == 0 {
+			continue
+		}
+
+		log.TPrintf("XcodeGen project spec found: %s", specPath)
+		s.specPath = specPath
+		s.manifest = manifest
+		s.searchDir = searchDir
+		s.warnings = warnings
+		log.TSuccessf("Platform detected")
+		return true, nil
+	}
+
+	log.TPrintf("platform not detected")
+	return false, nil
+}
+
+// ExcludedScannerNames ...
+func (*Scanner) ExcludedScannerNames() []string {
+	return projectgen.ExcludedScannerNames()
+}
+
+// Options ...
+func (s *Scanner) Options() (models.OptionNode, models.Warnings, models.Icons, error) {
+	options, configDescriptors, icons, warnings, err := ios.GenerateOptions(s.manifest.ProjectType(), s.manifest.DetectResult(s.searchDir))
+	s.configDescriptors = configDescriptors
+	return options, append(s.warnings, warnings...), icons, err
+}
+
+// DefaultOptions ...
+func (*Scanner) DefaultOptions() models.OptionNode {
+	return ios.GenerateDefaultOptions(ios.XcodeProjectTypeIOS)
+}
+
+// Configs ...
+func (s *Scanner) Configs(sshKeyActivation models.SSHKeyActivation) (models.BitriseConfigMap, error) {
+	return projectgen.GenerateConfigs(s.manifest.ProjectType(), s.configDescriptors, sshKeyActivation, GenerateStepListItem(s.specPath))
+}
+
+// DefaultConfigs ...
+func (*Scanner) DefaultConfigs() (models.BitriseConfigMap, error) {
+	return projectgen.GenerateDefaultConfigs(ios.XcodeProjectTypeIOS, GenerateStepListItem(specFileName))
+}
+
+// GenerateStepListItem returns a script step generating the Xcode project from the project spec.
+func GenerateStepListItem(specPath string) bitriseModels.StepListItemModel {
+	return initSteps.ScriptStepListItem("Generate Xcode project", fmt.Sprintf(`#!/usr/bin/env bash
+set -euxo pipefail
+
+if ! command -v xcodegen &> /dev/null; then
+  brew install xcodegen
+fi
+xcodegen generate --spec %q
+`, specPath))
+}
+
+// parseSpec reads the targets and schemes of the project spec. XcodeGen generates a scheme for the targets
+// with scheme settings and for the schemes of the spec, if there are none the app targets are used as schemes.
+func parseSpec(content []byte, specPath string) (projectgen.Manifest, models.Warnings, error) {
+	var projectSpec spec
+	if err := yaml.Unmarshal(content, &projectSpec); err != nil {
+		return projectgen.Manifest{}, nil, err
+	}
+	if projectSpec.Name == "" {
+		return projectgen.Manifest{}, nil, fmt.Errorf("no project name")
+	}
+
+	var warnings models.Warnings
+	if !projectSpec.Include.IsZero() {
+		warnings = append(warnings, fmt.Sprintf("%s includes other spec files, only its own targets and schemes are detected", specPath))
+	}
+
+	manifest := projectgen.Manifest{
+		ProjectPath:        filepath.Join(filepath.Dir(specPath), projectSpec.Name+".xcodeproj"),
+		HasSPMDependencies: len(projectSpec.Packages) > 0,
+	}
+
+	var targetNames []string
+	for name := range projectSpec.Targets {
+		targetNames = append(targetNames, name)
+	}
+	sort.Strings(targetNames)
+
+	var schemes []projectgen.Scheme
+	var appTargets []string
+	for _, name := range targetNames {
+		specTarget := projectSpec.Targets[name]
+		targetPlatforms := specTarget.Platform
+		if len(specTarget.SupportedDestinations) > 0 {
+			targetPlatforms = stringList{"iOS"}
+			if len(specTarget.SupportedDestinations) == 1 && specTarget.SupportedDestinations[0] == "macOS" {
+				targetPlatforms = stringList{"macOS"}
+			}
+		}
+
+		for _, platform := range targetPlatforms {
+			targetName := name
+			if len(targetPlatforms) > 1 {
+				// multi-platform targets are generated for every platform, with a platform suffix
+				targetName = name + "_" + platform
+			}
+
+			target := projectgen.Target{
+				Name:      targetName,
+				Platform:  platforms[platform],
+				IsApp:     specTarget.Type == "application",
+				IsTest:    specTarget.Type == "bundle.unit-test" || specTarget.Type == "bundle.ui-testing",
+				IsAppClip: specTarget.Type == "application.on-demand-install-capable",
+			}
+			manifest.Targets = append(manifest.Targets, target)
+			if target.IsApp {
+				appTargets = append(appTargets, targetName)
+			}
+
+			if specTarget.Scheme != nil {
+				schemes = append(schemes, projectgen.Scheme{
+					Name:         targetName,
+					BuildTargets: []string{targetName},
+					TestTargets:  testTargetNames(specTarget.Scheme.TestTargets),
+				})
+			}
+		}
+	}
+
+	var schemeNames []string
+	for name := range projectSpec.Schemes {
+		schemeNames = append(schemeNames, name)
+	}
+	sort.Strings(schemeNames)
+	for _, name := range schemeNames {
+		specScheme := projectSpec.Schemes[name]
+		var buildTargets []string
+		for target := range specScheme.Build.Targets {
+			buildTargets = append(buildTargets, target)
+		}
+		sort.Strings(buildTargets)
+
+		schemes = append(schemes, projectgen.Scheme{
+			Name:         name,
+			BuildTargets: buildTargets,
+			TestTargets:  testTargetNames(specScheme.Test.Targets),
+		})
+	}
+
+	if len(schemes) == 0 {
+		warnings = append(warnings, fmt.Sprintf("%s defines no schemes, the app targets are used as scheme names", specPath))
+		for _, name := range appTargets {
+			schemes = append(schemes, projectgen.Scheme{Name: name, BuildTargets: []string{name}})
+		}
+	}
+	manifest.Schemes = schemes
+
+	return manifest, warnings, nil
+}
+
+func testTargetNames(targets []testTarget) []string {
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	return names
+}
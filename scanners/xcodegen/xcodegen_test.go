@@ -0,0 +1,116 @@
+package xcodegen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/scanners/projectgen"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const projectSpec = `name: Weather
+packages:
+  Alamofire:
+    url: https://github.com/Alamofire/Alamofire
+    from: 5.8.0
+targets:
+  Weather:
+    type: application
+    platform: iOS
+    scheme:
+      testTargets:
+        - WeatherTests
+  WeatherTests:
+    type: bundle.unit-test
+    platform: iOS
+  WeatherKit:
+    type: framework
+    platform: [iOS, macOS]
+  WeatherMac:
+    type: application
+    platform: macOS
+schemes:
+  Weather-Release:
+    build:
+      targets:
+        Weather: all
+    test:
+      targets:
+        - name: WeatherTests
+`
+
+func TestScanner(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "app", "project.yml"), projectSpec)
+	testutil.WriteFile(t, filepath.Join(dir, "docs", "project.yml"), "title: not an XcodeGen spec\n")
+
+	scanner := NewScanner()
+	detected, err := scanner.DetectPlatform(dir)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	require.Equal(t, "app/project.yml", scanner.specPath)
+	require.Equal(t, projectgen.Manifest{
+		ProjectPath: "app/Weather.xcodeproj",
+		Targets: []projectgen.Target{
+			{Name: "Weather", Platform: ios.XcodeProjectTypeIOS, IsApp: true},
+			{Name: "WeatherKit_iOS", Platform: ios.XcodeProjectTypeIOS},
+			{Name: "WeatherKit_macOS", Platform: ios.XcodeProjectTypeMacOS},
+			{Name: "WeatherMac", Platform: ios.XcodeProjectTypeMacOS, IsApp: true},
+			{Name: "WeatherTests", Platform: ios.XcodeProjectTypeIOS, IsTest: true},
+		},
+		Schemes: []projectgen.Scheme{
+			{Name: "Weather", BuildTargets: []string{"Weather"}, TestTargets: []string{"WeatherTests"}},
+			{Name: "Weather-Release", BuildTargets: []string{"Weather"}, TestTargets: []string{"WeatherTests"}},
+		},
+		HasSPMDependencies: true,
+	}, scanner.manifest)
+
+	options, _, _, err := scanner.Options()
+	require.NoError(t, err)
+	schemeOption, ok := options.Child("app/Weather.xcodeproj")
+	require.True(t, ok)
+	require.ElementsMatch(t, []string{"Weather", "Weather-Release"}, schemeOption.GetValues())
+
+	configs, err := scanner.Configs(models.SSHKeyActivationNone)
+	require.NoError(t, err)
+	require.NotEmpty(t, configs)
+	for _, configStr := range configs {
+		var config bitriseModels.BitriseDataModel
+		require.NoError(t, yaml.Unmarshal([]byte(configStr), &config))
+		for _, workflow := range config.Workflows {
+			if !steps.Contains(workflow.Steps, initSteps.GitCloneID) {
+				continue
+			}
+			generateStep, err := workflow.Steps[steps.Index(workflow.Steps, initSteps.GitCloneID)+1].GetStep()
+			require.NoError(t, err)
+			require.Equal(t, "Generate Xcode project", *generateStep.Title)
+			require.Contains(t, generateStep.Inputs[0]["content"], `xcodegen generate --spec "app/project.yml"`)
+		}
+	}
+}
+
+func TestParseSpec_noSchemes(t *testing.T) {
+	manifest, warnings, err := parseSpec([]byte(`name: Tool
+include: [base.yml]
+targets:
+  Tool:
+    type: application
+    supportedDestinations: [iOS, macOS]
+`), "project.yml")
+	require.NoError(t, err)
+	require.Equal(t, projectgen.Manifest{
+		ProjectPath: "Tool.xcodeproj",
+		Targets:     []projectgen.Target{{Name: "Tool", Platform: ios.XcodeProjectTypeIOS, IsApp: true}},
+		Schemes:     []projectgen.Scheme{{Name: "Tool", BuildTargets: []string{"Tool"}}},
+	}, manifest)
+	require.Len(t, warnings, 2)
+}
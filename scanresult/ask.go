@@ -0,0 +1,74 @@
+package scanresult
+
+import (
+	"fmt"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-init/scanner"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/bitrise-io/goinp/goinp"
+)
+
+// Recommendation is the value preselected for the options of an env key, with the reason it is recommended.
+type Recommendation struct {
+	Value  string
+	Reason string
+}
+
+// AskForConfig is the interactive counterpart of SelectConfig, it asks for the config with scanner.AskForConfig.
+// The recommended values of the options, keyed by their env key, are offered first: if the user accepts
+// a recommended value, the option selects it without listing the other values.
+func AskForConfig(scanResult models.ScanResultModel, recommendations map[string]Recommendation) (bitriseModels.BitriseDataModel, error) {
+	if err := selectRecommended(scanResult, recommendations, askToAccept); err != nil {
+		return bitriseModels.BitriseDataModel{}, err
+	}
+	return scanner.AskForConfig(scanResult)
+}
+
+func askToAccept(title string, recommendation Recommendation) (bool, error) {
+	question := fmt.Sprintf("Use %s for \"%s\"? It is recommended, based on %s", recommendation.Value, title, recommendation.Reason)
+	return goinp.AskForBoolWithDefault(question, true)
+}
+
+// selectRecommended drops the other values of the selector options with a recommended value, if the user accepts it.
+// The user is asked once for each env key, even if the option is repeated in the branches of the option tree.
+func selectRecommended(scanResult models.ScanResultModel, recommendations map[string]Recommendation, accept func(title string, recommendation Recommendation) (bool, error)) error {
+	accepted := map[string]bool{}
+
+	var walk func(option *models.OptionNode) error
+	walk = func(option *models.OptionNode) error {
+		recommendation, ok := recommendations[option.EnvKey]
+		recommended, found := option.ChildOptionMap[recommendation.Value]
+		isSelector := option.Type == models.TypeSelector || option.Type == models.TypeOptionalSelector
+		if ok && found && isSelector && len(option.ChildOptionMap) > 1 {
+			isAccepted, asked := accepted[option.EnvKey]
+			if !asked {
+				var err error
+				if isAccepted, err = accept(option.Title, recommendation); err != nil {
+					return err
+				}
+				accepted[option.EnvKey] = isAccepted
+			}
+			if isAccepted {
+				option.ChildOptionMap = map[string]*models.OptionNode{recommendation.Value: recommended}
+			}
+		}
+
+		for _, child := range option.ChildOptionMap {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, platform := range maputil.SortedKeys(scanResult.ScannerToOptionRoot) {
+		root := scanResult.ScannerToOptionRoot[platform]
+		if err := walk(&root); err != nil {
+			return err
+		}
+		scanResult.ScannerToOptionRoot[platform] = root
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package scanresult
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+	"github.com/stretchr/testify/require"
+)
+
+func distributionScanResult() models.ScanResultModel {
+	root := models.NewOption("Scheme", "", "SCHEME", models.TypeSelector)
+	for _, scheme := range []string{"Notes", "Notes Beta"} {
+		methodOption := models.NewOption("Distribution method", "", "METHOD", models.TypeSelector)
+		root.AddOption(scheme, methodOption)
+		for _, method := range []string{"app-store", "ad-hoc", "enterprise", "development"} {
+			methodOption.AddConfig(method, models.NewConfigOption(method+"-config", nil))
+		}
+	}
+	return models.ScanResultModel{ScannerToOptionRoot: map[string]models.OptionNode{"ios": *root}}
+}
+
+func TestSelectRecommended(t *testing.T) {
+	recommendations := map[string]Recommendation{"METHOD": {Value: "enterprise", Reason: "fastlane/Matchfile (type: enterprise)"}}
+
+	t.Log("an accepted recommendation is the only value of the option")
+	{
+		scanResult := distributionScanResult()
+		var asked []string
+		require.NoError(t, selectRecommended(scanResult, recommendations, func(title string, recommendation Recommendation) (bool, error) {
+			asked = append(asked, title+": "+recommendation.Value)
+			return true, nil
+		}))
+		require.Equal(t, []string{"Distribution method: enterprise"}, asked)
+
+		root := scanResult.ScannerToOptionRoot["ios"]
+		require.Len(t, root.ChildOptionMap, 2)
+		for _, methodOption := range root.ChildOptionMap {
+			require.Equal(t, []string{"enterprise"}, maputil.SortedKeys(methodOption.ChildOptionMap))
+		}
+	}
+
+	t.Log("the other values are kept if the recommendation is declined")
+	{
+		scanResult := distributionScanResult()
+		require.NoError(t, selectRecommended(scanResult, recommendations, func(string, Recommendation) (bool, error) {
+			return false, nil
+		}))
+		require.Len(t, scanResult.ScannerToOptionRoot["ios"].ChildOptionMap["Notes"].ChildOptionMap, 4)
+	}
+
+	t.Log("without a matching value the user is not asked")
+	{
+		scanResult := distributionScanResult()
+		recommendations := map[string]Recommendation{"METHOD": {Value: "developer-id", Reason: "ExportOptions.plist"}}
+		require.NoError(t, selectRecommended(scanResult, recommendations, func(string, Recommendation) (bool, error) {
+			require.Fail(t, "asked for a recommendation without a matching value")
+			return false, nil
+		}))
+	}
+}
@@ -0,0 +1,113 @@
+package scanresult
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+)
+
+// SelectConfig walks the option tree of the scanner matching the project type of an existing config and returns
+// the scanner generated config, which the options recorded in the existing app envs lead to.
+// It returns an error if the recorded values do not lead to a config, for example if a recorded project path
+// is no longer detected.
+// The returned bool is false if no detected scanner generates configs for the project type.
+func SelectConfig(scanResult models.ScanResultModel, existing bitriseModels.BitriseDataModel) (bitriseModels.BitriseDataModel, bool, error) {
+	scannerName, ok := scannerForProjectType(scanResult, existing.ProjectType)
+	if !ok {
+		return bitriseModels.BitriseDataModel{}, false, nil
+	}
+
+	envs := map[string]string{}
+	for _, env := range existing.App.Environments {
+		key, value, err := env.GetKeyValuePair()
+		if err != nil {
+			continue
+		}
+		envs[key] = value
+	}
+
+	configName, appEnvs, err := selectOptions(scanResult.ScannerToOptionRoot[scannerName], envs)
+	if err != nil {
+		return bitriseModels.BitriseDataModel{}, false, fmt.Errorf("select the %s config: %s", scannerName, err)
+	}
+	if configName == "" {
+		return bitriseModels.BitriseDataModel{}, false, fmt.Errorf("no config found in the %s option tree", scannerName)
+	}
+
+	configStr, ok := scanResult.ScannerToBitriseConfigMap[scannerName][configName]
+	if !ok {
+		return bitriseModels.BitriseDataModel{}, false, fmt.Errorf("config (%s) not found for %s", configName, scannerName)
+	}
+
+	var config bitriseModels.BitriseDataModel
+	if err := yaml.Unmarshal([]byte(configStr), &config); err != nil {
+		return bitriseModels.BitriseDataModel{}, false, fmt.Errorf("failed to unmarshal config, error: %s", err)
+	}
+	config.App.Environments = append(config.App.Environments, appEnvs...)
+
+	return config, true, nil
+}
+
+func scannerForProjectType(scanResult models.ScanResultModel, projectType string) (string, bool) {
+	if _, ok := scanResult.ScannerToOptionRoot[projectType]; ok {
+		return projectType, true
+	}
+
+	// automation tool scanners (like fastlane) generate configs for the detected project types
+	for _, scannerName := range maputil.SortedKeys(scanResult.ScannerToBitriseConfigMap) {
+		for _, configStr := range scanResult.ScannerToBitriseConfigMap[scannerName] {
+			var config bitriseModels.BitriseDataModel
+			if err := yaml.Unmarshal([]byte(configStr), &config); err != nil {
+				continue
+			}
+			if config.ProjectType == projectType {
+				return scannerName, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// selectOptions is the non-interactive version of scanner.AskForOptions, the values are the recorded app envs.
+func selectOptions(option models.OptionNode, envs map[string]string) (string, []envmanModels.EnvironmentItemModel, error) {
+	var appEnvs []envmanModels.EnvironmentItemModel
+
+	current := &option
+	for current != nil {
+		if current.IsConfigOption() {
+			return current.Config, appEnvs, nil
+		}
+		if len(current.ChildOptionMap) == 0 {
+			return "", appEnvs, nil
+		}
+
+		value, recorded := envs[current.EnvKey]
+		next, found := current.ChildOptionMap[value]
+		switch {
+		case current.Type == models.TypeUserInput || current.Type == models.TypeOptionalUserInput:
+			if !recorded && current.Type == models.TypeUserInput {
+				return "", nil, fmt.Errorf("no %s app env recorded for the %s input", current.EnvKey, current.Title)
+			}
+			// user inputs accept any value, the only child belongs to every value
+			next = current.ChildOptionMap[maputil.SortedKeys(current.ChildOptionMap)[0]]
+		case !recorded && current.EnvKey != "":
+			return "", nil, fmt.Errorf("no %s app env recorded, the detected values are: %s", current.EnvKey, strings.Join(maputil.SortedKeys(current.ChildOptionMap), ", "))
+		case !found:
+			return "", nil, fmt.Errorf("the recorded %s app env (%s) is not one of the detected values: %s", current.EnvKey, value, strings.Join(maputil.SortedKeys(current.ChildOptionMap), ", "))
+		}
+
+		if current.EnvKey != "" {
+			appEnvs = append(appEnvs, envmanModels.EnvironmentItemModel{current.EnvKey: value})
+		}
+		current = next
+	}
+
+	return "", appEnvs, nil
+}
@@ -0,0 +1,45 @@
+package scanresult
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectOptions(t *testing.T) {
+	root := models.NewOption("Project", "", "PROJECT", models.TypeSelector)
+	appOption := models.NewOption("Module", "", "MODULE", models.TypeUserInput)
+	root.AddOption("app/build.gradle", appOption)
+	root.AddOption("lib/build.gradle", models.NewConfigOption("lib-config", nil))
+	appOption.AddConfig("", models.NewConfigOption("app-config", nil))
+
+	t.Log("follows the recorded values")
+	{
+		config, envs, err := selectOptions(*root, map[string]string{"PROJECT": "lib/build.gradle"})
+		require.NoError(t, err)
+		require.Equal(t, "lib-config", config)
+		require.Equal(t, []envmanModels.EnvironmentItemModel{{"PROJECT": "lib/build.gradle"}}, envs)
+	}
+
+	t.Log("keeps the recorded user inputs")
+	{
+		config, envs, err := selectOptions(*root, map[string]string{"PROJECT": "app/build.gradle", "MODULE": "app"})
+		require.NoError(t, err)
+		require.Equal(t, "app-config", config)
+		require.Equal(t, []envmanModels.EnvironmentItemModel{{"PROJECT": "app/build.gradle"}, {"MODULE": "app"}}, envs)
+	}
+
+	t.Log("fails if the recorded value is no longer detected")
+	{
+		_, _, err := selectOptions(*root, map[string]string{"PROJECT": "removed/build.gradle", "MODULE": "app"})
+		require.EqualError(t, err, "the recorded PROJECT app env (removed/build.gradle) is not one of the detected values: app/build.gradle, lib/build.gradle")
+	}
+
+	t.Log("fails if a value is not recorded")
+	{
+		_, _, err := selectOptions(*root, map[string]string{"PROJECT": "app/build.gradle"})
+		require.EqualError(t, err, "no MODULE app env recorded for the Module input")
+	}
+}
@@ -0,0 +1,61 @@
+package steps
+
+import (
+	"fmt"
+	"sort"
+
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+)
+
+// Input returns the value of a step input, or an empty string if the item is not a step or it has no such input.
+func Input(item bitriseModels.StepListItemModel, inputKey string) string {
+	step, err := item.GetStep()
+	if err != nil {
+		return ""
+	}
+	for _, input := range step.Inputs {
+		if key, value, err := input.GetKeyValuePair(); err == nil && key == inputKey {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+// AppEnv returns the value of an app level env var of the config, or an empty string if the config has no such env.
+func AppEnv(config bitriseModels.BitriseDataModel, envKey string) string {
+	for _, env := range config.App.Environments {
+		if key, value, err := env.GetKeyValuePair(); err == nil && key == envKey {
+			return value
+		}
+	}
+	return ""
+}
+
+// WorkflowWithStep returns the first workflow, in alphabetical order, which has the first of the steps any workflow has.
+// It returns an empty string if no workflow has any of the steps.
+func WorkflowWithStep(config bitriseModels.BitriseDataModel, stepIDs ...string) string {
+	var workflowIDs []string
+	for workflowID := range config.Workflows {
+		workflowIDs = append(workflowIDs, workflowID)
+	}
+	sort.Strings(workflowIDs)
+
+	for _, stepID := range stepIDs {
+		for _, workflowID := range workflowIDs {
+			if Contains(config.Workflows[workflowID].Steps, stepID) {
+				return workflowID
+			}
+		}
+	}
+	return ""
+}
+
+// Find returns the first occurrence of the step, in the alphabetical order of the workflows.
+func Find(config bitriseModels.BitriseDataModel, stepID string) (bitriseModels.StepListItemModel, bool) {
+	workflowID := WorkflowWithStep(config, stepID)
+	if workflowID == "" {
+		return bitriseModels.StepListItemModel{}, false
+	}
+	stepList := config.Workflows[workflowID].Steps
+	return stepList[Index(stepList, stepID)], true
+}
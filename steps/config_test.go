@@ -0,0 +1,38 @@
+package steps
+
+import (
+	"testing"
+
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowWithStep(t *testing.T) {
+	config := bitriseModels.BitriseDataModel{
+		App: bitriseModels.AppModel{Environments: []envmanModels.EnvironmentItemModel{{"PROJECT_LOCATION": "android"}}},
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			"test": {Steps: []bitriseModels.StepListItemModel{
+				initSteps.GitCloneStepListItem(),
+				initSteps.AndroidUnitTestStepListItem(envmanModels.EnvironmentItemModel{"module": "app"}),
+			}},
+			"build": {Steps: []bitriseModels.StepListItemModel{
+				initSteps.GitCloneStepListItem(),
+				initSteps.AndroidBuildStepListItem(envmanModels.EnvironmentItemModel{"module": "lib"}),
+			}},
+		},
+	}
+
+	require.Equal(t, "build", WorkflowWithStep(config, initSteps.GitCloneID))
+	require.Equal(t, "test", WorkflowWithStep(config, initSteps.AndroidUnitTestID, initSteps.AndroidBuildID))
+	require.Empty(t, WorkflowWithStep(config, initSteps.XcodeArchiveID))
+
+	item, ok := Find(config, initSteps.AndroidBuildID)
+	require.True(t, ok)
+	require.Equal(t, "lib", Input(item, "module"))
+	require.Empty(t, Input(item, "variant"))
+
+	require.Equal(t, "android", AppEnv(config, "PROJECT_LOCATION"))
+	require.Empty(t, AppEnv(config, "MODULE"))
+}
@@ -0,0 +1,22 @@
+package steps
+
+const (
+	RestoreCacheID      = "restore-cache"
+	RestoreCacheVersion = "2"
+
+	SaveCacheID      = "save-cache"
+	SaveCacheVersion = "1"
+)
+
+const (
+	GooglePlayDeployID      = "google-play-deploy"
+	GooglePlayDeployVersion = "3"
+
+	AppStoreConnectDeployID      = "deploy-to-itunesconnect-application-loader"
+	AppStoreConnectDeployVersion = "1"
+)
+
+const (
+	XcodeBuildForSimulatorID      = "xcode-build-for-simulator"
+	XcodeBuildForSimulatorVersion = "0"
+)
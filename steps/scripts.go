@@ -0,0 +1,59 @@
+package steps
+
+import (
+	"fmt"
+	"strings"
+
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+)
+
+const installAndroidAppScript = `#!/usr/bin/env bash
+set -euxo pipefail
+
+adb -s "$BITRISE_EMULATOR_SERIAL" install -r "$BITRISE_APK_PATH"
+`
+
+const bootSimulatorAndInstallAppScript = `#!/usr/bin/env bash
+set -euxo pipefail
+
+device_id="$(xcrun simctl list devices available | grep -m1 -E '^ +iPhone' | grep -oE '[0-9A-F]{8}(-[0-9A-F]{4}){3}-[0-9A-F]{12}')"
+xcrun simctl boot "$device_id"
+xcrun simctl bootstatus "$device_id"
+xcrun simctl install "$device_id" "$BITRISE_APP_DIR_PATH"
+`
+
+// maestroTestCommands is completed with the report dir name, the test name and the flows.
+const maestroTestCommands = `curl -Ls "https://get.maestro.mobile.dev" | bash
+export PATH="$PATH:$HOME/.maestro/bin"
+
+report_dir="$BITRISE_TEST_RESULT_DIR/%s"
+mkdir -p "$report_dir"
+echo "{\"test-name\": \"%s\"}" > "$report_dir/test-info.json"
+maestro test %s --format junit --output "$report_dir/report.xml"
+`
+
+// InstallAndroidAppStepListItem installs the APK of the Android Build step on the emulator of the AVD Manager step,
+// the commands run after the install.
+func InstallAndroidAppStepListItem(commands ...string) bitriseModels.StepListItemModel {
+	return initSteps.ScriptStepListItem("Install the app", scriptWithCommands(installAndroidAppScript, commands))
+}
+
+// BootSimulatorAndInstallAppStepListItem boots the first available iPhone simulator and installs the app of
+// the Xcode Build for Simulator step on it, the commands run after the install with the simulator UDID in $device_id.
+func BootSimulatorAndInstallAppStepListItem(commands ...string) bitriseModels.StepListItemModel {
+	return initSteps.ScriptStepListItem("Boot simulator and install the app", scriptWithCommands(bootSimulatorAndInstallAppScript, commands))
+}
+
+// MaestroTestCommands returns the commands installing Maestro and running the flows, the JUnit report is written
+// to the reportDir of the test result dir with the test name.
+func MaestroTestCommands(reportDir, testName, flows string) string {
+	return fmt.Sprintf(maestroTestCommands, reportDir, testName, flows)
+}
+
+func scriptWithCommands(script string, commands []string) string {
+	if len(commands) == 0 {
+		return script
+	}
+	return script + strings.Join(commands, "\n") + "\n"
+}
@@ -0,0 +1,24 @@
+package steps
+
+import (
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/pointers"
+)
+
+// AndroidKeystoreSecrets are the secrets of the Android Sign step, the keystore is uploaded to the Bitrise file storage.
+var AndroidKeystoreSecrets = []string{
+	"BITRISEIO_ANDROID_KEYSTORE_URL",
+	"BITRISEIO_ANDROID_KEYSTORE_PASSWORD",
+	"BITRISEIO_ANDROID_KEYSTORE_ALIAS",
+	"BITRISEIO_ANDROID_KEYSTORE_PRIVATE_KEY_PASSWORD",
+}
+
+// SecretEnv returns an empty secret env with the summary, to be filled in by the user.
+func SecretEnv(key, summary string) envmanModels.EnvironmentItemModel {
+	return envmanModels.EnvironmentItemModel{
+		key: "",
+		envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{
+			Summary: pointers.NewStringPtr(summary),
+		},
+	}
+}
@@ -0,0 +1,141 @@
+package steps
+
+import (
+	"strings"
+
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/pointers"
+)
+
+// ID returns the step ID of a step list item, without the step lib source prefix and the version suffix.
+// For step bundle and `with` group items it returns the item key as-is.
+func ID(item bitriseModels.StepListItemModel) string {
+	key, itemType, err := item.GetKeyAndType()
+	if err != nil || itemType != bitriseModels.StepListItemTypeStep {
+		return key
+	}
+
+	return idFromComposite(key)
+}
+
+// Version returns the version part of a step list item's key, or an empty string if the step is not version locked.
+func Version(item bitriseModels.StepListItemModel) string {
+	key, itemType, err := item.GetKeyAndType()
+	if err != nil || itemType != bitriseModels.StepListItemTypeStep {
+		return ""
+	}
+
+	if idx := strings.LastIndex(key, "@"); idx != -1 {
+		return key[idx+1:]
+	}
+	return ""
+}
+
+func idFromComposite(composite string) string {
+	if idx := strings.LastIndex(composite, "@"); idx != -1 {
+		composite = composite[:idx]
+	}
+	if idx := strings.Index(composite, "::"); idx != -1 {
+		composite = composite[idx+2:]
+	}
+	return composite
+}
+
+// Index returns the index of the first step with the given ID in the step list, or -1 if not found.
+func Index(stepList []bitriseModels.StepListItemModel, id string) int {
+	for i, item := range stepList {
+		if ID(item) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Contains reports whether the step list has a step with the given ID.
+func Contains(stepList []bitriseModels.StepListItemModel, id string) bool {
+	return Index(stepList, id) != -1
+}
+
+// Insert inserts the items into the step list at the given index.
+func Insert(stepList []bitriseModels.StepListItemModel, index int, items ...bitriseModels.StepListItemModel) []bitriseModels.StepListItemModel {
+	if index < 0 {
+		index = 0
+	}
+	if index > len(stepList) {
+		index = len(stepList)
+	}
+
+	newStepList := make([]bitriseModels.StepListItemModel, 0, len(stepList)+len(items))
+	newStepList = append(newStepList, stepList[:index]...)
+	newStepList = append(newStepList, items...)
+	newStepList = append(newStepList, stepList[index:]...)
+	return newStepList
+}
+
+// InsertAfterPrepare inserts the items right after the prepare section (SSH key activation and git clone)
+// of the step list. If the step list has no git clone step, the items are inserted at the beginning.
+func InsertAfterPrepare(stepList []bitriseModels.StepListItemModel, items ...bitriseModels.StepListItemModel) []bitriseModels.StepListItemModel {
+	return Insert(stepList, Index(stepList, initSteps.GitCloneID)+1, items...)
+}
+
+// WithInput returns a copy of the step list item with the given input value, the input is replaced if the step already has it.
+// Items which are not steps are returned as-is.
+func WithInput(item bitriseModels.StepListItemModel, inputKey, value string) bitriseModels.StepListItemModel {
+	key, _, err := item.GetKeyAndType()
+	if err != nil {
+		return item
+	}
+	step, err := item.GetStep()
+	if err != nil {
+		return item
+	}
+
+	inputs := []envmanModels.EnvironmentItemModel{{inputKey: value}}
+	for _, input := range step.Inputs {
+		if key, _, err := input.GetKeyValuePair(); err == nil && key == inputKey {
+			continue
+		}
+		inputs = append(inputs, input)
+	}
+	step.Inputs = inputs
+	return bitriseModels.StepListItemModel{key: *step}
+}
+
+// AlwaysRun returns the step list item with is_always_run set, the step runs even if a previous step failed.
+// Items which are not steps are returned as-is.
+func AlwaysRun(item bitriseModels.StepListItemModel) bitriseModels.StepListItemModel {
+	key, _, err := item.GetKeyAndType()
+	if err != nil {
+		return item
+	}
+	step, err := item.GetStep()
+	if err != nil {
+		return item
+	}
+
+	step.IsAlwaysRun = pointers.NewBoolPtr(true)
+	return bitriseModels.StepListItemModel{key: *step}
+}
+
+// WithBundleSteps returns the step list with the step bundle items replaced by the steps of the bundles,
+// the items of unknown bundles are kept as-is.
+func WithBundleSteps(stepList []bitriseModels.StepListItemModel, bundles map[string]bitriseModels.StepBundleModel) []bitriseModels.StepListItemModel {
+	var expanded []bitriseModels.StepListItemModel
+	for _, item := range stepList {
+		key, itemType, err := item.GetKeyAndType()
+		bundle, ok := bundles[strings.TrimPrefix(key, bitriseModels.StepListItemStepBundleKeyPrefix)]
+		if err != nil || itemType != bitriseModels.StepListItemTypeBundle || !ok {
+			expanded = append(expanded, item)
+			continue
+		}
+
+		var bundleSteps []bitriseModels.StepListItemModel
+		for _, bundleItem := range bundle.Steps {
+			bundleSteps = append(bundleSteps, bitriseModels.StepListItemModel(bundleItem))
+		}
+		expanded = append(expanded, WithBundleSteps(bundleSteps, bundles)...)
+	}
+	return expanded
+}
@@ -0,0 +1,60 @@
+package steps
+
+import (
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+func stepIDComposite(ID, version string) string {
+	if version != "" {
+		return ID + "@" + version
+	}
+	return ID
+}
+
+func stepListItem(stepIDComposite, title, runIf string, inputs ...envmanModels.EnvironmentItemModel) bitriseModels.StepListItemModel {
+	step := stepmanModels.StepModel{}
+	if title != "" {
+		step.Title = pointers.NewStringPtr(title)
+	}
+	if runIf != "" {
+		step.RunIf = pointers.NewStringPtr(runIf)
+	}
+	if len(inputs) > 0 {
+		step.Inputs = inputs
+	}
+
+	return bitriseModels.StepListItemModel{
+		stepIDComposite: step,
+	}
+}
+
+func RestoreCacheStepListItem(title, key string) bitriseModels.StepListItemModel {
+	stepIDComposite := stepIDComposite(RestoreCacheID, RestoreCacheVersion)
+	return stepListItem(stepIDComposite, title, "", envmanModels.EnvironmentItemModel{"key": key})
+}
+
+func SaveCacheStepListItem(title, key, paths string) bitriseModels.StepListItemModel {
+	stepIDComposite := stepIDComposite(SaveCacheID, SaveCacheVersion)
+	return stepListItem(stepIDComposite, title, "",
+		envmanModels.EnvironmentItemModel{"key": key},
+		envmanModels.EnvironmentItemModel{"paths": paths},
+	)
+}
+
+func GooglePlayDeployStepListItem(inputs ...envmanModels.EnvironmentItemModel) bitriseModels.StepListItemModel {
+	stepIDComposite := stepIDComposite(GooglePlayDeployID, GooglePlayDeployVersion)
+	return stepListItem(stepIDComposite, "", "", inputs...)
+}
+
+func AppStoreConnectDeployStepListItem(inputs ...envmanModels.EnvironmentItemModel) bitriseModels.StepListItemModel {
+	stepIDComposite := stepIDComposite(AppStoreConnectDeployID, AppStoreConnectDeployVersion)
+	return stepListItem(stepIDComposite, "", "", inputs...)
+}
+
+func XcodeBuildForSimulatorStepListItem(inputs ...envmanModels.EnvironmentItemModel) bitriseModels.StepListItemModel {
+	stepIDComposite := stepIDComposite(XcodeBuildForSimulatorID, XcodeBuildForSimulatorVersion)
+	return stepListItem(stepIDComposite, "", "", inputs...)
+}
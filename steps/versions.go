@@ -0,0 +1,66 @@
+package steps
+
+import (
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+)
+
+// LatestMajorVersions maps the step IDs used by the scanners to the major version the scanners generate.
+var LatestMajorVersions = map[string]string{
+	initSteps.ActivateSSHKeyID:                         initSteps.ActivateSSHKeyVersion,
+	initSteps.AndroidLintID:                            initSteps.AndroidLintVersion,
+	initSteps.AndroidUnitTestID:                        initSteps.AndroidUnitTestVersion,
+	initSteps.AndroidBuildID:                           initSteps.AndroidBuildVersion,
+	initSteps.GradleRunnerID:                           initSteps.GradleRunnerVersion,
+	initSteps.GradleUnitTestID:                         initSteps.GradleUnitTestVersion,
+	initSteps.GitCloneID:                               initSteps.GitCloneVersion,
+	initSteps.CacheRestoreGradleID:                     initSteps.CacheRestoreGradleVersion,
+	initSteps.CacheRestoreCocoapodsID:                  initSteps.CacheRestoreCocoapodsVersion,
+	initSteps.CacheRestoreCarthageID:                   initSteps.CacheRestoreCarthageVersion,
+	initSteps.CacheRestoreNPMID:                        initSteps.CacheRestoreNPMVersion,
+	initSteps.CacheRestoreSPMID:                        initSteps.CacheRestoreSPMVersion,
+	initSteps.CacheRestoreDartID:                       initSteps.CacheRestoreDartVersion,
+	initSteps.CacheSaveGradleID:                        initSteps.CacheSaveGradleVersion,
+	initSteps.CacheSaveCocoapodsID:                     initSteps.CacheSaveCocoapodsVersion,
+	initSteps.CacheSaveCarthageID:                      initSteps.CacheSaveCarthageVersion,
+	initSteps.CacheSaveNPMID:                           initSteps.CacheSaveNPMVersion,
+	initSteps.CacheSaveSPMID:                           initSteps.CacheSaveSPMVersion,
+	initSteps.CacheSaveDartID:                          initSteps.CacheSaveDartVersion,
+	initSteps.CertificateAndProfileInstallerID:         initSteps.CertificateAndProfileInstallerVersion,
+	initSteps.ChangeAndroidVersionCodeAndVersionNameID: initSteps.ChangeAndroidVersionCodeAndVersionNameVersion,
+	initSteps.DeployToBitriseIoID:                      initSteps.DeployToBitriseIoVersion,
+	initSteps.SignAPKID:                                initSteps.SignAPKVersion,
+	initSteps.InstallMissingAndroidToolsID:             initSteps.InstallMissingAndroidToolsVersion,
+	initSteps.FastlaneID:                               initSteps.FastlaneVersion,
+	initSteps.CocoapodsInstallID:                       initSteps.CocoapodsInstallVersion,
+	initSteps.CarthageID:                               initSteps.CarthageVersion,
+	initSteps.XcodeArchiveID:                           initSteps.XcodeArchiveVersion,
+	initSteps.XcodeTestID:                              initSteps.XcodeTestVersion,
+	initSteps.XcodeBuildForTestID:                      initSteps.XcodeBuildForTestVersion,
+	initSteps.XcodeArchiveMacID:                        initSteps.XcodeArchiveMacVersion,
+	initSteps.ExportXCArchiveID:                        initSteps.ExportXCArchiveVersion,
+	initSteps.XcodeTestMacID:                           initSteps.XcodeTestMacVersion,
+	initSteps.CordovaArchiveID:                         initSteps.CordovaArchiveVersion,
+	initSteps.IonicArchiveID:                           initSteps.IonicArchiveVersion,
+	initSteps.GenerateCordovaBuildConfigID:             initSteps.GenerateCordovaBuildConfigVersion,
+	initSteps.JasmineTestRunnerID:                      initSteps.JasmineTestRunnerVersion,
+	initSteps.KarmaJasmineTestRunnerID:                 initSteps.KarmaJasmineTestRunnerVersion,
+	initSteps.ScriptID:                                 initSteps.ScriptVersion,
+	initSteps.NpmID:                                    initSteps.NpmVersion,
+	initSteps.RunEASBuildID:                            initSteps.RunEASBuildVersion,
+	initSteps.YarnID:                                   initSteps.YarnVersion,
+	initSteps.FlutterInstallID:                         initSteps.FlutterInstallVersion,
+	initSteps.FlutterTestID:                            initSteps.FlutterTestVersion,
+	initSteps.FlutterAnalyzeID:                         initSteps.FlutterAnalyzeVersion,
+	initSteps.FlutterBuildID:                           initSteps.FlutterBuildVersion,
+	initSteps.XcodeTestShardCalculationID:              initSteps.XcodeTestShardCalculationVersion,
+	initSteps.PullIntermediateFilesID:                  initSteps.PullIntermediateFilesVersion,
+	initSteps.XcodeTestWithoutBuildingID:               initSteps.XcodeTestWithoutBuildingVersion,
+	initSteps.AvdManagerID:                             initSteps.AvdManagerVersion,
+	initSteps.WaitForAndroidEmulatorID:                 initSteps.WaitForAndroidEmulatorVersion,
+
+	RestoreCacheID:           RestoreCacheVersion,
+	SaveCacheID:              SaveCacheVersion,
+	GooglePlayDeployID:       GooglePlayDeployVersion,
+	AppStoreConnectDeployID:  AppStoreConnectDeployVersion,
+	XcodeBuildForSimulatorID: XcodeBuildForSimulatorVersion,
+}
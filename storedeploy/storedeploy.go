@@ -0,0 +1,268 @@
+package storedeploy
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/androidsigning"
+	"github.com/bitrise-io/bitrise-plugins-init/gradlescript"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcworkspace"
+)
+
+const (
+	// GooglePlayWorkflowID is the ID of the workflow uploading the App Bundle to Google Play.
+	GooglePlayWorkflowID          = "deploy_google_play"
+	googlePlayWorkflowSummary     = "Build an Android App Bundle (AAB) of your app and upload it to Google Play."
+	googlePlayWorkflowDescription = "The workflow will first clone your Git repository, install Android tools, build the project's AAB file and upload it to the selected Google Play track."
+
+	// AppStoreWorkflowID is the ID of the workflow uploading the IPA to App Store Connect.
+	AppStoreWorkflowID          = "deploy_app_store"
+	appStoreWorkflowSummary     = "Create an App Store IPA file of your app and upload it to App Store Connect."
+	appStoreWorkflowDescription = "The workflow will first clone your Git repository, cache and install your project's dependencies if any, export an App Store IPA file from the project and upload it to App Store Connect."
+
+	// PackageNameEnvKey is the app env of the Android application ID the Google Play deploy uses.
+	PackageNameEnvKey = "ANDROID_PACKAGE_NAME"
+	// BundleIDEnvKey is the app env of the iOS app's bundle ID.
+	BundleIDEnvKey = "IOS_BUNDLE_ID"
+
+	// DefaultTrack is the Google Play track the deploy workflow uploads to by default.
+	DefaultTrack = "internal"
+
+	serviceAccountKeyEnvKey = "BITRISEIO_SERVICE_ACCOUNT_JSON_KEY_URL"
+	apiKeyEnvKey            = "BITRISEIO_ASC_API_KEY_URL"
+	apiIssuerEnvKey         = "ASC_API_KEY_ISSUER_ID"
+
+	buildTypeInputKey = "build_type"
+	buildTypeAAB      = "aab"
+	appStoreExport    = "app-store"
+)
+
+// Tracks are the Google Play tracks the deploy workflow can upload to.
+var Tracks = []string{"internal", "alpha", "beta", "production"}
+
+var (
+	applicationIDPattern = regexp.MustCompile(`\bapplicationId\s*=?\s*["']([\w.]+)["']`)
+	buildSettingPattern  = regexp.MustCompile(`\$[({](TARGET_NAME|PRODUCT_NAME)(?::rfc1034identifier)?[)}]`)
+)
+
+// manifest is the root element of the AndroidManifest.xml, the package attribute is the application ID of the legacy projects.
+type manifest struct {
+	Package string `xml:"package,attr"`
+}
+
+// PackageName returns the application ID of the Android module of the config, as set by the applicationId of the
+// module's build script or the package of its manifest. It returns an empty string if the config has no Android module.
+func PackageName(searchDir string, config bitriseModels.BitriseDataModel) (string, error) {
+	module := steps.AppEnv(config, android.ModuleInputEnvKey)
+	if module == "" {
+		return "", nil
+	}
+	moduleDir := filepath.Join(searchDir, steps.AppEnv(config, android.ProjectLocationInputEnvKey), module)
+
+	for _, buildScript := range []string{"build.gradle", "build.gradle.kts"} {
+		content, err := fsutil.ReadOptionalFile(filepath.Join(moduleDir, buildScript))
+		if err != nil {
+			return "", err
+		}
+		script := gradlescript.StripComments(content)
+		if defaultConfig, ok := gradlescript.Block(script, "defaultConfig"); ok {
+			script = defaultConfig
+		}
+		if match := applicationIDPattern.FindStringSubmatch(script); match != nil {
+			return match[1], nil
+		}
+	}
+
+	content, err := fsutil.ReadOptionalFile(filepath.Join(moduleDir, "src", "main", "AndroidManifest.xml"))
+	if err != nil || content == "" {
+		return "", err
+	}
+	var androidManifest manifest
+	if err := xml.Unmarshal([]byte(content), &androidManifest); err != nil {
+		return "", fmt.Errorf("parse AndroidManifest.xml: %s", err)
+	}
+	return androidManifest.Package, nil
+}
+
+// BundleID returns the bundle ID of the app target the scheme of the config archives, as set by the
+// PRODUCT_BUNDLE_IDENTIFIER build setting of the archive configuration. It returns an empty string if the config
+// has no Xcode project or the bundle ID depends on build settings other than the target and product name.
+func BundleID(searchDir string, config bitriseModels.BitriseDataModel) (string, error) {
+	projectPath := steps.AppEnv(config, ios.ProjectPathInputEnvKey)
+	scheme := steps.AppEnv(config, ios.SchemeInputEnvKey)
+	if projectPath == "" || scheme == "" {
+		return "", nil
+	}
+	projectPath = filepath.Join(searchDir, projectPath)
+
+	projectPaths := []string{projectPath}
+	if xcworkspace.IsWorkspace(projectPath) {
+		workspace, err := xcworkspace.Open(projectPath)
+		if err != nil {
+			return "", err
+		}
+		if projectPaths, err = workspace.ProjectFileLocations(); err != nil {
+			return "", err
+		}
+	}
+
+	for _, pth := range projectPaths {
+		if filepath.Base(pth) == "Pods.xcodeproj" {
+			continue
+		}
+		project, err := xcodeproj.Open(pth)
+		if err != nil {
+			return "", err
+		}
+
+		target, configuration, ok := archivedTarget(project, scheme)
+		if !ok {
+			continue
+		}
+		for _, buildConfiguration := range target.BuildConfigurationList.BuildConfigurations {
+			if buildConfiguration.Name != configuration {
+				continue
+			}
+			bundleID, err := buildConfiguration.BuildSettings.String("PRODUCT_BUNDLE_IDENTIFIER")
+			if err != nil {
+				// the bundle ID is set by an xcconfig file or the Info.plist
+				return "", nil
+			}
+			bundleID = buildSettingPattern.ReplaceAllString(bundleID, target.Name)
+			if strings.Contains(bundleID, "$") {
+				return "", nil
+			}
+			return bundleID, nil
+		}
+	}
+	return "", nil
+}
+
+// AddGooglePlayWorkflow adds the Google Play deploy workflow to the config, it builds the App Bundle the same way as
+// the release AAB workflow, or the workflow with the Android Build step, and uploads it to the track.
+func AddGooglePlayWorkflow(config *bitriseModels.BitriseDataModel, packageName, track string) bool {
+	sourceWorkflowID := androidsigning.ReleaseWorkflowID
+	if _, ok := config.Workflows[sourceWorkflowID]; !ok {
+		sourceWorkflowID = steps.WorkflowWithStep(*config, initSteps.AndroidBuildID)
+	}
+	if sourceWorkflowID == "" {
+		return false
+	}
+
+	var stepList []bitriseModels.StepListItemModel
+	for _, item := range config.Workflows[sourceWorkflowID].Steps {
+		if steps.ID(item) == initSteps.AndroidBuildID {
+			item = steps.WithInput(item, buildTypeInputKey, buildTypeAAB)
+		}
+		stepList = append(stepList, item)
+	}
+	stepList = insertBeforeDeploy(stepList, steps.GooglePlayDeployStepListItem(
+		envmanModels.EnvironmentItemModel{"service_account_json_key_path": "$" + serviceAccountKeyEnvKey},
+		envmanModels.EnvironmentItemModel{"package_name": "$" + PackageNameEnvKey},
+		envmanModels.EnvironmentItemModel{"app_path": "$BITRISE_AAB_PATH"},
+		envmanModels.EnvironmentItemModel{"track": track},
+	))
+
+	config.Workflows[GooglePlayWorkflowID] = bitriseModels.WorkflowModel{
+		Summary:     googlePlayWorkflowSummary,
+		Description: googlePlayWorkflowDescription,
+		Steps:       stepList,
+	}
+	setAppEnv(config, PackageNameEnvKey, packageName)
+	return true
+}
+
+// AddAppStoreWorkflow adds the App Store deploy workflow to the config, it archives the app the same way as the
+// workflow with the Xcode Archive step, exports it for the App Store and uploads it to App Store Connect.
+func AddAppStoreWorkflow(config *bitriseModels.BitriseDataModel, bundleID string) bool {
+	sourceWorkflowID := steps.WorkflowWithStep(*config, initSteps.XcodeArchiveID)
+	if sourceWorkflowID == "" {
+		return false
+	}
+
+	var stepList []bitriseModels.StepListItemModel
+	for _, item := range config.Workflows[sourceWorkflowID].Steps {
+		if steps.ID(item) == initSteps.XcodeArchiveID {
+			item = steps.WithInput(item, ios.DistributionMethodInputKey, appStoreExport)
+		}
+		stepList = append(stepList, item)
+	}
+	stepList = insertBeforeDeploy(stepList, steps.AppStoreConnectDeployStepListItem(
+		envmanModels.EnvironmentItemModel{"connection": "api_key"},
+		envmanModels.EnvironmentItemModel{"api_key_path": "$" + apiKeyEnvKey},
+		envmanModels.EnvironmentItemModel{"api_issuer": "$" + apiIssuerEnvKey},
+	))
+
+	config.Workflows[AppStoreWorkflowID] = bitriseModels.WorkflowModel{
+		Summary:     appStoreWorkflowSummary,
+		Description: appStoreWorkflowDescription,
+		Steps:       stepList,
+	}
+	setAppEnv(config, BundleIDEnvKey, bundleID)
+	return true
+}
+
+// SecretEnvs returns the store credentials the deploy steps of the config expect, as empty secret envs.
+func SecretEnvs(config bitriseModels.BitriseDataModel) []envmanModels.EnvironmentItemModel {
+	var envs []envmanModels.EnvironmentItemModel
+	if steps.WorkflowWithStep(config, steps.GooglePlayDeployID) != "" {
+		envs = append(envs, steps.SecretEnv(serviceAccountKeyEnvKey, "Required by the Google Play Deploy step, upload the service account JSON key as a Bitrise file to set it"))
+	}
+	if steps.WorkflowWithStep(config, steps.AppStoreConnectDeployID) != "" {
+		envs = append(envs,
+			steps.SecretEnv(apiKeyEnvKey, "Required by the App Store Connect deploy step, upload the App Store Connect API key (.p8) as a Bitrise file to set it"),
+			steps.SecretEnv(apiIssuerEnvKey, "Required by the App Store Connect deploy step, the issuer ID of the App Store Connect API key"),
+		)
+	}
+	return envs
+}
+
+// archivedTarget returns the app target the scheme archives and the build configuration of the archive action.
+// Without a scheme file the target named after the scheme is archived with its default configuration.
+func archivedTarget(project xcodeproj.XcodeProj, schemeName string) (xcodeproj.Target, string, bool) {
+	if scheme, _, err := project.Scheme(schemeName); err == nil {
+		if entry, ok := scheme.AppBuildActionEntry(); ok {
+			if target, ok := project.Proj.Target(entry.BuildableReference.BlueprintIdentifier); ok {
+				configuration := scheme.ArchiveAction.BuildConfiguration
+				if configuration == "" {
+					configuration = target.BuildConfigurationList.DefaultConfigurationName
+				}
+				return target, configuration, true
+			}
+		}
+	}
+
+	target, ok := project.Proj.TargetByName(schemeName)
+	if !ok || !target.IsAppProduct() {
+		return xcodeproj.Target{}, "", false
+	}
+	return target, target.BuildConfigurationList.DefaultConfigurationName, true
+}
+
+func insertBeforeDeploy(stepList []bitriseModels.StepListItemModel, item bitriseModels.StepListItemModel) []bitriseModels.StepListItemModel {
+	if idx := steps.Index(stepList, initSteps.DeployToBitriseIoID); idx != -1 {
+		return steps.Insert(stepList, idx, item)
+	}
+	return append(stepList, item)
+}
+
+func setAppEnv(config *bitriseModels.BitriseDataModel, envKey, value string) {
+	for i, env := range config.App.Environments {
+		if key, _, err := env.GetKeyValuePair(); err == nil && key == envKey {
+			config.App.Environments[i] = envmanModels.EnvironmentItemModel{envKey: value}
+			return
+		}
+	}
+	config.App.Environments = append(config.App.Environments, envmanModels.EnvironmentItemModel{envKey: value})
+}
@@ -0,0 +1,183 @@
+package storedeploy
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-init/scanners/android"
+	"github.com/bitrise-io/bitrise-init/scanners/ios"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/androidsigning"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const pbxproj = `// !$*UTF8*$!
+{
+	archiveVersion = 1;
+	classes = {
+	};
+	objectVersion = 56;
+	objects = {
+		A10000000000000000000001 /* Project object */ = {
+			isa = PBXProject;
+			attributes = {
+				LastUpgradeCheck = 1500;
+			};
+			buildConfigurationList = A10000000000000000000002;
+			mainGroup = A10000000000000000000009;
+			targets = (
+				A10000000000000000000003 /* Notes */,
+			);
+		};
+		A10000000000000000000002 = {
+			isa = XCConfigurationList;
+			buildConfigurations = (
+			);
+			defaultConfigurationName = Release;
+		};
+		A10000000000000000000003 /* Notes */ = {
+			isa = PBXNativeTarget;
+			buildConfigurationList = A10000000000000000000004;
+			buildPhases = (
+			);
+			dependencies = (
+			);
+			name = Notes;
+			productReference = A10000000000000000000007 /* Notes.app */;
+			productType = "com.apple.product-type.application";
+		};
+		A10000000000000000000007 /* Notes.app */ = {
+			isa = PBXFileReference;
+			explicitFileType = wrapper.application;
+			includeInIndex = 0;
+			path = Notes.app;
+			sourceTree = BUILT_PRODUCTS_DIR;
+		};
+		A10000000000000000000004 = {
+			isa = XCConfigurationList;
+			buildConfigurations = (
+				A10000000000000000000005 /* Debug */,
+				A10000000000000000000006 /* Release */,
+			);
+			defaultConfigurationName = Release;
+		};
+		A10000000000000000000005 /* Debug */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				PRODUCT_BUNDLE_IDENTIFIER = "io.bitrise.$(TARGET_NAME).debug";
+			};
+			name = Debug;
+		};
+		A10000000000000000000006 /* Release */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				PRODUCT_BUNDLE_IDENTIFIER = "io.bitrise.$(PRODUCT_NAME:rfc1034identifier)";
+			};
+			name = Release;
+		};
+	};
+	rootObject = A10000000000000000000001 /* Project object */;
+}
+`
+
+func configWithAppEnvs(envs ...envmanModels.EnvironmentItemModel) bitriseModels.BitriseDataModel {
+	config := bitriseModels.BitriseDataModel{Workflows: map[string]bitriseModels.WorkflowModel{}}
+	config.App.Environments = envs
+	return config
+}
+
+func TestPackageName(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "android", "app", "build.gradle.kts"), `android {
+    namespace = "io.bitrise.notes.app"
+    defaultConfig {
+        // applicationId = "io.bitrise.sample"
+        applicationId = "io.bitrise.notes"
+    }
+}`)
+	testutil.WriteFile(t, filepath.Join(dir, "android", "legacy", "build.gradle"), "apply plugin: 'com.android.application'\n")
+	testutil.WriteFile(t, filepath.Join(dir, "android", "legacy", "src", "main", "AndroidManifest.xml"), `<?xml version="1.0" encoding="utf-8"?>
+<manifest xmlns:android="http://schemas.android.com/apk/res/android" package="io.bitrise.legacy">
+</manifest>`)
+
+	packageName, err := PackageName(dir, configWithAppEnvs(
+		envmanModels.EnvironmentItemModel{android.ProjectLocationInputEnvKey: "android"},
+		envmanModels.EnvironmentItemModel{android.ModuleInputEnvKey: "app"},
+	))
+	require.NoError(t, err)
+	require.Equal(t, "io.bitrise.notes", packageName)
+
+	packageName, err = PackageName(dir, configWithAppEnvs(
+		envmanModels.EnvironmentItemModel{android.ProjectLocationInputEnvKey: "android"},
+		envmanModels.EnvironmentItemModel{android.ModuleInputEnvKey: "legacy"},
+	))
+	require.NoError(t, err)
+	require.Equal(t, "io.bitrise.legacy", packageName)
+}
+
+func TestBundleID(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, filepath.Join(dir, "ios", "Notes.xcodeproj", "project.pbxproj"), pbxproj)
+
+	bundleID, err := BundleID(dir, configWithAppEnvs(
+		envmanModels.EnvironmentItemModel{ios.ProjectPathInputEnvKey: "ios/Notes.xcodeproj"},
+		envmanModels.EnvironmentItemModel{ios.SchemeInputEnvKey: "Notes"},
+	))
+	require.NoError(t, err)
+	require.Equal(t, "io.bitrise.Notes", bundleID)
+
+	bundleID, err = BundleID(dir, configWithAppEnvs())
+	require.NoError(t, err)
+	require.Empty(t, bundleID)
+}
+
+func TestAddGooglePlayWorkflow(t *testing.T) {
+	config := configWithAppEnvs(envmanModels.EnvironmentItemModel{android.ModuleInputEnvKey: "app"})
+	config.Workflows[androidsigning.ReleaseWorkflowID] = bitriseModels.WorkflowModel{Steps: []bitriseModels.StepListItemModel{
+		initSteps.GitCloneStepListItem(),
+		initSteps.AndroidBuildStepListItem(envmanModels.EnvironmentItemModel{buildTypeInputKey: buildTypeAAB}),
+		initSteps.SignAPKStepListItem(),
+		initSteps.DeployToBitriseIoStepListItem(),
+	}}
+
+	require.True(t, AddGooglePlayWorkflow(&config, "io.bitrise.notes", "beta"))
+
+	stepList := config.Workflows[GooglePlayWorkflowID].Steps
+	require.Equal(t, 3, steps.Index(stepList, steps.GooglePlayDeployID))
+	deployStep, err := stepList[3].GetStep()
+	require.NoError(t, err)
+	require.Contains(t, deployStep.Inputs, envmanModels.EnvironmentItemModel{"track": "beta"})
+	require.Equal(t, "io.bitrise.notes", steps.AppEnv(config, PackageNameEnvKey))
+
+	var keys []string
+	for _, env := range SecretEnvs(config) {
+		key, _, err := env.GetKeyValuePair()
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	require.Equal(t, []string{serviceAccountKeyEnvKey}, keys)
+}
+
+func TestAddAppStoreWorkflow(t *testing.T) {
+	config := configWithAppEnvs(envmanModels.EnvironmentItemModel{BundleIDEnvKey: ""})
+	require.False(t, AddAppStoreWorkflow(&config, "io.bitrise.Notes"))
+
+	config.Workflows["archive_and_export_app"] = bitriseModels.WorkflowModel{Steps: []bitriseModels.StepListItemModel{
+		initSteps.GitCloneStepListItem(),
+		initSteps.XcodeArchiveStepListItem(envmanModels.EnvironmentItemModel{ios.DistributionMethodInputKey: "$BITRISE_DISTRIBUTION_METHOD"}),
+		initSteps.DeployToBitriseIoStepListItem(),
+	}}
+	require.True(t, AddAppStoreWorkflow(&config, "io.bitrise.Notes"))
+
+	stepList := config.Workflows[AppStoreWorkflowID].Steps
+	archiveStep, err := stepList[steps.Index(stepList, initSteps.XcodeArchiveID)].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, []envmanModels.EnvironmentItemModel{{ios.DistributionMethodInputKey: appStoreExport}}, archiveStep.Inputs)
+	require.Equal(t, 2, steps.Index(stepList, steps.AppStoreConnectDeployID))
+	require.Equal(t, []envmanModels.EnvironmentItemModel{{BundleIDEnvKey: "io.bitrise.Notes"}}, config.App.Environments)
+	require.Len(t, SecretEnvs(config), 2)
+}
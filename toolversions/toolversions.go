@@ -0,0 +1,417 @@
+package toolversions
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-init/detectors/direntry"
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/fsutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+)
+
+const (
+	// SetupStepTitle is the title of the script step installing the detected tool versions.
+	SetupStepTitle = "Set up tool versions"
+
+	// nodeJSInstallStepTitle is the title of the asdf based Node.js install step added by the Node.js scanner.
+	nodeJSInstallStepTitle = "Install Node.js"
+
+	// FlutterToolName is the tool name used for Flutter in the detected tool list.
+	FlutterToolName = "flutter"
+	// JavaToolName is the tool name used for Java in the detected tool list.
+	JavaToolName = "java"
+	// NodeJSToolName is the tool name used for Node.js in the detected tool list.
+	NodeJSToolName = "nodejs"
+	// PythonToolName is the tool name used for Python in the detected tool list.
+	PythonToolName = "python"
+	// RubyToolName is the tool name used for Ruby in the detected tool list.
+	RubyToolName = "ruby"
+
+	toolVersionsFileName = ".tool-versions"
+	gradleSearchDepth    = 6
+)
+
+// Tool is a tool version the project expects, as declared in one of the project's version files.
+type Tool struct {
+	Name    string
+	Version string
+	Source  string
+}
+
+type versionFile struct {
+	name     string
+	toolName string
+	parse    func(content string) string
+}
+
+// legacyVersionFiles are tool specific version files. They are only taken into account
+// if the tool is not listed in the .tool-versions file, the same way asdf resolves versions.
+var legacyVersionFiles = []versionFile{
+	{name: ".nvmrc", toolName: NodeJSToolName, parse: parseNodeVersion},
+	{name: ".node-version", toolName: NodeJSToolName, parse: parseNodeVersion},
+	{name: ".ruby-version", toolName: RubyToolName, parse: parseRubyVersion},
+	{name: ".java-version", toolName: JavaToolName, parse: parseJavaVersion},
+	{name: ".python-version", toolName: PythonToolName, parse: parsePythonVersion},
+	// the FVM config of FVM 3, and of the earlier versions
+	{name: ".fvmrc", toolName: FlutterToolName, parse: fvmVersionParser("flutter")},
+	{name: filepath.Join(".fvm", "fvm_config.json"), toolName: FlutterToolName, parse: fvmVersionParser("flutterSdkVersion")},
+}
+
+var (
+	gradleJavaToolchainPattern      = regexp.MustCompile(`JavaLanguageVersion\s*\.\s*of\(\s*"?(\d+)"?\s*\)`)
+	gradleKotlinJvmToolchainPattern = regexp.MustCompile(`jvmToolchain\(\s*(\d+)\s*\)`)
+	javaMajorVersionPattern         = regexp.MustCompile(`(\d+)(?:\.(\d+))?`)
+	pythonVersionPattern            = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+)
+
+// Detect collects the tool versions declared in the search dir: the asdf .tool-versions file,
+// the tool specific version files (.nvmrc, .node-version, .ruby-version, .java-version, .python-version
+// and the FVM configs) and the Gradle Java toolchain declarations.
+func Detect(searchDir string) ([]Tool, error) {
+	toolToVersion := map[string]Tool{}
+
+	toolVersions, err := readToolVersionsFile(filepath.Join(searchDir, toolVersionsFileName))
+	if err != nil {
+		return nil, err
+	}
+	for _, tool := range toolVersions {
+		if tool.Name == JavaToolName {
+			tool.Version = parseJavaVersion(tool.Version)
+			if tool.Version == "" {
+				continue
+			}
+		}
+		toolToVersion[tool.Name] = tool
+	}
+
+	for _, file := range legacyVersionFiles {
+		if _, ok := toolToVersion[file.toolName]; ok {
+			continue
+		}
+
+		content, err := fsutil.ReadOptionalFile(filepath.Join(searchDir, file.name))
+		if err != nil {
+			return nil, err
+		}
+
+		if version := file.parse(content); version != "" {
+			toolToVersion[file.toolName] = Tool{Name: file.toolName, Version: version, Source: file.name}
+		}
+	}
+
+	if _, ok := toolToVersion[JavaToolName]; !ok {
+		tool, err := detectGradleJavaToolchain(searchDir)
+		if err != nil {
+			return nil, err
+		}
+		if tool != nil {
+			toolToVersion[JavaToolName] = *tool
+		}
+	}
+
+	var tools []Tool
+	for _, tool := range toolToVersion {
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+
+	return tools, nil
+}
+
+// AddSetupStep inserts a single tool setup step right after the prepare steps of every workflow in the config.
+// The Node.js scanner's own install step and the Flutter Installer step are dropped from the workflows and the
+// step bundles if the Node.js and the Flutter versions are covered by the tool setup step.
+func AddSetupStep(config *bitriseModels.BitriseDataModel, tools []Tool) {
+	if len(tools) == 0 {
+		return
+	}
+
+	covered := map[string]bool{}
+	for _, tool := range tools {
+		covered[tool.Name] = true
+	}
+
+	for workflowID, workflow := range config.Workflows {
+		if len(workflow.Steps) == 0 {
+			continue
+		}
+
+		stepList := removeInstallSteps(workflow.Steps, covered)
+		workflow.Steps = steps.InsertAfterPrepare(stepList, SetupStepListItem(tools))
+		config.Workflows[workflowID] = workflow
+	}
+
+	// the Node.js workspace workflows install Node.js with a step bundle
+	for bundleID, bundle := range config.StepBundles {
+		var stepList []bitriseModels.StepListItemModel
+		for _, item := range bundle.Steps {
+			stepList = append(stepList, bitriseModels.StepListItemModel(item))
+		}
+
+		var bundleSteps []bitriseModels.StepListItemStepOrBundleModel
+		for _, item := range removeInstallSteps(stepList, covered) {
+			bundleSteps = append(bundleSteps, bitriseModels.StepListItemStepOrBundleModel(item))
+		}
+		bundle.Steps = bundleSteps
+		config.StepBundles[bundleID] = bundle
+	}
+}
+
+// SetupStepListItem returns a script step, which installs and activates the given tool versions
+// on both macOS and Linux stacks.
+func SetupStepListItem(tools []Tool) bitriseModels.StepListItemModel {
+	return initSteps.ScriptStepListItem(SetupStepTitle, setupScriptContent(tools))
+}
+
+func setupScriptContent(tools []Tool) string {
+	var asdfTools []Tool
+	var javaTool *Tool
+	for _, tool := range tools {
+		if tool.Name == JavaToolName {
+			javaTool = &tool
+			continue
+		}
+		asdfTools = append(asdfTools, tool)
+	}
+
+	content := `#!/usr/bin/env bash
+set -euxo pipefail
+`
+
+	if len(asdfTools) > 0 {
+		content += `
+# Bitrise stacks come with asdf pre-installed to help auto-switch between various software versions.
+# The ASDF_<TOOL>_VERSION env var takes precedence over any version file, so the selected versions
+# are used by every subsequent step.
+install_tool() {
+  asdf plugin add "$1" || true
+  asdf install "$1" "$2"
+  envman add --key "ASDF_$(echo "$1" | tr '[:lower:]-' '[:upper:]_')_VERSION" --value "$2"
+}
+
+`
+		for _, tool := range asdfTools {
+			content += fmt.Sprintf("# %s version from %s\n", tool.Name, tool.Source)
+			content += fmt.Sprintf("install_tool %s %s\n", tool.Name, tool.Version)
+		}
+	}
+
+	if javaTool != nil {
+		content += fmt.Sprintf(`
+# Java %[1]s from %[2]s
+case "$(uname -s)" in
+  Darwin)
+    java_home="$(/usr/libexec/java_home -v %[1]s)"
+    ;;
+  *)
+    java_home="$(find /usr/lib/jvm -maxdepth 1 -type d -name "*-%[1]s-*" | sort | head -n 1)"
+    ;;
+esac
+if [ -z "$java_home" ]; then
+  echo "Java %[1]s is not installed on this stack"
+  exit 1
+fi
+envman add --key JAVA_HOME --value "$java_home"
+envman add --key PATH --value "$java_home/bin:$PATH"
+`, javaTool.Version, javaTool.Source)
+	}
+
+	return content
+}
+
+func readToolVersionsFile(pth string) ([]Tool, error) {
+	content, err := fsutil.ReadOptionalFile(pth)
+	if err != nil {
+		return nil, err
+	}
+	return parseToolVersions(content), nil
+}
+
+func parseToolVersions(content string) []Tool {
+	var tools []Tool
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		// asdf uses the first listed version, the rest are fallbacks
+		version := fields[1]
+		if version == "system" || strings.HasPrefix(version, "ref:") || strings.HasPrefix(version, "path:") {
+			continue
+		}
+
+		tools = append(tools, Tool{Name: fields[0], Version: version, Source: toolVersionsFileName})
+	}
+
+	return tools
+}
+
+func parseNodeVersion(content string) string {
+	version := strings.TrimPrefix(firstLine(content), "v")
+	// aliases like lts/* or node can not be pinned without resolving them against the Node.js release list
+	if version == "" || !isNumeric(version[:1]) {
+		return ""
+	}
+	return version
+}
+
+func parseRubyVersion(content string) string {
+	version := strings.TrimPrefix(firstLine(content), "ruby-")
+	if version == "" || !isNumeric(version[:1]) {
+		return ""
+	}
+	return version
+}
+
+// parsePythonVersion returns the first version of the pyenv version file. Only the exact versions are pinned,
+// as asdf installs the exact versions only, the prefixes like 3.12 are left to the stack's Python.
+func parsePythonVersion(content string) string {
+	version := firstLine(content)
+	if !pythonVersionPattern.MatchString(version) {
+		return ""
+	}
+	return version
+}
+
+// fvmVersionParser returns a parser of the Flutter version stored under the given key of the FVM config.
+// The version is converted to the asdf Flutter version format, like 3.19.0-stable.
+func fvmVersionParser(key string) func(content string) string {
+	return func(content string) string {
+		var fvmConfig map[string]interface{}
+		if err := json.Unmarshal([]byte(content), &fvmConfig); err != nil {
+			return ""
+		}
+		version, _ := fvmConfig[key].(string)
+		// channels like stable can not be pinned without resolving them against the Flutter release list
+		if version == "" || !isNumeric(version[:1]) {
+			return ""
+		}
+
+		version, channel, ok := strings.Cut(version, "@")
+		if !ok {
+			// the pre-releases, like 3.20.0-1.2.pre, are released on the beta channel
+			channel = "stable"
+			if strings.Contains(version, "-") {
+				channel = "beta"
+			}
+		}
+		return version + "-" + channel
+	}
+}
+
+// parseJavaVersion returns the Java major version from the supported version formats,
+// like 17, 17.0.2, 1.8, temurin-17.0.2+8 or openjdk64-11.0.2.
+func parseJavaVersion(content string) string {
+	match := javaMajorVersionPattern.FindStringSubmatch(firstLine(content))
+	if match == nil {
+		return ""
+	}
+
+	major := match[1]
+	if major == "1" && match[2] != "" {
+		// legacy version scheme: 1.8 means Java 8
+		major = match[2]
+	}
+	return major
+}
+
+func detectGradleJavaToolchain(searchDir string) (*Tool, error) {
+	rootEntry, err := direntry.WalkDir(searchDir, gradleSearchDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	buildScripts := rootEntry.FindAllEntriesByName("build.gradle", false)
+	buildScripts = append(buildScripts, rootEntry.FindAllEntriesByName("build.gradle.kts", false)...)
+
+	var tool *Tool
+	for _, buildScript := range buildScripts {
+		content, err := os.ReadFile(buildScript.AbsPath)
+		if err != nil {
+			return nil, err
+		}
+
+		version := parseGradleJavaToolchain(string(content))
+		if version == 0 {
+			continue
+		}
+
+		// modules can target different toolchains, the highest one is able to build all of them
+		if tool == nil || version > atoiOrZero(tool.Version) {
+			tool = &Tool{Name: JavaToolName, Version: strconv.Itoa(version), Source: buildScript.RelPath}
+		}
+	}
+
+	return tool, nil
+}
+
+func parseGradleJavaToolchain(content string) int {
+	version := 0
+	for _, pattern := range []*regexp.Regexp{gradleJavaToolchainPattern, gradleKotlinJvmToolchainPattern} {
+		for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+			if v := atoiOrZero(match[1]); v > version {
+				version = v
+			}
+		}
+	}
+	return version
+}
+
+// removeInstallSteps drops the install steps the scanners add for the tools covered by the tool setup step.
+func removeInstallSteps(stepList []bitriseModels.StepListItemModel, covered map[string]bool) []bitriseModels.StepListItemModel {
+	var newStepList []bitriseModels.StepListItemModel
+	for _, item := range stepList {
+		switch id := steps.ID(item); {
+		case covered[NodeJSToolName] && id == initSteps.ScriptID:
+			step, err := item.GetStep()
+			if err == nil && step.Title != nil && *step.Title == nodeJSInstallStepTitle {
+				continue
+			}
+		case covered[FlutterToolName] && id == initSteps.FlutterInstallID:
+			continue
+		}
+		newStepList = append(newStepList, item)
+	}
+	return newStepList
+}
+
+func firstLine(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func atoiOrZero(s string) int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return i
+}
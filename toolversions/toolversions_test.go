@@ -0,0 +1,155 @@
+package toolversions
+
+import (
+	"path/filepath"
+	"testing"
+
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/testutil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	t.Log(".tool-versions takes precedence over the tool specific version files")
+	{
+		dir := t.TempDir()
+		testutil.WriteFile(t, filepath.Join(dir, ".tool-versions"), "# comment\nnodejs 20.11.0 18.0.0\nflutter 3.19.0-stable\njava temurin-17.0.2+8\n")
+		testutil.WriteFile(t, filepath.Join(dir, ".nvmrc"), "v18.19.0\n")
+		testutil.WriteFile(t, filepath.Join(dir, ".ruby-version"), "ruby-3.2.2\n")
+
+		tools, err := Detect(dir)
+		require.NoError(t, err)
+		require.Equal(t, []Tool{
+			{Name: "flutter", Version: "3.19.0-stable", Source: ".tool-versions"},
+			{Name: "java", Version: "17", Source: ".tool-versions"},
+			{Name: "nodejs", Version: "20.11.0", Source: ".tool-versions"},
+			{Name: "ruby", Version: "3.2.2", Source: ".ruby-version"},
+		}, tools)
+	}
+
+	t.Log("Node.js aliases are not pinned")
+	{
+		dir := t.TempDir()
+		testutil.WriteFile(t, filepath.Join(dir, ".nvmrc"), "lts/*\n")
+		testutil.WriteFile(t, filepath.Join(dir, ".node-version"), "v20.1.0\n")
+
+		tools, err := Detect(dir)
+		require.NoError(t, err)
+		require.Equal(t, []Tool{{Name: "nodejs", Version: "20.1.0", Source: ".node-version"}}, tools)
+	}
+
+	t.Log("Python and Flutter version files")
+	{
+		dir := t.TempDir()
+		testutil.WriteFile(t, filepath.Join(dir, ".python-version"), "3.12.1\n3.11.7\n")
+		testutil.WriteFile(t, filepath.Join(dir, ".fvmrc"), `{"flutter": "3.19.0", "flavors": {}}`)
+		testutil.WriteFile(t, filepath.Join(dir, ".fvm", "fvm_config.json"), `{"flutterSdkVersion": "3.16.0"}`)
+
+		tools, err := Detect(dir)
+		require.NoError(t, err)
+		require.Equal(t, []Tool{
+			{Name: "flutter", Version: "3.19.0-stable", Source: ".fvmrc"},
+			{Name: "python", Version: "3.12.1", Source: ".python-version"},
+		}, tools)
+	}
+
+	t.Log("FVM config of the earlier FVM versions, Python version prefixes are not pinned")
+	{
+		dir := t.TempDir()
+		testutil.WriteFile(t, filepath.Join(dir, ".python-version"), "3.12\n")
+		testutil.WriteFile(t, filepath.Join(dir, ".fvm", "fvm_config.json"), `{"flutterSdkVersion": "3.20.0-1.2.pre"}`)
+
+		tools, err := Detect(dir)
+		require.NoError(t, err)
+		require.Equal(t, []Tool{{Name: "flutter", Version: "3.20.0-1.2.pre-beta", Source: ".fvm/fvm_config.json"}}, tools)
+	}
+
+	t.Log("Flutter channels are not pinned")
+	{
+		dir := t.TempDir()
+		testutil.WriteFile(t, filepath.Join(dir, ".fvmrc"), `{"flutter": "stable"}`)
+
+		tools, err := Detect(dir)
+		require.NoError(t, err)
+		require.Empty(t, tools)
+	}
+
+	t.Log("legacy Java version scheme")
+	{
+		dir := t.TempDir()
+		testutil.WriteFile(t, filepath.Join(dir, ".java-version"), "1.8\n")
+
+		tools, err := Detect(dir)
+		require.NoError(t, err)
+		require.Equal(t, []Tool{{Name: "java", Version: "8", Source: ".java-version"}}, tools)
+	}
+
+	t.Log("highest Gradle toolchain version")
+	{
+		dir := t.TempDir()
+		testutil.WriteFile(t, filepath.Join(dir, "build.gradle"), "java {\n    toolchain {\n        languageVersion = JavaLanguageVersion.of(11)\n    }\n}\n")
+		testutil.WriteFile(t, filepath.Join(dir, "app", "build.gradle.kts"), "kotlin {\n    jvmToolchain(17)\n}\n")
+
+		tools, err := Detect(dir)
+		require.NoError(t, err)
+		require.Equal(t, []Tool{{Name: "java", Version: "17", Source: "./app/build.gradle.kts"}}, tools)
+	}
+
+	t.Log("no version files")
+	{
+		tools, err := Detect(t.TempDir())
+		require.NoError(t, err)
+		require.Empty(t, tools)
+	}
+}
+
+func TestAddSetupStep(t *testing.T) {
+	config := bitriseModels.BitriseDataModel{
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			"run_tests": {
+				Steps: []bitriseModels.StepListItemModel{
+					initSteps.ActivateSSHKeyStepListItem(""),
+					initSteps.GitCloneStepListItem(),
+					initSteps.ScriptStepListItem(nodeJSInstallStepTitle, "asdf install nodejs"),
+					initSteps.NpmStepListItem("install", ""),
+				},
+			},
+		},
+	}
+
+	AddSetupStep(&config, []Tool{{Name: NodeJSToolName, Version: "20.11.0", Source: ".nvmrc"}})
+
+	stepList := config.Workflows["run_tests"].Steps
+	require.Equal(t, 4, len(stepList))
+	require.Equal(t, initSteps.GitCloneID, steps.ID(stepList[1]))
+	require.Equal(t, initSteps.ScriptID, steps.ID(stepList[2]))
+
+	step, err := stepList[2].GetStep()
+	require.NoError(t, err)
+	require.Equal(t, SetupStepTitle, *step.Title)
+	require.Equal(t, initSteps.NpmID, steps.ID(stepList[3]))
+}
+
+func TestAddSetupStep_flutter(t *testing.T) {
+	config := bitriseModels.BitriseDataModel{
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			"primary": {
+				Steps: []bitriseModels.StepListItemModel{
+					initSteps.GitCloneStepListItem(),
+					initSteps.FlutterInstallStepListItem("3.19.0", false),
+					initSteps.FlutterTestStepListItem(),
+				},
+			},
+		},
+	}
+
+	AddSetupStep(&config, []Tool{{Name: FlutterToolName, Version: "3.19.0-stable", Source: ".fvmrc"}})
+
+	var ids []string
+	for _, item := range config.Workflows["primary"].Steps {
+		ids = append(ids, steps.ID(item))
+	}
+	require.Equal(t, []string{initSteps.GitCloneID, initSteps.ScriptID, initSteps.FlutterTestID}, ids)
+}
@@ -0,0 +1,361 @@
+package upgrade
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	yamlv2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	"github.com/bitrise-io/bitrise-plugins-init/internal/maputil"
+	"github.com/bitrise-io/bitrise-plugins-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+	"github.com/bitrise-io/stepman/stepid"
+)
+
+// ChangeKind is the type of a proposed config change.
+type ChangeKind string
+
+const (
+	// VersionBump changes a step's major version to the one the scanners use.
+	VersionBump ChangeKind = "version-bump"
+	// NewStep adds a step the scanners recommend.
+	NewStep ChangeKind = "new-step"
+	// NewInput adds an input the scanners set on a step already present in the workflow.
+	NewInput ChangeKind = "new-input"
+	// RemovedStep removes a step which is no longer maintained.
+	RemovedStep ChangeKind = "removed-step"
+	// RenamedStep replaces a step with its successor.
+	RenamedStep ChangeKind = "renamed-step"
+)
+
+// Change is a single proposed modification of an existing bitrise.yml.
+type Change struct {
+	Kind       ChangeKind
+	WorkflowID string
+	Summary    string
+	// Hunk is the diff-like representation of the change.
+	Hunk string
+
+	apply func(stepsNode *yaml.Node) error
+}
+
+// defaultStepLibSource is the StepLib of the step keys without a source prefix.
+const defaultStepLibSource = "https://github.com/bitrise-io/bitrise-steplib.git"
+
+type deprecation struct {
+	successorID      string
+	successorVersion string
+	reason           string
+}
+
+// deprecatedSteps are steps, which the scanners replaced with other steps.
+var deprecatedSteps = map[string]deprecation{
+	"cache-pull": {reason: "branch based caching is deprecated, use the key-based cache steps instead"},
+	"cache-push": {reason: "branch based caching is deprecated, use the key-based cache steps instead"},
+	"ios-auto-provision": {
+		successorID:      "manage-ios-code-signing",
+		successorVersion: "2",
+		reason:           "replaced by manage-ios-code-signing, review the step inputs after the upgrade",
+	},
+	"ios-auto-provision-appstoreconnect": {
+		successorID:      "manage-ios-code-signing",
+		successorVersion: "2",
+		reason:           "replaced by manage-ios-code-signing, review the step inputs after the upgrade",
+	},
+}
+
+// Plan compares the existing config with the config the scanners generate for the project today,
+// and returns the proposed changes workflow by workflow. Steps and inputs only present in the existing config
+// are treated as user customizations and are left untouched.
+// The recommended config can be empty, in this case only step version and deprecation changes are proposed.
+func Plan(existing, recommended bitriseModels.BitriseDataModel) []Change {
+	var changes []Change
+
+	for _, workflowID := range maputil.SortedKeys(existing.Workflows) {
+		existingSteps := existing.Workflows[workflowID].Steps
+
+		changes = append(changes, deprecationChanges(workflowID, existingSteps)...)
+		changes = append(changes, versionBumpChanges(workflowID, existingSteps)...)
+
+		if recommendedWorkflow, ok := recommended.Workflows[workflowID]; ok {
+			changes = append(changes, recommendedStepChanges(workflowID, existingSteps, recommendedWorkflow.Steps)...)
+		}
+	}
+
+	return changes
+}
+
+func deprecationChanges(workflowID string, existingSteps []bitriseModels.StepListItemModel) []Change {
+	var changes []Change
+	seen := map[string]bool{}
+
+	for _, item := range existingSteps {
+		key, ok := stepKey(item)
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		id := steps.ID(item)
+		deprecation, ok := deprecatedSteps[id]
+		if !ok {
+			continue
+		}
+
+		if deprecation.successorID == "" {
+			changes = append(changes, Change{
+				Kind:       RemovedStep,
+				WorkflowID: workflowID,
+				Summary:    fmt.Sprintf("remove %s: %s", id, deprecation.reason),
+				Hunk:       hunk(workflowID, "", []string{"- - " + key}),
+				apply: func(stepsNode *yaml.Node) error {
+					return removeSteps(stepsNode, key)
+				},
+			})
+			continue
+		}
+
+		newKey := deprecation.successorID + "@" + deprecation.successorVersion
+		changes = append(changes, Change{
+			Kind:       RenamedStep,
+			WorkflowID: workflowID,
+			Summary:    fmt.Sprintf("replace %s with %s: %s", id, deprecation.successorID, deprecation.reason),
+			Hunk:       hunk(workflowID, "", []string{"- - " + key, "+ - " + newKey}),
+			apply: func(stepsNode *yaml.Node) error {
+				return renameSteps(stepsNode, key, newKey)
+			},
+		})
+	}
+
+	return changes
+}
+
+func versionBumpChanges(workflowID string, existingSteps []bitriseModels.StepListItemModel) []Change {
+	var changes []Change
+	seen := map[string]bool{}
+
+	for _, item := range existingSteps {
+		key, ok := stepKey(item)
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		// steps referenced by git URL or local path are versioned by branch or not at all,
+		// the steps of a StepLib can be prefixed with the StepLib URL
+		stepID, err := stepid.CreateCanonicalIDFromString(key, defaultStepLibSource)
+		if err != nil || !stepID.IsUniqueResourceID() {
+			continue
+		}
+
+		id := steps.ID(item)
+		if _, deprecated := deprecatedSteps[id]; deprecated {
+			continue
+		}
+
+		latest, ok := steps.LatestMajorVersions[id]
+		if !ok {
+			continue
+		}
+
+		version := steps.Version(item)
+		if version == "" || majorVersion(version) >= majorVersion(latest) {
+			continue
+		}
+
+		newKey := strings.TrimSuffix(key, version) + latest
+		changes = append(changes, Change{
+			Kind:       VersionBump,
+			WorkflowID: workflowID,
+			Summary:    fmt.Sprintf("update %s from %s to %s", id, version, latest),
+			Hunk:       hunk(workflowID, "", []string{"- - " + key, "+ - " + newKey}),
+			apply: func(stepsNode *yaml.Node) error {
+				return renameSteps(stepsNode, key, newKey)
+			},
+		})
+	}
+
+	return changes
+}
+
+func recommendedStepChanges(workflowID string, existingSteps, recommendedSteps []bitriseModels.StepListItemModel) []Change {
+	var changes []Change
+
+	existingByMatchKey := map[string]bitriseModels.StepListItemModel{}
+	for _, item := range existingSteps {
+		if _, ok := stepKey(item); !ok {
+			continue
+		}
+		if _, ok := existingByMatchKey[matchKey(item)]; !ok {
+			existingByMatchKey[matchKey(item)] = item
+		}
+	}
+
+	// anchors collects the match keys of the recommended steps preceding the current one,
+	// new steps are inserted after the closest one present in the workflow
+	var anchors []string
+	for i, recommendedItem := range recommendedSteps {
+		recommendedKey, ok := stepKey(recommendedItem)
+		if !ok {
+			continue
+		}
+		id := matchKey(recommendedItem)
+
+		if existingItem, ok := existingByMatchKey[id]; ok {
+			changes = append(changes, newInputChanges(workflowID, existingItem, recommendedItem)...)
+			anchors = append(anchors, id)
+			continue
+		}
+		if _, deprecated := deprecatedSteps[steps.ID(recommendedItem)]; deprecated {
+			continue
+		}
+
+		itemAnchors := append([]string{}, anchors...)
+		item := recommendedItem
+		context := ""
+		if i > 0 {
+			context, _ = stepKey(recommendedSteps[i-1])
+		}
+
+		changes = append(changes, Change{
+			Kind:       NewStep,
+			WorkflowID: workflowID,
+			Summary:    fmt.Sprintf("add %s", stepDisplayName(recommendedItem, recommendedKey)),
+			Hunk:       hunk(workflowID, context, prefixLines("+ ", marshalStepListItem(recommendedItem))),
+			apply: func(stepsNode *yaml.Node) error {
+				return insertStep(stepsNode, item, itemAnchors)
+			},
+		})
+		anchors = append(anchors, id)
+	}
+
+	return changes
+}
+
+func newInputChanges(workflowID string, existingItem, recommendedItem bitriseModels.StepListItemModel) []Change {
+	existingStep, err := existingItem.GetStep()
+	if err != nil {
+		return nil
+	}
+	recommendedStep, err := recommendedItem.GetStep()
+	if err != nil {
+		return nil
+	}
+
+	existingInputs := map[string]bool{}
+	for _, input := range existingStep.Inputs {
+		if key, _, err := input.GetKeyValuePair(); err == nil {
+			existingInputs[key] = true
+		}
+	}
+
+	existingKey, _ := stepKey(existingItem)
+	match := matchKey(existingItem)
+
+	var changes []Change
+	for _, input := range recommendedStep.Inputs {
+		key, value, err := input.GetKeyValuePair()
+		if err != nil || existingInputs[key] {
+			continue
+		}
+
+		newInput := envmanModels.EnvironmentItemModel{key: value}
+		changes = append(changes, Change{
+			Kind:       NewInput,
+			WorkflowID: workflowID,
+			Summary:    fmt.Sprintf("add %s input to %s", key, steps.ID(existingItem)),
+			Hunk:       hunk(workflowID, existingKey+" inputs", prefixLines("+ ", marshal([]envmanModels.EnvironmentItemModel{newInput}))),
+			apply: func(stepsNode *yaml.Node) error {
+				return addInput(stepsNode, match, newInput)
+			},
+		})
+	}
+
+	return changes
+}
+
+// Apply applies the changes on the parsed bitrise.yml document. Only the affected step list items are modified,
+// everything else, including comments, is kept as-is.
+func Apply(doc *yaml.Node, changes []Change) error {
+	for _, change := range changes {
+		stepsNode, err := workflowStepsNode(doc, change.WorkflowID)
+		if err != nil {
+			return err
+		}
+		if err := change.apply(stepsNode); err != nil {
+			return fmt.Errorf("failed to apply change (%s): %s", change.Summary, err)
+		}
+	}
+	return nil
+}
+
+// stepKey returns the step list item's key (the step ID composite) if the item is a step.
+func stepKey(item bitriseModels.StepListItemModel) (string, bool) {
+	key, itemType, err := item.GetKeyAndType()
+	if err != nil || itemType != bitriseModels.StepListItemTypeStep {
+		return "", false
+	}
+	return key, true
+}
+
+// matchKey identifies a step in a workflow. Script and generic cache steps can occur multiple times in a workflow
+// with different purposes, they are matched by their titles too.
+func matchKey(item bitriseModels.StepListItemModel) string {
+	id := steps.ID(item)
+	switch id {
+	case initSteps.ScriptID, steps.RestoreCacheID, steps.SaveCacheID:
+		if step, err := item.GetStep(); err == nil && step.Title != nil {
+			return id + "|" + *step.Title
+		}
+	}
+	return id
+}
+
+func stepDisplayName(item bitriseModels.StepListItemModel, key string) string {
+	if step, err := item.GetStep(); err == nil && step.Title != nil {
+		return fmt.Sprintf("%s (%s)", key, *step.Title)
+	}
+	return key
+}
+
+func majorVersion(version string) int {
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	if err != nil {
+		return -1
+	}
+	return major
+}
+
+func hunk(workflowID, context string, lines []string) string {
+	header := fmt.Sprintf("@@ workflows.%s.steps @@", workflowID)
+	body := []string{header}
+	if context != "" {
+		body = append(body, "  "+context)
+	}
+	body = append(body, lines...)
+	return strings.Join(body, "\n")
+}
+
+func marshalStepListItem(item bitriseModels.StepListItemModel) []string {
+	return marshal([]bitriseModels.StepListItemModel{item})
+}
+
+func marshal(v interface{}) []string {
+	out, err := yamlv2.Marshal(v)
+	if err != nil {
+		return []string{fmt.Sprintf("%v", v)}
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+}
+
+func prefixLines(prefix string, lines []string) []string {
+	var prefixed []string
+	for _, line := range lines {
+		prefixed = append(prefixed, prefix+line)
+	}
+	return prefixed
+}
@@ -0,0 +1,160 @@
+package upgrade
+
+import (
+	"strings"
+	"testing"
+
+	yamlv2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+
+	initSteps "github.com/bitrise-io/bitrise-init/steps"
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const existingConfig = `format_version: "11"
+project_type: node-js
+workflows:
+  run_tests:
+    steps:
+    # clone the repository
+    - git-clone@6: {}
+    - cache-pull@2: {}
+    - npm@1:
+        title: npm install
+        inputs:
+        - command: install
+    - npm@1:
+        title: npm run test
+        inputs:
+        - command: run test
+    - script@1:
+        title: Custom
+        inputs:
+        - content: echo custom
+    - cache-push@2: {}
+`
+
+func parseExisting(t *testing.T) (bitriseModels.BitriseDataModel, *yaml.Node) {
+	var config bitriseModels.BitriseDataModel
+	require.NoError(t, yamlv2.Unmarshal([]byte(existingConfig), &config))
+
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(existingConfig), &doc))
+
+	return config, &doc
+}
+
+func recommendedConfig() bitriseModels.BitriseDataModel {
+	return bitriseModels.BitriseDataModel{
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			"run_tests": {
+				Steps: []bitriseModels.StepListItemModel{
+					initSteps.ActivateSSHKeyStepListItem(""),
+					initSteps.GitCloneStepListItem(),
+					initSteps.RestoreNPMCache(),
+					initSteps.NpmStepListItem("install", ""),
+					initSteps.NpmStepListItem("run test", ""),
+					initSteps.SaveNPMCache(),
+					initSteps.DeployToBitriseIoStepListItem(),
+				},
+			},
+		},
+	}
+}
+
+func TestPlan(t *testing.T) {
+	t.Log("without recommended config only proposes deprecation and version changes")
+	{
+		existing, _ := parseExisting(t)
+
+		changes := Plan(existing, bitriseModels.BitriseDataModel{})
+
+		var summaries []string
+		for _, change := range changes {
+			summaries = append(summaries, string(change.Kind)+": "+change.Summary)
+		}
+		require.Equal(t, []string{
+			"removed-step: remove cache-pull: branch based caching is deprecated, use the key-based cache steps instead",
+			"removed-step: remove cache-push: branch based caching is deprecated, use the key-based cache steps instead",
+			"version-bump: update git-clone from 6 to " + initSteps.GitCloneVersion,
+		}, summaries)
+	}
+
+	t.Log("proposes the recommended steps missing from the workflow")
+	{
+		existing, _ := parseExisting(t)
+
+		changes := Plan(existing, recommendedConfig())
+
+		var newSteps []string
+		for _, change := range changes {
+			if change.Kind == NewStep {
+				newSteps = append(newSteps, change.Summary)
+			}
+		}
+		require.Equal(t, []string{
+			"add " + initSteps.ActivateSSHKeyID + "@" + initSteps.ActivateSSHKeyVersion,
+			"add " + initSteps.CacheRestoreNPMID + "@" + initSteps.CacheRestoreNPMVersion,
+			"add " + initSteps.CacheSaveNPMID + "@" + initSteps.CacheSaveNPMVersion,
+			"add " + initSteps.DeployToBitriseIoID + "@" + initSteps.DeployToBitriseIoVersion,
+		}, newSteps)
+	}
+}
+
+func TestPlan_stepSources(t *testing.T) {
+	config := bitriseModels.BitriseDataModel{
+		Workflows: map[string]bitriseModels.WorkflowModel{
+			"build": {
+				Steps: []bitriseModels.StepListItemModel{
+					{"https://github.com/bitrise-io/bitrise-steplib.git::git-clone@6": nil},
+					{"git::https://github.com/bitrise-steplib/steps-git-clone.git@6": nil},
+					{"path::./steps/git-clone": nil},
+				},
+			},
+		},
+	}
+
+	changes := Plan(config, bitriseModels.BitriseDataModel{})
+	require.Len(t, changes, 1)
+	require.Equal(t, VersionBump, changes[0].Kind)
+	require.Contains(t, changes[0].Hunk, "+ - https://github.com/bitrise-io/bitrise-steplib.git::git-clone@"+initSteps.GitCloneVersion)
+}
+
+func TestApply(t *testing.T) {
+	existing, doc := parseExisting(t)
+
+	require.NoError(t, Apply(doc, Plan(existing, recommendedConfig())))
+
+	out, err := yaml.Marshal(doc)
+	require.NoError(t, err)
+	require.Contains(t, string(out), "# clone the repository")
+
+	var upgraded bitriseModels.BitriseDataModel
+	require.NoError(t, yamlv2.Unmarshal(out, &upgraded))
+
+	var keys []string
+	for _, item := range upgraded.Workflows["run_tests"].Steps {
+		key, ok := stepKey(item)
+		require.True(t, ok)
+		keys = append(keys, key)
+	}
+	require.Equal(t, []string{
+		initSteps.ActivateSSHKeyID + "@" + initSteps.ActivateSSHKeyVersion,
+		initSteps.GitCloneID + "@" + initSteps.GitCloneVersion,
+		initSteps.CacheRestoreNPMID + "@" + initSteps.CacheRestoreNPMVersion,
+		"npm@1",
+		"npm@1",
+		initSteps.CacheSaveNPMID + "@" + initSteps.CacheSaveNPMVersion,
+		initSteps.DeployToBitriseIoID + "@" + initSteps.DeployToBitriseIoVersion,
+		"script@1",
+	}, keys)
+}
+
+func TestHunk(t *testing.T) {
+	require.Equal(t, strings.Join([]string{
+		"@@ workflows.primary.steps @@",
+		"  git-clone@8",
+		"+ - save-npm-cache@1: {}",
+	}, "\n"), hunk("primary", "git-clone@8", []string{"+ - save-npm-cache@1: {}"}))
+}
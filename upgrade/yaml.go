@@ -0,0 +1,175 @@
+package upgrade
+
+import (
+	"fmt"
+
+	yamlv2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+
+	bitriseModels "github.com/bitrise-io/bitrise/v2/models"
+	envmanModels "github.com/bitrise-io/envman/v2/models"
+)
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func workflowStepsNode(doc *yaml.Node, workflowID string) (*yaml.Node, error) {
+	root := doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+
+	workflowNode := mappingValue(mappingValue(root, "workflows"), workflowID)
+	if workflowNode == nil {
+		return nil, fmt.Errorf("workflow (%s) not found", workflowID)
+	}
+
+	stepsNode := mappingValue(workflowNode, "steps")
+	if stepsNode == nil || stepsNode.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("workflow (%s) has no steps", workflowID)
+	}
+	return stepsNode, nil
+}
+
+// stepItemKeyNode returns the key node of a step list item node, which holds the step ID composite.
+func stepItemKeyNode(itemNode *yaml.Node) *yaml.Node {
+	if itemNode.Kind != yaml.MappingNode || len(itemNode.Content) != 2 {
+		return nil
+	}
+	return itemNode.Content[0]
+}
+
+func stepItemMatchKey(itemNode *yaml.Node) (string, bool) {
+	var item bitriseModels.StepListItemModel
+	if err := itemNode.Decode(&item); err != nil {
+		return "", false
+	}
+	if _, ok := stepKey(item); !ok {
+		return "", false
+	}
+	return matchKey(item), true
+}
+
+func indexOfMatchKey(stepsNode *yaml.Node, match string) int {
+	for i, itemNode := range stepsNode.Content {
+		if key, ok := stepItemMatchKey(itemNode); ok && key == match {
+			return i
+		}
+	}
+	return -1
+}
+
+func lastIndexOfMatchKey(stepsNode *yaml.Node, match string) int {
+	for i := len(stepsNode.Content) - 1; i >= 0; i-- {
+		if key, ok := stepItemMatchKey(stepsNode.Content[i]); ok && key == match {
+			return i
+		}
+	}
+	return -1
+}
+
+func renameSteps(stepsNode *yaml.Node, key, newKey string) error {
+	renamed := false
+	for _, itemNode := range stepsNode.Content {
+		if keyNode := stepItemKeyNode(itemNode); keyNode != nil && keyNode.Value == key {
+			keyNode.Value = newKey
+			renamed = true
+		}
+	}
+	if !renamed {
+		return fmt.Errorf("step (%s) not found", key)
+	}
+	return nil
+}
+
+func removeSteps(stepsNode *yaml.Node, key string) error {
+	var content []*yaml.Node
+	for _, itemNode := range stepsNode.Content {
+		if keyNode := stepItemKeyNode(itemNode); keyNode != nil && keyNode.Value == key {
+			continue
+		}
+		content = append(content, itemNode)
+	}
+	if len(content) == len(stepsNode.Content) {
+		return fmt.Errorf("step (%s) not found", key)
+	}
+	stepsNode.Content = content
+	return nil
+}
+
+func insertStep(stepsNode *yaml.Node, item bitriseModels.StepListItemModel, anchors []string) error {
+	itemNode, err := toNode(item)
+	if err != nil {
+		return err
+	}
+
+	index := 0
+	for i := len(anchors) - 1; i >= 0; i-- {
+		// steps occurring multiple times (like npm) are followed by the new step after their last occurrence
+		if idx := lastIndexOfMatchKey(stepsNode, anchors[i]); idx != -1 {
+			index = idx + 1
+			break
+		}
+	}
+
+	content := make([]*yaml.Node, 0, len(stepsNode.Content)+1)
+	content = append(content, stepsNode.Content[:index]...)
+	content = append(content, itemNode)
+	content = append(content, stepsNode.Content[index:]...)
+	stepsNode.Content = content
+	return nil
+}
+
+func addInput(stepsNode *yaml.Node, match string, input envmanModels.EnvironmentItemModel) error {
+	idx := indexOfMatchKey(stepsNode, match)
+	if idx == -1 {
+		return fmt.Errorf("step (%s) not found", match)
+	}
+	itemNode := stepsNode.Content[idx]
+
+	stepNode := itemNode.Content[1]
+	if stepNode.Kind != yaml.MappingNode {
+		// the step has no properties: `- git-clone@8:` or `- git-clone@8: {}`
+		*stepNode = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	stepNode.Style = 0
+
+	inputsNode := mappingValue(stepNode, "inputs")
+	if inputsNode == nil {
+		inputsNode = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		stepNode.Content = append(stepNode.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "inputs"}, inputsNode)
+	}
+
+	inputNode, err := toNode(input)
+	if err != nil {
+		return err
+	}
+	inputsNode.Content = append(inputsNode.Content, inputNode)
+	return nil
+}
+
+// toNode converts a value to a yaml.v3 node, through yaml.v2 which the bitrise models are designed for.
+func toNode(v interface{}) (*yaml.Node, error) {
+	out, err := yamlv2.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("empty yaml document")
+	}
+	return doc.Content[0], nil
+}